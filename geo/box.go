@@ -0,0 +1,208 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// BoundingBox is an axis-aligned rectangle, from its lower left corner Min to its upper
+// right corner Max.
+type BoundingBox struct {
+	Min Point
+	Max Point
+}
+
+// IsEmpty tests if a BoundingBox contains no Points, i.e. if Min is above or to the
+// right of Max.
+func (b BoundingBox) IsEmpty() bool {
+	return !(b.Min.X <= b.Max.X) || !(b.Min.Y <= b.Max.Y)
+}
+
+// ContainsPoint tests if the Point `p` lies inside or on the edge of a BoundingBox.
+func (b BoundingBox) ContainsPoint(p Point) bool {
+	return (b.Min.X <= p.X) && (p.X <= b.Max.X) && (b.Min.Y <= p.Y) && (p.Y <= b.Max.Y)
+}
+
+// Polygon returns the corners of a BoundingBox as a counter-clockwise Polygon, starting
+// from Min.
+func (b BoundingBox) Polygon() Polygon {
+	return Polygon{[]Point{b.Min, {b.Max.X, b.Min.Y}, b.Max, {b.Min.X, b.Max.Y}}}
+}
+
+// Intersects tests if two BoundingBoxes share any Point, including along their edges.
+func (b BoundingBox) Intersects(c BoundingBox) bool {
+	return (b.Min.X <= c.Max.X) && (c.Min.X <= b.Max.X) && (b.Min.Y <= c.Max.Y) && (c.Min.Y <= b.Max.Y)
+}
+
+// Contains tests if the BoundingBox `c` lies entirely inside `b`, including along its
+// edges.
+func (b BoundingBox) Contains(c BoundingBox) bool {
+	return (b.Min.X <= c.Min.X) && (c.Max.X <= b.Max.X) && (b.Min.Y <= c.Min.Y) && (c.Max.Y <= b.Max.Y)
+}
+
+// Union returns the smallest BoundingBox that contains both `b` and `c`. The Union
+// with an empty BoundingBox from Polygon.BoundingBox is the other BoundingBox.
+func (b BoundingBox) Union(c BoundingBox) BoundingBox {
+	return BoundingBox{
+		Min: Point{math.Min(b.Min.X, c.Min.X), math.Min(b.Min.Y, c.Min.Y)},
+		Max: Point{math.Max(b.Max.X, c.Max.X), math.Max(b.Max.Y, c.Max.Y)},
+	}
+}
+
+// Area returns the area of a BoundingBox, which is 0 if it is empty.
+func (b BoundingBox) Area() float64 {
+	if b.IsEmpty() {
+		return 0
+	}
+	return (b.Max.X - b.Min.X) * (b.Max.Y - b.Min.Y)
+}
+
+// DistanceToPoint returns the shortest distance from the Point `p` to any Point in a
+// BoundingBox, which is 0 if `p` is inside it.
+func (b BoundingBox) DistanceToPoint(p Point) float64 {
+	dx := math.Max(0, math.Max(b.Min.X-p.X, p.X-b.Max.X))
+	dy := math.Max(0, math.Max(b.Min.Y-p.Y, p.Y-b.Max.Y))
+	return math.Hypot(dx, dy)
+}
+
+// Bounded is any shape with a BoundingBox, such as a Point, LineSegment, Triangle,
+// Polyline, Polygon, PolygonWithHoles, MultiPolygon, MultiPoint, MultiPolyline or
+// GeometryCollection.
+type Bounded interface {
+	BoundingBox() BoundingBox
+}
+
+// BoundingBox returns the BoundingBox of a Point, which is just the Point itself.
+func (p Point) BoundingBox() BoundingBox {
+	return BoundingBox{p, p}
+}
+
+// BoundingBox returns the smallest BoundingBox that contains a LineSegment.
+func (l LineSegment) BoundingBox() BoundingBox {
+	return BoundingBox{
+		Min: Point{math.Min(l.P1.X, l.P2.X), math.Min(l.P1.Y, l.P2.Y)},
+		Max: Point{math.Max(l.P1.X, l.P2.X), math.Max(l.P1.Y, l.P2.Y)},
+	}
+}
+
+// BoundingBox returns the smallest BoundingBox that contains the Triangle.
+func (t Triangle) BoundingBox() BoundingBox {
+	return BoundingBox{
+		Min: Point{math.Min(t.P1.X, math.Min(t.P2.X, t.P3.X)), math.Min(t.P1.Y, math.Min(t.P2.Y, t.P3.Y))},
+		Max: Point{math.Max(t.P1.X, math.Max(t.P2.X, t.P3.X)), math.Max(t.P1.Y, math.Max(t.P2.Y, t.P3.Y))},
+	}
+}
+
+// BoundingBox returns the smallest BoundingBox that contains the Polygon. The
+// BoundingBox of a Polygon with no Points is empty.
+func (p Polygon) BoundingBox() BoundingBox {
+	b := BoundingBox{Point{math.Inf(1), math.Inf(1)}, Point{math.Inf(-1), math.Inf(-1)}}
+	for _, q := range p.Points {
+		b.Min = Point{math.Min(b.Min.X, q.X), math.Min(b.Min.Y, q.Y)}
+		b.Max = Point{math.Max(b.Max.X, q.X), math.Max(b.Max.Y, q.Y)}
+	}
+	return b
+}
+
+// BoundingBox returns the smallest BoundingBox that contains the Polyline. The
+// BoundingBox of a Polyline with no Points is empty.
+func (l Polyline) BoundingBox() BoundingBox {
+	return Polygon(l).BoundingBox()
+}
+
+// BoundingBox returns the smallest BoundingBox that contains the PolygonWithHoles, which
+// is that of its Shell.
+func (p PolygonWithHoles) BoundingBox() BoundingBox {
+	return p.Shell.BoundingBox()
+}
+
+// BoundingBox returns the smallest BoundingBox that contains every part of the
+// MultiPolygon. The BoundingBox of a MultiPolygon with no parts is empty.
+func (m MultiPolygon) BoundingBox() BoundingBox {
+	b := Polygon{}.BoundingBox()
+	for _, p := range m.Polygons {
+		b = b.Union(p.BoundingBox())
+	}
+	return b
+}
+
+// BoundingBox returns the smallest BoundingBox that contains every Point. The
+// BoundingBox of a MultiPoint with no Points is empty.
+func (m MultiPoint) BoundingBox() BoundingBox {
+	return Polygon(m).BoundingBox()
+}
+
+// BoundingBox returns the smallest BoundingBox that contains every Polyline. The
+// BoundingBox of a MultiPolyline with no Points is empty.
+func (m MultiPolyline) BoundingBox() BoundingBox {
+	b := Polygon{}.BoundingBox()
+	for _, l := range m.Polylines {
+		b = b.Union(l.BoundingBox())
+	}
+	return b
+}
+
+// BoundingBox returns the smallest BoundingBox that contains every shape of the
+// GeometryCollection. The BoundingBox of a GeometryCollection with no shapes is empty.
+func (g GeometryCollection) BoundingBox() BoundingBox {
+	b := Polygon{}.BoundingBox()
+	for _, shape := range g.Geometries {
+		if box := shape.BoundingBox(); !box.IsEmpty() {
+			b = b.Union(box)
+		}
+	}
+	return b
+}
+
+// intersecting_boxes calls `visit` once for every pair of indexes i < j whose
+// BoundingBoxes intersect. The boxes are put into a grid of square cells, about the size
+// of an average box, and only boxes sharing a cell are compared. Each pair is visited
+// from the cell holding the lower left corner of the area where the two overlap.
+func intersecting_boxes(boxes []BoundingBox, visit func(i, j int)) {
+	if len(boxes) < 2 {
+		return
+	}
+	bounds := boxes[0]
+	size := 0.0
+	for _, box := range boxes {
+		bounds.Min = Point{math.Min(bounds.Min.X, box.Min.X), math.Min(bounds.Min.Y, box.Min.Y)}
+		bounds.Max = Point{math.Max(bounds.Max.X, box.Max.X), math.Max(bounds.Max.Y, box.Max.Y)}
+		size += math.Max(box.Max.X-box.Min.X, box.Max.Y-box.Min.Y)
+	}
+	width, height := bounds.Max.X-bounds.Min.X, bounds.Max.Y-bounds.Min.Y
+	size = math.Max(size/float64(len(boxes)), math.Sqrt(width*height/float64(len(boxes))))
+	if size == 0 {
+		size = 1
+	}
+	// Keep the number of cells in proportion to the number of boxes.
+	for (math.Floor(width/size)+1)*(math.Floor(height/size)+1) > float64(4*len(boxes)) {
+		size *= 2
+	}
+	columns := int(width/size) + 1
+	cell := func(p Point) (int, int) {
+		return int((p.X - bounds.Min.X) / size), int((p.Y - bounds.Min.Y) / size)
+	}
+
+	cells := make([][]int, columns*(int(height/size)+1))
+	for i, box := range boxes {
+		x0, y0 := cell(box.Min)
+		x1, y1 := cell(box.Max)
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				cells[x+y*columns] = append(cells[x+y*columns], i)
+			}
+		}
+	}
+	for c, members := range cells {
+		for k, i := range members {
+			for _, j := range members[k+1:] {
+				if !boxes[i].Intersects(boxes[j]) {
+					continue
+				}
+				x, y := cell(Point{math.Max(boxes[i].Min.X, boxes[j].Min.X), math.Max(boxes[i].Min.Y, boxes[j].Min.Y)})
+				if x+y*columns == c {
+					visit(i, j)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBoundingBoxContainsPoint(t *testing.T) {
+	b := BoundingBox{Point{0, 0}, Point{2, 1}}
+	testCases := []struct {
+		desc string
+		p    Point
+		out  bool
+	}{
+		{
+			desc: "Inside",
+			p:    Point{1, 0.5},
+			out:  true,
+		},
+		{
+			desc: "On an edge",
+			p:    Point{2, 0.5},
+			out:  true,
+		},
+		{
+			desc: "On a corner",
+			p:    Point{0, 0},
+			out:  true,
+		},
+		{
+			desc: "Outside",
+			p:    Point{2.5, 0.5},
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := b.ContainsPoint(tC.p); got != tC.out {
+				t.Errorf("ContainsPoint() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxIsEmpty(t *testing.T) {
+	testCases := []struct {
+		desc string
+		b    BoundingBox
+		out  bool
+	}{
+		{
+			desc: "Unit square",
+			b:    BoundingBox{Point{0, 0}, Point{1, 1}},
+			out:  false,
+		},
+		{
+			desc: "Single Point",
+			b:    BoundingBox{Point{1, 1}, Point{1, 1}},
+			out:  false,
+		},
+		{
+			desc: "Min and Max swapped",
+			b:    BoundingBox{Point{1, 1}, Point{0, 0}},
+			out:  true,
+		},
+		{
+			desc: "NaN",
+			b:    BoundingBox{Point{math.NaN(), 0}, Point{1, 1}},
+			out:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.b.IsEmpty(); got != tC.out {
+				t.Errorf("IsEmpty() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	b := BoundingBox{Point{0, 0}, Point{2, 1}}
+	testCases := []struct {
+		desc string
+		c    BoundingBox
+		out  bool
+	}{
+		{
+			desc: "Inside",
+			c:    BoundingBox{Point{0.5, 0.25}, Point{1, 0.75}},
+			out:  true,
+		},
+		{
+			desc: "Itself",
+			c:    b,
+			out:  true,
+		},
+		{
+			desc: "Overlapping",
+			c:    BoundingBox{Point{1, 0.5}, Point{3, 0.75}},
+			out:  false,
+		},
+		{
+			desc: "Around it",
+			c:    BoundingBox{Point{-1, -1}, Point{3, 2}},
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := b.Contains(tC.c); got != tC.out {
+				t.Errorf("Contains() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolygonBoundingBox(t *testing.T) {
+	if got := l_shape.BoundingBox(); got != (BoundingBox{Point{0, 0}, Point{2, 2}}) {
+		t.Errorf("BoundingBox() = %v, want {{0 0} {2 2}}", got)
+	}
+	if got := (Polygon{}).BoundingBox(); !got.IsEmpty() {
+		t.Errorf("BoundingBox() of an empty Polygon = %v, want an empty BoundingBox", got)
+	}
+}
+
+func TestShapeBoundingBox(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		shape Bounded
+		want  BoundingBox
+	}{
+		{"Point", Point{1, 2}, BoundingBox{Point{1, 2}, Point{1, 2}}},
+		{"LineSegment", LineSegment{Point{2, 0}, Point{0, 1}}, BoundingBox{Point{0, 0}, Point{2, 1}}},
+		{"Triangle", Triangle{Point{0, 0}, Point{2, 3}, Point{-1, 1}}, BoundingBox{Point{-1, 0}, Point{2, 3}}},
+		{"Polyline", staircase, BoundingBox{Point{0, 0}, Point{3, 3}}},
+		{"PolygonWithHoles", swiss_cheese, BoundingBox{Point{0, 0}, Point{10, 10}}},
+		{"MultiPolygon", multi(square(0, 0, 1), square(3, -2, 1)), BoundingBox{Point{0, -2}, Point{4, 1}}},
+		{"MultiPoint", MultiPoint{[]Point{{1, 2}, {-1, 0}}}, BoundingBox{Point{-1, 0}, Point{1, 2}}},
+		{"MultiPolyline", MultiPolyline{[]Polyline{staircase, {[]Point{{-1, 4}}}}}, BoundingBox{Point{-1, 0}, Point{3, 4}}},
+		{
+			"GeometryCollection",
+			GeometryCollection{[]Bounded{Point{5, 5}, MultiPoint{}, GeometryCollection{[]Bounded{staircase}}}},
+			BoundingBox{Point{0, 0}, Point{5, 5}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.shape.BoundingBox(); got != tC.want {
+				t.Errorf("BoundingBox() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+	if got := (MultiPolygon{}).BoundingBox(); !got.IsEmpty() {
+		t.Errorf("BoundingBox() of an empty MultiPolygon = %v, want an empty BoundingBox", got)
+	}
+	if got := (GeometryCollection{}).BoundingBox(); !got.IsEmpty() {
+		t.Errorf("BoundingBox() of an empty GeometryCollection = %v, want an empty BoundingBox", got)
+	}
+}
+
+func TestBoundingBoxUnion(t *testing.T) {
+	b := BoundingBox{Point{0, 0}, Point{2, 1}}
+	testCases := []struct {
+		desc string
+		c    BoundingBox
+		out  BoundingBox
+	}{
+		{
+			desc: "Inside",
+			c:    BoundingBox{Point{0.5, 0.25}, Point{1, 0.75}},
+			out:  b,
+		},
+		{
+			desc: "Apart",
+			c:    BoundingBox{Point{3, -1}, Point{4, 0}},
+			out:  BoundingBox{Point{0, -1}, Point{4, 1}},
+		},
+		{
+			desc: "Empty",
+			c:    Polygon{}.BoundingBox(),
+			out:  b,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := b.Union(tC.c); got != tC.out {
+				t.Errorf("Union() = %v, want %v", got, tC.out)
+			}
+			if got := tC.c.Union(b); got != tC.out {
+				t.Errorf("Union() the other way = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxArea(t *testing.T) {
+	if got := (BoundingBox{Point{0, 0}, Point{2, 1.5}}).Area(); got != 3 {
+		t.Errorf("Area() = %v, want 3", got)
+	}
+	if got := (Polygon{}).BoundingBox().Area(); got != 0 {
+		t.Errorf("Area() of an empty BoundingBox = %v, want 0", got)
+	}
+}
+
+func TestBoundingBoxDistanceToPoint(t *testing.T) {
+	b := BoundingBox{Point{0, 0}, Point{2, 1}}
+	testCases := []struct {
+		desc string
+		p    Point
+		out  float64
+	}{
+		{
+			desc: "Inside",
+			p:    Point{1, 0.5},
+			out:  0,
+		},
+		{
+			desc: "On an edge",
+			p:    Point{2, 0.5},
+			out:  0,
+		},
+		{
+			desc: "Beside an edge",
+			p:    Point{1, -2},
+			out:  2,
+		},
+		{
+			desc: "Past a corner",
+			p:    Point{5, 5},
+			out:  5,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := b.DistanceToPoint(tC.p); got != tC.out {
+				t.Errorf("DistanceToPoint() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestIntersectingBoxes(t *testing.T) {
+	rng := rand.New(rand.NewSource(20))
+	testCases := []struct {
+		desc  string
+		boxes []BoundingBox
+	}{
+		{"None", nil},
+		{"Identical", []BoundingBox{{Point{0, 0}, Point{1, 1}}, {Point{0, 0}, Point{1, 1}}}},
+		{"Touching", []BoundingBox{{Point{0, 0}, Point{1, 1}}, {Point{1, 1}, Point{2, 2}}, {Point{3, 0}, Point{4, 1}}}},
+		{"Points", []BoundingBox{{Point{1, 1}, Point{1, 1}}, {Point{1, 1}, Point{1, 1}}}},
+	}
+	var random []BoundingBox
+	for i := 0; i < 300; i++ {
+		min := Point{10 * rng.Float64(), 10 * rng.Float64()}
+		size := 0.1
+		if i%10 == 0 {
+			size = 5
+		}
+		random = append(random, BoundingBox{min, min.Plus(Point{size * rng.Float64(), size * rng.Float64()})})
+	}
+	testCases = append(testCases, struct {
+		desc  string
+		boxes []BoundingBox
+	}{"Random", random})
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			visited := make(map[[2]int]int)
+			intersecting_boxes(tC.boxes, func(i, j int) {
+				visited[[2]int{i, j}]++
+			})
+			for i := range tC.boxes {
+				for j := i + 1; j < len(tC.boxes); j++ {
+					want := 0
+					if tC.boxes[i].Intersects(tC.boxes[j]) {
+						want = 1
+					}
+					if got := visited[[2]int{i, j}]; got != want {
+						t.Errorf("intersecting_boxes() visited %v and %v %v times, want %v", tC.boxes[i], tC.boxes[j], got, want)
+					}
+				}
+			}
+			for pair := range visited {
+				if pair[0] >= pair[1] {
+					t.Errorf("intersecting_boxes() visited %v and %v out of order", pair[0], pair[1])
+				}
+			}
+		})
+	}
+}
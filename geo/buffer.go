@@ -0,0 +1,317 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// JoinStyle selects the shape Buffer gives to the outside of each corner.
+type JoinStyle int
+
+const (
+	// RoundJoin rounds each corner with an arc around its vertex.
+	RoundJoin JoinStyle = iota
+	// MiterJoin extends the edges on either side of a corner until they meet, unless
+	// that point is further from the vertex than the MiterLimit allows, in which case the
+	// corner is beveled instead.
+	MiterJoin
+	// BevelJoin cuts each corner off with a straight line.
+	BevelJoin
+)
+
+// CapStyle selects the shape Buffer gives to the ends of a Polyline.
+type CapStyle int
+
+const (
+	// RoundCap ends a Polyline with a half circle around its end.
+	RoundCap CapStyle = iota
+	// FlatCap ends a Polyline with a straight line across its end.
+	FlatCap
+	// SquareCap ends a Polyline with a half square, reaching past its end by the buffer
+	// distance.
+	SquareCap
+)
+
+const (
+	default_miter_limit       = 5.0
+	default_quadrant_segments = 8
+)
+
+// BufferOptions configure Buffer. The zero value uses RoundJoin and RoundCap, with
+// eight LineSegments for each quarter of a circle.
+type BufferOptions struct {
+	Join JoinStyle
+	Cap  CapStyle
+	// MiterLimit is how far the point of a MiterJoin may reach from its vertex, as a
+	// multiple of the buffer distance. Zero uses the default of 5.
+	MiterLimit float64
+	// QuadrantSegments is the number of LineSegments used for each quarter of a circle
+	// by RoundJoin and RoundCap. Zero uses the default of 8.
+	QuadrantSegments int
+}
+
+// Buffer returns the area within `distance` of the Point, which is a circle with a
+// RoundCap, a square with a SquareCap, and empty with a FlatCap or a `distance` that is
+// not positive.
+func (p Point) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	return Polyline{[]Point{p}}.Buffer(distance, options)
+}
+
+// Buffer returns the area within `distance` of the LineSegment, with ends shaped by the
+// CapStyle. It is empty if `distance` is not positive.
+func (l LineSegment) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	return Polyline{[]Point{l.P1, l.P2}}.Buffer(distance, options)
+}
+
+// Buffer returns the area within `distance` of the Polyline, with corners shaped by the
+// JoinStyle and ends shaped by the CapStyle. A closed Polyline has no ends, so its first
+// vertex is joined like the others. The result is empty if `distance` is not positive.
+//
+// Each LineSegment is widened into a rectangle, and each corner and end is covered by a
+// shape of its own, so that the result is the Union of these overlapping pieces. Like
+// Overlay, it fails with ErrTooManySplits if their edges cannot be split apart.
+func (l Polyline) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	var points []Point
+	for _, p := range l.Points {
+		if (len(points) == 0) || !points[len(points)-1].Equals(p) {
+			points = append(points, p)
+		}
+	}
+	if (distance <= 0) || (len(points) == 0) {
+		return MultiPolygon{}, nil
+	}
+	b := buffer_builder{distance, options.with_defaults(), nil}
+
+	if len(points) == 1 {
+		p := points[0]
+		switch b.options.Cap {
+		case RoundCap:
+			b.arc(p, Point{distance, 0}, Point{distance, 0}, 2*math.Pi)
+		case SquareCap:
+			b.add(
+				p.Plus(Point{-distance, -distance}), p.Plus(Point{distance, -distance}),
+				p.Plus(Point{distance, distance}), p.Plus(Point{-distance, distance}),
+			)
+		}
+		return b.union()
+	}
+
+	closed := (len(points) > 2) && points[0].Equals(points[len(points)-1])
+	if closed {
+		points = points[:len(points)-1]
+	}
+	n := len(points)
+	for i := 0; i < n; i++ {
+		if !closed && (i == n-1) {
+			break
+		}
+		// The ends of the LineSegment are kept as vertices of its rectangle, so that the
+		// pieces around them share edges exactly.
+		p, q := points[i], points[(i+1)%n]
+		normal := b.left_normal(p, q)
+		b.add(p, p.Minus(normal), q.Minus(normal), q, q.Plus(normal), p.Plus(normal))
+	}
+
+	for i := 0; i < n; i++ {
+		if !closed && ((i == 0) || (i == n-1)) {
+			continue
+		}
+		prev, v, next := points[(i+n-1)%n], points[i], points[(i+1)%n]
+		turn := Orient2D(prev, v, next)
+		if (turn == 0) && (v.Minus(prev).DotProduct(next.Minus(v)) > 0) {
+			continue
+		}
+		in, out := b.left_normal(prev, v), b.left_normal(v, next)
+		if turn >= 0 {
+			in, out = in.Times(-1), out.Times(-1)
+		}
+		b.join(v, in, out)
+		// The inside of the turn is mostly covered by the rectangles on either side, but
+		// not if they are shorter than the distance.
+		b.arc(v, in.Times(-1), out.Times(-1), sweep(in.Times(-1), out.Times(-1)))
+	}
+
+	if !closed {
+		for _, end := range [][2]Point{{points[1], points[0]}, {points[n-2], points[n-1]}} {
+			from, p := end[0], end[1]
+			normal := b.left_normal(from, p)
+			switch b.options.Cap {
+			case RoundCap:
+				b.arc(p, normal.Times(-1), normal, math.Pi)
+			case SquareCap:
+				ahead := p.Minus(from).Normalize().Times(distance)
+				b.add(p, p.Minus(normal), p.Minus(normal).Plus(ahead), p.Plus(normal).Plus(ahead), p.Plus(normal))
+			}
+		}
+	}
+	return b.union()
+}
+
+// Buffer returns the area within `distance` of the Polygon, with corners shaped by the
+// JoinStyle. See MultiPolygon.Buffer.
+func (p Polygon) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	return MultiPolygon{[]PolygonWithHoles{{Shell: p}}}.Buffer(distance, options)
+}
+
+// Buffer returns the area within `distance` of the PolygonWithHoles, with corners shaped
+// by the JoinStyle. See MultiPolygon.Buffer.
+func (p PolygonWithHoles) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	return MultiPolygon{[]PolygonWithHoles{p}}.Buffer(distance, options)
+}
+
+// Buffer grows a MultiPolygon by `distance`, returning the area inside it or within
+// `distance` of it, with corners shaped by the JoinStyle. A negative `distance` shrinks
+// it instead, returning the area inside it and further than -`distance` from its
+// boundary, which may split parts in two or remove them altogether. The CapStyle is not
+// used.
+//
+// Each edge is widened into a rectangle on the side being grown or shrunk, and each
+// corner on that side that turns away from it is covered by a shape of its own. The
+// result is then the Union, or the Difference, of the MultiPolygon and these pieces. Like
+// Overlay, it fails with ErrTooManySplits if their edges cannot be split apart.
+func (m MultiPolygon) Buffer(distance float64, options BufferOptions) (MultiPolygon, error) {
+	b := buffer_builder{math.Abs(distance), options.with_defaults(), nil}
+	for _, polygon := range m.Oriented().Polygons {
+		for _, ring := range polygon.Rings() {
+			points := clean_ring(ring.Points)
+			n := len(points)
+			if (distance == 0) || (n == 0) {
+				continue
+			}
+			// The interior is to the left of every ring, so pieces go on its right to grow it,
+			// and on its left to shrink it.
+			normal := func(p, q Point) Point {
+				if distance > 0 {
+					return b.left_normal(p, q).Times(-1)
+				}
+				return b.left_normal(p, q)
+			}
+			for i, p := range points {
+				q := points[(i+1)%n]
+				b.add(p, p.Plus(normal(p, q)), q.Plus(normal(p, q)), q)
+
+				prev := points[(i+n-1)%n]
+				if turn := Orient2D(prev, p, q); (turn > 0) == (distance > 0) {
+					b.join(p, normal(prev, p), normal(p, q))
+				}
+			}
+		}
+	}
+
+	operation := Union
+	if distance < 0 {
+		operation = Difference
+	}
+	result, err := Overlay(m, MultiPolygon{b.pieces}, operation)
+	if err != nil {
+		return MultiPolygon{}, err
+	}
+	return drop_slivers(result, sliver_width*b.distance), nil
+}
+
+// sliver_width is how thin a ring of a buffer can be, relative to the buffer distance,
+// before it is taken to be left over from rounding where pieces nearly meet, rather
+// than part of the buffer.
+const sliver_width = 1e-9
+
+// drop_slivers removes the parts and holes of a MultiPolygon whose average width, twice
+// their area over their perimeter, is less than `width`.
+func drop_slivers(m MultiPolygon, width float64) MultiPolygon {
+	thin := func(ring Polygon) bool {
+		return 2*ring.Area() < width*ring.Perimeter()
+	}
+	kept := MultiPolygon{m.Polygons[:0:0]}
+	for _, polygon := range m.Polygons {
+		if thin(polygon.Shell) {
+			continue
+		}
+		holes := polygon.Holes[:0:0]
+		for _, hole := range polygon.Holes {
+			if !thin(hole) {
+				holes = append(holes, hole)
+			}
+		}
+		kept.Polygons = append(kept.Polygons, PolygonWithHoles{Shell: polygon.Shell, Holes: holes})
+	}
+	return kept
+}
+
+// with_defaults fills in the BufferOptions that were left as zero.
+func (o BufferOptions) with_defaults() BufferOptions {
+	if o.MiterLimit == 0 {
+		o.MiterLimit = default_miter_limit
+	}
+	if o.QuadrantSegments <= 0 {
+		o.QuadrantSegments = default_quadrant_segments
+	}
+	return o
+}
+
+// buffer_builder collects the overlapping pieces that make up a buffer.
+type buffer_builder struct {
+	distance float64
+	options  BufferOptions
+	pieces   []PolygonWithHoles
+}
+
+// add adds a piece with the given vertices, in either order.
+func (b *buffer_builder) add(points ...Point) {
+	b.pieces = append(b.pieces, PolygonWithHoles{Shell: Polygon{points}})
+}
+
+// union returns the area covered by any of the pieces.
+func (b *buffer_builder) union() (MultiPolygon, error) {
+	return Overlay(MultiPolygon{b.pieces}, MultiPolygon{}, Union)
+}
+
+// left_normal returns the vector at right angles to the direction from `p` to `q`,
+// pointing to its left, with a length of the buffer distance.
+func (b *buffer_builder) left_normal(p, q Point) Point {
+	d := q.Minus(p).Normalize()
+	return Point{-d.Y, d.X}.Times(b.distance)
+}
+
+// join covers the outside of the corner at `v`, where the offset from `v` turns from
+// `in` to `out`, in the JoinStyle.
+func (b *buffer_builder) join(v, in, out Point) {
+	switch b.options.Join {
+	case RoundJoin:
+		b.arc(v, in, out, sweep(in, out))
+	case MiterJoin:
+		// The point where the offset edges meet is along the bisector of the two offsets,
+		// 1 / cos(θ/2) times the distance from `v`, for a turn through θ.
+		cos := in.DotProduct(out) / (b.distance * b.distance)
+		if half_cos := math.Sqrt((1 + cos) / 2); half_cos*b.options.MiterLimit >= 1 {
+			b.add(v, v.Plus(in), v.Plus(in.Plus(out).Divide(1+cos)), v.Plus(out))
+			return
+		}
+		b.add(v, v.Plus(in), v.Plus(out))
+	case BevelJoin:
+		b.add(v, v.Plus(in), v.Plus(out))
+	}
+}
+
+// arc adds the sector of the circle around `v` that turns through the `angle` from the
+// offset `from` to the offset `to`, using QuadrantSegments LineSegments for each quarter
+// turn, or a whole circle if the `angle` is a full turn.
+func (b *buffer_builder) arc(v, from, to Point, angle float64) {
+	steps := int(math.Ceil(math.Abs(angle) / (math.Pi / 2) * float64(b.options.QuadrantSegments)))
+	if steps < 1 {
+		steps = 1
+	}
+	full := math.Abs(angle) >= 2*math.Pi
+	var points []Point
+	if !full {
+		points = append(points, v, v.Plus(from))
+	}
+	for i := 1; i < steps; i++ {
+		points = append(points, v.Plus(from.Rotate(angle*float64(i)/float64(steps))))
+	}
+	points = append(points, v.Plus(to))
+	b.add(points...)
+}
+
+// sweep returns the angle turned through from the direction of `p` to the direction of
+// `q`, between -π and π.
+func sweep(p, q Point) float64 {
+	return math.Atan2(p.CrossProduct(q), p.DotProduct(q))
+}
@@ -0,0 +1,188 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// buffered is a shape that can be buffered.
+type buffered interface {
+	Buffer(distance float64, options BufferOptions) (MultiPolygon, error)
+}
+
+func TestBuffer(t *testing.T) {
+	circle := regular_polygon(Point{0, 0}, 1, 32).Area()
+	miter := BufferOptions{Join: MiterJoin}
+	dumbbell := Polygon{[]Point{
+		{0, 0}, {3, 0}, {3, 1.25}, {5, 1.25}, {5, 0}, {8, 0},
+		{8, 3}, {5, 3}, {5, 1.75}, {3, 1.75}, {3, 3}, {0, 3},
+	}}
+	with_hole := PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(4, 4, 2).Reverse()}}
+	testCases := []struct {
+		desc     string
+		shape    buffered
+		distance float64
+		options  BufferOptions
+		area     float64
+		polygons int
+		holes    int
+	}{
+		{"Point", Point{1, 2}, 1, BufferOptions{}, circle, 1, 0},
+		{"Point with a square cap", Point{1, 2}, 1, BufferOptions{Cap: SquareCap}, 4, 1, 0},
+		{"Point with a flat cap", Point{1, 2}, 1, BufferOptions{Cap: FlatCap}, 0, 0, 0},
+		{"Point with a negative distance", Point{1, 2}, -1, BufferOptions{}, 0, 0, 0},
+		{"LineSegment", LineSegment{Point{0, 0}, Point{2, 0}}, 1, BufferOptions{}, 4 + circle, 1, 0},
+		{"LineSegment with flat caps", LineSegment{Point{0, 0}, Point{2, 0}}, 1, BufferOptions{Cap: FlatCap}, 4, 1, 0},
+		{"LineSegment with square caps", LineSegment{Point{0, 0}, Point{2, 0}}, 1, BufferOptions{Cap: SquareCap}, 8, 1, 0},
+		{
+			"Polyline with a mitered corner",
+			Polyline{[]Point{{0, 0}, {2, 0}, {2, 2}}}, 0.5, BufferOptions{Join: MiterJoin, Cap: FlatCap},
+			4, 1, 0,
+		},
+		{
+			"Polyline with a beveled corner",
+			Polyline{[]Point{{0, 0}, {2, 0}, {2, 2}}}, 0.5, BufferOptions{Join: BevelJoin, Cap: FlatCap},
+			3.875, 1, 0,
+		},
+		{
+			"Closed Polyline",
+			Polyline{[]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}, 0.25, miter,
+			2, 1, 1,
+		},
+		{"Square", unit_square, 1, BufferOptions{}, 5 + circle, 1, 0},
+		{"Square, mitered", unit_square, 1, miter, 9, 1, 0},
+		{"Square, beyond the miter limit", unit_square, 1, BufferOptions{Join: MiterJoin, MiterLimit: 1.2}, 7, 1, 0},
+		{"Square, beveled", unit_square, 1, BufferOptions{Join: BevelJoin}, 7, 1, 0},
+		{"Shrunk square", square(0, 0, 4), -1, BufferOptions{}, 4, 1, 0},
+		{"Shrunk until empty", square(0, 0, 4), -2, BufferOptions{}, 0, 0, 0},
+		{"Zero distance", l_shape, 0, BufferOptions{}, 3, 1, 0},
+		{"Shrunk hole", with_hole, 0.5, miter, 120, 1, 1},
+		{"Hole shrunk shut", with_hole, 1, miter, 144, 1, 0},
+		{
+			"Shrunk without slivers where the pieces nearly meet",
+			Polygon{[]Point{{1, 9}, {3, 2}, {4, 7}, {6, 6}}}, -1, BufferOptions{},
+			0.0643797463104785, 1, 0,
+		},
+		{"Shrunk apart", dumbbell, -0.5, miter, 8, 2, 0},
+		{"Grown together", multi(square(0, 0, 1), square(1.5, 0, 1)), 0.5, miter, 7, 1, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tC.shape.Buffer(tC.distance, tC.options)
+			if err != nil {
+				t.Fatalf("Buffer() returned %v", err)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("Buffer() = %v is not valid: %v", got, err)
+			}
+			if !almost_zero(got.Area() - tC.area) {
+				t.Errorf("Buffer() has area %v, want %v", got.Area(), tC.area)
+			}
+			holes := 0
+			for _, polygon := range got.Polygons {
+				holes += len(polygon.Holes)
+			}
+			if (len(got.Polygons) != tC.polygons) || (holes != tC.holes) {
+				t.Errorf("Buffer() = %v, want %v Polygons with %v Holes", got, tC.polygons, tC.holes)
+			}
+		})
+	}
+}
+
+// check_buffer verifies that the Buffer of `shape` is a valid MultiPolygon, and that
+// random Points are inside it exactly when they are within `distance` of the shape, given
+// the `signed_distance` to the shape, allowing for the arcs being made of LineSegments.
+func check_buffer(t *testing.T, rng *rand.Rand, box BoundingBox, distance float64, signed_distance func(Point) float64, shape buffered) {
+	t.Helper()
+	result, err := shape.Buffer(distance, BufferOptions{})
+	if err != nil {
+		t.Fatalf("Buffer(%v) returned %v", distance, err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Fatalf("Buffer(%v) = %v is not valid: %v", distance, result, err)
+	}
+
+	inscribed := distance * math.Cos(math.Pi/(4*default_quadrant_segments))
+	for i := 0; i < 500; i++ {
+		q := Point{
+			box.Min.X - 2*math.Abs(distance) + (box.Max.X-box.Min.X+4*math.Abs(distance))*rng.Float64(),
+			box.Min.Y - 2*math.Abs(distance) + (box.Max.Y-box.Min.Y+4*math.Abs(distance))*rng.Float64(),
+		}
+		d := signed_distance(q)
+		got := result.LocatePoint(q)
+		if (d < math.Min(distance, inscribed)-1e-9) && (got != Interior) {
+			t.Fatalf("Buffer(%v) = %v does not contain %v, at a distance of %v", distance, result, q, d)
+		}
+		if (d > math.Max(distance, inscribed)+1e-9) && (got != Exterior) {
+			t.Fatalf("Buffer(%v) = %v contains %v, at a distance of %v", distance, result, q, d)
+		}
+	}
+}
+
+func TestBufferRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(18))
+	for trial := 0; trial < 30; trial++ {
+		// A random walk, which may cross itself.
+		line := Polyline{[]Point{{0, 0}}}
+		for i := 0; i < 2+rng.Intn(20); i++ {
+			last := line.Points[len(line.Points)-1]
+			line.Points = append(line.Points, last.Plus(Point{rng.Float64() - 0.5, rng.Float64() - 0.5}))
+		}
+		distance := 0.01 + 0.2*rng.Float64()
+		line_distance := func(q Point) float64 {
+			d := math.Inf(1)
+			for _, segment := range line.Segments() {
+				d = math.Min(d, segment.DistanceToPoint(q))
+			}
+			return d
+		}
+		check_buffer(t, rng, Polygon(line).BoundingBox(), distance, line_distance, line)
+
+		// A star with a hole, grown or shrunk. The signed distance is negative inside it.
+		p := PolygonWithHoles{
+			Shell: random_star(rng, Point{0, 0}, 0.5, 1, 3+rng.Intn(50)),
+			Holes: []Polygon{random_star(rng, Point{0, 0}, 0.1, 0.3, 3+rng.Intn(10)).Reverse()},
+		}
+		distance = 0.3 * (2*rng.Float64() - 1)
+		polygon_distance := func(q Point) float64 {
+			d := math.Inf(1)
+			for _, edge := range p.Edges() {
+				d = math.Min(d, edge.DistanceToPoint(q))
+			}
+			if p.LocatePoint(q) == Interior {
+				return -d
+			}
+			return d
+		}
+		check_buffer(t, rng, p.Shell.BoundingBox(), distance, polygon_distance, p)
+	}
+}
+
+func BenchmarkBuffer(b *testing.B) {
+	rng := rand.New(rand.NewSource(19))
+	star := random_star(rng, Point{0, 0}, 0.5, 1, 1000)
+	walk := Polyline{[]Point{{0, 0}}}
+	for i := 0; i < 1000; i++ {
+		walk.Points = append(walk.Points, walk.Points[i].Plus(Point{rng.Float64() - 0.5, rng.Float64() - 0.5}))
+	}
+	benchmarks := []struct {
+		desc     string
+		shape    buffered
+		distance float64
+	}{
+		{"Point", Point{0, 0}, 1},
+		{"1000 vertex star, grown", star, 0.05},
+		{"1000 vertex star, shrunk", star, -0.05},
+		{"1000 vertex random walk", walk, 0.05},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.shape.Buffer(bm.distance, BufferOptions{})
+			}
+
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package gogeo
+
+// MultiPoint is a collection of Points.
+type MultiPoint struct {
+	Points []Point
+}
+
+// MultiPolyline is a collection of Polylines, which may cross each other.
+type MultiPolyline struct {
+	Polylines []Polyline
+}
+
+// GeometryCollection is a collection of shapes of any type, including other
+// GeometryCollections.
+type GeometryCollection struct {
+	Geometries []Bounded
+}
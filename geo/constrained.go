@@ -0,0 +1,531 @@
+package gogeo
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrTooManySplits is returned when LineSegments still cross after being split
+// max_split_passes times, so they cannot be made into a planar graph.
+var ErrTooManySplits = errors.New("segments still cross after splitting")
+
+// snap_distance is how close a crossing Point must be to the end of one of the pieces
+// that cross, relative to the size of its coordinates, to be moved onto that end. Where
+// many pieces cross within a few units of rounding of each other, splitting them at
+// every rounded crossing would only make more tiny pieces that cross again.
+const snap_distance = 64 * epsilon
+
+// max_split_passes limits how many times segments are checked for crossings when they
+// are split. A crossing Point is rounded, so a piece ending at it can, very rarely,
+// cross some other piece, which the next pass then splits again. If pieces still cross
+// after the last pass, splitting fails with ErrTooManySplits.
+const max_split_passes = 8
+
+// ConstrainedDelaunay is a constrained Delaunay triangulation of a set of Points. Every
+// constraint is made up of edges of the triangulation, and otherwise it is as close to
+// Delaunay as the constraints allow: no Point lies strictly inside the circumcircle of a
+// triangle unless a constraint blocks it from view.
+//
+// Where two constraints cross, a Steiner Point is added at the crossing, and where a
+// constraint runs through a Point, it is split there. Like Delaunay, duplicate Points
+// are only triangulated once. Points that NewDelaunay leaves out, because a coordinate
+// is not finite or is too large, are left out here too, along with any piece of a
+// constraint that ends at one.
+type ConstrainedDelaunay struct {
+	// Points holds the Points that were triangulated, followed by any constraint ends
+	// that were not among them, and then any Steiner Points.
+	Points []Point
+	// Vertices holds the indexes into Points of the three vertices of each triangle, in
+	// counter-clockwise order.
+	Vertices [][3]int
+	// Neighbors holds, for each triangle, the indexes of the triangles across each of
+	// its edges. Neighbors[t][i] is across the edge from Vertices[t][i] to
+	// Vertices[t][(i+1)%3], and is -1 if that edge is on the convex hull.
+	Neighbors [][3]int
+	// Constrained marks, for each triangle, which of its edges are part of a
+	// constraint, in the same order as Neighbors.
+	Constrained [][3]bool
+	// Constraints holds the pieces of the constraints after splitting, as pairs of
+	// indexes into Points. Each piece is an edge of the triangulation.
+	Constraints [][2]int
+	// Hull holds the indexes into Points of the vertices of the convex hull, in
+	// counter-clockwise order.
+	Hull []int
+}
+
+// NewConstrainedDelaunay calculates the constrained Delaunay triangulation of `points`
+// with the edges `constraints`. The Points are first triangulated with NewDelaunay, and
+// each constraint is then inserted by flipping away the edges that cross it, before
+// flipping the new edges until the triangulation is as Delaunay as possible again.
+//
+// It fails with ErrTooManySplits if the constraints cannot be split into pieces that only
+// meet at their ends.
+func NewConstrainedDelaunay(points []Point, constraints []LineSegment) (*ConstrainedDelaunay, error) {
+	pieces, err := split_constraints(constraints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every end of a piece must be one of the Points.
+	all_points := make([]Point, len(points), len(points)+2*len(pieces))
+	copy(all_points, points)
+	index := make(map[Point]int, len(all_points))
+	for i := len(points) - 1; i >= 0; i-- {
+		index[points[i]] = i
+	}
+	index_of := func(p Point) int {
+		if i, ok := index[p]; ok {
+			return i
+		}
+		index[p] = len(all_points)
+		all_points = append(all_points, p)
+		return index[p]
+	}
+	piece_ends := make([][2]int, len(pieces))
+	for i, piece := range pieces {
+		piece_ends[i] = [2]int{index_of(piece.P1), index_of(piece.P2)}
+	}
+
+	c := &constrained_mesh{mesh: *new_delaunay_mesh(all_points)}
+	c.constrained = make([]bool, len(c.triangles))
+	c.vertex_edge = make([]int, len(all_points))
+	for i := range c.vertex_edge {
+		c.vertex_edge[i] = -1
+	}
+	for e, v := range c.triangles {
+		c.vertex_edge[v] = e
+	}
+
+	// Duplicate Points are not in the mesh, so use the copy that is.
+	for _, p := range all_points {
+		index[p] = -1
+	}
+	for i, p := range all_points {
+		if (index[p] == -1) && (c.vertex_edge[i] != -1) {
+			index[p] = i
+		}
+	}
+
+	var constraint_edges [][2]int
+	if len(c.triangles) > 0 {
+		for _, ends := range piece_ends {
+			// A crossing rounded just outside the range that can be triangulated is not
+			// in the mesh.
+			if !triangulable(all_points[ends[0]]) || !triangulable(all_points[ends[1]]) {
+				continue
+			}
+			constraint_edges = c.insert(index[all_points[ends[0]]], index[all_points[ends[1]]], constraint_edges)
+		}
+	}
+
+	d := c.delaunay()
+	cd := &ConstrainedDelaunay{
+		Points:      d.Points,
+		Vertices:    d.Vertices,
+		Neighbors:   d.Neighbors,
+		Constrained: make([][3]bool, len(d.Vertices)),
+		Constraints: constraint_edges,
+		Hull:        d.Hull,
+	}
+	for e, constrained := range c.constrained {
+		cd.Constrained[e/3][e%3] = constrained
+	}
+	return cd, nil
+}
+
+// Triangle returns the t-th triangle.
+func (c *ConstrainedDelaunay) Triangle(t int) Triangle {
+	v := c.Vertices[t]
+	return Triangle{c.Points[v[0]], c.Points[v[1]], c.Points[v[2]]}
+}
+
+// Triangles returns all the triangles.
+func (c *ConstrainedDelaunay) Triangles() []Triangle {
+	triangles := make([]Triangle, len(c.Vertices))
+	for t := range c.Vertices {
+		triangles[t] = c.Triangle(t)
+	}
+	return triangles
+}
+
+// TrianglesInside returns the indexes, in increasing order, of the triangles that lie
+// inside `boundary`. The edges of every ring of `boundary` should be among the
+// constraints, so that no triangle is partly inside and partly outside. The triangles
+// are grouped into regions that are not separated by any constraint, and only one
+// triangle from each region needs to be located.
+func (c *ConstrainedDelaunay) TrianglesInside(boundary PolygonWithHoles) []int {
+	region := make([]int, len(c.Vertices))
+	for t := range region {
+		region[t] = -1
+	}
+
+	inside := []int{}
+	var stack []int
+	for start := range c.Vertices {
+		if region[start] != -1 {
+			continue
+		}
+
+		// Every triangle of the region is inside if its first one is. The centroid of a
+		// triangle is never on one of its edges, so it is on the Boundary only if
+		// `boundary` does not follow the constraints.
+		first := c.Triangle(start)
+		centroid := first.P1.Plus(first.P2).Plus(first.P3).Divide(3)
+		is_inside := boundary.LocatePoint(centroid) == Interior
+
+		region[start] = start
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			t := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if is_inside {
+				inside = append(inside, t)
+			}
+			for i, neighbor := range c.Neighbors[t] {
+				if (neighbor != -1) && !c.Constrained[t][i] && (region[neighbor] == -1) {
+					region[neighbor] = start
+					stack = append(stack, neighbor)
+				}
+			}
+		}
+	}
+	sort.Ints(inside)
+	return inside
+}
+
+// split_constraints splits the constraints wherever they cross or touch each other, so
+// that the pieces only meet at their ends. Constraints with an end that is not
+// triangulable are dropped first, and then pieces with no length and repeated pieces.
+func split_constraints(constraints []LineSegment) ([]LineSegment, error) {
+	usable := make([]LineSegment, 0, len(constraints))
+	for _, constraint := range constraints {
+		if triangulable(constraint.P1) && triangulable(constraint.P2) {
+			usable = append(usable, constraint)
+		}
+	}
+	pieces, _, err := split_segments(usable)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop repeated pieces, in either direction.
+	seen := make(map[LineSegment]bool, len(pieces))
+	unique := pieces[:0]
+	for _, piece := range pieces {
+		if !seen[piece] && !seen[LineSegment{piece.P2, piece.P1}] {
+			seen[piece] = true
+			unique = append(unique, piece)
+		}
+	}
+	return unique, nil
+}
+
+// split_segments splits the segments wherever they cross or touch each other, so that
+// the pieces only meet at their ends, or lie exactly on top of each other. Each piece
+// runs the same way as the segment it came from, and is returned along with the index
+// of that segment. Segments with no length are dropped. It fails with ErrTooManySplits if
+// pieces still cross after max_split_passes.
+func split_segments(segments []LineSegment) ([]LineSegment, []int, error) {
+	pieces := make([]LineSegment, 0, len(segments))
+	origins := make([]int, 0, len(segments))
+	for i, segment := range segments {
+		if !segment.P1.Equals(segment.P2) {
+			pieces = append(pieces, segment)
+			origins = append(origins, i)
+		}
+	}
+
+	// Pieces that were not split in one pass have already been checked against each
+	// other, so after the first pass only pairs with a new piece are checked.
+	changed := make([]bool, len(pieces))
+	for i := range changed {
+		changed[i] = true
+	}
+	for pass := 0; ; pass++ {
+		splits := make([][]Point, len(pieces))
+		split := false
+		add_split := func(i int, p Point) {
+			if !p.Equals(pieces[i].P1) && !p.Equals(pieces[i].P2) {
+				splits[i] = append(splits[i], p)
+				split = true
+			}
+		}
+
+		boxes := make([]BoundingBox, len(pieces))
+		for i, piece := range pieces {
+			boxes[i] = piece.BoundingBox()
+		}
+		intersecting_boxes(boxes, func(i, j int) {
+			if !changed[i] && !changed[j] {
+				return
+			}
+			intersection := pieces[i].Intersection(pieces[j])
+			switch intersection.Kind {
+			case PointIntersection:
+				p := snap_to_ends(intersection.Point, pieces[i], pieces[j])
+				add_split(i, p)
+				add_split(j, p)
+			case OverlapIntersection:
+				for _, p := range []Point{intersection.Segment.P1, intersection.Segment.P2} {
+					add_split(i, p)
+					add_split(j, p)
+				}
+			}
+		})
+		if !split {
+			return pieces, origins, nil
+		}
+		if pass == max_split_passes {
+			return nil, nil, ErrTooManySplits
+		}
+
+		var split_pieces []LineSegment
+		var split_origins []int
+		var split_changed []bool
+		for i, piece := range pieces {
+			// The end of the piece is sorted with the splits only to set the direction. A
+			// crossing Point can be rounded or snapped to just past that end, but the piece
+			// must still finish there.
+			points := append(splits[i], piece.P2)
+			sort_along(piece.P1, points)
+			chain := []Point{piece.P1}
+			for _, p := range points {
+				if !p.Equals(piece.P2) {
+					chain = append(chain, p)
+				}
+			}
+			chain = append(chain, piece.P2)
+			for k := 1; k < len(chain); k++ {
+				if !chain[k].Equals(chain[k-1]) {
+					split_pieces = append(split_pieces, LineSegment{chain[k-1], chain[k]})
+					split_origins = append(split_origins, origins[i])
+					split_changed = append(split_changed, len(splits[i]) > 0)
+				}
+			}
+		}
+		pieces, origins, changed = split_pieces, split_origins, split_changed
+	}
+}
+
+// snap_to_ends returns the end of the LineSegment `a` or `b` nearest to the crossing
+// Point `p`, if it is within snap_distance of it, or else `p` itself.
+func snap_to_ends(p Point, a, b LineSegment) Point {
+	snapped := p
+	best := snap_distance * math.Max(math.Abs(p.X), math.Abs(p.Y))
+	for _, end := range []Point{a.P1, a.P2, b.P1, b.P2} {
+		if d := math.Max(math.Abs(end.X-p.X), math.Abs(end.Y-p.Y)); d <= best {
+			snapped, best = end, d
+		}
+	}
+	return snapped
+}
+
+// constrained_mesh is a mesh that tracks which half-edges are constrained, and one
+// half-edge starting at each vertex.
+type constrained_mesh struct {
+	mesh
+	constrained []bool
+	vertex_edge []int
+	stack       [][2]int
+}
+
+// insert makes the LineSegment from vertex `a` to vertex `b` part of the mesh, and marks
+// it as constrained. If it runs through other vertices, each part is inserted
+// separately. Every inserted edge is appended to `edges`, which is returned.
+func (c *constrained_mesh) insert(a, b int, edges [][2]int) [][2]int {
+	for a != b {
+		end, crossing := c.crossing_edges(a, b)
+		if len(crossing) > 0 {
+			c.flip_out(a, end, crossing)
+		}
+		e := c.find_edge(a, end)
+		c.constrained[e] = true
+		if opposite := c.halfedges[e]; opposite != -1 {
+			c.constrained[opposite] = true
+		}
+		edges = append(edges, [2]int{a, end})
+
+		// Restore the Delaunay property around the new edges, without flipping any
+		// constrained edge.
+		for len(c.stack) > 0 {
+			edge := c.stack[len(c.stack)-1]
+			c.stack = c.stack[:len(c.stack)-1]
+			c.legalize(edge[0], edge[1])
+		}
+		a = end
+	}
+	return edges
+}
+
+// crossing_edges follows the LineSegment from vertex `a` towards vertex `b` until it
+// reaches `b` or runs into another vertex, `end`. It returns `end`, along with the
+// edges that the LineSegment crosses on the way as pairs of vertices, each with its
+// first vertex to the right of the LineSegment.
+func (c *constrained_mesh) crossing_edges(a, b int) (int, [][2]int) {
+	pa, pb := c.points[a], c.points[b]
+	on_segment := func(v int) bool {
+		return (Orient2D(pa, pb, c.points[v]) == 0) && (c.points[v].Minus(pa).DotProduct(pb.Minus(pa)) > 0)
+	}
+
+	// Find the triangle around `a` that the LineSegment leaves through.
+	h := -1
+	for _, e := range c.outgoing_edges(a) {
+		x := c.triangles[next_half_edge(e)]
+		y := c.triangles[previous_half_edge(e)]
+		for _, v := range []int{x, y} {
+			if (v == b) || on_segment(v) {
+				return v, nil
+			}
+		}
+		if (Orient2D(pa, c.points[x], pb) > 0) && (Orient2D(pa, c.points[y], pb) < 0) {
+			h = next_half_edge(e)
+			break
+		}
+	}
+
+	var crossing [][2]int
+	for h != -1 {
+		crossing = append(crossing, [2]int{c.triangles[h], c.triangles[next_half_edge(h)]})
+		opposite := c.halfedges[h]
+		z := c.triangles[previous_half_edge(opposite)]
+		if (z == b) || on_segment(z) {
+			return z, crossing
+		}
+		if Orient2D(pa, pb, c.points[z]) > 0 {
+			h = next_half_edge(opposite)
+		} else {
+			h = previous_half_edge(opposite)
+		}
+	}
+	return b, crossing
+}
+
+// outgoing_edges returns every half-edge that starts at vertex `v`.
+func (c *constrained_mesh) outgoing_edges(v int) []int {
+	start := c.vertex_edge[v]
+	edges := []int{start}
+	// Turn counter-clockwise around `v` until reaching the hull, or coming back to the
+	// start.
+	for e := c.halfedges[previous_half_edge(start)]; e != start; e = c.halfedges[previous_half_edge(e)] {
+		if e == -1 {
+			// Then turn clockwise from the start to cover the rest.
+			for f := c.halfedges[start]; f != -1; f = c.halfedges[next_half_edge(f)] {
+				edges = append(edges, next_half_edge(f))
+			}
+			break
+		}
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+// find_edge returns the half-edge from vertex `a` to vertex `b`, or the half-edge from
+// `b` to `a` if that edge is on the hull.
+func (c *constrained_mesh) find_edge(a, b int) int {
+	for _, e := range c.outgoing_edges(a) {
+		if c.triangles[next_half_edge(e)] == b {
+			return e
+		}
+		if c.triangles[previous_half_edge(e)] == b {
+			return previous_half_edge(e)
+		}
+	}
+	return -1
+}
+
+// flip_out flips the edges `crossing`, which cross the LineSegment from vertex `a` to
+// vertex `b`, until none do. An edge can only be flipped once the two triangles on
+// either side of it form a convex quadrilateral, so any edge that cannot be flipped yet
+// waits until the others have been. Each new edge that does not cross the LineSegment
+// is pushed onto the stack.
+func (c *constrained_mesh) flip_out(a, b int, crossing [][2]int) {
+	pa, pb := c.points[a], c.points[b]
+	for len(crossing) > 0 {
+		edge := crossing[0]
+		crossing = crossing[1:]
+
+		e := c.find_edge(edge[0], edge[1])
+		u, v := c.points[edge[0]], c.points[edge[1]]
+		w1 := c.triangles[previous_half_edge(e)]
+		w2 := c.triangles[previous_half_edge(c.halfedges[e])]
+		p1, p2 := c.points[w1], c.points[w2]
+		if sign(Orient2D(p1, p2, u))*sign(Orient2D(p1, p2, v)) >= 0 {
+			crossing = append(crossing, edge)
+			continue
+		}
+
+		c.flip(e)
+		s1 := sign(Orient2D(pa, pb, p1))
+		s2 := sign(Orient2D(pa, pb, p2))
+		if (w1 != a) && (w1 != b) && (w2 != a) && (w2 != b) && (s1*s2 < 0) {
+			if s1 < 0 {
+				crossing = append(crossing, [2]int{w1, w2})
+			} else {
+				crossing = append(crossing, [2]int{w2, w1})
+			}
+		} else {
+			c.stack = append(c.stack, [2]int{w1, w2})
+		}
+	}
+}
+
+// legalize flips the edge between vertices `a` and `b` if it is not constrained and the
+// triangles on either side of it are not Delaunay, and then pushes the edges around
+// them onto the stack to be checked in turn.
+func (c *constrained_mesh) legalize(a, b int) {
+	e := c.find_edge(a, b)
+	if (e == -1) || c.constrained[e] || (c.halfedges[e] == -1) {
+		return
+	}
+	u, v := c.triangles[e], c.triangles[next_half_edge(e)]
+	w1 := c.triangles[previous_half_edge(e)]
+	w2 := c.triangles[previous_half_edge(c.halfedges[e])]
+	if InCircle(c.points[u], c.points[v], c.points[w1], c.points[w2]) <= 0 {
+		return
+	}
+	c.flip(e)
+	c.stack = append(c.stack, [2]int{u, w1}, [2]int{w1, v}, [2]int{v, w2}, [2]int{w2, u})
+}
+
+// flip replaces half-edge `h`, from u to v, and its opposite with the other diagonal of
+// the quadrilateral formed by their triangles, which must be convex.
+//
+//	     w1                    w1
+//	    /  \                  /||\
+//	 p1/    \n1            n1/ || \p2
+//	  /  h   \     flip     /  ||  \
+//	u -------- v    =>     u  h||g  v
+//	  \  g   /              \  ||  /
+//	 n2\    /p2            p1\ || /n2
+//	    \  /                  \||/
+//	     w2                    w2
+func (c *constrained_mesh) flip(h int) {
+	g := c.halfedges[h]
+	n1, p1 := next_half_edge(h), previous_half_edge(h)
+	n2, p2 := next_half_edge(g), previous_half_edge(g)
+	u, v := c.triangles[h], c.triangles[g]
+	w1, w2 := c.triangles[p1], c.triangles[p2]
+
+	// The outside edges move to new places in the two triangles.
+	opposite_n1, opposite_p1 := c.halfedges[n1], c.halfedges[p1]
+	opposite_n2, opposite_p2 := c.halfedges[n2], c.halfedges[p2]
+	constrained_n1, constrained_p1 := c.constrained[n1], c.constrained[p1]
+	constrained_n2, constrained_p2 := c.constrained[n2], c.constrained[p2]
+
+	c.triangles[h], c.triangles[n1], c.triangles[p1] = w2, w1, u
+	c.triangles[g], c.triangles[n2], c.triangles[p2] = w1, w2, v
+	c.link(h, g)
+	c.link(n1, opposite_p1)
+	c.link(p1, opposite_n2)
+	c.link(n2, opposite_p2)
+	c.link(p2, opposite_n1)
+	c.constrained[h], c.constrained[g] = false, false
+	c.constrained[n1], c.constrained[p1] = constrained_p1, constrained_n2
+	c.constrained[n2], c.constrained[p2] = constrained_p2, constrained_n1
+
+	c.vertex_edge[u] = p1
+	c.vertex_edge[v] = p2
+	c.vertex_edge[w1] = n1
+	c.vertex_edge[w2] = n2
+}
@@ -0,0 +1,353 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// check_constrained verifies the structure of a constrained Delaunay triangulation:
+// every triangle is counter-clockwise, neighbors agree, every constraint is an edge,
+// every edge that is not constrained is locally Delaunay, and the triangles cover the
+// hull.
+func check_constrained(t *testing.T, c *ConstrainedDelaunay) {
+	t.Helper()
+
+	// The constrained edges, in both directions.
+	constrained := make(map[[2]int]bool)
+	area := 0.0
+	for tri, v := range c.Vertices {
+		triangle := c.Triangle(tri)
+		if Orient2D(triangle.P1, triangle.P2, triangle.P3) <= 0 {
+			t.Fatalf("triangle %v = %v is not counter-clockwise", tri, triangle)
+		}
+		area += triangle.Area()
+
+		for i := 0; i < 3; i++ {
+			a, b := v[i], v[(i+1)%3]
+			if c.Constrained[tri][i] {
+				constrained[[2]int{a, b}] = true
+				constrained[[2]int{b, a}] = true
+			}
+
+			neighbor := c.Neighbors[tri][i]
+			if neighbor == -1 {
+				continue
+			}
+			shared := false
+			for j, w := 0, c.Vertices[neighbor]; j < 3; j++ {
+				if (w[j] == b) && (w[(j+1)%3] == a) && (c.Neighbors[neighbor][j] == tri) &&
+					(c.Constrained[neighbor][j] == c.Constrained[tri][i]) {
+					shared = true
+				}
+			}
+			if !shared {
+				t.Fatalf("triangles %v and %v do not agree on edge %v", tri, neighbor, i)
+			}
+
+			if !c.Constrained[tri][i] {
+				for _, opposite := range c.Vertices[neighbor] {
+					if (opposite != v[0]) && (opposite != v[1]) && (opposite != v[2]) &&
+						(InCircle(triangle.P1, triangle.P2, triangle.P3, c.Points[opposite]) > 0) {
+						t.Fatalf("unconstrained edge %v of triangle %v is not Delaunay", i, tri)
+					}
+				}
+			}
+		}
+	}
+
+	for _, edge := range c.Constraints {
+		if !constrained[edge] {
+			t.Fatalf("constraint %v, from %v to %v, is not a constrained edge",
+				edge, c.Points[edge[0]], c.Points[edge[1]])
+		}
+	}
+
+	if len(c.Vertices) > 0 {
+		hull := make([]Point, len(c.Hull))
+		for i, h := range c.Hull {
+			hull[i] = c.Points[h]
+		}
+		if hull_area := (Polygon{hull}).SignedArea(); math.Abs(hull_area-area) > 1e-9*math.Max(1, area) {
+			t.Errorf("triangles have area %v, but the hull has area %v", area, hull_area)
+		}
+	}
+}
+
+func TestNewConstrainedDelaunay(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		points      []Point
+		constraints []LineSegment
+		triangles   int
+		pieces      []LineSegment
+	}{
+		{
+			desc:        "No constraints",
+			points:      unit_square.Points,
+			constraints: nil,
+			triangles:   2,
+			pieces:      nil,
+		},
+		{
+			desc:        "Forced long diagonal",
+			points:      []Point{{-2, 0}, {0, -0.5}, {2, 0}, {0, 0.5}},
+			constraints: []LineSegment{{Point{-2, 0}, Point{2, 0}}},
+			triangles:   2,
+			pieces:      []LineSegment{{Point{-2, 0}, Point{2, 0}}},
+		},
+		{
+			desc:   "Crossing constraints",
+			points: unit_square.Points,
+			constraints: []LineSegment{
+				{Point{0, 0}, Point{1, 1}},
+				{Point{1, 0}, Point{0, 1}},
+			},
+			triangles: 4,
+			pieces: []LineSegment{
+				{Point{0, 0}, Point{0.5, 0.5}},
+				{Point{0.5, 0.5}, Point{1, 1}},
+				{Point{1, 0}, Point{0.5, 0.5}},
+				{Point{0.5, 0.5}, Point{0, 1}},
+			},
+		},
+		{
+			desc:        "Constraint through a Point",
+			points:      []Point{{0, 0}, {1, 0}, {2, 0}, {1, 1}, {1, -1}},
+			constraints: []LineSegment{{Point{0, 0}, Point{2, 0}}},
+			triangles:   4,
+			pieces: []LineSegment{
+				{Point{0, 0}, Point{1, 0}},
+				{Point{1, 0}, Point{2, 0}},
+			},
+		},
+		{
+			desc:        "Constraint with new ends",
+			points:      unit_square.Points,
+			constraints: []LineSegment{{Point{0.25, 0.5}, Point{0.75, 0.5}}},
+			triangles:   6,
+			pieces:      []LineSegment{{Point{0.25, 0.5}, Point{0.75, 0.5}}},
+		},
+		{
+			desc:   "Overlapping and repeated constraints",
+			points: []Point{{0, 0}, {3, 0}, {1.5, 1}, {1.5, -1}},
+			constraints: []LineSegment{
+				{Point{0, 0}, Point{2, 0}},
+				{Point{3, 0}, Point{1, 0}},
+				{Point{2, 0}, Point{0, 0}},
+				{Point{1, 1}, Point{1, 1}},
+			},
+			triangles: 6,
+			pieces: []LineSegment{
+				{Point{0, 0}, Point{1, 0}},
+				{Point{1, 0}, Point{2, 0}},
+				{Point{3, 0}, Point{2, 0}},
+			},
+		},
+		{
+			desc:   "Constraints to Points that are not finite or too large",
+			points: append([]Point{{math.NaN(), 0.5}, {1e200, 1e200}}, unit_square.Points...),
+			constraints: []LineSegment{
+				{Point{0, 0}, Point{math.NaN(), 0.5}},
+				{Point{0, 0}, Point{1, 1}},
+				{Point{1, 0}, Point{1e200, 1e200}},
+			},
+			triangles: 2,
+			pieces:    []LineSegment{{Point{0, 0}, Point{1, 1}}},
+		},
+		{
+			desc:        "Collinear Points",
+			points:      []Point{{0, 0}, {1, 1}, {2, 2}},
+			constraints: []LineSegment{{Point{0, 0}, Point{2, 2}}},
+			triangles:   0,
+			pieces:      nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c, err := NewConstrainedDelaunay(tC.points, tC.constraints)
+			if err != nil {
+				t.Fatalf("NewConstrainedDelaunay() returned %v", err)
+			}
+			if len(c.Vertices) != tC.triangles {
+				t.Errorf("NewConstrainedDelaunay() has %v triangles, want %v", len(c.Vertices), tC.triangles)
+			}
+			if len(c.Constraints) != len(tC.pieces) {
+				t.Fatalf("Constraints = %v, want %v", c.Constraints, tC.pieces)
+			}
+			for i, piece := range tC.pieces {
+				got := LineSegment{c.Points[c.Constraints[i][0]], c.Points[c.Constraints[i][1]]}
+				if !got.Equals(piece) {
+					t.Errorf("Constraints[%v] = %v, want %v", i, got, piece)
+				}
+			}
+			check_constrained(t, c)
+		})
+	}
+}
+
+func TestNewConstrainedDelaunayRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(10))
+	for trial := 0; trial < 50; trial++ {
+		points := random_points(rng, 100)
+		var constraints []LineSegment
+		for i := 0; i < 10; i++ {
+			// A mix of constraints between existing Points, and between new ones.
+			if rng.Intn(2) == 0 {
+				constraints = append(constraints, LineSegment{points[rng.Intn(len(points))], points[rng.Intn(len(points))]})
+			} else {
+				constraints = append(constraints, LineSegment{random_points(rng, 1)[0], random_points(rng, 1)[0]})
+			}
+		}
+		// Points on a small grid, to give collinear Points and constraints.
+		for i := 0; i < 20; i++ {
+			points = append(points, Point{float64(rng.Intn(5)) / 4, float64(rng.Intn(5)) / 4})
+		}
+		constraints = append(constraints, LineSegment{Point{0, 0.5}, Point{1, 0.5}}, LineSegment{Point{0, 0}, Point{1, 1}})
+
+		c, err := NewConstrainedDelaunay(points, constraints)
+		if err != nil {
+			t.Fatalf("NewConstrainedDelaunay() returned %v", err)
+		}
+		check_constrained(t, c)
+	}
+}
+
+func TestSplitSegments(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		segments []LineSegment
+	}{
+		{
+			desc: "Crossing at one Point",
+			segments: []LineSegment{
+				{Point{0, 0}, Point{2, 2}},
+				{Point{2, 0}, Point{0, 2}},
+				{Point{1, 0}, Point{1, 2}},
+			},
+		},
+		{
+			// The three crossings are within a few units of rounding of each other, so
+			// splitting at each of them makes tiny pieces that cross again.
+			desc: "Nearly concurrent",
+			segments: []LineSegment{
+				{Point{0.15451433775520748, -0.1787734011504104}, Point{0.5186370152820153, 0.2726928911137621}},
+				{Point{0.3378133632795316, -0.06705620717745604}, Point{0.40013571473626747, 0.15680928205316252}},
+				{Point{0.0915465482048215, -0.36644909406552073}, Point{0.5209093914016586, 0.3252197010084493}},
+			},
+		},
+		{
+			// The pieces from splitting at the rounded crossings cross again, so they
+			// are split a second time.
+			desc: "Crossing again after the first pass",
+			segments: []LineSegment{
+				{Point{1.4523480907450672, -0.23263381133850616}, Point{0.49541565739627746, 0.6577557094391456}},
+				{Point{1.1619963312436188, 0.26409658510986267}, Point{0.7857674168977269, 0.16102531299077563}},
+				{Point{0.8020527678360337, 0.3123726596266766}, Point{1.1457109803053127, 0.11274923847396345}},
+				{Point{1.1545506064635553, 0.2131754552979059}, Point{0.7932131416777901, 0.2119464428027323}},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			pieces, origins, err := split_segments(tC.segments)
+			if err != nil {
+				t.Fatalf("split_segments() returned %v", err)
+			}
+			at_end := func(p Point, l LineSegment) bool { return p.Equals(l.P1) || p.Equals(l.P2) }
+			for i := range pieces {
+				for j := i + 1; j < len(pieces); j++ {
+					intersection := pieces[i].Intersection(pieces[j])
+					if (intersection.Kind == PointIntersection) &&
+						(!at_end(intersection.Point, pieces[i]) || !at_end(intersection.Point, pieces[j])) {
+						t.Errorf("pieces %v and %v cross at %v", pieces[i], pieces[j], intersection.Point)
+					}
+				}
+			}
+
+			// The pieces of each segment run from one of its ends to the other.
+			k := 0
+			for s, segment := range tC.segments {
+				end := segment.P1
+				for ; (k < len(pieces)) && (origins[k] == s); k++ {
+					if !pieces[k].P1.Equals(end) {
+						t.Errorf("piece %v of %v does not start at %v", pieces[k], segment, end)
+					}
+					end = pieces[k].P2
+				}
+				if !end.Equals(segment.P2) {
+					t.Errorf("pieces of %v end at %v", segment, end)
+				}
+			}
+		})
+	}
+}
+
+func TestConstrainedDelaunayTrianglesInside(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	testCases := []struct {
+		desc     string
+		boundary PolygonWithHoles
+		points   []Point
+	}{
+		{
+			desc:     "L-shape",
+			boundary: PolygonWithHoles{Shell: l_shape},
+			points:   nil,
+		},
+		{
+			desc:     "L-shape with Points inside and out",
+			boundary: PolygonWithHoles{Shell: l_shape},
+			points:   append(random_points(rng, 50), Point{1.5, 1.5}, Point{1.8, 1.9}),
+		},
+		{
+			desc:     "Polygon with holes",
+			boundary: swiss_cheese,
+			points:   []Point{{3, 3}, {5, 5}, {1, 9}, {7, 7}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c, err := NewConstrainedDelaunay(tC.points, tC.boundary.Edges())
+			if err != nil {
+				t.Fatalf("NewConstrainedDelaunay() returned %v", err)
+			}
+			check_constrained(t, c)
+
+			area := 0.0
+			for _, tri := range c.TrianglesInside(tC.boundary) {
+				triangle := c.Triangle(tri)
+				area += triangle.Area()
+				centroid := triangle.P1.Plus(triangle.P2).Plus(triangle.P3).Divide(3)
+				if tC.boundary.LocatePoint(centroid) != Interior {
+					t.Errorf("TrianglesInside() has %v, which is outside", triangle)
+				}
+			}
+			if !almost_zero(area - tC.boundary.Area()) {
+				t.Errorf("TrianglesInside() has area %v, want %v", area, tC.boundary.Area())
+			}
+		})
+	}
+}
+
+func BenchmarkNewConstrainedDelaunay(b *testing.B) {
+	rng := rand.New(rand.NewSource(12))
+	circle := regular_polygon(Point{0.5, 0.5}, 0.5, 1000)
+	benchmarks := []struct {
+		desc        string
+		points      []Point
+		constraints []LineSegment
+	}{
+		{"10000 random Points with a circle", random_points(rng, 10000), circle.Edges()},
+		{"10000 random Points with 100 crossing lines", random_points(rng, 10000), Polyline{random_points(rng, 101)}.Segments()},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewConstrainedDelaunay(bm.points, bm.constraints)
+			}
+
+		})
+	}
+}
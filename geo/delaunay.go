@@ -0,0 +1,606 @@
+package gogeo
+
+import (
+	"math"
+	"sort"
+)
+
+// Delaunay is a Delaunay triangulation of a set of Points. No Point lies strictly inside
+// the circumcircle of any triangle, which maximizes the smallest angle over all possible
+// triangulations. The triangles exactly cover the convex hull of the Points.
+//
+// Duplicate Points are only triangulated once, and any other copies are not a vertex of
+// any triangle. Nor is any Point with an infinite or NaN coordinate, or a coordinate
+// larger in magnitude than 1e75, beyond which the predicates overflow. If all the
+// Points lie on one line, there are no triangles, and Hull holds the distinct Points in
+// order along the line.
+type Delaunay struct {
+	// Points are the Points that were triangulated.
+	Points []Point
+	// Vertices holds the indexes into Points of the three vertices of each triangle, in
+	// counter-clockwise order.
+	Vertices [][3]int
+	// Neighbors holds, for each triangle, the indexes of the triangles across each of
+	// its edges. Neighbors[t][i] is across the edge from Vertices[t][i] to
+	// Vertices[t][(i+1)%3], and is -1 if that edge is on the convex hull.
+	Neighbors [][3]int
+	// Hull holds the indexes into Points of the vertices of the convex hull, in
+	// counter-clockwise order.
+	Hull []int
+}
+
+// NewDelaunay calculates the Delaunay triangulation of `points`. It uses a sweep-hull
+// algorithm: Points are added in order of distance from a seed triangle, each one
+// connected to the edges of the current convex hull that it can see, and edges are then
+// flipped until the triangulation is Delaunay again. All decisions are made with the
+// exact Orient2D and InCircle predicates.
+func NewDelaunay(points []Point) *Delaunay {
+	m := new_delaunay_mesh(points)
+	return m.delaunay()
+}
+
+// Triangle returns the t-th triangle.
+func (d *Delaunay) Triangle(t int) Triangle {
+	v := d.Vertices[t]
+	return Triangle{d.Points[v[0]], d.Points[v[1]], d.Points[v[2]]}
+}
+
+// Triangles returns all the triangles.
+func (d *Delaunay) Triangles() []Triangle {
+	triangles := make([]Triangle, len(d.Vertices))
+	for t := range d.Vertices {
+		triangles[t] = d.Triangle(t)
+	}
+	return triangles
+}
+
+// Edges returns every edge of the triangulation once, as pairs of indexes into Points.
+func (d *Delaunay) Edges() [][2]int {
+	var edges [][2]int
+	for t, v := range d.Vertices {
+		for i := 0; i < 3; i++ {
+			// Each interior edge is shared by two triangles, so only report it from the
+			// triangle with the lower index.
+			if neighbor := d.Neighbors[t][i]; (neighbor == -1) || (t < neighbor) {
+				edges = append(edges, [2]int{v[i], v[(i+1)%3]})
+			}
+		}
+	}
+	return edges
+}
+
+// mesh is a triangulation stored as half-edges. Half-edge e belongs to triangle e/3 and
+// runs from vertex triangles[e] to vertex triangles[next_half_edge(e)].
+// halfedges[e] is the half-edge running the other way along the same edge in the
+// neighboring triangle, or -1 if there is no neighboring triangle.
+type mesh struct {
+	points    []Point
+	triangles []int
+	halfedges []int
+	hull      []int
+}
+
+// next_half_edge returns the next half-edge counter-clockwise around the same triangle.
+func next_half_edge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// previous_half_edge returns the previous half-edge counter-clockwise around the same
+// triangle.
+func previous_half_edge(e int) int {
+	if e%3 == 0 {
+		return e + 2
+	}
+	return e - 1
+}
+
+// delaunay converts a mesh into a Delaunay.
+func (m *mesh) delaunay() *Delaunay {
+	n := len(m.triangles) / 3
+	d := &Delaunay{
+		Points:    m.points,
+		Vertices:  make([][3]int, n),
+		Neighbors: make([][3]int, n),
+		Hull:      m.hull,
+	}
+	for t := 0; t < n; t++ {
+		for i := 0; i < 3; i++ {
+			e := 3*t + i
+			d.Vertices[t][i] = m.triangles[e]
+			d.Neighbors[t][i] = -1
+			if opposite := m.halfedges[e]; opposite != -1 {
+				d.Neighbors[t][i] = opposite / 3
+			}
+		}
+	}
+	return d
+}
+
+// delaunay_builder holds the state of the sweep-hull algorithm while it runs.
+type delaunay_builder struct {
+	mesh
+	// The convex hull so far, as a circular doubly linked list of Point indexes. A Point
+	// that has left the hull has hull_next pointing at itself.
+	hull_start int
+	hull_prev  []int
+	hull_next  []int
+	// hull_tri holds, for each Point on the hull, the half-edge of the triangle on the
+	// hull edge starting at that Point.
+	hull_tri []int
+	// hull_hash finds a Point on the hull near a given angle around center.
+	hull_hash []int
+	center    Point
+	stack     []int
+}
+
+// max_delaunay_coordinate is the largest magnitude of a coordinate that can be
+// triangulated. InCircle multiplies together four differences of coordinates, which
+// overflow beyond about 1e77, and then the sign it returns is meaningless.
+const max_delaunay_coordinate = 1e75
+
+// triangulable tests if both coordinates of `p` are finite and no larger in magnitude
+// than max_delaunay_coordinate.
+func triangulable(p Point) bool {
+	return (math.Abs(p.X) <= max_delaunay_coordinate) && (math.Abs(p.Y) <= max_delaunay_coordinate)
+}
+
+// new_delaunay_mesh triangulates the triangulable `points` into a mesh.
+func new_delaunay_mesh(points []Point) *mesh {
+	n := len(points)
+	b := &delaunay_builder{mesh: mesh{points: points}}
+	ids := make([]int, 0, n)
+	for i, p := range points {
+		if triangulable(p) {
+			ids = append(ids, i)
+		}
+	}
+	if len(ids) < 3 {
+		b.hull = collinear_hull(points, ids)
+		return &b.mesh
+	}
+
+	// Pick a seed Point close to the center of the bounding box, its nearest neighbor,
+	// and the Point which makes the smallest circumcircle with those two.
+	min_x, min_y := math.Inf(1), math.Inf(1)
+	max_x, max_y := math.Inf(-1), math.Inf(-1)
+	for _, i := range ids {
+		p := points[i]
+		min_x, max_x = math.Min(min_x, p.X), math.Max(max_x, p.X)
+		min_y, max_y = math.Min(min_y, p.Y), math.Max(max_y, p.Y)
+	}
+	middle := Point{(min_x + max_x) / 2, (min_y + max_y) / 2}
+
+	i0 := closest_point_index(points, ids, middle, -1)
+	i1 := closest_point_index(points, ids, points[i0], i0)
+	if i1 == -1 {
+		b.hull = collinear_hull(points, ids)
+		return &b.mesh
+	}
+	i2 := -1
+	min_radius := math.Inf(1)
+	for _, i := range ids {
+		p := points[i]
+		if (i == i0) || (i == i1) || (Orient2D(points[i0], points[i1], p) == 0) {
+			continue
+		}
+		// The radius can overflow for a nearly collinear triangle, which is still better
+		// than none.
+		if r := circumradius_squared(points[i0], points[i1], p); (i2 == -1) || (r < min_radius) {
+			i2 = i
+			min_radius = r
+		}
+	}
+	if i2 == -1 {
+		b.hull = collinear_hull(points, ids)
+		return &b.mesh
+	}
+	if Orient2D(points[i0], points[i1], points[i2]) < 0 {
+		i1, i2 = i2, i1
+	}
+	b.center = circumcenter(points[i0], points[i1], points[i2])
+	if math.IsInf(b.center.X, 0) || math.IsInf(b.center.Y, 0) || math.IsNaN(b.center.X) || math.IsNaN(b.center.Y) {
+		// The seed triangle is too flat to find its circumcenter, so sweep out from its
+		// centroid instead. Points can then be inside the hull when they are reached,
+		// which insert_inside handles.
+		b.center = points[i0].Plus(points[i1]).Plus(points[i2]).Divide(3)
+	}
+
+	// Sort the Points by distance from the center of the seed triangle, so that each one
+	// is outside the hull of those before it. Ties are broken by position so that
+	// duplicate Points end up next to each other. The distances are compared exactly,
+	// since a Point put ahead of a closer one by rounding could end up on or inside the
+	// hull.
+	sort.Slice(ids, func(i, j int) bool {
+		a, c := ids[i], ids[j]
+		if order := compare_distances(b.center, points[a], points[c]); order != 0 {
+			return order < 0
+		}
+		return less_xy(points[a], points[c])
+	})
+
+	max_triangles := 2*n - 5
+	b.triangles = make([]int, 0, 3*max_triangles)
+	b.halfedges = make([]int, 0, 3*max_triangles)
+	b.hull_prev = make([]int, n)
+	b.hull_next = make([]int, n)
+	b.hull_tri = make([]int, n)
+	b.hull_hash = make([]int, int(math.Ceil(math.Sqrt(float64(n)))))
+	for i := range b.hull_hash {
+		b.hull_hash[i] = -1
+	}
+
+	// The seed triangle is the starting hull.
+	b.hull_start = i0
+	hull_size := 3
+	b.hull_next[i0], b.hull_prev[i2] = i1, i1
+	b.hull_next[i1], b.hull_prev[i0] = i2, i2
+	b.hull_next[i2], b.hull_prev[i1] = i0, i0
+	b.hull_tri[i0], b.hull_tri[i1], b.hull_tri[i2] = 0, 1, 2
+	b.hull_hash[b.hash_key(points[i0])] = i0
+	b.hull_hash[b.hash_key(points[i1])] = i1
+	b.hull_hash[b.hash_key(points[i2])] = i2
+	b.add_triangle(i0, i1, i2, -1, -1, -1)
+
+	for k, i := range ids {
+		p := points[i]
+
+		// Skip duplicates, and the seed triangle itself.
+		if (k > 0) && p.Equals(points[ids[k-1]]) {
+			continue
+		}
+		if (i == i0) || (i == i1) || (i == i2) {
+			continue
+		}
+
+		// Find an edge of the hull that is visible from the new Point, starting from a
+		// hull Point at a similar angle around the center.
+		start := 0
+		key := b.hash_key(p)
+		for j := 0; j < len(b.hull_hash); j++ {
+			start = b.hull_hash[(key+j)%len(b.hull_hash)]
+			if (start != -1) && (start != b.hull_next[start]) {
+				break
+			}
+		}
+		start = b.hull_prev[start]
+		e := start
+		for Orient2D(p, points[e], points[b.hull_next[e]]) >= 0 {
+			e = b.hull_next[e]
+			if e == start {
+				e = -1
+				break
+			}
+		}
+		if e == -1 {
+			// The Point is not outside the hull. That only happens when the seed
+			// triangle is nearly flat, so it is rare enough to simply search for the
+			// triangle that contains it.
+			if b.insert_inside(i) {
+				hull_size++
+			}
+			b.reset_hull_tri()
+			continue
+		}
+
+		// Add the first triangle from the new Point, and flip edges until it is
+		// Delaunay.
+		t := b.add_triangle(e, i, b.hull_next[e], -1, -1, b.hull_tri[e])
+		b.hull_tri[i] = b.legalize(t + 2)
+		b.hull_tri[e] = t
+		hull_size++
+
+		// Walk forward along the hull, adding triangles for each visible edge.
+		next := b.hull_next[e]
+		for q := b.hull_next[next]; Orient2D(p, points[next], points[q]) < 0; q = b.hull_next[next] {
+			t = b.add_triangle(next, i, q, b.hull_tri[i], -1, b.hull_tri[next])
+			b.hull_tri[i] = b.legalize(t + 2)
+			b.hull_next[next] = next
+			hull_size--
+			next = q
+		}
+
+		// Walk backward along the hull from the other side.
+		if e == start {
+			for q := b.hull_prev[e]; Orient2D(p, points[q], points[e]) < 0; q = b.hull_prev[e] {
+				t = b.add_triangle(q, i, e, -1, b.hull_tri[e], b.hull_tri[q])
+				b.legalize(t + 2)
+				b.hull_tri[q] = t
+				b.hull_next[e] = e
+				hull_size--
+				e = q
+			}
+		}
+
+		// Connect the new Point into the hull.
+		b.hull_start = e
+		b.hull_prev[i] = e
+		b.hull_next[e] = i
+		b.hull_prev[next] = i
+		b.hull_next[i] = next
+		b.hull_hash[b.hash_key(p)] = i
+		b.hull_hash[b.hash_key(points[e])] = e
+	}
+
+	b.hull = make([]int, hull_size)
+	for i, e := 0, b.hull_start; i < hull_size; i++ {
+		b.hull[i] = e
+		e = b.hull_next[e]
+	}
+	return &b.mesh
+}
+
+// insert_inside adds the Point `i`, which lies inside or on the edge of the hull, by
+// splitting the triangle that contains it into three, or the triangles on either side of
+// the edge that it lies on into two each. It returns true if the Point was added to the
+// hull.
+func (b *delaunay_builder) insert_inside(i int) bool {
+	p := b.points[i]
+	for t := 0; t < len(b.triangles); t += 3 {
+		v0, v1, v2 := b.triangles[t], b.triangles[t+1], b.triangles[t+2]
+		o0 := sign(Orient2D(b.points[v0], b.points[v1], p))
+		o1 := sign(Orient2D(b.points[v1], b.points[v2], p))
+		o2 := sign(Orient2D(b.points[v2], b.points[v0], p))
+		if (o0 < 0) || (o1 < 0) || (o2 < 0) {
+			continue
+		}
+
+		switch {
+		case p.Equals(b.points[v0]) || p.Equals(b.points[v1]) || p.Equals(b.points[v2]):
+			return false
+		case o0 == 0:
+			return b.split_edge(t, i)
+		case o1 == 0:
+			return b.split_edge(t+1, i)
+		case o2 == 0:
+			return b.split_edge(t+2, i)
+		}
+
+		// Replace the triangle with three that meet at `i`.
+		h1, h2 := b.halfedges[t+1], b.halfedges[t+2]
+		b.triangles[t+2] = i
+		t1 := b.add_triangle(v1, v2, i, h1, -1, t+1)
+		t2 := b.add_triangle(v2, v0, i, h2, t+2, t1+1)
+		if h1 == -1 {
+			b.hull_tri[v1] = t1
+		}
+		if h2 == -1 {
+			b.hull_tri[v2] = t2
+		}
+		b.legalize(t)
+		b.legalize(t1)
+		b.legalize(t2)
+		return false
+	}
+	return false
+}
+
+// reset_hull_tri finds the half-edge on the hull starting at each Point of the hull
+// from scratch. Flipping edges next to the hull can move these half-edges in ways that
+// legalize only keeps track of during the sweep.
+func (b *delaunay_builder) reset_hull_tri() {
+	for e, opposite := range b.halfedges {
+		if opposite == -1 {
+			b.hull_tri[b.triangles[e]] = e
+		}
+	}
+}
+
+// split_edge adds the Point `i`, which lies on the edge of half-edge `h`, by splitting
+// each triangle beside that edge into two. It returns true if the edge was on the hull,
+// so that `i` was added to the hull.
+func (b *delaunay_builder) split_edge(h, i int) bool {
+	g := b.halfedges[h]
+	n1 := next_half_edge(h)
+	u, v, w1 := b.triangles[h], b.triangles[n1], b.triangles[previous_half_edge(h)]
+
+	// The triangle of `h` becomes (u, i, w1), and a new one (i, v, w1) is added.
+	hn1 := b.halfedges[n1]
+	b.triangles[n1] = i
+	a := b.add_triangle(i, v, w1, -1, hn1, n1)
+	if hn1 == -1 {
+		b.hull_tri[v] = a + 1
+	}
+
+	if g == -1 {
+		// The edge was on the hull, so `i` joins the hull between `u` and `v`.
+		b.hull_next[u], b.hull_prev[i] = i, u
+		b.hull_next[i], b.hull_prev[v] = v, i
+		b.hull_tri[i] = a
+		b.hull_hash[b.hash_key(b.points[i])] = i
+		b.legalize(previous_half_edge(h))
+		b.legalize(a + 1)
+		return true
+	}
+
+	// Likewise the triangle of `g` becomes (v, i, w2), and a new one (i, u, w2) is added.
+	n2 := next_half_edge(g)
+	w2 := b.triangles[previous_half_edge(g)]
+	hn2 := b.halfedges[n2]
+	b.triangles[n2] = i
+	c := b.add_triangle(i, u, w2, h, hn2, n2)
+	b.link(a, g)
+	if hn2 == -1 {
+		b.hull_tri[u] = c + 1
+	}
+
+	b.legalize(previous_half_edge(h))
+	b.legalize(a + 1)
+	b.legalize(previous_half_edge(g))
+	b.legalize(c + 1)
+	return false
+}
+
+// hash_key maps the angle of `p` around the center of the seed triangle to a bucket of
+// hull_hash.
+func (b *delaunay_builder) hash_key(p Point) int {
+	d := p.Minus(b.center)
+	angle := d.X / (math.Abs(d.X) + math.Abs(d.Y))
+	// A pseudo-angle from 0 to 1, increasing clockwise, which is the order in which
+	// the hash is searched.
+	if d.Y < 0 {
+		angle = (3 - angle) / 4
+	} else {
+		angle = (1 + angle) / 4
+	}
+	size := len(b.hull_hash)
+	key := int(math.Floor(angle*float64(size))) % size
+	if key < 0 {
+		// Only possible if `p` is the center itself, making the angle NaN.
+		return 0
+	}
+	return key
+}
+
+// add_triangle adds the triangle with vertices i0, i1 and i2, in counter-clockwise
+// order, linking its edges to the half-edges a, b and c of its neighbors. It returns
+// the first half-edge of the new triangle.
+func (m *mesh) add_triangle(i0, i1, i2, a, b, c int) int {
+	t := len(m.triangles)
+	m.triangles = append(m.triangles, i0, i1, i2)
+	m.halfedges = append(m.halfedges, -1, -1, -1)
+	m.link(t, a)
+	m.link(t+1, b)
+	m.link(t+2, c)
+	return t
+}
+
+// link makes the half-edges a and b opposites of each other.
+func (m *mesh) link(a, b int) {
+	m.halfedges[a] = b
+	if b != -1 {
+		m.halfedges[b] = a
+	}
+}
+
+// legalize flips the edge of half-edge `a`, and then any edges made illegal by that
+// flip, until every triangle around them is Delaunay. It returns the half-edge that
+// ends up in the place of the original `a`'s predecessor.
+//
+//	      pl                    pl
+//	     /||\                  /  \
+//	  al/ || \bl            al/    \a
+//	   /  ||  \              /      \
+//	  /  a||b  \    flip    /___ar___\
+//	p0\   ||   /p1   =>   p0\---bl---/p1
+//	   \  ||  /              \      /
+//	  ar\ || /br             b\    /br
+//	     \||/                  \  /
+//	      pr                    pr
+func (b *delaunay_builder) legalize(a int) int {
+	b.stack = b.stack[:0]
+	ar := 0
+	for {
+		opposite := b.halfedges[a]
+		a0 := a - a%3
+		ar = a0 + (a+2)%3
+
+		if opposite == -1 {
+			// An edge of the hull cannot be flipped.
+			if len(b.stack) == 0 {
+				break
+			}
+			a = b.stack[len(b.stack)-1]
+			b.stack = b.stack[:len(b.stack)-1]
+			continue
+		}
+
+		b0 := opposite - opposite%3
+		al := a0 + (a+1)%3
+		bl := b0 + (opposite+2)%3
+
+		p0 := b.triangles[ar]
+		pr := b.triangles[a]
+		pl := b.triangles[al]
+		p1 := b.triangles[bl]
+
+		if InCircle(b.points[p0], b.points[pr], b.points[pl], b.points[p1]) > 0 {
+			b.triangles[a] = p1
+			b.triangles[opposite] = p0
+
+			hbl := b.halfedges[bl]
+			if hbl == -1 {
+				// The flipped edge was on the hull, so fix the hull's reference to it.
+				e := b.hull_start
+				for {
+					if b.hull_tri[e] == bl {
+						b.hull_tri[e] = a
+						break
+					}
+					e = b.hull_prev[e]
+					if e == b.hull_start {
+						break
+					}
+				}
+			}
+			b.link(a, hbl)
+			b.link(opposite, b.halfedges[ar])
+			b.link(ar, bl)
+
+			br := b0 + (opposite+1)%3
+			b.stack = append(b.stack, br)
+		} else {
+			if len(b.stack) == 0 {
+				break
+			}
+			a = b.stack[len(b.stack)-1]
+			b.stack = b.stack[:len(b.stack)-1]
+		}
+	}
+	return ar
+}
+
+// closest_point_index finds the index of the Point closest to `q`, out of the indexes
+// `ids`, ignoring the index `skip` and any Points equal to `q` when `skip` is not -1.
+// The distances are compared exactly, so the closest is never missed. It returns -1
+// only if there is no such Point.
+func closest_point_index(points []Point, ids []int, q Point, skip int) int {
+	closest := -1
+	for _, i := range ids {
+		p := points[i]
+		if (i == skip) || ((skip != -1) && p.Equals(q)) {
+			continue
+		}
+		if (closest == -1) || (compare_distances(q, p, points[closest]) < 0) {
+			closest = i
+		}
+	}
+	return closest
+}
+
+// circumradius_squared is the square of the radius of the circle through `a`, `b` and
+// `c`.
+func circumradius_squared(a, b, c Point) float64 {
+	r := circumcenter(a, b, c).Minus(a)
+	return r.DotProduct(r)
+}
+
+// circumcenter is the center of the circle through `a`, `b` and `c`. If they are
+// collinear, its coordinates are infinite or NaN.
+func circumcenter(a, b, c Point) Point {
+	d := b.Minus(a)
+	e := c.Minus(a)
+	bl := d.DotProduct(d)
+	cl := e.DotProduct(e)
+	denominator := 0.5 / d.CrossProduct(e)
+	return Point{
+		X: a.X + (e.Y*bl-d.Y*cl)*denominator,
+		Y: a.Y + (d.X*cl-e.X*bl)*denominator,
+	}
+}
+
+// collinear_hull returns the indexes, out of `ids`, of the distinct Points, which are
+// known to lie on a line, in order along it. It sorts `ids` in place.
+func collinear_hull(points []Point, ids []int) []int {
+	sort.Slice(ids, func(i, j int) bool { return less_xy(points[ids[i]], points[ids[j]]) })
+
+	hull := ids[:0]
+	for k, i := range ids {
+		if (k == 0) || !points[i].Equals(points[ids[k-1]]) {
+			hull = append(hull, i)
+		}
+	}
+	return hull
+}
@@ -0,0 +1,270 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// random_points creates `n` Points uniformly distributed in the unit square.
+func random_points(rng *rand.Rand, n int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{rng.Float64(), rng.Float64()}
+	}
+	return points
+}
+
+// grid_points creates the Points of an `n` by `n` integer grid, which are full of
+// collinear and cocircular Points.
+func grid_points(n int) []Point {
+	points := make([]Point, 0, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			points = append(points, Point{float64(i), float64(j)})
+		}
+	}
+	return points
+}
+
+// check_delaunay verifies the structure of a Delaunay triangulation, and, if
+// `brute_force` is set, checks the empty circumcircle property against every Point.
+func check_delaunay(t *testing.T, d *Delaunay, brute_force bool) {
+	t.Helper()
+	var points []Point
+	for _, p := range d.Points {
+		if triangulable(p) {
+			points = append(points, p)
+		}
+	}
+	distinct := len(sorted_unique_points(points))
+
+	if (distinct >= 3) && !all_collinear(sorted_unique_points(points)) {
+		// Euler's formula for a triangulation of a point set.
+		if want := 2*distinct - 2 - len(d.Hull); len(d.Vertices) != want {
+			t.Errorf("got %v triangles, want %v", len(d.Vertices), want)
+		}
+	}
+
+	area := 0.0
+	for tri, v := range d.Vertices {
+		triangle := d.Triangle(tri)
+		if Orient2D(triangle.P1, triangle.P2, triangle.P3) <= 0 {
+			t.Fatalf("triangle %v = %v is not counter-clockwise", tri, triangle)
+		}
+		area += triangle.Area()
+
+		for i := 0; i < 3; i++ {
+			neighbor := d.Neighbors[tri][i]
+			if neighbor == -1 {
+				continue
+			}
+			// The neighbor must share the edge, running the other way.
+			shared := false
+			for j := 0; j < 3; j++ {
+				w := d.Vertices[neighbor]
+				if (w[j] == v[(i+1)%3]) && (w[(j+1)%3] == v[i]) && (d.Neighbors[neighbor][j] == tri) {
+					shared = true
+				}
+			}
+			if !shared {
+				t.Fatalf("triangles %v and %v are not neighbors across edge %v", tri, neighbor, i)
+			}
+
+			// Every edge must be locally Delaunay.
+			w := d.Vertices[neighbor]
+			for _, opposite := range w {
+				if (opposite != v[0]) && (opposite != v[1]) && (opposite != v[2]) {
+					if InCircle(d.Points[v[0]], d.Points[v[1]], d.Points[v[2]], d.Points[opposite]) > 0 {
+						t.Fatalf("edge %v of triangle %v is not Delaunay", i, tri)
+					}
+				}
+			}
+		}
+
+		if brute_force {
+			for _, p := range points {
+				if InCircle(triangle.P1, triangle.P2, triangle.P3, p) > 0 {
+					t.Fatalf("%v is inside the circumcircle of %v", p, triangle)
+				}
+			}
+		}
+	}
+
+	if len(d.Vertices) > 0 {
+		hull := make([]Point, len(d.Hull))
+		for i, h := range d.Hull {
+			hull[i] = d.Points[h]
+		}
+		if hull_area := (Polygon{hull}).SignedArea(); math.Abs(hull_area-area) > 1e-9*math.Max(1, area) {
+			t.Errorf("triangles have area %v, but the hull has area %v", area, hull_area)
+		}
+	}
+}
+
+func TestNewDelaunay(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		points    []Point
+		triangles int
+		hull      int
+	}{
+		{
+			desc:      "No Points",
+			points:    nil,
+			triangles: 0,
+			hull:      0,
+		},
+		{
+			desc:      "Two Points",
+			points:    []Point{{1, 0}, {0, 0}},
+			triangles: 0,
+			hull:      2,
+		},
+		{
+			desc:      "One triangle",
+			points:    []Point{{0, 0}, {0, 1}, {1, 0}},
+			triangles: 1,
+			hull:      3,
+		},
+		{
+			desc:      "Square",
+			points:    unit_square.Points,
+			triangles: 2,
+			hull:      4,
+		},
+		{
+			desc:      "Square with a Point in the middle",
+			points:    append([]Point{{0.5, 0.5}}, unit_square.Points...),
+			triangles: 4,
+			hull:      4,
+		},
+		{
+			desc:      "Duplicated Points",
+			points:    []Point{{0, 0}, {1, 0}, {0, 0}, {1, 1}, {0, 1}, {1, 1}, {0.5, 0.5}, {0.5, 0.5}},
+			triangles: 4,
+			hull:      4,
+		},
+		{
+			desc:      "Collinear Points",
+			points:    []Point{{2, 2}, {0, 0}, {1, 1}, {3, 3}, {1, 1}},
+			triangles: 0,
+			hull:      4,
+		},
+		{
+			desc:      "Collinear Points along the hull",
+			points:    []Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {1.5, 2}},
+			triangles: 3,
+			hull:      5,
+		},
+		{
+			desc:      "Nearly flat seed triangle",
+			points:    []Point{{1.2470199572805587, 1.2440111831949028}, {4, 4}, {nudge(1.2470199572805587, 1), 1.2440111831949028}},
+			triangles: 1,
+			hull:      3,
+		},
+		{
+			desc: "Cluster of nearly equal Points",
+			points: []Point{
+				{3.2487501105919336, 1.0893924769593086}, {3.2487501105919345, 1.089392476959309},
+				{3.248750110591935, 1.0893924769593093}, {4, 3}, {3, 1}, {3.8683388235639886, 1.3067943192039164},
+				{2.3183240881561167, 1.8794279013713493}, {4, 1},
+			},
+			triangles: 10,
+			hull:      4,
+		},
+		{
+			desc:      "Point with a NaN coordinate",
+			points:    []Point{{0, 0}, {1, 0}, {math.NaN(), 0.5}, {0, 1}},
+			triangles: 1,
+			hull:      3,
+		},
+		{
+			desc:      "Points too large to triangulate",
+			points:    []Point{{1e200, 1e200}, {0, 0}, {1, 0}, {-1e200, 3}, {0, 1}, {2, 1e200}},
+			triangles: 1,
+			hull:      3,
+		},
+		{
+			desc:      "Only Points too large to triangulate",
+			points:    []Point{{1e200, 0}, {0, 1e200}, {-1e200, -1e200}, {math.Inf(1), 0}},
+			triangles: 0,
+			hull:      0,
+		},
+		{
+			desc:      "Grid",
+			points:    grid_points(10),
+			triangles: 162,
+			hull:      36,
+		},
+		{
+			desc:      "Regular polygon, all cocircular",
+			points:    regular_polygon(Point{3, -2}, 5, 40).Points,
+			triangles: 38,
+			hull:      40,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			d := NewDelaunay(tC.points)
+			if len(d.Vertices) != tC.triangles || len(d.Hull) != tC.hull {
+				t.Errorf("NewDelaunay() has %v triangles and %v hull Points, want %v and %v",
+					len(d.Vertices), len(d.Hull), tC.triangles, tC.hull)
+			}
+			check_delaunay(t, d, true)
+		})
+	}
+}
+
+func TestNewDelaunayRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for trial := 0; trial < 20; trial++ {
+		points := random_points(rng, 200)
+		// Add some duplicates and some Points on a small grid.
+		points = append(points, points[:20]...)
+		for i := 0; i < 50; i++ {
+			points = append(points, Point{float64(rng.Intn(5)) / 4, float64(rng.Intn(5)) / 4})
+		}
+		check_delaunay(t, NewDelaunay(points), true)
+	}
+
+	check_delaunay(t, NewDelaunay(random_points(rng, 100000)), false)
+}
+
+func TestDelaunayEdges(t *testing.T) {
+	d := NewDelaunay(append([]Point{{0.5, 0.5}}, unit_square.Points...))
+	// Four around the outside, and four spokes to the middle.
+	if edges := d.Edges(); len(edges) != 8 {
+		t.Errorf("Edges() = %v, want 8 edges", edges)
+	}
+	triangles := d.Triangles()
+	if len(triangles) != 4 {
+		t.Fatalf("Triangles() = %v, want 4 triangles", triangles)
+	}
+	for _, triangle := range triangles {
+		if triangle.Area() != 0.25 {
+			t.Errorf("Triangles() has %v with area %v, want 0.25", triangle, triangle.Area())
+		}
+	}
+}
+
+func BenchmarkNewDelaunay(b *testing.B) {
+	rng := rand.New(rand.NewSource(7))
+	benchmarks := []struct {
+		desc   string
+		points []Point
+	}{
+		{"1000 random Points", random_points(rng, 1000)},
+		{"100000 random Points", random_points(rng, 100000)},
+		{"90000 Points on a grid", grid_points(300)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewDelaunay(bm.points)
+			}
+
+		})
+	}
+}
@@ -1,10 +1,11 @@
-// Package gogeo provides simple Point and Line Segment types. It also provides
-// functionality for rotating Points and Line Segments, and checking if Line Segments
-// intersect.
+// Package gogeo provides simple Point and Line Segment types, along with Triangles and
+// Polygons built from them. It also provides functionality for rotating Points and Line
+// Segments, and checking if Line Segments intersect.
 package gogeo
 
 import (
 	"math"
+	"strconv"
 )
 
 // Point is a point in 2D space. It can also be thought of as a vector from the origin
@@ -88,6 +89,12 @@ func (p Point) DotProduct(q Point) float64 {
 	return p.X*q.X + p.Y*q.Y
 }
 
+// CrossProduct is the z-component of the cross product of two Points, interpreted as
+// vectors in the xy-plane.
+func (p Point) CrossProduct(q Point) float64 {
+	return p.X*q.Y - p.Y*q.X
+}
+
 // LineSegment is a line segment in 2D space. It is defined by two Points.
 type LineSegment struct {
 	P1 Point
@@ -123,6 +130,39 @@ func (l LineSegment) RotateAboutOrigin(angle float64) LineSegment {
 	return LineSegment{l.P1.Rotate(angle), l.P2.Rotate(angle)}
 }
 
+// Length is the distance between the two Points of a LineSegment.
+func (l LineSegment) Length() float64 {
+	return l.P2.Minus(l.P1).Magnitude()
+}
+
+// closest_fraction finds how far along a LineSegment the Point closest to `p` lies, as a
+// fraction of the length of the LineSegment between 0 (at P1) and 1 (at P2).
+func (l LineSegment) closest_fraction(p Point) float64 {
+	direction := l.P2.Minus(l.P1)
+	length_squared := direction.DotProduct(direction)
+	if length_squared == 0 {
+		return 0
+	}
+	fraction := p.Minus(l.P1).DotProduct(direction) / length_squared
+	return math.Min(math.Max(fraction, 0), 1)
+}
+
+// ClosestPoint finds the Point on a LineSegment that is closest to `p`.
+func (l LineSegment) ClosestPoint(p Point) Point {
+	fraction := l.closest_fraction(p)
+	if fraction == 0 {
+		return l.P1
+	} else if fraction == 1 {
+		return l.P2
+	}
+	return l.P1.Plus(l.P2.Minus(l.P1).Times(fraction))
+}
+
+// DistanceToPoint is the shortest distance from a LineSegment to the Point `p`.
+func (l LineSegment) DistanceToPoint(p Point) float64 {
+	return p.Minus(l.ClosestPoint(p)).Magnitude()
+}
+
 // sign returns +1 for positive, 0 for 0.0, and -1 for negative
 func sign(x float64) int {
 	if x > 0 {
@@ -208,24 +248,150 @@ func (l LineSegment) XIntercept() OpenInterval {
 }
 
 // Intersects will determine if two LineSegments intersect. They are said to intersect
-// if any point on the segments, including the endpoints intersects.
+// if any point on the segments, including the endpoints intersects. The test is built
+// on the exact Orient2D predicate, so the answer does not depend on rounding error.
 func (l1 LineSegment) Intersects(l2 LineSegment) bool {
-	// Pick a point on segment 1 and make it the origin. Move other points relative to it.
-	l1_translated := l1.Minus(l1.P1)
-	l2_translated := l2.Minus(l1.P1)
+	// Which side of each segment are the endpoints of the other segment on?
+	o1 := sign(Orient2D(l1.P1, l1.P2, l2.P1))
+	o2 := sign(Orient2D(l1.P1, l1.P2, l2.P2))
+	o3 := sign(Orient2D(l2.P1, l2.P2, l1.P1))
+	o4 := sign(Orient2D(l2.P1, l2.P2, l1.P2))
+
+	// The general case: each segment straddles the line through the other.
+	if (o1 != o2) && (o3 != o4) {
+		return true
+	}
+
+	// The special cases: an endpoint lies on the other segment.
+	return (o1 == 0 && l1.collinear_point_within(l2.P1)) ||
+		(o2 == 0 && l1.collinear_point_within(l2.P2)) ||
+		(o3 == 0 && l2.collinear_point_within(l1.P1)) ||
+		(o4 == 0 && l2.collinear_point_within(l1.P2))
+}
 
-	// Rotate all points so that segment 1 is aligned with the x-axis.
-	angle_to_rotate_through := -l1_translated.Angle()
-	l1_rotated := l1_translated.RotateAboutOrigin(angle_to_rotate_through)
-	l2_rotated := l2_translated.RotateAboutOrigin(angle_to_rotate_through)
+// collinear_point_within checks if a Point `p`, already known to be collinear with the
+// LineSegment, lies between its endpoints.
+func (l LineSegment) collinear_point_within(p Point) bool {
+	return (math.Min(l.P1.X, l.P2.X) <= p.X) && (p.X <= math.Max(l.P1.X, l.P2.X)) &&
+		(math.Min(l.P1.Y, l.P2.Y) <= p.Y) && (p.Y <= math.Max(l.P1.Y, l.P2.Y))
+}
 
-	// Find the x-intercept of segment 2
-	l2_x_intercept := l2_rotated.XIntercept()
+// IntersectionKind describes the geometry shared by two LineSegments.
+type IntersectionKind int
 
-	// Is it between the two points on segment 1?
-	l1_x_intercept := OpenInterval{l1_rotated.P1.X, l1_rotated.P2.X}
+const (
+	// NoIntersection means the LineSegments do not touch.
+	NoIntersection IntersectionKind = iota
+	// PointIntersection means the LineSegments meet at a single Point.
+	PointIntersection
+	// OverlapIntersection means the LineSegments are collinear and share a LineSegment.
+	OverlapIntersection
+)
 
-	return !l1_x_intercept.Intersection(l2_x_intercept).IsEmpty()
+// LineSegmentIntersection is the result of intersecting two LineSegments. Depending on
+// Kind, either Point or Segment holds the shared geometry.
+type LineSegmentIntersection struct {
+	Kind    IntersectionKind
+	Point   Point
+	Segment LineSegment
+}
+
+// Intersection calculates where two LineSegments meet. The result is empty, a single
+// Point, or, for collinear segments that overlap, the LineSegment they share. An
+// overlap is directed the same way as `l1`. Whenever the shared geometry starts or ends
+// at an endpoint of either segment, that endpoint is returned exactly.
+func (l1 LineSegment) Intersection(l2 LineSegment) LineSegmentIntersection {
+	o1 := sign(Orient2D(l1.P1, l1.P2, l2.P1))
+	o2 := sign(Orient2D(l1.P1, l1.P2, l2.P2))
+	o3 := sign(Orient2D(l2.P1, l2.P2, l1.P1))
+	o4 := sign(Orient2D(l2.P1, l2.P2, l1.P2))
+
+	if (o1 == 0) && (o2 == 0) && (o3 == 0) && (o4 == 0) {
+		return l1.collinear_intersection(l2)
+	}
+
+	// An endpoint touching the other segment.
+	switch {
+	case o1 == 0 && l1.collinear_point_within(l2.P1):
+		return LineSegmentIntersection{Kind: PointIntersection, Point: l2.P1}
+	case o2 == 0 && l1.collinear_point_within(l2.P2):
+		return LineSegmentIntersection{Kind: PointIntersection, Point: l2.P2}
+	case o3 == 0 && l2.collinear_point_within(l1.P1):
+		return LineSegmentIntersection{Kind: PointIntersection, Point: l1.P1}
+	case o4 == 0 && l2.collinear_point_within(l1.P2):
+		return LineSegmentIntersection{Kind: PointIntersection, Point: l1.P2}
+	}
+
+	// A proper crossing, where each segment straddles the other.
+	if (o1 != o2) && (o3 != o4) && (o1 != 0) && (o2 != 0) && (o3 != 0) && (o4 != 0) {
+		return LineSegmentIntersection{Kind: PointIntersection, Point: l1.crossing_point(l2)}
+	}
+	return LineSegmentIntersection{Kind: NoIntersection}
+}
+
+// crossing_point calculates where two properly crossing LineSegments meet. The fraction
+// of the way along `l1` comes from the orientations of its ends relative to `l2`, which
+// have opposite signs, so it is never NaN, even if the segments are so close to parallel
+// that their cross product rounds to zero. Rounding can still put the calculated Point
+// slightly outside the segments, so it is clamped to the overlap of their bounding
+// boxes.
+func (l1 LineSegment) crossing_point(l2 LineSegment) Point {
+	d1 := Orient2D(l2.P1, l2.P2, l1.P1)
+	d2 := Orient2D(l2.P1, l2.P2, l1.P2)
+	p := l1.P1.Plus(l1.P2.Minus(l1.P1).Times(d1 / (d1 - d2)))
+
+	x_range := OpenInterval{math.Min(l1.P1.X, l1.P2.X), math.Max(l1.P1.X, l1.P2.X)}.
+		Intersection(OpenInterval{math.Min(l2.P1.X, l2.P2.X), math.Max(l2.P1.X, l2.P2.X)})
+	y_range := OpenInterval{math.Min(l1.P1.Y, l1.P2.Y), math.Max(l1.P1.Y, l1.P2.Y)}.
+		Intersection(OpenInterval{math.Min(l2.P1.Y, l2.P2.Y), math.Max(l2.P1.Y, l2.P2.Y)})
+	return Point{
+		X: math.Min(math.Max(p.X, x_range.Lower), x_range.Upper),
+		Y: math.Min(math.Max(p.Y, y_range.Lower), y_range.Upper),
+	}
+}
+
+// collinear_intersection calculates the overlap of two LineSegments known to lie on a
+// common line. The segments are projected onto the axis along which `l1` has the
+// larger extent, and the overlap of the projections is found with an OpenInterval. Each
+// end of that overlap is an endpoint of one of the segments, which is returned as-is.
+func (l1 LineSegment) collinear_intersection(l2 LineSegment) LineSegmentIntersection {
+	if l1.P1.Equals(l1.P2) {
+		if l2.collinear_point_within(l1.P1) {
+			return LineSegmentIntersection{Kind: PointIntersection, Point: l1.P1}
+		}
+		return LineSegmentIntersection{Kind: NoIntersection}
+	}
+
+	along := func(p Point) float64 { return p.X }
+	if math.Abs(l1.P2.Y-l1.P1.Y) > math.Abs(l1.P2.X-l1.P1.X) {
+		along = func(p Point) float64 { return p.Y }
+	}
+
+	l1_interval := OpenInterval{math.Min(along(l1.P1), along(l1.P2)), math.Max(along(l1.P1), along(l1.P2))}
+	l2_interval := OpenInterval{math.Min(along(l2.P1), along(l2.P2)), math.Max(along(l2.P1), along(l2.P2))}
+	overlap := l1_interval.Intersection(l2_interval)
+	if overlap.IsEmpty() {
+		return LineSegmentIntersection{Kind: NoIntersection}
+	}
+
+	// Map the ends of the overlap back onto the original endpoints.
+	endpoint := func(coordinate float64) Point {
+		for _, p := range []Point{l1.P1, l1.P2, l2.P1, l2.P2} {
+			if along(p) == coordinate {
+				return p
+			}
+		}
+		return Point{math.NaN(), math.NaN()}
+	}
+	if overlap.Lower == overlap.Upper {
+		return LineSegmentIntersection{Kind: PointIntersection, Point: endpoint(overlap.Lower)}
+	}
+
+	shared := LineSegment{endpoint(overlap.Lower), endpoint(overlap.Upper)}
+	if along(l1.P1) > along(l1.P2) {
+		shared = LineSegment{shared.P2, shared.P1}
+	}
+	return LineSegmentIntersection{Kind: OverlapIntersection, Segment: shared}
 }
 
 // OpenInterval represents the open interval [a, b].
@@ -292,7 +458,8 @@ func (t Triangle) Area() float64 {
 // Intersects will determine if two Triangles intersect. They are said to intersect
 // if any point on the triangles, including the vertices, intersects. This is done by
 // creating LineSegments between all vertices and checking if any intersect between the
-// two triangles.
+// two triangles. If no edges intersect, the triangles can still intersect when one lies
+// entirely inside the other, so that is checked as well.
 func (t Triangle) Intersects(u Triangle) bool {
 	// Create a LineSegment between each Point in t
 	t1 := LineSegment{t.P1, t.P2}
@@ -305,7 +472,190 @@ func (t Triangle) Intersects(u Triangle) bool {
 	u3 := LineSegment{u.P3, u.P1}
 
 	// Check if any of the LineSegments intersect
-	return t1.Intersects(u1) || t1.Intersects(u2) || t1.Intersects(u3) ||
+	if t1.Intersects(u1) || t1.Intersects(u2) || t1.Intersects(u3) ||
 		t2.Intersects(u1) || t2.Intersects(u2) || t2.Intersects(u3) ||
-		t3.Intersects(u1) || t3.Intersects(u2) || t3.Intersects(u3)
+		t3.Intersects(u1) || t3.Intersects(u2) || t3.Intersects(u3) {
+		return true
+	}
+
+	// No edges cross, so either one triangle is inside the other or they are disjoint.
+	return t.ContainsPoint(u.P1) || u.ContainsPoint(t.P1)
+}
+
+// Location describes where a Point lies relative to a shape.
+type Location int
+
+const (
+	// Exterior means the Point lies outside the shape.
+	Exterior Location = iota
+	// Boundary means the Point lies on the edge of the shape.
+	Boundary
+	// Interior means the Point lies strictly inside the shape.
+	Interior
+)
+
+// String returns the name of a Location.
+func (l Location) String() string {
+	switch l {
+	case Exterior:
+		return "Exterior"
+	case Boundary:
+		return "Boundary"
+	case Interior:
+		return "Interior"
+	default:
+		return "Location(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// Edges returns the three edges of a Triangle, P1 to P2, P2 to P3, and P3 to P1.
+func (t Triangle) Edges() [3]LineSegment {
+	return [3]LineSegment{{t.P1, t.P2}, {t.P2, t.P3}, {t.P3, t.P1}}
+}
+
+// Barycentric calculates the barycentric coordinates of a Point `p` with respect to the
+// Triangle. These are the weights given to P1, P2 and P3 such that the weighted sum of
+// the vertices is `p`, and they sum to 1. All three are positive for Points inside the
+// Triangle. If the Triangle has no area, all three are NaN.
+func (t Triangle) Barycentric(p Point) (l1, l2, l3 float64) {
+	v0 := t.P2.Minus(t.P1)
+	v1 := t.P3.Minus(t.P1)
+	v2 := p.Minus(t.P1)
+	denominator := v0.CrossProduct(v1)
+	if denominator == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	l2 = v2.CrossProduct(v1) / denominator
+	l3 = v0.CrossProduct(v2) / denominator
+	l1 = 1 - l2 - l3
+	return l1, l2, l3
+}
+
+// LocatePoint classifies a Point `p` as being in the Interior, on the Boundary, or in
+// the Exterior of the Triangle. The classification uses the exact Orient2D predicate,
+// so Points on an edge are always found to be on the Boundary. A Triangle with no area
+// has no Interior.
+func (t Triangle) LocatePoint(p Point) Location {
+	orientation := sign(Orient2D(t.P1, t.P2, t.P3))
+	if orientation == 0 {
+		for _, edge := range t.Edges() {
+			if sign(Orient2D(edge.P1, edge.P2, p)) == 0 && edge.collinear_point_within(p) {
+				return Boundary
+			}
+		}
+		return Exterior
+	}
+
+	d1 := sign(Orient2D(t.P1, t.P2, p)) * orientation
+	d2 := sign(Orient2D(t.P2, t.P3, p)) * orientation
+	d3 := sign(Orient2D(t.P3, t.P1, p)) * orientation
+	if (d1 < 0) || (d2 < 0) || (d3 < 0) {
+		return Exterior
+	}
+	if (d1 == 0) || (d2 == 0) || (d3 == 0) {
+		return Boundary
+	}
+	return Interior
+}
+
+// ContainsPoint tests if a Point `p` lies inside the Triangle or on its boundary.
+func (t Triangle) ContainsPoint(p Point) bool {
+	return t.LocatePoint(p) != Exterior
+}
+
+// Contains tests if the Triangle `u` lies entirely inside the Triangle `t`. Since a
+// Triangle is convex, this is the case when all three vertices of `u` are inside `t` or
+// on its boundary.
+func (t Triangle) Contains(u Triangle) bool {
+	return t.ContainsPoint(u.P1) && t.ContainsPoint(u.P2) && t.ContainsPoint(u.P3)
+}
+
+// Overlap calculates the region shared by two Triangles, returned as the vertices of a
+// convex polygon in counter-clockwise order, along with its area. It clips `u` against
+// each edge of `t` in turn (Sutherland-Hodgman). If the Triangles do not overlap, or
+// either one has no area, it returns no vertices and an area of 0.
+func (t Triangle) Overlap(u Triangle) ([]Point, float64) {
+	t_orientation := sign(Orient2D(t.P1, t.P2, t.P3))
+	u_orientation := sign(Orient2D(u.P1, u.P2, u.P3))
+	if (t_orientation == 0) || (u_orientation == 0) {
+		return nil, 0
+	}
+
+	// Work with both triangles in counter-clockwise order.
+	if t_orientation < 0 {
+		t = Triangle{t.P1, t.P3, t.P2}
+	}
+	polygon := []Point{u.P1, u.P2, u.P3}
+	if u_orientation < 0 {
+		polygon = []Point{u.P1, u.P3, u.P2}
+	}
+
+	for _, edge := range t.Edges() {
+		polygon = clip_to_left_of(polygon, edge)
+		if len(polygon) == 0 {
+			return nil, 0
+		}
+	}
+
+	area := signed_area(polygon)
+	if area <= 0 {
+		return nil, 0
+	}
+	return polygon, area
+}
+
+// clip_to_left_of clips a convex polygon to the closed half-plane to the left of the
+// directed line through `edge`.
+func clip_to_left_of(polygon []Point, edge LineSegment) []Point {
+	clipped := make([]Point, 0, len(polygon)+1)
+	add := func(p Point) {
+		if len(clipped) == 0 || !clipped[len(clipped)-1].Equals(p) {
+			clipped = append(clipped, p)
+		}
+	}
+
+	for i, current := range polygon {
+		previous := polygon[(i+len(polygon)-1)%len(polygon)]
+		current_side := sign(Orient2D(edge.P1, edge.P2, current))
+		previous_side := sign(Orient2D(edge.P1, edge.P2, previous))
+
+		if previous_side*current_side < 0 {
+			// The polygon edge crosses the clipping line.
+			add(LineSegment{previous, current}.line_crossing(edge))
+		}
+		if current_side >= 0 {
+			add(current)
+		}
+	}
+
+	if len(clipped) > 1 && clipped[0].Equals(clipped[len(clipped)-1]) {
+		clipped = clipped[:len(clipped)-1]
+	}
+	return clipped
+}
+
+// line_crossing calculates where a LineSegment crosses the infinite line through `m`.
+// The LineSegment must have its endpoints on opposite sides of that line, as Orient2D
+// finds them. The orientations then have opposite signs, as in crossing_point, so the
+// fraction of the way along the LineSegment is never NaN and stays within [0, 1].
+func (l LineSegment) line_crossing(m LineSegment) Point {
+	d1 := Orient2D(m.P1, m.P2, l.P1)
+	d2 := Orient2D(m.P1, m.P2, l.P2)
+	return l.P1.Plus(l.P2.Minus(l.P1).Times(d1 / (d1 - d2)))
+}
+
+// signed_area calculates the area enclosed by a ring of Points with the shoelace
+// formula. The area is positive if the Points are in counter-clockwise order and
+// negative if they are clockwise.
+func signed_area(points []Point) float64 {
+	if len(points) < 3 {
+		return 0
+	}
+	// Measure relative to the first Point to reduce cancellation.
+	origin := points[0]
+	sum := 0.0
+	for i := 1; i < len(points)-1; i++ {
+		sum += points[i].Minus(origin).CrossProduct(points[i+1].Minus(origin))
+	}
+	return sum / 2
 }
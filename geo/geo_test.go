@@ -381,6 +381,41 @@ func BenchmarkPointDotProduct(b *testing.B) {
 	}
 }
 
+func TestPointCrossProduct(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p1   Point
+		p2   Point
+		out  float64
+	}{
+		{
+			desc: "x cross y is 1",
+			p1:   Point{1, 0},
+			p2:   Point{0, 1},
+			out:  1,
+		},
+		{
+			desc: "y cross x is -1",
+			p1:   Point{0, 1},
+			p2:   Point{1, 0},
+			out:  -1,
+		},
+		{
+			desc: "parallel vectors have cross product of 0",
+			p1:   Point{2, 3},
+			p2:   Point{4, 6},
+			out:  0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p1.CrossProduct(tC.p2); got != tC.out {
+				t.Errorf("CrossProduct() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
 func TestLineSegmentAdd(t *testing.T) {
 	testCases := []struct {
 		desc string
@@ -555,6 +590,113 @@ func BenchmarkLineSegmentRotateAboutOrigin(b *testing.B) {
 	}
 }
 
+func TestLineSegmentLength(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    LineSegment
+		out  float64
+	}{
+		{
+			desc: "Unit length along the x-axis",
+			l:    LineSegment{Point{0, 0}, Point{1, 0}},
+			out:  1,
+		},
+		{
+			desc: "A 3-4-5 triangle",
+			l:    LineSegment{Point{1, 1}, Point{4, 5}},
+			out:  5,
+		},
+		{
+			desc: "No length",
+			l:    LineSegment{Point{2, 2}, Point{2, 2}},
+			out:  0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.Length(); got != tC.out {
+				t.Errorf("Length() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestLineSegmentClosestPoint(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		l        LineSegment
+		p        Point
+		out      Point
+		distance float64
+	}{
+		{
+			desc:     "Beside the middle of the segment",
+			l:        LineSegment{Point{0, 0}, Point{2, 0}},
+			p:        Point{1, 1},
+			out:      Point{1, 0},
+			distance: 1,
+		},
+		{
+			desc:     "Beyond the first end",
+			l:        LineSegment{Point{0, 0}, Point{2, 0}},
+			p:        Point{-3, 4},
+			out:      Point{0, 0},
+			distance: 5,
+		},
+		{
+			desc:     "Beyond the second end",
+			l:        LineSegment{Point{0, 0}, Point{2, 0}},
+			p:        Point{3, 0},
+			out:      Point{2, 0},
+			distance: 1,
+		},
+		{
+			desc:     "On the segment",
+			l:        LineSegment{Point{0, 0}, Point{2, 2}},
+			p:        Point{0.5, 0.5},
+			out:      Point{0.5, 0.5},
+			distance: 0,
+		},
+		{
+			desc:     "Segment with no length",
+			l:        LineSegment{Point{1, 1}, Point{1, 1}},
+			p:        Point{1, 2},
+			out:      Point{1, 1},
+			distance: 1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.ClosestPoint(tC.p); !got.AlmostEquals(tC.out) {
+				t.Errorf("ClosestPoint() = %v, want %v", got, tC.out)
+			}
+			if got := tC.l.DistanceToPoint(tC.p); !almost_zero(got - tC.distance) {
+				t.Errorf("DistanceToPoint() = %v, want %v", got, tC.distance)
+			}
+		})
+	}
+}
+
+func BenchmarkLineSegmentClosestPoint(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		l    LineSegment
+		p    Point
+	}{
+		{"Beside the middle", LineSegment{Point{0, 0}, Point{2, 0}}, Point{1, 1}},
+		{"Beyond an end", LineSegment{Point{0, 0}, Point{2, 0}}, Point{-3, 4}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.l.ClosestPoint(bm.p)
+			}
+
+		})
+	}
+}
+
 func TestOpenIntervalIsEmpty(t *testing.T) {
 	testCases := []struct {
 		desc string
@@ -810,6 +952,204 @@ func BenchmarkLineSegmentsIntersects(b *testing.B) {
 	}
 }
 
+func TestLineSegmentIntersection(t *testing.T) {
+	none := LineSegmentIntersection{Kind: NoIntersection}
+	at := func(x, y float64) LineSegmentIntersection {
+		return LineSegmentIntersection{Kind: PointIntersection, Point: Point{x, y}}
+	}
+	overlap := func(x1, y1, x2, y2 float64) LineSegmentIntersection {
+		return LineSegmentIntersection{Kind: OverlapIntersection, Segment: LineSegment{Point{x1, y1}, Point{x2, y2}}}
+	}
+	testCases := []struct {
+		desc string
+		l1   LineSegment
+		l2   LineSegment
+		out  LineSegmentIntersection
+	}{
+		{
+			desc: "Two segments cross in an X",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{1, 0}, Point{0, 1}},
+			out:  at(0.5, 0.5),
+		},
+		{
+			desc: "Two segments cross away from the origin",
+			l1:   LineSegment{Point{10, 10}, Point{14, 12}},
+			l2:   LineSegment{Point{12, 9}, Point{12, 13}},
+			out:  at(12, 11),
+		},
+		{
+			desc: "Two segments definitely don't cross",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{-10, -10}, Point{-20, -20}},
+			out:  none,
+		},
+		{
+			desc: "Lines cross, but not within the segments",
+			l1:   LineSegment{Point{0, 0}, Point{1, 0}},
+			l2:   LineSegment{Point{2, 1}, Point{2, -1}},
+			out:  none,
+		},
+		{
+			desc: "Parallel segments",
+			l1:   LineSegment{Point{0, 0}, Point{1, 0}},
+			l2:   LineSegment{Point{0, 1}, Point{1, 1}},
+			out:  none,
+		},
+		{
+			desc: "Touching endpoints",
+			l1:   LineSegment{Point{0, 0}, Point{0, 1}},
+			l2:   LineSegment{Point{1, 1}, Point{0, 1}},
+			out:  at(0, 1),
+		},
+		{
+			desc: "Touching endpoints, both starting at the same Point",
+			l1:   LineSegment{Point{0.1, 0.2}, Point{0.7, 0.3}},
+			l2:   LineSegment{Point{0.1, 0.2}, Point{-0.3, 0.9}},
+			out:  at(0.1, 0.2),
+		},
+		{
+			desc: "Collinear segments touching end to end",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{1, 1}, Point{2, 2}},
+			out:  at(1, 1),
+		},
+		{
+			desc: "T-junction, second segment ends on the first",
+			l1:   LineSegment{Point{0, 0}, Point{2, 0}},
+			l2:   LineSegment{Point{1, 1}, Point{1, 0}},
+			out:  at(1, 0),
+		},
+		{
+			desc: "T-junction, first segment ends on the second",
+			l1:   LineSegment{Point{0.3, 0.7}, Point{0.3, 0.1}},
+			l2:   LineSegment{Point{-1, 0.1}, Point{1, 0.1}},
+			out:  at(0.3, 0.1),
+		},
+		{
+			desc: "T-junction on a diagonal",
+			l1:   LineSegment{Point{0, 0}, Point{4, 4}},
+			l2:   LineSegment{Point{3, 1}, Point{2, 2}},
+			out:  at(2, 2),
+		},
+		{
+			desc: "Partial overlap along the line y = x",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{0.9, 0.9}, Point{1.1, 1.1}},
+			out:  overlap(0.9, 0.9, 1, 1),
+		},
+		{
+			desc: "Partial overlap along the line y = 0",
+			l1:   LineSegment{Point{0, 0}, Point{1, 0}},
+			l2:   LineSegment{Point{0.9, 0}, Point{1.1, 0}},
+			out:  overlap(0.9, 0, 1, 0),
+		},
+		{
+			desc: "Nearly parallel segments crossing",
+			l1:   LineSegment{Point{0.5353702836405467, 0.592197207725432}, Point{0.7191569630997201, 0.9540076745141356}},
+			l2:   LineSegment{Point{0.48258068824753486, 0.48827330961193854}, Point{0.6663673677067082, 0.8500837764006421}},
+			out:  at(0.5852001351022441, 0.6902944249471702),
+		},
+		{
+			desc: "Partial overlap along the line x = 0",
+			l1:   LineSegment{Point{0, 0}, Point{0, 1}},
+			l2:   LineSegment{Point{0, 0.9}, Point{0, 1.1}},
+			out:  overlap(0, 0.9, 0, 1),
+		},
+		{
+			desc: "Partial overlap with the segments pointing opposite ways",
+			l1:   LineSegment{Point{2, 1}, Point{0, 0}},
+			l2:   LineSegment{Point{-2, -1}, Point{1, 0.5}},
+			out:  overlap(1, 0.5, 0, 0),
+		},
+		{
+			desc: "One segment contains the other",
+			l1:   LineSegment{Point{0, 0}, Point{0, 10}},
+			l2:   LineSegment{Point{0, 7}, Point{0, 3}},
+			out:  overlap(0, 3, 0, 7),
+		},
+		{
+			desc: "Identical segments",
+			l1:   LineSegment{Point{-1, 3}, Point{5, 4}},
+			l2:   LineSegment{Point{-1, 3}, Point{5, 4}},
+			out:  overlap(-1, 3, 5, 4),
+		},
+		{
+			desc: "Collinear, but disjoint",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{1.1, 1.1}, Point{1.2, 1.2}},
+			out:  none,
+		},
+		{
+			desc: "Degenerate segment lying on the other",
+			l1:   LineSegment{Point{0.5, 0.5}, Point{0.5, 0.5}},
+			l2:   LineSegment{Point{0, 0}, Point{1, 1}},
+			out:  at(0.5, 0.5),
+		},
+		{
+			desc: "Other segment is degenerate and lies on the first",
+			l1:   LineSegment{Point{0, 0}, Point{1, 1}},
+			l2:   LineSegment{Point{0.5, 0.5}, Point{0.5, 0.5}},
+			out:  at(0.5, 0.5),
+		},
+		{
+			desc: "Degenerate segment off the other",
+			l1:   LineSegment{Point{0.5, 0.6}, Point{0.5, 0.6}},
+			l2:   LineSegment{Point{0, 0}, Point{1, 1}},
+			out:  none,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := tC.l1.Intersection(tC.l2)
+			if got.Kind != tC.out.Kind || !got.Point.AlmostEquals(tC.out.Point) || !got.Segment.Equals(tC.out.Segment) {
+				t.Errorf("Intersection() = %v, want %v", got, tC.out)
+			}
+			if intersects := tC.l1.Intersects(tC.l2); intersects != (got.Kind != NoIntersection) {
+				t.Errorf("Intersection() = %v, but Intersects() = %v", got, intersects)
+			}
+		})
+	}
+}
+
+func BenchmarkLineSegmentIntersection(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		l1   LineSegment
+		l2   LineSegment
+	}{
+		{
+			"Two that cross",
+			LineSegment{Point{0, 0}, Point{1, 1}},
+			LineSegment{Point{1, 0}, Point{0, 1}},
+		},
+		{
+			"Two that don't cross",
+			LineSegment{Point{0, 0}, Point{1, 1}},
+			LineSegment{Point{2, 0}, Point{3, 1}},
+		},
+		{
+			"Two that touch at one point",
+			LineSegment{Point{0, 0}, Point{0, 1}},
+			LineSegment{Point{1, 1}, Point{0, 1}},
+		},
+		{
+			"They overlap along a section",
+			LineSegment{Point{0, 0}, Point{1, 1}},
+			LineSegment{Point{0.9, 0.9}, Point{1.1, 1.1}},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.l1.Intersection(bm.l2)
+			}
+
+		})
+	}
+}
+
 func TestTriangleEquals(t *testing.T) {
 	testCases := []struct {
 		desc string
@@ -989,6 +1329,18 @@ func TestTriangleIntersects(t *testing.T) {
 			t2:   Triangle{Point{2, 0}, Point{2, 1}, Point{3, 0}},
 			out:  false,
 		},
+		{
+			desc: "The second is entirely inside the first",
+			t1:   Triangle{Point{0, 0}, Point{10, 0}, Point{0, 10}},
+			t2:   Triangle{Point{1, 1}, Point{2, 1}, Point{1, 2}},
+			out:  true,
+		},
+		{
+			desc: "The first is entirely inside the second",
+			t1:   Triangle{Point{1, 1}, Point{2, 1}, Point{1, 2}},
+			t2:   Triangle{Point{0, 0}, Point{0, 10}, Point{10, 0}},
+			out:  true,
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -1031,3 +1383,338 @@ func BenchmarkTriangleIntersects(b *testing.B) {
 		})
 	}
 }
+
+func TestTriangleBarycentric(t *testing.T) {
+	testCases := []struct {
+		desc string
+		t    Triangle
+		p    Point
+		out  [3]float64
+	}{
+		{
+			desc: "At the first vertex",
+			t:    Triangle{Point{0, 0}, Point{4, 0}, Point{0, 4}},
+			p:    Point{0, 0},
+			out:  [3]float64{1, 0, 0},
+		},
+		{
+			desc: "At the third vertex",
+			t:    Triangle{Point{0, 0}, Point{4, 0}, Point{0, 4}},
+			p:    Point{0, 4},
+			out:  [3]float64{0, 0, 1},
+		},
+		{
+			desc: "Midpoint of the second edge",
+			t:    Triangle{Point{0, 0}, Point{4, 0}, Point{0, 4}},
+			p:    Point{2, 2},
+			out:  [3]float64{0, 0.5, 0.5},
+		},
+		{
+			desc: "Centroid of a clockwise triangle",
+			t:    Triangle{Point{0, 0}, Point{0, 3}, Point{3, 0}},
+			p:    Point{1, 1},
+			out:  [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3},
+		},
+		{
+			desc: "Outside the triangle",
+			t:    Triangle{Point{0, 0}, Point{4, 0}, Point{0, 4}},
+			p:    Point{4, 4},
+			out:  [3]float64{-1, 1, 1},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			l1, l2, l3 := tC.t.Barycentric(tC.p)
+			if !almost_zero(l1-tC.out[0]) || !almost_zero(l2-tC.out[1]) || !almost_zero(l3-tC.out[2]) {
+				t.Errorf("Barycentric() = %v, %v, %v, want %v", l1, l2, l3, tC.out)
+			}
+		})
+	}
+
+	t.Run("Triangle with no area", func(t *testing.T) {
+		l1, l2, l3 := Triangle{Point{0, 0}, Point{1, 1}, Point{2, 2}}.Barycentric(Point{1, 1})
+		if !math.IsNaN(l1) || !math.IsNaN(l2) || !math.IsNaN(l3) {
+			t.Errorf("Barycentric() = %v, %v, %v, want NaN", l1, l2, l3)
+		}
+	})
+}
+
+func TestTriangleLocatePoint(t *testing.T) {
+	testCases := []struct {
+		desc string
+		t    Triangle
+		p    Point
+		out  Location
+	}{
+		{
+			desc: "Inside",
+			t:    Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			p:    Point{0.25, 0.25},
+			out:  Interior,
+		},
+		{
+			desc: "Inside a clockwise triangle",
+			t:    Triangle{Point{0, 0}, Point{0, 1}, Point{1, 0}},
+			p:    Point{0.25, 0.25},
+			out:  Interior,
+		},
+		{
+			desc: "On the hypotenuse",
+			t:    Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			p:    Point{0.5, 0.5},
+			out:  Boundary,
+		},
+		{
+			desc: "On a vertex",
+			t:    Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			p:    Point{1, 0},
+			out:  Boundary,
+		},
+		{
+			desc: "On an edge with decimal coordinates",
+			t:    Triangle{Point{0.1, 0.1}, Point{0.3, 0.3}, Point{0, 1}},
+			p:    Point{0.2, 0.2},
+			out:  Boundary,
+		},
+		{
+			desc: "Just outside an edge",
+			t:    Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			p:    Point{0.5, nudge(0.5, 1)},
+			out:  Exterior,
+		},
+		{
+			desc: "Far outside",
+			t:    Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			p:    Point{5, -3},
+			out:  Exterior,
+		},
+		{
+			desc: "On a triangle with no area",
+			t:    Triangle{Point{0, 0}, Point{1, 1}, Point{2, 2}},
+			p:    Point{1.5, 1.5},
+			out:  Boundary,
+		},
+		{
+			desc: "Off a triangle with no area",
+			t:    Triangle{Point{0, 0}, Point{1, 1}, Point{2, 2}},
+			p:    Point{1.5, 1},
+			out:  Exterior,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.t.LocatePoint(tC.p); got != tC.out {
+				t.Errorf("LocatePoint() = %v, want %v", got, tC.out)
+			}
+			if got := tC.t.ContainsPoint(tC.p); got != (tC.out != Exterior) {
+				t.Errorf("ContainsPoint() = %v, want %v", got, tC.out != Exterior)
+			}
+		})
+	}
+}
+
+func BenchmarkTriangleLocatePoint(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		t    Triangle
+		p    Point
+	}{
+		{"Inside", Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}, Point{0.25, 0.25}},
+		{"On the boundary", Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}, Point{0.5, 0.5}},
+		{"Outside", Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}, Point{5, -3}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.t.LocatePoint(bm.p)
+			}
+
+		})
+	}
+}
+
+func TestTriangleContains(t *testing.T) {
+	testCases := []struct {
+		desc string
+		t1   Triangle
+		t2   Triangle
+		out  bool
+	}{
+		{
+			desc: "Small triangle inside a big one",
+			t1:   Triangle{Point{0, 0}, Point{10, 0}, Point{0, 10}},
+			t2:   Triangle{Point{1, 1}, Point{2, 1}, Point{1, 2}},
+			out:  true,
+		},
+		{
+			desc: "Big triangle does not fit in a small one",
+			t1:   Triangle{Point{1, 1}, Point{2, 1}, Point{1, 2}},
+			t2:   Triangle{Point{0, 0}, Point{10, 0}, Point{0, 10}},
+			out:  false,
+		},
+		{
+			desc: "A triangle contains itself",
+			t1:   Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			t2:   Triangle{Point{0, 1}, Point{0, 0}, Point{1, 0}},
+			out:  true,
+		},
+		{
+			desc: "Inside, sharing an edge",
+			t1:   Triangle{Point{0, 0}, Point{2, 0}, Point{0, 2}},
+			t2:   Triangle{Point{0, 0}, Point{2, 0}, Point{0.5, 0.5}},
+			out:  true,
+		},
+		{
+			desc: "Partly overlapping",
+			t1:   Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			t2:   Triangle{Point{0.5, 2}, Point{0.5, -2}, Point{4, 0.5}},
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.t1.Contains(tC.t2); got != tC.out {
+				t.Errorf("Contains() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestTriangleOverlap(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		t1       Triangle
+		t2       Triangle
+		vertices int
+		area     float64
+	}{
+		{
+			desc:     "Identical triangles",
+			t1:       Triangle{Point{0, 0}, Point{2, 0}, Point{0, 2}},
+			t2:       Triangle{Point{0, 0}, Point{2, 0}, Point{0, 2}},
+			vertices: 3,
+			area:     2,
+		},
+		{
+			desc:     "One inside the other",
+			t1:       Triangle{Point{0, 0}, Point{10, 0}, Point{0, 10}},
+			t2:       Triangle{Point{1, 1}, Point{1, 3}, Point{3, 1}},
+			vertices: 3,
+			area:     2,
+		},
+		{
+			desc:     "Overlapping in a square",
+			t1:       Triangle{Point{0, 0}, Point{2, 0}, Point{0, 2}},
+			t2:       Triangle{Point{1, 1}, Point{-1, 1}, Point{1, -1}},
+			vertices: 4,
+			area:     1,
+		},
+		{
+			desc:     "Star of David makes a hexagon",
+			t1:       Triangle{Point{0, 3}, Point{-3 * math.Sqrt(3) / 2, -1.5}, Point{3 * math.Sqrt(3) / 2, -1.5}},
+			t2:       Triangle{Point{0, -3}, Point{3 * math.Sqrt(3) / 2, 1.5}, Point{-3 * math.Sqrt(3) / 2, 1.5}},
+			vertices: 6,
+			area:     4.5 * math.Sqrt(3),
+		},
+		{
+			desc:     "Sharing only an edge",
+			t1:       Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			t2:       Triangle{Point{0, 0}, Point{-1, 0}, Point{0, 1}},
+			vertices: 0,
+			area:     0,
+		},
+		{
+			desc:     "Disjoint",
+			t1:       Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			t2:       Triangle{Point{2, 0}, Point{2, 1}, Point{3, 0}},
+			vertices: 0,
+			area:     0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			vertices, area := tC.t1.Overlap(tC.t2)
+			if len(vertices) != tC.vertices || !almost_zero(area-tC.area) {
+				t.Errorf("Overlap() = %v, %v, want %v vertices and area %v", vertices, area, tC.vertices, tC.area)
+			}
+			for _, v := range vertices {
+				for _, tri := range []Triangle{tC.t1, tC.t2} {
+					if l1, l2, l3 := tri.Barycentric(v); l1 < -1e-9 || l2 < -1e-9 || l3 < -1e-9 {
+						t.Errorf("Overlap() vertex %v is outside %v", v, tri)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLineSegmentLineCrossing(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    LineSegment
+		m    LineSegment
+		want Point
+	}{
+		{
+			desc: "Perpendicular",
+			l:    LineSegment{Point{1, -1}, Point{1, 3}},
+			m:    LineSegment{Point{0, 0}, Point{2, 0}},
+			want: Point{1, 0},
+		},
+		{
+			desc: "Crossing beyond the end of m",
+			l:    LineSegment{Point{4, 1}, Point{6, -3}},
+			m:    LineSegment{Point{0, 0}, Point{1, 0}},
+			want: Point{4.5, 0},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.line_crossing(tC.m); !got.AlmostEquals(tC.want) {
+				t.Errorf("line_crossing() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+
+	// The cross products of the ends of `l` with `m` both round to zero, though Orient2D
+	// puts them on opposite sides, so the crossing must come from Orient2D.
+	l := LineSegment{Point{-0.030175069442149677, -0.0814015641459099}, Point{5.7908062837536134e-05, 0.0340304137104741}}
+	m := LineSegment{Point{0.24399197174364354, 0.9653905959014507}, Point{0.022749363886038574, 0.12066824571629557}}
+	if got := l.line_crossing(m); !l.BoundingBox().ContainsPoint(got) || (l.DistanceToPoint(got) > 1e-15) {
+		t.Errorf("line_crossing() of nearly parallel lines = %v, which is not on %v", got, l)
+	}
+}
+
+func BenchmarkTriangleOverlap(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		t1   Triangle
+		t2   Triangle
+	}{
+		{
+			desc: "One inside the other",
+			t1:   Triangle{Point{0, 0}, Point{10, 0}, Point{0, 10}},
+			t2:   Triangle{Point{1, 1}, Point{1, 3}, Point{3, 1}},
+		},
+		{
+			desc: "Overlapping in a square",
+			t1:   Triangle{Point{0, 0}, Point{2, 0}, Point{0, 2}},
+			t2:   Triangle{Point{1, 1}, Point{-1, 1}, Point{1, -1}},
+		},
+		{
+			desc: "Disjoint",
+			t1:   Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			t2:   Triangle{Point{2, 0}, Point{2, 1}, Point{3, 0}},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.t1.Overlap(bm.t2)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// Ellipsoid is a model of the Earth as an ellipse of revolution about its polar axis.
+type Ellipsoid struct {
+	// A is the equatorial radius in meters.
+	A float64
+	// F is the flattening, the amount by which the polar radius is shorter than A, as a
+	// fraction of A.
+	F float64
+}
+
+// WGS84 is the ellipsoid of the World Geodetic System 1984, used by GPS.
+var WGS84 = Ellipsoid{A: 6378137, F: 1 / 298.257223563}
+
+// Geodesic describes the shortest path between two LatLngs on an Ellipsoid.
+type Geodesic struct {
+	// Distance is the length of the path in meters.
+	Distance float64
+	// InitialBearing is the bearing of the path as it leaves the first LatLng, in
+	// degrees from 0 up to, but not including, 360.
+	InitialBearing float64
+	// FinalBearing is the bearing of the path as it arrives at the second LatLng.
+	FinalBearing float64
+}
+
+// GeodesicDistance calculates the distance to `m` in meters along the shortest path on
+// the WGS84 Ellipsoid. See Ellipsoid.Inverse.
+func (l LatLng) GeodesicDistance(m LatLng) float64 {
+	return WGS84.Inverse(l, m).Distance
+}
+
+// Inverse solves the inverse geodesic problem, finding the shortest path between two
+// LatLngs on the Ellipsoid. Distances are accurate to a fraction of a millimeter.
+//
+// Vincenty's iteration for this fails to converge when the LatLngs are nearly
+// antipodal, so instead, as Karney does, the path is found by searching for the
+// initial bearing whose geodesic reaches the latitude of `to` at its longitude. The
+// points are first swapped and reflected so that that longitude only increases with
+// the bearing, and so the search always finds it. Vincenty's series then give the
+// distance. The bearings are 0 if the LatLngs are the same. Latitudes outside -90 to
+// 90, or angles that are not finite, give NaN.
+func (e Ellipsoid) Inverse(from, to LatLng) Geodesic {
+	if !(math.Abs(from.Lat) <= 90) || !(math.Abs(to.Lat) <= 90) ||
+		math.IsInf(from.Lng, 0) || math.IsInf(to.Lng, 0) || math.IsNaN(from.Lng) || math.IsNaN(to.Lng) {
+		return Geodesic{math.NaN(), math.NaN(), math.NaN()}
+	}
+
+	// Reflect and swap the points so that the first is at least as far from the equator
+	// as the second, and south of it, and the second is east of the first.
+	lat1, lat2 := from.Lat, to.Lat
+	lng12 := wrap_degrees(to.Lng - from.Lng)
+	if (lat1 == lat2) && (lng12 == 0) {
+		return Geodesic{}
+	}
+	lng_sign := 1.0
+	if lng12 < 0 {
+		lng_sign, lng12 = -1, -lng12
+	}
+	swap := 1.0
+	if math.Abs(lat1) < math.Abs(lat2) {
+		swap, lng_sign = -1, -lng_sign
+		lat1, lat2 = lat2, lat1
+	}
+	lat_sign := 1.0
+	if lat1 > 0 {
+		lat_sign = -1
+	}
+	lat1, lat2 = lat_sign*lat1, lat_sign*lat2
+
+	g := geodesic_search{ellipsoid: e, lambda12: radians(lng12)}
+	g.sbet1, g.cbet1 = e.reduced_latitude(lat1)
+	g.sbet2, g.cbet2 = e.reduced_latitude(lat2)
+	var path geodesic_path
+	if (lat1 == 0) && (g.lambda12 <= (1-e.F)*math.Pi) {
+		// Both points are on the equator, and the equator is the shortest path.
+		path = geodesic_path{sigma12: g.lambda12 / (1 - e.F), salp1: 1, salp2: 1, salp0: 1}
+	} else {
+		path = g.solve()
+	}
+	distance := e.A * (1 - e.F) * path.distance(e)
+
+	// Undo the reflections and the swap.
+	salp1, calp1, salp2, calp2 := path.salp1, path.calp1, path.salp2, path.calp2
+	if swap < 0 {
+		salp1, salp2 = salp2, salp1
+		calp1, calp2 = calp2, calp1
+	}
+	salp1, calp1 = swap*lng_sign*salp1, swap*lat_sign*calp1
+	salp2, calp2 = swap*lng_sign*salp2, swap*lat_sign*calp2
+	return Geodesic{
+		Distance:       distance,
+		InitialBearing: bearing_degrees(math.Atan2(salp1, calp1)),
+		FinalBearing:   bearing_degrees(math.Atan2(salp2, calp2)),
+	}
+}
+
+// Direct solves the direct geodesic problem by Vincenty's method, finding where the
+// geodesic from `from` with the initial `bearing` ends after `distance` meters, and its
+// bearing there. Distances may be longer than half way around the Earth, or negative,
+// to go backwards.
+func (e Ellipsoid) Direct(from LatLng, bearing, distance float64) (LatLng, float64) {
+	f := e.F
+	b := e.A * (1 - f)
+	salp1, calp1 := math.Sincos(radians(bearing))
+	sbet1, cbet1 := e.reduced_latitude(from.Lat)
+
+	// The geodesic as a great circle on the auxiliary sphere, with σ measured from
+	// where it crosses the equator heading north.
+	sigma1 := math.Atan2(sbet1, cbet1*calp1)
+	salp0 := cbet1 * salp1
+	calp0_sq := 1 - salp0*salp0
+	a, b_coef := vincenty_coefficients(calp0_sq * e.second_eccentricity_sq())
+
+	sigma := distance / (b * a)
+	var ssig, csig, cos2sm float64
+	for i := 0; i < 100; i++ {
+		ssig, csig = math.Sincos(sigma)
+		cos2sm = math.Cos(2*sigma1 + sigma)
+		next := distance/(b*a) + vincenty_delta_sigma(b_coef, ssig, csig, cos2sm)
+		converged := math.Abs(next-sigma) <= 1e-14
+		sigma = next
+		if converged {
+			break
+		}
+	}
+	ssig, csig = math.Sincos(sigma)
+	cos2sm = math.Cos(2*sigma1 + sigma)
+
+	x := sbet1*ssig - cbet1*csig*calp1
+	lat2 := math.Atan2(sbet1*csig+cbet1*ssig*calp1, (1-f)*math.Hypot(salp0, x))
+	omega := math.Atan2(ssig*salp1, cbet1*csig-sbet1*ssig*calp1)
+	lng12 := omega - vincenty_longitude_correction(f, salp0, calp0_sq, sigma, ssig, csig, cos2sm)
+	return LatLng{degrees(lat2), wrap_degrees(from.Lng + degrees(lng12))}, bearing_degrees(math.Atan2(salp0, -x))
+}
+
+// reduced_latitude returns the sine and cosine of the latitude on the auxiliary sphere
+// that corresponds to a latitude in degrees on the Ellipsoid.
+func (e Ellipsoid) reduced_latitude(lat float64) (float64, float64) {
+	s, c := math.Sincos(radians(lat))
+	s *= 1 - e.F
+	h := math.Hypot(s, c)
+	return s / h, c / h
+}
+
+// second_eccentricity_sq returns e'², the square of the second eccentricity.
+func (e Ellipsoid) second_eccentricity_sq() float64 {
+	return e.F * (2 - e.F) / ((1 - e.F) * (1 - e.F))
+}
+
+// vincenty_coefficients returns Vincenty's A and B, which relate distance along a
+// geodesic to arc length on the auxiliary sphere, for u² = cos²α₀ e'².
+func vincenty_coefficients(u_sq float64) (float64, float64) {
+	a := 1 + u_sq/16384*(4096+u_sq*(-768+u_sq*(320-175*u_sq)))
+	b := u_sq / 1024 * (256 + u_sq*(-128+u_sq*(74-47*u_sq)))
+	return a, b
+}
+
+// vincenty_delta_sigma returns Vincenty's Δσ, the difference between the arc length
+// `sigma` on the auxiliary sphere and the distance divided by b A.
+func vincenty_delta_sigma(b, ssig, csig, cos2sm float64) float64 {
+	return b * ssig * (cos2sm + b/4*(csig*(-1+2*cos2sm*cos2sm)-
+		b/6*cos2sm*(-3+4*ssig*ssig)*(-3+4*cos2sm*cos2sm)))
+}
+
+// vincenty_longitude_correction returns the difference between the longitude on the
+// auxiliary sphere and on the Ellipsoid, along an arc of length `sigma`.
+func vincenty_longitude_correction(f, salp0, calp0_sq, sigma, ssig, csig, cos2sm float64) float64 {
+	c := f / 16 * calp0_sq * (4 + f*(4-3*calp0_sq))
+	return (1 - c) * f * salp0 * (sigma + c*ssig*(cos2sm+c*csig*(-1+2*cos2sm*cos2sm)))
+}
+
+// geodesic_search finds the geodesic between two points, after Inverse has swapped and
+// reflected them so that the first is on or south of the equator, and at least as far
+// from it as the second, and the difference in longitude `lambda12` is from 0 to π.
+type geodesic_search struct {
+	ellipsoid    Ellipsoid
+	sbet1, cbet1 float64
+	sbet2, cbet2 float64
+	lambda12     float64
+}
+
+// geodesic_path is a geodesic that leaves its first point at the azimuth with sine
+// `salp1` and cosine `calp1`, and reaches the second after `sigma12` radians on the
+// auxiliary sphere, starting `sigma1` radians after it crosses the equator.
+type geodesic_path struct {
+	salp1, calp1 float64
+	salp2, calp2 float64
+	sigma1       float64
+	sigma12      float64
+	salp0        float64
+	lambda12     float64
+}
+
+// distance returns the length of the path, divided by the polar radius of the
+// Ellipsoid.
+func (p geodesic_path) distance(e Ellipsoid) float64 {
+	a, b := vincenty_coefficients((1 - p.salp0*p.salp0) * e.second_eccentricity_sq())
+	ssig, csig := math.Sincos(p.sigma12)
+	return a * (p.sigma12 - vincenty_delta_sigma(b, ssig, csig, math.Cos(2*p.sigma1+p.sigma12)))
+}
+
+// follow follows the geodesic from the first point at the azimuth with sine `salp1`
+// and cosine `calp1` until it reaches the latitude of the second point heading north,
+// and returns the path. Its lambda12 is the longitude it has then covered, which
+// increases with the azimuth from 0 at 0 to π at π.
+func (g geodesic_search) follow(salp1, calp1 float64) geodesic_path {
+	sbet1, cbet1, sbet2, cbet2 := g.sbet1, g.cbet1, g.sbet2, g.cbet2
+
+	// The azimuth α₀ where the geodesic crosses the equator is the same everywhere
+	// along it, by Clairaut's relation.
+	salp0 := salp1 * cbet1
+	calp0 := math.Hypot(calp1, salp1*sbet1)
+
+	// The azimuth at the second point, heading north, from cos α₂ cos β₂, computed in a
+	// way that keeps its accuracy when the latitudes are close.
+	salp2, calp2 := salp1, math.Abs(calp1)
+	if (cbet2 != cbet1) || (math.Abs(sbet2) != -sbet1) {
+		salp2 = salp0 / cbet2
+		d := (sbet1 - sbet2) * (sbet1 + sbet2)
+		if cbet1 < -sbet1 {
+			d = (cbet2 - cbet1) * (cbet1 + cbet2)
+		}
+		calp2 = math.Sqrt(calp1*cbet1*calp1*cbet1+d) / cbet2
+	}
+
+	// Arc lengths σ and longitudes ω on the auxiliary sphere, from the equator crossing.
+	// The differences are found from the angle between the vectors, so they need not be
+	// unit vectors, and are kept from 0 to π.
+	ssig1, csig1 := sbet1, calp1*cbet1
+	ssig2, csig2 := sbet2, calp2*cbet2
+	somg1, somg2 := salp0*sbet1, salp0*sbet2
+	sigma12 := math.Atan2(math.Max(0, csig1*ssig2-ssig1*csig2), csig1*csig2+ssig1*ssig2)
+	omega12 := math.Atan2(math.Max(0, csig1*somg2-somg1*csig2), csig1*csig2+somg1*somg2)
+
+	sigma1 := math.Atan2(ssig1, csig1)
+	ssig, csig := math.Sincos(sigma12)
+	cos2sm := math.Cos(2*sigma1 + sigma12)
+	correction := vincenty_longitude_correction(g.ellipsoid.F, salp0, calp0*calp0, sigma12, ssig, csig, cos2sm)
+	return geodesic_path{
+		salp1: salp1, calp1: calp1, salp2: salp2, calp2: calp2,
+		sigma1: sigma1, sigma12: sigma12, salp0: salp0,
+		lambda12: omega12 - correction,
+	}
+}
+
+// solve searches for the azimuth at the first point whose geodesic reaches the second.
+// It uses the Illinois variant of the false position method, which converges quickly
+// where the longitude changes smoothly with the azimuth, with a bisection every third
+// step, so that it always narrows down the azimuth even where it does not. Azimuths
+// are kept as their sine and cosine, and moved by rotating them, as near the equator
+// the cosine needs more precision than an angle in radians has.
+func (g geodesic_search) solve() geodesic_path {
+	lo, hi := g.follow(0, 1), g.follow(0, -1)
+	f_lo, f_hi := lo.lambda12-g.lambda12, hi.lambda12-g.lambda12
+	if f_lo >= 0 {
+		return lo
+	}
+	if f_hi <= 0 {
+		return hi
+	}
+
+	best, side := lo, 0
+	for i := 0; i < 200; i++ {
+		width := math.Atan2(hi.salp1*lo.calp1-hi.calp1*lo.salp1, lo.calp1*hi.calp1+lo.salp1*hi.salp1)
+		t := 0.5
+		if i%3 != 2 {
+			t = f_lo / (f_lo - f_hi)
+		}
+		sd, cd := math.Sincos(t * width)
+		salp1, calp1 := lo.salp1*cd+lo.calp1*sd, lo.calp1*cd-lo.salp1*sd
+		if ((salp1 == lo.salp1) && (calp1 == lo.calp1)) || ((salp1 == hi.salp1) && (calp1 == hi.calp1)) {
+			break
+		}
+		best = g.follow(salp1, calp1)
+		f := best.lambda12 - g.lambda12
+		switch {
+		case math.Abs(f) <= 1e-15:
+			return best
+		case f < 0:
+			// Halve the value at the end that is kept again, so that it does not stay
+			// stuck there.
+			lo, f_lo = best, f
+			if side < 0 {
+				f_hi /= 2
+			}
+			side = -1
+		default:
+			hi, f_hi = best, f
+			if side > 0 {
+				f_lo /= 2
+			}
+			side = 1
+		}
+	}
+	return best
+}
@@ -0,0 +1,143 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEllipsoidInverse(t *testing.T) {
+	dms := func(d, m, s float64) float64 { return d + m/60 + s/3600 }
+	grs80 := Ellipsoid{A: 6378137, F: 1 / 298.257222101}
+	testCases := []struct {
+		desc      string
+		ellipsoid Ellipsoid
+		from      LatLng
+		to        LatLng
+		want      Geodesic
+	}{
+		{
+			desc:      "Flinders Peak to Buninyong",
+			ellipsoid: grs80,
+			from:      LatLng{-dms(37, 57, 3.72030), dms(144, 25, 29.52440)},
+			to:        LatLng{-dms(37, 39, 10.15610), dms(143, 55, 35.38390)},
+			want:      Geodesic{54972.271, dms(306, 52, 5.37), dms(307, 10, 25.07)},
+		},
+		{
+			desc:      "Nearly antipodal",
+			ellipsoid: WGS84,
+			from:      LatLng{-30, 0},
+			to:        LatLng{29.9, 179.8},
+			want:      Geodesic{19989832.82761, 161.890524736, 18.090737246},
+		},
+		{
+			desc:      "Along the equator",
+			ellipsoid: WGS84,
+			from:      LatLng{0, 100},
+			to:        LatLng{0, 10},
+			want:      Geodesic{WGS84.A * math.Pi / 2, 270, 270},
+		},
+		{
+			desc:      "Quarter of a meridian",
+			ellipsoid: WGS84,
+			from:      LatLng{90, 0},
+			to:        LatLng{0, 0},
+			want:      Geodesic{10001965.7293, 180, 180},
+		},
+		{
+			desc:      "Same place",
+			ellipsoid: WGS84,
+			from:      LatLng{12, 34},
+			to:        LatLng{12, 34},
+			want:      Geodesic{0, 0, 0},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := tC.ellipsoid.Inverse(tC.from, tC.to)
+			if (math.Abs(got.Distance-tC.want.Distance) > 1e-3) ||
+				(angle_difference(got.InitialBearing, tC.want.InitialBearing) > 1e-5) ||
+				(angle_difference(got.FinalBearing, tC.want.FinalBearing) > 1e-5) {
+				t.Errorf("Inverse(%v, %v) = %+v, want %+v", tC.from, tC.to, got, tC.want)
+			}
+		})
+	}
+
+	if got := WGS84.Inverse(LatLng{91, 0}, LatLng{0, 0}); !math.IsNaN(got.Distance) {
+		t.Errorf("Inverse() from a latitude of 91 = %+v, want NaN", got)
+	}
+}
+
+func TestEllipsoidInverseDirect(t *testing.T) {
+	rng := rand.New(rand.NewSource(35))
+	for trial := 0; trial < 2000; trial++ {
+		from := LatLng{178*rng.Float64() - 89, 360*rng.Float64() - 180}
+		var to LatLng
+		switch trial % 4 {
+		case 0:
+			// Nearly antipodal, where Vincenty's own iteration fails.
+			to = LatLng{-from.Lat + rng.NormFloat64(), from.Lng + 180 + rng.NormFloat64()}.Normalize()
+		case 1:
+			// Close by.
+			to = LatLng{from.Lat + 0.01*rng.NormFloat64(), from.Lng + 0.01*rng.NormFloat64()}.Normalize()
+		case 2:
+			// On or near the equator.
+			from.Lat = 0
+			to = LatLng{1e-3 * rng.NormFloat64(), 360*rng.Float64() - 180}
+		default:
+			to = LatLng{178*rng.Float64() - 89, 360*rng.Float64() - 180}
+		}
+
+		g := WGS84.Inverse(from, to)
+		back := WGS84.Inverse(to, from)
+		if math.Abs(g.Distance-back.Distance) > 1e-6 {
+			t.Errorf("Inverse(%v, %v).Distance = %v, but back is %v", from, to, g.Distance, back.Distance)
+		}
+		if spherical := from.HaversineDistance(to); math.Abs(g.Distance-spherical) > 0.006*spherical {
+			t.Errorf("Inverse(%v, %v).Distance = %v, want about %v", from, to, g.Distance, spherical)
+		}
+
+		got, bearing := WGS84.Direct(from, g.InitialBearing, g.Distance)
+		if (math.Abs(got.Lat-to.Lat) > 1e-11) || (angle_difference(got.Lng, to.Lng) > 1e-11) {
+			t.Fatalf("Inverse(%v, %v) = %+v, but Direct() of that ends at %v", from, to, g, got)
+		}
+		if angle_difference(bearing, g.FinalBearing) > 1e-6 {
+			t.Errorf("Inverse(%v, %v) = %+v, but Direct() of that ends at a bearing of %v", from, to, g, bearing)
+		}
+	}
+}
+
+func TestEllipsoidDirect(t *testing.T) {
+	// Once around the equator, or a meridian, comes back to the start.
+	if got, bearing := WGS84.Direct(LatLng{0, 10}, 90, 2*math.Pi*WGS84.A); (math.Abs(got.Lat) > 1e-9) ||
+		(angle_difference(got.Lng, 10) > 1e-9) || (bearing != 90) {
+		t.Errorf("Direct() around the equator = %v, %v, want %v, 90", got, bearing, LatLng{0, 10})
+	}
+	meridian := 4 * WGS84.Inverse(LatLng{0, 0}, LatLng{90, 0}).Distance
+	if got, bearing := WGS84.Direct(LatLng{0, 10}, 0, meridian); (math.Abs(got.Lat) > 1e-9) ||
+		(angle_difference(got.Lng, 10) > 1e-9) || (angle_difference(bearing, 0) > 1e-9) {
+		t.Errorf("Direct() around a meridian = %v, %v, want %v, 0", got, bearing, LatLng{0, 10})
+	}
+	// A negative distance goes backwards.
+	if got, _ := WGS84.Direct(LatLng{0, 0}, 90, -WGS84.A*math.Pi/2); (math.Abs(got.Lat) > 1e-9) ||
+		(angle_difference(got.Lng, -90) > 1e-9) {
+		t.Errorf("Direct() backwards = %v, want %v", got, LatLng{0, -90})
+	}
+}
+
+func BenchmarkEllipsoidInverse(b *testing.B) {
+	benchmarks := []struct {
+		desc     string
+		from, to LatLng
+	}{
+		{"Short", LatLng{-37.95, 144.42}, LatLng{-37.65, 143.93}},
+		{"Nearly antipodal", LatLng{-30, 0}, LatLng{29.9, 179.8}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				WGS84.Inverse(bm.from, bm.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package gogeo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidGeoJSON is returned, with a description of the problem, for GeoJSON that is
+// valid JSON but not a valid GeoJSON object, or not of the type being unmarshalled.
+var ErrInvalidGeoJSON = errors.New("invalid GeoJSON")
+
+// MarshalGeoJSON encodes a shape as a GeoJSON geometry object, as in RFC 7946. The
+// types written are
+//
+//	Point               Point, with empty coordinates if both are NaN
+//	LineSegment         LineString
+//	Polyline            LineString
+//	Triangle            Polygon
+//	Polygon             Polygon
+//	PolygonWithHoles    Polygon
+//	MultiPoint          MultiPoint
+//	MultiPolyline       MultiLineString
+//	MultiPolygon        MultiPolygon
+//	GeometryCollection  GeometryCollection
+//
+// Rings are closed by repeating their first Point, and are reversed where needed so that
+// exterior rings are counter-clockwise and holes clockwise, as RFC 7946 requires. It
+// returns ErrUnsupportedShape for any other type, ErrNotFinite for other infinite or NaN
+// coordinates, ErrTooFewPoints for a Polyline with a single Point or a ring with fewer
+// than 3 Points, and ErrTooDeep for GeometryCollections nested more deeply than
+// UnmarshalGeoJSON reads. To have encoding/json write a shape as GeoJSON, wrap it in a
+// Geometry.
+func MarshalGeoJSON(shape Bounded) ([]byte, error) {
+	return marshal_geojson(shape, 0)
+}
+
+// marshal_geojson encodes a shape inside `depth` GeometryCollections.
+func marshal_geojson(shape Bounded, depth int) ([]byte, error) {
+	if g, ok := shape.(GeometryCollection); ok {
+		if depth == max_collection_depth {
+			return nil, ErrTooDeep
+		}
+		geometries := make([]json.RawMessage, len(g.Geometries))
+		for i, member := range g.Geometries {
+			var err error
+			if geometries[i], err = marshal_geojson(member, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(geojson_collection{"GeometryCollection", geometries})
+	}
+	geometry_type, coordinates, err := geojson_coordinates(shape)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geojson_geometry{geometry_type, coordinates})
+}
+
+// geojson_geometry holds the members of a GeoJSON geometry other than a
+// GeometryCollection.
+type geojson_geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// geojson_collection holds the members of a GeoJSON GeometryCollection.
+type geojson_collection struct {
+	Type       string            `json:"type"`
+	Geometries []json.RawMessage `json:"geometries"`
+}
+
+// geojson_coordinates returns the GeoJSON type of a shape other than a
+// GeometryCollection, and its coordinates as nested slices of positions.
+func geojson_coordinates(shape Bounded) (string, interface{}, error) {
+	switch s := shape.(type) {
+	case Point:
+		position, err := geojson_point(s)
+		return "Point", position, err
+	case LineSegment:
+		positions, err := geojson_positions([]Point{s.P1, s.P2})
+		return "LineString", positions, err
+	case Polyline:
+		positions, err := geojson_line(s.Points)
+		return "LineString", positions, err
+	case Triangle:
+		rings, err := geojson_polygon(PolygonWithHoles{Shell: Polygon{[]Point{s.P1, s.P2, s.P3}}})
+		return "Polygon", rings, err
+	case Polygon:
+		rings, err := geojson_polygon(PolygonWithHoles{Shell: s})
+		return "Polygon", rings, err
+	case PolygonWithHoles:
+		rings, err := geojson_polygon(s)
+		return "Polygon", rings, err
+	case MultiPoint:
+		points := make([][]float64, len(s.Points))
+		for i, p := range s.Points {
+			var err error
+			if points[i], err = geojson_point(p); err != nil {
+				return "", nil, err
+			}
+		}
+		return "MultiPoint", points, nil
+	case MultiPolyline:
+		lines := make([][][]float64, len(s.Polylines))
+		for i, l := range s.Polylines {
+			var err error
+			if lines[i], err = geojson_line(l.Points); err != nil {
+				return "", nil, err
+			}
+		}
+		return "MultiLineString", lines, nil
+	case MultiPolygon:
+		polygons := make([][][][]float64, len(s.Polygons))
+		for i, p := range s.Polygons {
+			var err error
+			if polygons[i], err = geojson_polygon(p); err != nil {
+				return "", nil, err
+			}
+		}
+		return "MultiPolygon", polygons, nil
+	}
+	return "", nil, fmt.Errorf("%T: %w", shape, ErrUnsupportedShape)
+}
+
+// geojson_point returns the position of a Point, which is empty if both coordinates
+// are NaN.
+func geojson_point(p Point) ([]float64, error) {
+	if math.IsNaN(p.X) && math.IsNaN(p.Y) {
+		return []float64{}, nil
+	}
+	if err := check_finite(p); err != nil {
+		return nil, err
+	}
+	return []float64{p.X, p.Y}, nil
+}
+
+// geojson_positions returns the positions of finite Points.
+func geojson_positions(points []Point) ([][]float64, error) {
+	positions := make([][]float64, len(points))
+	for i, p := range points {
+		if err := check_finite(p); err != nil {
+			return nil, err
+		}
+		positions[i] = []float64{p.X, p.Y}
+	}
+	return positions, nil
+}
+
+// geojson_line returns the positions of a LineString, which must not have just one.
+func geojson_line(points []Point) ([][]float64, error) {
+	if err := check_line(points); err != nil {
+		return nil, err
+	}
+	return geojson_positions(points)
+}
+
+// geojson_rings returns the positions of each ring, with its first Point repeated at
+// the end.
+func geojson_rings(rings []Polygon) ([][][]float64, error) {
+	positions := make([][][]float64, len(rings))
+	for i, ring := range rings {
+		points, err := closed_ring(ring)
+		if err != nil {
+			return nil, err
+		}
+		if positions[i], err = geojson_positions(points); err != nil {
+			return nil, err
+		}
+	}
+	return positions, nil
+}
+
+// geojson_polygon returns the rings of a PolygonWithHoles, or none if its Shell has no
+// Points, with the Shell counter-clockwise and the Holes clockwise.
+func geojson_polygon(p PolygonWithHoles) ([][][]float64, error) {
+	if len(p.Shell.Points) == 0 {
+		return [][][]float64{}, nil
+	}
+	return geojson_rings(p.Oriented().Rings())
+}
+
+// UnmarshalGeoJSON decodes a GeoJSON geometry object. The types returned are
+//
+//	Point               Point, which is Point{NaN, NaN} if its coordinates are empty
+//	LineString          Polyline
+//	Polygon             PolygonWithHoles, with the first ring as the Shell
+//	MultiPoint          MultiPoint
+//	MultiLineString     MultiPolyline
+//	MultiPolygon        MultiPolygon
+//	GeometryCollection  GeometryCollection
+//
+// A LineString must have no positions or at least 2. Rings must be closed, and the
+// repeated closing Point is dropped. Their orientation is kept as it was written. Only
+// 2D positions are supported, so a position with an altitude is an error.
+// GeometryCollections can be nested up to 100 deep. Other members, such as "bbox", are
+// ignored. A Feature or FeatureCollection is not a geometry, and is an error here; use
+// Feature.UnmarshalJSON or FeatureCollection.UnmarshalJSON for those.
+func UnmarshalGeoJSON(data []byte) (Bounded, error) {
+	return unmarshal_geojson(data, 0)
+}
+
+// unmarshal_geojson decodes a geometry object inside `depth` GeometryCollections.
+func unmarshal_geojson(data []byte, depth int) (Bounded, error) {
+	var object struct {
+		Type        string            `json:"type"`
+		Coordinates json.RawMessage   `json:"coordinates"`
+		Geometries  []json.RawMessage `json:"geometries"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if object.Type == "GeometryCollection" {
+		if object.Geometries == nil {
+			return nil, invalid_geojson("a GeometryCollection has no geometries")
+		}
+		if depth == max_collection_depth {
+			return nil, invalid_geojson("a GeometryCollection is nested more than %v deep", max_collection_depth)
+		}
+		var g GeometryCollection
+		for _, member := range object.Geometries {
+			shape, err := unmarshal_geojson(member, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			g.Geometries = append(g.Geometries, shape)
+		}
+		return g, nil
+	}
+	switch object.Type {
+	case "":
+		return nil, invalid_geojson("the object has no type")
+	case "Feature", "FeatureCollection":
+		return nil, invalid_geojson("unknown geometry type %q, use %v.UnmarshalJSON", object.Type, object.Type)
+	}
+	if (object.Coordinates == nil) || (string(object.Coordinates) == "null") {
+		switch object.Type {
+		case "Point", "LineString", "Polygon", "MultiPoint", "MultiLineString", "MultiPolygon":
+			return nil, invalid_geojson("a %v has no coordinates", object.Type)
+		}
+		return nil, invalid_geojson("unknown geometry type %q", object.Type)
+	}
+
+	switch object.Type {
+	case "Point":
+		var position []float64
+		if err := json.Unmarshal(object.Coordinates, &position); err != nil {
+			return nil, err
+		}
+		p, err := point_from_geojson(position)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "LineString":
+		var positions [][]float64
+		if err := json.Unmarshal(object.Coordinates, &positions); err != nil {
+			return nil, err
+		}
+		l, err := line_from_geojson(positions)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &rings); err != nil {
+			return nil, err
+		}
+		p, err := polygon_from_geojson(rings)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "MultiPoint":
+		var positions [][]float64
+		if err := json.Unmarshal(object.Coordinates, &positions); err != nil {
+			return nil, err
+		}
+		var m MultiPoint
+		for _, position := range positions {
+			p, err := point_from_geojson(position)
+			if err != nil {
+				return nil, err
+			}
+			m.Points = append(m.Points, p)
+		}
+		return m, nil
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &lines); err != nil {
+			return nil, err
+		}
+		var m MultiPolyline
+		for _, line := range lines {
+			polyline, err := line_from_geojson(line)
+			if err != nil {
+				return nil, err
+			}
+			m.Polylines = append(m.Polylines, polyline)
+		}
+		return m, nil
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, err
+		}
+		var m MultiPolygon
+		for _, rings := range polygons {
+			p, err := polygon_from_geojson(rings)
+			if err != nil {
+				return nil, err
+			}
+			m.Polygons = append(m.Polygons, p)
+		}
+		return m, nil
+	default:
+		return nil, invalid_geojson("unknown geometry type %q", object.Type)
+	}
+}
+
+// invalid_geojson returns an error wrapping ErrInvalidGeoJSON.
+func invalid_geojson(format string, args ...interface{}) error {
+	return fmt.Errorf("geojson: %v: %w", fmt.Sprintf(format, args...), ErrInvalidGeoJSON)
+}
+
+// point_from_geojson converts a position to a Point, or an empty one to a Point of NaNs.
+func point_from_geojson(position []float64) (Point, error) {
+	switch len(position) {
+	case 0:
+		return Point{math.NaN(), math.NaN()}, nil
+	case 2:
+		return Point{position[0], position[1]}, nil
+	case 1:
+		return Point{}, invalid_geojson("position %v has a single coordinate", position)
+	default:
+		return Point{}, invalid_geojson("position %v has an altitude, only 2D is supported", position)
+	}
+}
+
+// points_from_geojson converts positions to Points, none of which may be empty.
+func points_from_geojson(positions [][]float64) ([]Point, error) {
+	var points []Point
+	for _, position := range positions {
+		if len(position) == 0 {
+			return nil, invalid_geojson("position is empty")
+		}
+		p, err := point_from_geojson(position)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// line_from_geojson converts the positions of a LineString to a Polyline. As in RFC
+// 7946, a LineString needs at least 2 positions, unless it is empty.
+func line_from_geojson(positions [][]float64) (Polyline, error) {
+	if len(positions) == 1 {
+		return Polyline{}, invalid_geojson("linestring has 1 position, at least 2 are needed")
+	}
+	points, err := points_from_geojson(positions)
+	if err != nil {
+		return Polyline{}, err
+	}
+	return Polyline{points}, nil
+}
+
+// polygon_from_geojson converts closed rings to a PolygonWithHoles, dropping their
+// closing Points.
+func polygon_from_geojson(rings [][][]float64) (PolygonWithHoles, error) {
+	var polygon []Polygon
+	for _, ring := range rings {
+		points, err := points_from_geojson(ring)
+		switch {
+		case err != nil:
+			return PolygonWithHoles{}, err
+		case len(points) < 4:
+			return PolygonWithHoles{}, invalid_geojson("ring has %v positions, at least 4 are needed", len(points))
+		case points[0] != points[len(points)-1]:
+			return PolygonWithHoles{}, invalid_geojson("ring is not closed")
+		}
+		polygon = append(polygon, Polygon{points[:len(points)-1]})
+	}
+	return polygon_from_rings(polygon), nil
+}
+
+// Geometry wraps a shape so that encoding/json reads and writes it as a GeoJSON
+// geometry object, as MarshalGeoJSON and UnmarshalGeoJSON do. The shapes themselves are
+// left to encoding/json's default encoding of structs.
+type Geometry struct {
+	// Shape is the wrapped shape, which is written as JSON null if it is nil.
+	Shape Bounded
+}
+
+// MarshalJSON encodes the Shape with MarshalGeoJSON.
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	if g.Shape == nil {
+		return []byte("null"), nil
+	}
+	return MarshalGeoJSON(g.Shape)
+}
+
+// UnmarshalJSON decodes the Shape with UnmarshalGeoJSON. JSON null is ignored, as
+// encoding/json does for other types.
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	shape, err := UnmarshalGeoJSON(data)
+	if err != nil {
+		return err
+	}
+	g.Shape = shape
+	return nil
+}
+
+// Feature is a GeoJSON Feature: a shape with properties describing it.
+type Feature struct {
+	// ID identifies the Feature, and is a string or a number, or nil if it has none.
+	// Numbers are float64 when unmarshalled.
+	ID interface{}
+	// Geometry is the shape of the Feature, which may be nil.
+	Geometry Bounded
+	// Properties are any members that can be marshalled to JSON. When unmarshalled,
+	// values are decoded as by encoding/json into an interface{}.
+	Properties map[string]interface{}
+	// BBox is written as the "bbox" member if it is not nil.
+	BBox *BoundingBox
+}
+
+// geojson_feature holds the members of a GeoJSON Feature, as they are read and written.
+type geojson_feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	BBox       []float64              `json:"bbox,omitempty"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// MarshalJSON encodes a Feature as a GeoJSON Feature, with its Geometry encoded by
+// MarshalGeoJSON.
+func (f Feature) MarshalJSON() ([]byte, error) {
+	object := geojson_feature{Type: "Feature", ID: f.ID, Geometry: json.RawMessage("null"), Properties: f.Properties}
+	if f.Geometry != nil {
+		geometry, err := MarshalGeoJSON(f.Geometry)
+		if err != nil {
+			return nil, err
+		}
+		object.Geometry = geometry
+	}
+	var err error
+	if object.BBox, err = geojson_bbox(f.BBox); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON decodes a Feature from a GeoJSON Feature, with its geometry decoded by
+// UnmarshalGeoJSON.
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	var object geojson_feature
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	if object.Type != "Feature" {
+		return invalid_geojson("expected a Feature, found %q", object.Type)
+	}
+	feature := Feature{ID: object.ID, Properties: object.Properties}
+	if (object.Geometry != nil) && (string(object.Geometry) != "null") {
+		geometry, err := UnmarshalGeoJSON(object.Geometry)
+		if err != nil {
+			return err
+		}
+		feature.Geometry = geometry
+	}
+	var err error
+	if feature.BBox, err = bbox_from_geojson(object.BBox); err != nil {
+		return err
+	}
+	*f = feature
+	return nil
+}
+
+// FeatureCollection is a GeoJSON FeatureCollection, which holds any number of Features.
+type FeatureCollection struct {
+	Features []Feature
+	// BBox is written as the "bbox" member if it is not nil.
+	BBox *BoundingBox
+}
+
+// geojson_feature_collection holds the members of a GeoJSON FeatureCollection.
+type geojson_feature_collection struct {
+	Type     string    `json:"type"`
+	BBox     []float64 `json:"bbox,omitempty"`
+	Features []Feature `json:"features"`
+}
+
+// MarshalJSON encodes a FeatureCollection as a GeoJSON FeatureCollection.
+func (c FeatureCollection) MarshalJSON() ([]byte, error) {
+	object := geojson_feature_collection{Type: "FeatureCollection", Features: c.Features}
+	if object.Features == nil {
+		object.Features = []Feature{}
+	}
+	var err error
+	if object.BBox, err = geojson_bbox(c.BBox); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON decodes a FeatureCollection from a GeoJSON FeatureCollection.
+func (c *FeatureCollection) UnmarshalJSON(data []byte) error {
+	var object geojson_feature_collection
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	if object.Type != "FeatureCollection" {
+		return invalid_geojson("expected a FeatureCollection, found %q", object.Type)
+	}
+	if object.Features == nil {
+		return invalid_geojson("a FeatureCollection has no features")
+	}
+	bbox, err := bbox_from_geojson(object.BBox)
+	if err != nil {
+		return err
+	}
+	*c = FeatureCollection{Features: object.Features, BBox: bbox}
+	return nil
+}
+
+// geojson_bbox returns the "bbox" member for a BoundingBox, which is nil if the
+// BoundingBox is.
+func geojson_bbox(b *BoundingBox) ([]float64, error) {
+	if b == nil {
+		return nil, nil
+	}
+	for _, p := range []Point{b.Min, b.Max} {
+		if err := check_finite(p); err != nil {
+			return nil, err
+		}
+	}
+	return []float64{b.Min.X, b.Min.Y, b.Max.X, b.Max.Y}, nil
+}
+
+// bbox_from_geojson converts a "bbox" member to a BoundingBox, or nil if it is missing.
+func bbox_from_geojson(bbox []float64) (*BoundingBox, error) {
+	switch len(bbox) {
+	case 0:
+		return nil, nil
+	case 4:
+		return &BoundingBox{Point{bbox[0], bbox[1]}, Point{bbox[2], bbox[3]}}, nil
+	case 6:
+		return nil, invalid_geojson("bbox %v has altitudes, only 2D is supported", bbox)
+	default:
+		return nil, invalid_geojson("bbox %v does not have 4 numbers", bbox)
+	}
+}
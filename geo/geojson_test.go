@@ -0,0 +1,296 @@
+package gogeo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalGeoJSON(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		shape Bounded
+		want  string
+	}{
+		{"Point", Point{1, -2.5}, `{"type":"Point","coordinates":[1,-2.5]}`},
+		{"Point, empty", Point{math.NaN(), math.NaN()}, `{"type":"Point","coordinates":[]}`},
+		{"LineSegment", LineSegment{Point{0, 0}, Point{1e-7, 1e21}}, `{"type":"LineString","coordinates":[[0,0],[1e-7,1e+21]]}`},
+		{"Polyline, empty", Polyline{}, `{"type":"LineString","coordinates":[]}`},
+		{
+			"Triangle",
+			Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,1],[0,0]]]}`,
+		},
+		{
+			"Triangle, clockwise",
+			Triangle{Point{0, 0}, Point{0, 1}, Point{1, 0}},
+			`{"type":"Polygon","coordinates":[[[1,0],[0,1],[0,0],[1,0]]]}`,
+		},
+		{
+			"PolygonWithHoles",
+			PolygonWithHoles{Shell: square(0, 0, 4), Holes: []Polygon{square(1, 1, 1).Reverse()}},
+			`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,2],[2,2],[2,1],[1,1],[1,2]]]}`,
+		},
+		{
+			"PolygonWithHoles, wrongly oriented",
+			PolygonWithHoles{Shell: square(0, 0, 4).Reverse(), Holes: []Polygon{square(1, 1, 1)}},
+			`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,2],[2,2],[2,1],[1,1],[1,2]]]}`,
+		},
+		{"MultiPoint", MultiPoint{[]Point{{1, 2}, {3, 4}}}, `{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`},
+		{
+			"MultiPolyline",
+			MultiPolyline{[]Polyline{{[]Point{{0, 0}, {1, 1}}}, {}}},
+			`{"type":"MultiLineString","coordinates":[[[0,0],[1,1]],[]]}`,
+		},
+		{"MultiPolygon, empty", MultiPolygon{}, `{"type":"MultiPolygon","coordinates":[]}`},
+		{
+			"GeometryCollection",
+			GeometryCollection{[]Bounded{Point{1, 2}, GeometryCollection{}}},
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"GeometryCollection","geometries":[]}]}`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := MarshalGeoJSON(tC.shape)
+			if (err != nil) || (string(got) != tC.want) {
+				t.Errorf("MarshalGeoJSON(%v) = %s, %v, want %s", tC.shape, got, err, tC.want)
+			}
+		})
+	}
+
+	if _, err := MarshalGeoJSON(Polyline{[]Point{{1, 1}}}); !errors.Is(err, ErrTooFewPoints) {
+		t.Errorf("MarshalGeoJSON() of a one-Point Polyline returned %v, want %v", err, ErrTooFewPoints)
+	}
+	if _, err := MarshalGeoJSON(nested_collection(max_collection_depth + 1)); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("MarshalGeoJSON() of a deeply nested GeometryCollection returned %v, want %v", err, ErrTooDeep)
+	}
+	if _, err := MarshalGeoJSON(MultiPoint{[]Point{{0, math.Inf(1)}}}); !errors.Is(err, ErrNotFinite) {
+		t.Errorf("MarshalGeoJSON() of an infinite coordinate returned %v, want %v", err, ErrNotFinite)
+	}
+	if _, err := json.Marshal(Geometry{GeometryCollection{[]Bounded{circle{Point{0, 0}, 1}}}}); !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("json.Marshal() of a circle returned %v, want %v", err, ErrUnsupportedShape)
+	}
+}
+
+func TestUnmarshalGeoJSON(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		data  string
+		want  Bounded
+		error error
+	}{
+		{
+			desc: "Point, with other members",
+			data: `{"bbox": [1, 2, 1, 2], "coordinates": [1, 2], "type": "Point"}`,
+			want: Point{1, 2},
+		},
+		{
+			desc: "Polygon, closing Point dropped",
+			data: `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 1], [0, 0]]]}`,
+			want: PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}}},
+		},
+		{
+			desc: "MultiPolygon",
+			data: `{"type": "MultiPolygon", "coordinates": [[[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]], []]}`,
+			want: MultiPolygon{[]PolygonWithHoles{{Shell: square(0, 0, 1)}, {}}},
+		},
+		{
+			desc: "GeometryCollection",
+			data: `{"type": "GeometryCollection", "geometries": [{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}]}`,
+			want: GeometryCollection{[]Bounded{Polyline{[]Point{{0, 0}, {1, 1}}}}},
+		},
+		{
+			desc:  "Unknown type",
+			data:  `{"type": "Circle", "coordinates": [0, 0]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "No type",
+			data:  `{"coordinates": [0, 0]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "No coordinates",
+			data:  `{"type": "Point"}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "Altitude",
+			data:  `{"type": "LineString", "coordinates": [[0, 0, 10], [1, 1, 10]]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "Empty position in a LineString",
+			data:  `{"type": "LineString", "coordinates": [[0, 0], []]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "LineString with one position",
+			data:  `{"type": "LineString", "coordinates": [[0, 0]]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "MultiLineString with one position in a line",
+			data:  `{"type": "MultiLineString", "coordinates": [[[0, 0], [1, 1]], [[2, 2]]]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "Ring not closed",
+			data:  `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc:  "GeometryCollection without geometries",
+			data:  `{"type": "GeometryCollection"}`,
+			error: ErrInvalidGeoJSON,
+		},
+		{
+			desc: "Deeply nested GeometryCollection",
+			data: strings.Repeat(`{"type": "GeometryCollection", "geometries": [`, 1000) +
+				`{"type": "Point", "coordinates": [1, 2]}` + strings.Repeat("]}", 1000),
+			error: ErrInvalidGeoJSON,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := UnmarshalGeoJSON([]byte(tC.data))
+			if !errors.Is(err, tC.error) || ((err == nil) != (tC.error == nil)) {
+				t.Fatalf("UnmarshalGeoJSON(%s) returned %v, want %v", tC.data, err, tC.error)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("UnmarshalGeoJSON(%s) = %v, want %v", tC.data, got, tC.want)
+			}
+		})
+	}
+
+	feature := `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": null}`
+	if _, err := UnmarshalGeoJSON([]byte(feature)); !errors.Is(err, ErrInvalidGeoJSON) || !strings.Contains(err.Error(), "Feature.UnmarshalJSON") {
+		t.Errorf("UnmarshalGeoJSON() of a Feature returned %v, want an error pointing to Feature.UnmarshalJSON", err)
+	}
+
+	var syntax *json.SyntaxError
+	if _, err := UnmarshalGeoJSON([]byte(`{"type": "Point",`)); !errors.As(err, &syntax) {
+		t.Errorf("UnmarshalGeoJSON() of malformed JSON returned %v, want a *json.SyntaxError", err)
+	}
+}
+
+func TestGeometryJSON(t *testing.T) {
+	// Shapes wrapped in a Geometry are written as GeoJSON, and others as plain structs.
+	type shapes struct {
+		Point    Point
+		Triangle Geometry
+		Polygon  *Geometry
+		Missing  Geometry
+	}
+	want := shapes{
+		Point:    Point{1, 2},
+		Triangle: Geometry{Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}},
+		Polygon:  &Geometry{swiss_cheese},
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned %v", err)
+	}
+	encoded := `{"Point":{"X":1,"Y":2},` +
+		`"Triangle":{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,1],[0,0]]]},` +
+		`"Polygon":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],` +
+		`[[2,4],[4,4],[4,2],[2,2],[2,4]],[[6,8],[8,8],[8,6],[6,6],[6,8]]]},` +
+		`"Missing":null}`
+	if string(data) != encoded {
+		t.Errorf("json.Marshal() = %s, want %s", data, encoded)
+	}
+
+	var got shapes
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) returned %v", data, err)
+	}
+	// A Triangle comes back as a PolygonWithHoles.
+	want.Triangle = Geometry{PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json.Unmarshal(%s) = %v, want %v", data, got, want)
+	}
+
+	invalid := []string{
+		`{"type": "Feature", "geometry": null, "properties": null}`,
+		`{"type": "LineString", "coordinates": [[0, 0]]}`,
+	}
+	for _, data := range invalid {
+		var g Geometry
+		if err := json.Unmarshal([]byte(data), &g); !errors.Is(err, ErrInvalidGeoJSON) {
+			t.Errorf("json.Unmarshal(%s) returned %v, want %v", data, err, ErrInvalidGeoJSON)
+		}
+	}
+}
+
+func TestFeatureCollection(t *testing.T) {
+	collection := FeatureCollection{
+		Features: []Feature{
+			{
+				ID:         "park",
+				Geometry:   swiss_cheese,
+				Properties: map[string]interface{}{"name": "Swiss Cheese Park", "area": 92.0, "open": true},
+				BBox:       &BoundingBox{Point{0, 0}, Point{10, 10}},
+			},
+			{ID: 7.0, Geometry: Point{1, 2}},
+			{},
+		},
+		BBox: &BoundingBox{Point{0, 0}, Point{10, 10}},
+	}
+	data, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned %v", err)
+	}
+	want := `{"type":"FeatureCollection","bbox":[0,0,10,10],"features":[` +
+		`{"type":"Feature","id":"park","bbox":[0,0,10,10],` +
+		`"geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],` +
+		`[[2,4],[4,4],[4,2],[2,2],[2,4]],[[6,8],[8,8],[8,6],[6,6],[6,8]]]},` +
+		`"properties":{"area":92,"name":"Swiss Cheese Park","open":true}},` +
+		`{"type":"Feature","id":7,"geometry":{"type":"Point","coordinates":[1,2]},"properties":null},` +
+		`{"type":"Feature","geometry":null,"properties":null}]}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	var got FeatureCollection
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) returned %v", data, err)
+	}
+	if !reflect.DeepEqual(got, collection) {
+		t.Errorf("json.Unmarshal(%s) = %v, want %v", data, got, collection)
+	}
+
+	invalid := []string{
+		`{"type": "Feature", "geometry": null, "properties": null}`,
+		`{"type": "FeatureCollection"}`,
+		`{"type": "FeatureCollection", "features": [{"type": "Point", "coordinates": [0, 0]}]}`,
+		`{"type": "FeatureCollection", "features": [], "bbox": [0, 0, 0, 1, 1, 1]}`,
+	}
+	for _, data := range invalid {
+		if err := json.Unmarshal([]byte(data), &got); !errors.Is(err, ErrInvalidGeoJSON) {
+			t.Errorf("json.Unmarshal(%s) returned %v, want %v", data, err, ErrInvalidGeoJSON)
+		}
+	}
+}
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(33))
+	for trial := 0; trial < 500; trial++ {
+		shape := random_geometry(rng, 3)
+		data, err := MarshalGeoJSON(shape)
+		if err != nil {
+			t.Fatalf("MarshalGeoJSON(%v) returned %v", shape, err)
+		}
+		got, err := UnmarshalGeoJSON(data)
+		if err != nil {
+			t.Fatalf("UnmarshalGeoJSON(%s) returned %v", data, err)
+		}
+		// A Triangle comes back as a PolygonWithHoles, so the encodings are compared.
+		if again, err := MarshalGeoJSON(got); (err != nil) || (string(again) != string(data)) {
+			t.Fatalf("UnmarshalGeoJSON(%s) = %v, which marshals to %s", data, got, again)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package gogeo
+
+import (
+	"math"
+	"sort"
+)
+
+// HullAlgorithm selects the algorithm used by ConvexHull.
+type HullAlgorithm int
+
+const (
+	// MonotoneChain is Andrew's monotone chain algorithm. It sorts the Points and then
+	// builds the lower and upper halves of the hull in a single pass each, taking
+	// O(n log n) time.
+	MonotoneChain HullAlgorithm = iota
+	// QuickHull recursively splits the Points by the Point farthest from the current
+	// hull edge. It is usually faster when most Points lie well inside the hull, but
+	// takes O(n²) time in the worst case.
+	QuickHull
+)
+
+// HullOptions configure ConvexHull. The zero value uses MonotoneChain and leaves out
+// Points that lie along the edges of the hull.
+type HullOptions struct {
+	Algorithm HullAlgorithm
+	// IncludeCollinear keeps Points that lie on an edge of the hull, between two of its
+	// corners, as vertices of the returned Polygon.
+	IncludeCollinear bool
+}
+
+// ConvexHull calculates the smallest convex Polygon that contains all the `points`. The
+// Polygon is in counter-clockwise order, starting from the Point with the smallest X
+// (and then the smallest Y), and has no repeated vertices. Duplicate input Points, and
+// Points with an infinite or NaN coordinate, are ignored. If every Point is the same,
+// the Polygon has a single vertex. If every Point lies on one line, the Polygon has the
+// two ends of that line as its vertices, or, with IncludeCollinear, every distinct
+// Point in order along the line.
+//
+// All turns are tested with the exact Orient2D predicate, the same one used by
+// LineSegment.Intersects, so the results of the two always agree.
+func ConvexHull(points []Point, options HullOptions) Polygon {
+	sorted := sorted_unique_points(points)
+	if len(sorted) < 3 {
+		return Polygon{sorted}
+	}
+	if all_collinear(sorted) {
+		if options.IncludeCollinear {
+			return Polygon{sorted}
+		}
+		return Polygon{[]Point{sorted[0], sorted[len(sorted)-1]}}
+	}
+
+	switch options.Algorithm {
+	case QuickHull:
+		return quick_hull(sorted, options.IncludeCollinear)
+	default:
+		return monotone_chain(sorted, options.IncludeCollinear)
+	}
+}
+
+// sorted_unique_points returns a copy of the finite `points`, sorted by X and then by Y,
+// with duplicates removed.
+func sorted_unique_points(points []Point) []Point {
+	sorted := make([]Point, 0, len(points))
+	for _, p := range points {
+		if check_finite(p) == nil {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return less_xy(sorted[i], sorted[j]) })
+
+	unique := sorted[:0]
+	for i, p := range sorted {
+		if (i == 0) || !p.Equals(sorted[i-1]) {
+			unique = append(unique, p)
+		}
+	}
+	return unique
+}
+
+// less_xy orders Points by X, and then by Y.
+func less_xy(p, q Point) bool {
+	return (p.X < q.X) || ((p.X == q.X) && (p.Y < q.Y))
+}
+
+// all_collinear tests if Points, sorted by less_xy, all lie on one line.
+func all_collinear(sorted []Point) bool {
+	first := sorted[0]
+	last := sorted[len(sorted)-1]
+	for _, p := range sorted[1 : len(sorted)-1] {
+		if Orient2D(first, last, p) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// keeps_turn decides if the middle of three consecutive hull vertices should stay. A
+// left turn always stays, and going straight on stays only if collinear Points are
+// wanted.
+func keeps_turn(a, b, c Point, include_collinear bool) bool {
+	turn := sign(Orient2D(a, b, c))
+	return (turn > 0) || (include_collinear && (turn == 0))
+}
+
+// monotone_chain builds the hull of sorted, unique, non-collinear Points with Andrew's
+// algorithm.
+func monotone_chain(sorted []Point, include_collinear bool) Polygon {
+	hull := make([]Point, 0, 2*len(sorted))
+
+	// The lower hull, from left to right.
+	for _, p := range sorted {
+		for (len(hull) >= 2) && !keeps_turn(hull[len(hull)-2], hull[len(hull)-1], p, include_collinear) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	// The upper hull, from right to left.
+	lower_size := len(hull)
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for (len(hull) > lower_size) && !keeps_turn(hull[len(hull)-2], hull[len(hull)-1], p, include_collinear) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	// The first Point was added again at the end.
+	return Polygon{hull[:len(hull)-1]}
+}
+
+// quick_hull builds the hull of sorted, unique, non-collinear Points with the quickhull
+// algorithm.
+func quick_hull(sorted []Point, include_collinear bool) Polygon {
+	first := sorted[0]
+	last := sorted[len(sorted)-1]
+
+	var below, above, on []Point
+	for _, p := range sorted[1 : len(sorted)-1] {
+		switch sign(Orient2D(first, last, p)) {
+		case -1:
+			below = append(below, p)
+		case 1:
+			above = append(above, p)
+		default:
+			on = append(on, p)
+		}
+	}
+
+	hull := []Point{first}
+	hull = append(hull, quick_hull_side(first, last, below, on, include_collinear)...)
+	hull = append(hull, last)
+	hull = append(hull, quick_hull_side(last, first, above, reversed_points(on), include_collinear)...)
+
+	// Choosing the farthest Point is subject to rounding, so a Point within rounding
+	// error of the hull might have been picked as a vertex when it is not one. A final
+	// pass over the vertices removes any such Point.
+	cleaned := make([]Point, 0, len(hull))
+	for _, p := range append(hull, first) {
+		for (len(cleaned) >= 2) && !keeps_turn(cleaned[len(cleaned)-2], cleaned[len(cleaned)-1], p, include_collinear) {
+			cleaned = cleaned[:len(cleaned)-1]
+		}
+		cleaned = append(cleaned, p)
+	}
+	return Polygon{cleaned[:len(cleaned)-1]}
+}
+
+// quick_hull_side finds the vertices of the hull strictly between `a` and `b`, in order,
+// given the Points `outside` that lie to the right of the directed line from `a` to `b`
+// and the Points `on` that lie on the LineSegment between them, ordered from `a` to `b`.
+func quick_hull_side(a, b Point, outside, on []Point, include_collinear bool) []Point {
+	if len(outside) == 0 {
+		// The LineSegment from `a` to `b` is an edge of the hull.
+		if include_collinear {
+			return on
+		}
+		return nil
+	}
+
+	// The Point farthest from the line is a vertex of the hull.
+	farthest := outside[0]
+	farthest_distance := -Orient2D(a, b, farthest)
+	for _, p := range outside[1:] {
+		if distance := -Orient2D(a, b, p); distance > farthest_distance {
+			farthest = p
+			farthest_distance = distance
+		}
+	}
+
+	var outside_a, on_a, outside_b, on_b []Point
+	for _, p := range outside {
+		if p.Equals(farthest) {
+			continue
+		}
+		if side := sign(Orient2D(a, farthest, p)); side < 0 {
+			outside_a = append(outside_a, p)
+		} else if side == 0 {
+			on_a = append(on_a, p)
+		} else if side = sign(Orient2D(farthest, b, p)); side < 0 {
+			outside_b = append(outside_b, p)
+		} else if side == 0 {
+			on_b = append(on_b, p)
+		}
+	}
+	sort_along(a, on_a)
+	sort_along(farthest, on_b)
+
+	hull := quick_hull_side(a, farthest, outside_a, on_a, include_collinear)
+	hull = append(hull, farthest)
+	return append(hull, quick_hull_side(farthest, b, outside_b, on_b, include_collinear)...)
+}
+
+// sort_along sorts Points that lie on a line, all on the same side of `origin`, in order
+// of their distance from it. They are compared by the coordinate that changes most along
+// the line, without any arithmetic, so that Points only a rounding error apart are still
+// put in the right order.
+func sort_along(origin Point, points []Point) {
+	var dx, dy float64
+	for _, p := range points {
+		if math.Abs(p.X-origin.X) > math.Abs(dx) {
+			dx = p.X - origin.X
+		}
+		if math.Abs(p.Y-origin.Y) > math.Abs(dy) {
+			dy = p.Y - origin.Y
+		}
+	}
+	key := func(p Point) float64 { return p.X }
+	if math.Abs(dy) > math.Abs(dx) {
+		key = func(p Point) float64 { return p.Y }
+		dx = dy
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if dx < 0 {
+			return key(points[i]) > key(points[j])
+		}
+		return key(points[i]) < key(points[j])
+	})
+}
+
+// reversed_points returns a reversed copy of `points`.
+func reversed_points(points []Point) []Point {
+	return Polygon{points}.Reverse().Points
+}
@@ -0,0 +1,230 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var hull_algorithms = []struct {
+	name      string
+	algorithm HullAlgorithm
+}{
+	{"MonotoneChain", MonotoneChain},
+	{"QuickHull", QuickHull},
+}
+
+func TestConvexHull(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		points           []Point
+		includeCollinear bool
+		out              Polygon
+	}{
+		{
+			desc:   "No Points",
+			points: nil,
+			out:    Polygon{[]Point{}},
+		},
+		{
+			desc:   "One Point, repeated",
+			points: []Point{{1, 1}, {1, 1}, {1, 1}},
+			out:    Polygon{[]Point{{1, 1}}},
+		},
+		{
+			desc:   "Triangle",
+			points: []Point{{0, 1}, {1, 0}, {0, 0}},
+			out:    Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}},
+		},
+		{
+			desc:   "Square with Points inside",
+			points: []Point{{0.5, 0.5}, {1, 1}, {0, 0}, {0.2, 0.7}, {1, 0}, {0, 1}, {0.9, 0.1}},
+			out:    unit_square,
+		},
+		{
+			desc:   "Square with duplicate corners",
+			points: []Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}, {1, 1}, {1, 1}},
+			out:    unit_square,
+		},
+		{
+			desc:   "Square with Points along the edges",
+			points: []Point{{0, 0}, {0.5, 0}, {1, 0}, {1, 0.5}, {1, 1}, {0.5, 1}, {0, 1}, {0, 0.5}},
+			out:    unit_square,
+		},
+		{
+			desc:             "Square with Points along the edges, keeping them",
+			points:           []Point{{0, 0}, {0.5, 0}, {1, 0}, {1, 0.5}, {1, 1}, {0.5, 1}, {0, 1}, {0, 0.5}},
+			includeCollinear: true,
+			out:              Polygon{[]Point{{0, 0}, {0.5, 0}, {1, 0}, {1, 0.5}, {1, 1}, {0.5, 1}, {0, 1}, {0, 0.5}}},
+		},
+		{
+			desc:   "All collinear",
+			points: []Point{{2, 2}, {0, 0}, {3, 3}, {1, 1}},
+			out:    Polygon{[]Point{{0, 0}, {3, 3}}},
+		},
+		{
+			desc:             "All collinear, keeping them",
+			points:           []Point{{2, 2}, {0, 0}, {3, 3}, {1, 1}, {1, 1}},
+			includeCollinear: true,
+			out:              Polygon{[]Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}},
+		},
+		{
+			desc:   "All on a vertical line",
+			points: []Point{{0, 2}, {0, -1}, {0, 5}},
+			out:    Polygon{[]Point{{0, -1}, {0, 5}}},
+		},
+		{
+			desc:   "Nearly collinear",
+			points: []Point{{0.5, nudge(0.5, 1)}, {12, 12}, {24, 24}},
+			out:    Polygon{[]Point{{0.5, nudge(0.5, 1)}, {12, 12}, {24, 24}}},
+		},
+		{
+			desc:   "Point with a NaN coordinate",
+			points: []Point{{math.NaN(), 0}, {2, 0}},
+			out:    Polygon{[]Point{{2, 0}}},
+		},
+		{
+			desc:   "Triangle with Points that are not finite",
+			points: []Point{{0, 1}, {math.Inf(1), 0}, {1, 0}, {0, math.NaN()}, {0, 0}, {math.Inf(-1), math.Inf(1)}},
+			out:    Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}},
+		},
+		{
+			desc:             "Triangle with collinear Points on the bottom edge only",
+			points:           []Point{{0, 0}, {1, 0}, {2, 0}, {1, 1}},
+			includeCollinear: true,
+			out:              Polygon{[]Point{{0, 0}, {1, 0}, {2, 0}, {1, 1}}},
+		},
+	}
+	for _, tC := range testCases {
+		for _, a := range hull_algorithms {
+			t.Run(tC.desc+"/"+a.name, func(t *testing.T) {
+				got := ConvexHull(tC.points, HullOptions{a.algorithm, tC.includeCollinear})
+				if !got.Equals(tC.out) {
+					t.Errorf("ConvexHull() = %v, want %v", got, tC.out)
+				}
+			})
+		}
+	}
+}
+
+func TestConvexHullProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for trial := 0; trial < 200; trial++ {
+		// Points on a small integer grid, so many are duplicated or collinear.
+		points := make([]Point, rng.Intn(50)+3)
+		for i := range points {
+			points[i] = Point{float64(rng.Intn(8)), float64(rng.Intn(8))}
+		}
+		if all_collinear(sorted_unique_points(points)) {
+			continue
+		}
+
+		for _, include_collinear := range []bool{false, true} {
+			reference := ConvexHull(points, HullOptions{MonotoneChain, include_collinear})
+			quick := ConvexHull(points, HullOptions{QuickHull, include_collinear})
+			if !quick.Equals(reference) {
+				t.Fatalf("QuickHull %v != MonotoneChain %v for %v", quick, reference, points)
+			}
+			if !reference.IsCounterClockwise() || !reference.IsSimple() {
+				t.Fatalf("ConvexHull() = %v is not a counter-clockwise simple Polygon", reference)
+			}
+
+			for _, p := range points {
+				location := reference.LocatePoint(p)
+				if location == Exterior {
+					t.Fatalf("ConvexHull() = %v does not contain %v", reference, p)
+				}
+				// With collinear Points kept, every Point on the boundary is a vertex.
+				if include_collinear && (location == Boundary) && !has_vertex(reference, p) {
+					t.Fatalf("ConvexHull() = %v is missing the collinear Point %v", reference, p)
+				}
+			}
+		}
+	}
+}
+
+// has_vertex tests if `p` is one of the vertices of the Polygon.
+func has_vertex(polygon Polygon, p Point) bool {
+	for _, q := range polygon.Points {
+		if q.Equals(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSortAlong(t *testing.T) {
+	x := 123.456
+	testCases := []struct {
+		desc   string
+		origin Point
+		points []Point
+		out    []Point
+	}{
+		{
+			desc:   "Along a diagonal",
+			origin: Point{0, 0},
+			points: []Point{{3, 3}, {1, 1}, {2, 2}},
+			out:    []Point{{1, 1}, {2, 2}, {3, 3}},
+		},
+		{
+			desc:   "Towards negative X",
+			origin: Point{0, 0},
+			points: []Point{{-1, 2}, {-3, 6}, {-2, 4}},
+			out:    []Point{{-1, 2}, {-2, 4}, {-3, 6}},
+		},
+		{
+			desc:   "Along a vertical line",
+			origin: Point{1, 5},
+			points: []Point{{1, 2}, {1, 4}, {1, 3}},
+			out:    []Point{{1, 4}, {1, 3}, {1, 2}},
+		},
+		{
+			// Both Points are the same rounded distance from the origin.
+			desc:   "One ulp apart",
+			origin: Point{0, 0},
+			points: []Point{{nudge(x, 1), nudge(x, 1)}, {x, x}},
+			out:    []Point{{x, x}, {nudge(x, 1), nudge(x, 1)}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			points := append([]Point(nil), tC.points...)
+			sort_along(tC.origin, points)
+			for i := range points {
+				if !points[i].Equals(tC.out[i]) {
+					t.Errorf("sort_along(%v, %v) = %v, want %v", tC.origin, tC.points, points, tC.out)
+					break
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConvexHull(b *testing.B) {
+	rng := rand.New(rand.NewSource(5))
+	in_square := make([]Point, 10000)
+	for i := range in_square {
+		in_square[i] = Point{rng.Float64(), rng.Float64()}
+	}
+	on_circle := regular_polygon(Point{0, 0}, 1, 10000).Points
+
+	benchmarks := []struct {
+		desc   string
+		points []Point
+	}{
+		{"10000 random Points in a square", in_square},
+		{"10000 Points on a circle", on_circle},
+	}
+
+	for _, bm := range benchmarks {
+		for _, a := range hull_algorithms {
+			b.Run(bm.desc+"/"+a.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					ConvexHull(bm.points, HullOptions{Algorithm: a.algorithm})
+				}
+
+			})
+		}
+	}
+}
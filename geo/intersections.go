@@ -0,0 +1,580 @@
+package gogeo
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// SegmentIntersection records that the i-th and j-th of a list of LineSegments meet.
+type SegmentIntersection struct {
+	I            int
+	J            int
+	Intersection LineSegmentIntersection
+}
+
+// Intersections finds every pair of the `segments` that intersect, including those that
+// only share an end, or overlap along a line, and where they meet. Each pair is reported
+// once, with I < J, in order of I and then J. A LineSegment that is a single Point is
+// reported wherever it lies on another.
+//
+// It uses the Bentley-Ottmann algorithm, sweeping a line across the plane from left to
+// right and only testing LineSegments that are next to each other along it, which takes
+// O((n+k) log n) time for n LineSegments and k intersections. Every decision of the
+// sweep is exact, including where crossings are, and every pair reported has been tested
+// with LineSegment.Intersects, so the results always agree with it.
+func Intersections(segments []LineSegment) []SegmentIntersection {
+	s := new_intersection_sweep(segments)
+	for len(s.events) > 0 {
+		s.handle(s.pop_events())
+	}
+
+	sort.Slice(s.found, func(i, j int) bool {
+		return (s.found[i].I < s.found[j].I) || ((s.found[i].I == s.found[j].I) && (s.found[i].J < s.found[j].J))
+	})
+	return s.found
+}
+
+// intersection_sweep holds the state of the sweep for Intersections. Each LineSegment is
+// stored running from its lower end by less_xy to its upper end, so the sweep reaches
+// its start first. A vertical LineSegment starts at its bottom, as the sweep line is
+// thought of as being very slightly tilted.
+type intersection_sweep struct {
+	original []LineSegment
+	segments []LineSegment
+	events   event_queue
+	status   *crossing_status
+	// group marks the LineSegments passing through the current event Point.
+	group      []int
+	group_mark int
+	found      []SegmentIntersection
+	reported   map[[2]int]bool
+	scheduled  map[[2]int]bool
+}
+
+func new_intersection_sweep(segments []LineSegment) *intersection_sweep {
+	s := &intersection_sweep{
+		original:  segments,
+		segments:  make([]LineSegment, len(segments)),
+		status:    new_crossing_status(len(segments)),
+		group:     make([]int, len(segments)),
+		reported:  make(map[[2]int]bool),
+		scheduled: make(map[[2]int]bool),
+	}
+	for i, segment := range segments {
+		if less_xy(segment.P2, segment.P1) {
+			segment = LineSegment{segment.P2, segment.P1}
+		}
+		s.segments[i] = segment
+		s.events = append(s.events, sweep_event{end_point(segment.P1), i, -1}, sweep_event{end_point(segment.P2), i, -1})
+		s.group[i] = -1
+	}
+	heap.Init(&s.events)
+	return s
+}
+
+// pop_events takes every event at the next Point from the queue.
+func (s *intersection_sweep) pop_events() []sweep_event {
+	events := []sweep_event{heap.Pop(&s.events).(sweep_event)}
+	for (len(s.events) > 0) && (s.events[0].p.compare(events[0].p) == 0) {
+		events = append(events, heap.Pop(&s.events).(sweep_event))
+	}
+	return events
+}
+
+// handle processes the events at a Point. Every LineSegment starting, ending or
+// crossing there is taken out of the status, and those that carry on are put back in
+// the order they leave the Point in. The pairs that end up next to each other are then
+// tested for crossings further on.
+func (s *intersection_sweep) handle(events []sweep_event) {
+	p := events[0].p
+	s.group_mark++
+	var group []int
+	add := func(e int) {
+		if s.group[e] != s.group_mark {
+			s.group[e] = s.group_mark
+			group = append(group, e)
+		}
+	}
+	for _, event := range events {
+		if event.b == -1 {
+			add(event.a)
+			continue
+		}
+		add(event.a)
+		add(event.b)
+	}
+	// The LineSegments passing exactly through `p` are next to each other in the status.
+	for t := s.status.lower_bound(s.below_point(p)); (t != -1) && (p.orientation(s.segments[t]) == 0); t = s.status.next(t) {
+		add(t)
+	}
+
+	for k, e := range group {
+		for _, f := range group[k+1:] {
+			s.report(e, f)
+		}
+	}
+
+	for _, e := range group {
+		if s.status.contains(e) {
+			s.status.remove(e)
+		}
+	}
+	inserted := -1
+	for _, e := range group {
+		if p.compare(end_point(s.segments[e].P2)) < 0 {
+			s.status.insert(e, s.below_segment(e, p))
+			inserted = e
+		}
+	}
+
+	if inserted == -1 {
+		above := s.status.lower_bound(s.below_point(p))
+		below := s.status.last()
+		if above != -1 {
+			below = s.status.previous(above)
+		}
+		s.check(below, above, p)
+		return
+	}
+	// The LineSegments inserted are next to each other, so the lowest and highest are
+	// found by stepping along from any of them.
+	lowest, highest := inserted, inserted
+	for (s.status.previous(lowest) != -1) && (s.group[s.status.previous(lowest)] == s.group_mark) {
+		lowest = s.status.previous(lowest)
+	}
+	for (s.status.next(highest) != -1) && (s.group[s.status.next(highest)] == s.group_mark) {
+		highest = s.status.next(highest)
+	}
+	s.check(s.status.previous(lowest), lowest, p)
+	s.check(highest, s.status.next(highest), p)
+}
+
+// below_point returns a test for whether a LineSegment in the status passes below `p`.
+func (s *intersection_sweep) below_point(p sweep_point) func(t int) bool {
+	return func(t int) bool {
+		return p.orientation(s.segments[t]) > 0
+	}
+}
+
+// below_segment returns a test for whether a LineSegment in the status is below the
+// LineSegment `e`, which is being inserted at `p`. Those that pass through `p` are
+// ordered by the direction they leave it in, with vertical LineSegments on top.
+func (s *intersection_sweep) below_segment(e int, p sweep_point) func(t int) bool {
+	return func(t int) bool {
+		if s.group[t] != s.group_mark {
+			if side := p.orientation(s.segments[t]); side != 0 {
+				return side > 0
+			}
+		}
+		return s.after(t, e)
+	}
+}
+
+// after tests if LineSegment `a` is below LineSegment `b` after the Point where they
+// meet. LineSegments along the same line are ordered by index.
+func (s *intersection_sweep) after(a, b int) bool {
+	sa, sb := s.segments[a], s.segments[b]
+	side := sign(Orient2D(sa.P1, sa.P2, sb.P2))
+	if side == 0 {
+		side = -sign(Orient2D(sb.P1, sb.P2, sa.P2))
+	}
+	if side == 0 {
+		return a < b
+	}
+	return side > 0
+}
+
+// check tests LineSegments `a` and `b`, which are next to each other in the status with
+// `a` below, and if they cross, adds an event where they swap places.
+func (s *intersection_sweep) check(a, b int, p sweep_point) {
+	if (a == -1) || (b == -1) || !s.report(a, b) {
+		return
+	}
+	key := [2]int{a, b}
+	if a > b {
+		key = [2]int{b, a}
+	}
+	if s.scheduled[key] || s.after(a, b) {
+		return
+	}
+	s.scheduled[key] = true
+	heap.Push(&s.events, sweep_event{crossing(s.segments[a], s.segments[b]), a, b})
+}
+
+// report records the intersection of LineSegments `e` and `f`, if they intersect, and
+// returns whether they do.
+func (s *intersection_sweep) report(e, f int) bool {
+	if e > f {
+		e, f = f, e
+	}
+	key := [2]int{e, f}
+	if s.reported[key] {
+		return true
+	}
+	if !s.segments[e].Intersects(s.segments[f]) {
+		return false
+	}
+	s.reported[key] = true
+	s.found = append(s.found, SegmentIntersection{e, f, s.original[e].Intersection(s.original[f])})
+	return true
+}
+
+// sweep_event is a LineSegment `a` starting or ending at `p`, or, if `b` is not -1, the
+// LineSegments `a` and `b` crossing there.
+type sweep_event struct {
+	p    sweep_point
+	a, b int
+}
+
+// event_queue is a heap of sweep_events, in order of their Points as by less_xy.
+type event_queue []sweep_event
+
+func (q event_queue) Len() int            { return len(q) }
+func (q event_queue) Less(i, j int) bool  { return q[i].p.compare(q[j].p) < 0 }
+func (q event_queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *event_queue) Push(x interface{}) { *q = append(*q, x.(sweep_event)) }
+func (q *event_queue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// sweep_point is the Point of a sweep_event. It is either the end of a LineSegment, which
+// is exact, or the Point where two LineSegments cross, which is rounded to `p` with an
+// error of at most `err` in each coordinate. Comparisons that the rounded Point cannot
+// decide are made exactly, from the ends of the LineSegments.
+type sweep_point struct {
+	p     Point
+	err   float64
+	exact *exact_crossing
+}
+
+// exact_crossing is the exact crossing of LineSegments `a` and `b`. With d1 and d2 the
+// orientations of the ends of `a` relative to `b`, the crossing is at
+// (d1 a.P2 - d2 a.P1) / (d1 - d2). The expansions are only calculated when first
+// needed, and are shared by every copy of a sweep_point.
+type exact_crossing struct {
+	a, b    LineSegment
+	d1, d2  []float64
+	x, y, w []float64
+}
+
+// end_point returns the sweep_point for the end of a LineSegment.
+func end_point(p Point) sweep_point {
+	return sweep_point{p: p}
+}
+
+// crossing returns the sweep_point where the LineSegments `a` and `b` cross. If they
+// meet at the end of either, that end is exact.
+func crossing(a, b LineSegment) sweep_point {
+	switch {
+	case Orient2D(b.P1, b.P2, a.P1) == 0:
+		return end_point(a.P1)
+	case Orient2D(b.P1, b.P2, a.P2) == 0:
+		return end_point(a.P2)
+	case Orient2D(a.P1, a.P2, b.P1) == 0:
+		return end_point(b.P1)
+	case Orient2D(a.P1, a.P2, b.P2) == 0:
+		return end_point(b.P2)
+	}
+
+	// A Point at distances da and db from the lines through `a` and `b`, which meet at
+	// angle θ, is within (da + db) √2 / sin θ of where they cross. The bound is widened
+	// to allow for the error of Orient2D, and sin θ is reduced to allow for its own
+	// rounding.
+	p := a.crossing_point(b)
+	la, lb := a.Length(), b.Length()
+	sin := math.Abs(a.P2.Minus(a.P1).CrossProduct(b.P2.Minus(b.P1)))/(la*lb) - 16*epsilon
+	err := math.Inf(1)
+	if sin > 0 {
+		err = 3 * (math.Abs(Orient2D(a.P1, a.P2, p))/la + math.Abs(Orient2D(b.P1, b.P2, p))/lb) / sin
+	}
+	if err == 0 {
+		return end_point(p)
+	}
+	return sweep_point{p, err, &exact_crossing{a: a, b: b}}
+}
+
+// compare compares sweep_points as by less_xy, returning -1, 0 or 1.
+func (s sweep_point) compare(t sweep_point) int {
+	margin := 1.01 * (s.err + t.err)
+	if (s.exact != nil) && (s.exact == t.exact) {
+		return 0
+	}
+	if d := s.p.X - t.p.X; (d > margin) || (-d > margin) || ((margin == 0) && (d != 0)) {
+		return sign(d)
+	}
+	if margin > 0 {
+		sx, _, sw := s.homogeneous()
+		tx, _, tw := t.homogeneous()
+		if c := compare_fractions(sx, sw, tx, tw); c != 0 {
+			return c
+		}
+	}
+	if d := s.p.Y - t.p.Y; (d > margin) || (-d > margin) || (margin == 0) {
+		return sign(d)
+	}
+	_, sy, sw := s.homogeneous()
+	_, ty, tw := t.homogeneous()
+	return compare_fractions(sy, sw, ty, tw)
+}
+
+// orientation returns the sign of Orient2D(l.P1, l.P2, s): 1 if `s` is to the left of
+// the line through `l`, -1 if it is to the right, and 0 if it is on it.
+func (s sweep_point) orientation(l LineSegment) int {
+	if s.exact == nil {
+		return sign(Orient2D(l.P1, l.P2, s.p))
+	}
+	c := s.exact
+	if (l == c.a) || (l == c.b) {
+		return 0
+	}
+	// The error of the rounded determinant, as in Orient2D, and the most that it can
+	// change by as `p` moves by `err`.
+	det_left := (l.P1.X - s.p.X) * (l.P2.Y - s.p.Y)
+	det_right := (l.P1.Y - s.p.Y) * (l.P2.X - s.p.X)
+	det := det_left - det_right
+	err_bound := ccw_err_bound_a*(math.Abs(det_left)+math.Abs(det_right)) +
+		1.01*(math.Abs(l.P2.X-l.P1.X)+math.Abs(l.P2.Y-l.P1.Y))*s.err
+	if (det > err_bound) || (-det > err_bound) {
+		return sign(det)
+	}
+
+	// The orientation of the crossing is the same combination of those of the ends of
+	// `a` as the crossing is of the ends themselves. As d1 and d2 have opposite signs,
+	// d1 - d2 has the sign of d1.
+	s.homogeneous()
+	o1 := orient2d_expansion(l.P1, l.P2, c.a.P1)
+	o2 := orient2d_expansion(l.P1, l.P2, c.a.P2)
+	numerator := expansion_sum(multiply_expansions(c.d1, o2), negate_expansion(multiply_expansions(c.d2, o1)))
+	return sign(most_significant(numerator)) * sign(most_significant(c.d1))
+}
+
+// homogeneous returns the exact coordinates of the sweep_point as x / w and y / w, with w
+// positive.
+func (s sweep_point) homogeneous() (x, y, w []float64) {
+	c := s.exact
+	if c == nil {
+		return []float64{s.p.X}, []float64{s.p.Y}, []float64{1}
+	}
+	if c.w == nil {
+		c.d1 = orient2d_expansion(c.b.P1, c.b.P2, c.a.P1)
+		c.d2 = orient2d_expansion(c.b.P1, c.b.P2, c.a.P2)
+		c.x = compress_expansion(expansion_sum(scale_expansion(c.d1, c.a.P2.X), negate_expansion(scale_expansion(c.d2, c.a.P1.X))))
+		c.y = compress_expansion(expansion_sum(scale_expansion(c.d1, c.a.P2.Y), negate_expansion(scale_expansion(c.d2, c.a.P1.Y))))
+		c.w = compress_expansion(expansion_sum(c.d1, negate_expansion(c.d2)))
+		if most_significant(c.w) < 0 {
+			c.x, c.y, c.w = negate_expansion(c.x), negate_expansion(c.y), negate_expansion(c.w)
+		}
+	}
+	return c.x, c.y, c.w
+}
+
+// compare_fractions compares a / b with c / d, where `b` and `d` are positive
+// expansions, returning -1, 0 or 1.
+func compare_fractions(a, b, c, d []float64) int {
+	difference := expansion_sum(multiply_expansions(a, d), negate_expansion(multiply_expansions(c, b)))
+	return sign(most_significant(difference))
+}
+
+// orient2d_expansion calculates the orientation determinant exactly as an expansion.
+func orient2d_expansion(a, b, c Point) []float64 {
+	ab := two_two_diff(a.X, b.Y, b.X, a.Y)
+	bc := two_two_diff(b.X, c.Y, c.X, b.Y)
+	ca := two_two_diff(c.X, a.Y, a.X, c.Y)
+	return compress_expansion(expansion_sum(expansion_sum(ab, bc), ca))
+}
+
+// multiply_expansions multiplies the expansions `e` and `f`.
+func multiply_expansions(e, f []float64) []float64 {
+	var h []float64
+	for _, f_i := range f {
+		h = expansion_sum(h, scale_expansion(e, f_i))
+	}
+	return compress_expansion(h)
+}
+
+// compress_expansion returns an expansion with the same value as `e`, but usually far
+// fewer components, to keep products of expansions small.
+func compress_expansion(e []float64) []float64 {
+	if len(e) == 0 {
+		return e
+	}
+	h := make([]float64, len(e))
+	bottom := len(e) - 1
+	q := e[bottom]
+	for i := len(e) - 2; i >= 0; i-- {
+		sum, small := two_sum(q, e[i])
+		if small != 0 {
+			h[bottom] = sum
+			bottom--
+			q = small
+		} else {
+			q = sum
+		}
+	}
+	top := 0
+	for _, h_i := range h[bottom+1:] {
+		sum, small := two_sum(h_i, q)
+		if small != 0 {
+			h[top] = small
+			top++
+		}
+		q = sum
+	}
+	h[top] = q
+	return h[:top+1]
+}
+
+// crossing_status holds the LineSegments crossed by the sweep line of Intersections, in
+// order from bottom to top, as a treap. LineSegments that cross each other cannot be
+// compared without knowing where the sweep line is, so unlike sweep_status, the treap
+// keeps parent links, and LineSegments are found and removed by their place in it.
+type crossing_status struct {
+	root   int
+	left   []int
+	right  []int
+	parent []int
+	in     []bool
+}
+
+func new_crossing_status(n int) *crossing_status {
+	return &crossing_status{-1, make([]int, n), make([]int, n), make([]int, n), make([]bool, n)}
+}
+
+// priority is the treap priority of LineSegment `e`, scrambled as by sweep_status.
+func (s *crossing_status) priority(e int) uint32 {
+	return uint32(e) * 2654435761
+}
+
+// contains tests if LineSegment `e` is in the status.
+func (s *crossing_status) contains(e int) bool {
+	return s.in[e]
+}
+
+// insert adds LineSegment `e` above every LineSegment for which `below` is true, and
+// below the rest.
+func (s *crossing_status) insert(e int, below func(t int) bool) {
+	parent, t, to_right := -1, s.root, false
+	for t != -1 {
+		parent, to_right = t, below(t)
+		if to_right {
+			t = s.right[t]
+		} else {
+			t = s.left[t]
+		}
+	}
+	s.left[e], s.right[e], s.parent[e], s.in[e] = -1, -1, parent, true
+	switch {
+	case parent == -1:
+		s.root = e
+	case to_right:
+		s.right[parent] = e
+	default:
+		s.left[parent] = e
+	}
+	for (s.parent[e] != -1) && (s.priority(e) > s.priority(s.parent[e])) {
+		s.rotate_up(e)
+	}
+}
+
+// remove takes LineSegment `e` out of the status, by rotating it down to a leaf.
+func (s *crossing_status) remove(e int) {
+	for (s.left[e] != -1) || (s.right[e] != -1) {
+		child := s.left[e]
+		if (child == -1) || ((s.right[e] != -1) && (s.priority(s.right[e]) > s.priority(child))) {
+			child = s.right[e]
+		}
+		s.rotate_up(child)
+	}
+	switch parent := s.parent[e]; {
+	case parent == -1:
+		s.root = -1
+	case s.left[parent] == e:
+		s.left[parent] = -1
+	default:
+		s.right[parent] = -1
+	}
+	s.in[e] = false
+}
+
+// rotate_up swaps LineSegment `e` with its parent in the treap, keeping their order.
+func (s *crossing_status) rotate_up(e int) {
+	p := s.parent[e]
+	g := s.parent[p]
+	if s.left[p] == e {
+		s.left[p] = s.right[e]
+		if s.right[e] != -1 {
+			s.parent[s.right[e]] = p
+		}
+		s.right[e] = p
+	} else {
+		s.right[p] = s.left[e]
+		if s.left[e] != -1 {
+			s.parent[s.left[e]] = p
+		}
+		s.left[e] = p
+	}
+	s.parent[p], s.parent[e] = e, g
+	switch {
+	case g == -1:
+		s.root = e
+	case s.left[g] == p:
+		s.left[g] = e
+	default:
+		s.right[g] = e
+	}
+}
+
+// lower_bound finds the lowest LineSegment for which `below` is false, or -1 if there is
+// none.
+func (s *crossing_status) lower_bound(below func(t int) bool) int {
+	found := -1
+	for t := s.root; t != -1; {
+		if below(t) {
+			t = s.right[t]
+		} else {
+			found, t = t, s.left[t]
+		}
+	}
+	return found
+}
+
+// last finds the highest LineSegment, or -1 if the status is empty.
+func (s *crossing_status) last() int {
+	t := s.root
+	for (t != -1) && (s.right[t] != -1) {
+		t = s.right[t]
+	}
+	return t
+}
+
+// next finds the LineSegment directly above `e`, or -1 if there is none.
+func (s *crossing_status) next(e int) int {
+	if t := s.right[e]; t != -1 {
+		for s.left[t] != -1 {
+			t = s.left[t]
+		}
+		return t
+	}
+	for (s.parent[e] != -1) && (s.right[s.parent[e]] == e) {
+		e = s.parent[e]
+	}
+	return s.parent[e]
+}
+
+// previous finds the LineSegment directly below `e`, or -1 if there is none.
+func (s *crossing_status) previous(e int) int {
+	if t := s.left[e]; t != -1 {
+		for s.right[t] != -1 {
+			t = s.right[t]
+		}
+		return t
+	}
+	for (s.parent[e] != -1) && (s.left[s.parent[e]] == e) {
+		e = s.parent[e]
+	}
+	return s.parent[e]
+}
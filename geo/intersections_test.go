@@ -0,0 +1,252 @@
+package gogeo
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// brute_force_intersections tests every pair of `segments`, for comparison with
+// Intersections.
+func brute_force_intersections(segments []LineSegment) []SegmentIntersection {
+	var intersections []SegmentIntersection
+	for i := range segments {
+		for j := i + 1; j < len(segments); j++ {
+			if segments[i].Intersects(segments[j]) {
+				intersections = append(intersections, SegmentIntersection{i, j, segments[i].Intersection(segments[j])})
+			}
+		}
+	}
+	return intersections
+}
+
+// check_intersections verifies that Intersections finds the same pairs as testing
+// every pair, in the same order.
+func check_intersections(t *testing.T, segments []LineSegment) {
+	t.Helper()
+	got := Intersections(segments)
+	want := brute_force_intersections(segments)
+	if len(got) != len(want) {
+		debug_intersections(t, segments, got, want)
+		t.Fatalf("Intersections(%v) found %v pairs, want %v", segments, len(got), len(want))
+	}
+	for i := range got {
+		if (got[i].I != want[i].I) || (got[i].J != want[i].J) || (got[i].Intersection != want[i].Intersection) {
+			debug_intersections(t, segments, got, want)
+			t.Fatalf("Intersections(%v)[%v] = %v, want %v", segments, i, got[i], want[i])
+		}
+	}
+}
+
+// debug_intersections draws the segments, labelled by index, with the intersections
+// that were found in blue and the ones that should have been in red.
+func debug_intersections(t *testing.T, segments []LineSegment, got, want []SegmentIntersection) {
+	t.Helper()
+	scene := NewSVG(SVGOptions{})
+	for i, s := range segments {
+		scene.Add(Style{Stroke: "gray"}, s)
+		scene.Label(s.P1, strconv.Itoa(i), Style{})
+	}
+	shape := func(x LineSegmentIntersection) Bounded {
+		if x.Kind == OverlapIntersection {
+			return x.Segment
+		}
+		return x.Point
+	}
+	for _, x := range want {
+		scene.Add(Style{Stroke: "red", StrokeWidth: 5, PointRadius: 5}, shape(x.Intersection))
+	}
+	for _, x := range got {
+		scene.Add(Style{Stroke: "blue"}, shape(x.Intersection))
+	}
+	debug_svg(t, scene)
+}
+
+func TestIntersections(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		segments []LineSegment
+		pairs    [][2]int
+	}{
+		{
+			desc:     "None",
+			segments: nil,
+			pairs:    nil,
+		},
+		{
+			desc:     "Crossing",
+			segments: []LineSegment{{Point{0, 0}, Point{2, 2}}, {Point{0, 2}, Point{2, 0}}},
+			pairs:    [][2]int{{0, 1}},
+		},
+		{
+			desc:     "Parallel",
+			segments: []LineSegment{{Point{0, 0}, Point{2, 0}}, {Point{0, 1}, Point{2, 1}}},
+			pairs:    nil,
+		},
+		{
+			desc:     "Vertical",
+			segments: []LineSegment{{Point{1, 0}, Point{1, 4}}, {Point{0, 1}, Point{2, 1}}, {Point{0, 3}, Point{2, 2}}},
+			pairs:    [][2]int{{0, 1}, {0, 2}},
+		},
+		{
+			desc: "Vertical, touching along it",
+			segments: []LineSegment{
+				{Point{1, 4}, Point{1, 0}}, {Point{1, 1}, Point{2, 1}}, {Point{0, 2}, Point{1, 2}}, {Point{1, 5}, Point{1, 6}},
+			},
+			pairs: [][2]int{{0, 1}, {0, 2}},
+		},
+		{
+			desc:     "Shared ends",
+			segments: []LineSegment{{Point{0, 0}, Point{1, 1}}, {Point{1, 1}, Point{2, 0}}, {Point{1, 1}, Point{1, 2}}},
+			pairs:    [][2]int{{0, 1}, {0, 2}, {1, 2}},
+		},
+		{
+			desc:     "Collinear overlap",
+			segments: []LineSegment{{Point{0, 0}, Point{2, 2}}, {Point{3, 3}, Point{1, 1}}, {Point{4, 4}, Point{5, 5}}},
+			pairs:    [][2]int{{0, 1}},
+		},
+		{
+			desc:     "Identical",
+			segments: []LineSegment{{Point{0, 0}, Point{1, 0}}, {Point{1, 0}, Point{0, 0}}},
+			pairs:    [][2]int{{0, 1}},
+		},
+		{
+			desc: "Many through one Point",
+			segments: []LineSegment{
+				{Point{-1, -1}, Point{1, 1}}, {Point{-1, 1}, Point{1, -1}}, {Point{0, -1}, Point{0, 1}}, {Point{-1, 0}, Point{1, 0}},
+			},
+			pairs: [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}},
+		},
+		{
+			desc:     "A single Point",
+			segments: []LineSegment{{Point{0, 0}, Point{2, 0}}, {Point{1, 0}, Point{1, 0}}, {Point{1, 1}, Point{1, 1}}},
+			pairs:    [][2]int{{0, 1}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := Intersections(tC.segments)
+			if len(got) != len(tC.pairs) {
+				t.Fatalf("Intersections() = %v, want pairs %v", got, tC.pairs)
+			}
+			for i, pair := range tC.pairs {
+				if (got[i].I != pair[0]) || (got[i].J != pair[1]) {
+					t.Errorf("Intersections()[%v] = %v, want pair %v", i, got[i], pair)
+				}
+			}
+			check_intersections(t, tC.segments)
+		})
+	}
+}
+
+func TestIntersectionsRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(23))
+	for trial := 0; trial < 200; trial++ {
+		var segments []LineSegment
+		n := 1 + rng.Intn(60)
+		switch trial % 3 {
+		case 0:
+			// Random LineSegments, which cross in general position.
+			for i := 0; i < n; i++ {
+				p := Point{rng.Float64(), rng.Float64()}
+				segments = append(segments, LineSegment{p, p.Plus(Point{rng.Float64() - 0.5, rng.Float64() - 0.5})})
+			}
+		case 1:
+			// LineSegments between the Points of a small grid, with many shared ends,
+			// vertical LineSegments, overlaps and crossings at the same Point.
+			grid := func() Point { return Point{float64(rng.Intn(5)), float64(rng.Intn(5))} }
+			for i := 0; i < n; i++ {
+				segments = append(segments, LineSegment{grid(), grid()})
+			}
+		default:
+			// Lines through a common Point that cannot be represented exactly, so that
+			// every crossing has to be placed exactly.
+			center := Point{1.0 / 3, 2.0 / 3}
+			for i := 0; i < n/3; i++ {
+				d := Point{1, 0}.Rotate(math.Pi * rng.Float64()).Times(0.1 + rng.Float64())
+				segments = append(segments, LineSegment{center.Minus(d), center.Plus(d.Times(rng.Float64()))})
+			}
+		}
+		check_intersections(t, segments)
+	}
+}
+
+func TestMultiplyExpansions(t *testing.T) {
+	rng := rand.New(rand.NewSource(38))
+	for i := 0; i < 2000; i++ {
+		e, f := random_expansion(rng), random_expansion(rng)
+		if i%10 == 0 {
+			// Sums that cancel out, entirely or in their largest components.
+			f = negate_expansion(e)
+			if i%20 == 0 {
+				f = expansion_sum(f, []float64{math.Ldexp(1, -70)})
+			}
+		}
+		sum := expansion_sum(e, f)
+		compressed := compress_expansion(sum)
+		check_expansion(t, "compress_expansion()", compressed)
+		if len(compressed) > len(sum) {
+			t.Fatalf("compress_expansion(%v) = %v has more components", sum, compressed)
+		}
+		if expansion_value(compressed).Cmp(expansion_value(sum)) != 0 {
+			t.Fatalf("compress_expansion(%v) = %v changes the value", sum, compressed)
+		}
+
+		product := multiply_expansions(e, f)
+		check_expansion(t, "multiply_expansions()", product)
+		if want := new(big.Rat).Mul(expansion_value(e), expansion_value(f)); expansion_value(product).Cmp(want) != 0 {
+			t.Fatalf("multiply_expansions(%v, %v) = %v, want %v", e, f, product, want)
+		}
+	}
+}
+
+func TestOrient2DExpansion(t *testing.T) {
+	rng := rand.New(rand.NewSource(22))
+	r := func(f float64) *big.Rat { return new(big.Rat).SetFloat64(f) }
+	for i := 0; i < 2000; i++ {
+		// Nearly collinear Points, so that the determinant has many components.
+		a := Point{rng.Float64(), rng.Float64()}
+		b := Point{rng.Float64(), rng.Float64()}
+		c := a.Plus(b.Minus(a).Times(rng.Float64() * 3))
+		c = Point{nudge(c.X, rng.Intn(5)-2), nudge(c.Y, rng.Intn(5)-2)}
+
+		acx := new(big.Rat).Sub(r(a.X), r(c.X))
+		bcy := new(big.Rat).Sub(r(b.Y), r(c.Y))
+		acy := new(big.Rat).Sub(r(a.Y), r(c.Y))
+		bcx := new(big.Rat).Sub(r(b.X), r(c.X))
+		want := new(big.Rat).Sub(new(big.Rat).Mul(acx, bcy), new(big.Rat).Mul(acy, bcx))
+
+		got := orient2d_expansion(a, b, c)
+		check_expansion(t, "orient2d_expansion()", got)
+		if expansion_value(got).Cmp(want) != 0 {
+			t.Fatalf("orient2d_expansion(%v, %v, %v) = %v, want %v", a, b, c, got, want)
+		}
+	}
+}
+
+func BenchmarkIntersections(b *testing.B) {
+	rng := rand.New(rand.NewSource(24))
+	var segments []LineSegment
+	for i := 0; i < 10000; i++ {
+		p := Point{rng.Float64(), rng.Float64()}
+		segments = append(segments, LineSegment{p, p.Plus(Point{0.02 * (rng.Float64() - 0.5), 0.02 * (rng.Float64() - 0.5)})})
+	}
+	benchmarks := []struct {
+		desc          string
+		intersections func([]LineSegment) []SegmentIntersection
+	}{
+		{"Sweep, 10000 LineSegments", Intersections},
+		{"Every pair, 10000 LineSegments", brute_force_intersections},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.intersections(segments)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package gogeo
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// KDTree is a static spatial index of Points, for finding the Points nearest to others.
+// It is a balanced binary tree, which splits the Points in half at the median of
+// whichever coordinate they are most spread out in, and then splits each half the same
+// way. Queries return indexes into the slice of Points the tree was built from.
+//
+// The tree is stored implicitly: the Points are reordered so that the Point splitting
+// each range of them is in the middle of it, with the lower half before it and the
+// upper half after.
+type KDTree struct {
+	points []Point
+	// index maps each Point of the tree back to its index in the original slice.
+	index []int
+	// axis holds the coordinate, 0 for x and 1 for y, that the Point at the same place
+	// splits its range on.
+	axis []uint8
+}
+
+// NewKDTree builds a KDTree over the `points` in O(n log n) time. The Points are copied,
+// so the slice may be changed afterwards, but the tree will not see the change.
+func NewKDTree(points []Point) *KDTree {
+	t := &KDTree{
+		points: append([]Point{}, points...),
+		index:  make([]int, len(points)),
+		axis:   make([]uint8, len(points)),
+	}
+	for i := range t.index {
+		t.index[i] = i
+	}
+	t.build(0, len(points))
+	return t
+}
+
+// Len returns the number of Points in the KDTree.
+func (t *KDTree) Len() int {
+	return len(t.points)
+}
+
+// coordinate returns the x or y coordinate of the i-th Point of the tree.
+func (t *KDTree) coordinate(i int, axis uint8) float64 {
+	return component(t.points[i], axis)
+}
+
+// component returns the x coordinate of `p` for axis 0, and the y coordinate for 1.
+func component(p Point, axis uint8) float64 {
+	if axis == 0 {
+		return p.X
+	}
+	return p.Y
+}
+
+// build arranges the Points from `lo` up to `hi` into a subtree.
+func (t *KDTree) build(lo, hi int) {
+	if hi-lo < 1 {
+		return
+	}
+	bounds := Polyline{t.points[lo:hi]}.BoundingBox()
+	axis := uint8(0)
+	if bounds.Max.Y-bounds.Min.Y > bounds.Max.X-bounds.Min.X {
+		axis = 1
+	}
+	mid := lo + (hi-lo)/2
+	t.select_median(lo, hi, mid, axis)
+	t.axis[mid] = axis
+	t.build(lo, mid)
+	t.build(mid+1, hi)
+}
+
+// select_median reorders the Points from `lo` up to `hi` so that the k-th is where it
+// would be if they were sorted by `axis`, with none greater before it and none less
+// after it. It uses quickselect with a median of three pivot.
+func (t *KDTree) select_median(lo, hi, k int, axis uint8) {
+	swap := func(i, j int) {
+		t.points[i], t.points[j] = t.points[j], t.points[i]
+		t.index[i], t.index[j] = t.index[j], t.index[i]
+	}
+	for hi-lo > 1 {
+		a, b, c := lo, lo+(hi-lo)/2, hi-1
+		if t.coordinate(a, axis) > t.coordinate(b, axis) {
+			a, b = b, a
+		}
+		if t.coordinate(b, axis) > t.coordinate(c, axis) {
+			b = c
+			if t.coordinate(a, axis) > t.coordinate(b, axis) {
+				b = a
+			}
+		}
+		pivot := t.coordinate(b, axis)
+
+		// Split into those less than the pivot, those equal to it, and those greater.
+		less, i, greater := lo, lo, hi
+		for i < greater {
+			switch x := t.coordinate(i, axis); {
+			case x < pivot:
+				swap(i, less)
+				less++
+				i++
+			case x > pivot:
+				greater--
+				swap(i, greater)
+			default:
+				i++
+			}
+		}
+		switch {
+		case k < less:
+			hi = less
+		case k >= greater:
+			lo = greater
+		default:
+			return
+		}
+	}
+}
+
+// Nearest returns the indexes of the `k` Points closest to `p`, nearest first, or of
+// every Point if there are fewer than `k`. Points at the same distance are in order of
+// their indexes.
+func (t *KDTree) Nearest(p Point, k int) []int {
+	return t.ApproximateNearest(p, k, 0)
+}
+
+// ApproximateNearest returns the indexes of `k` Points close to `p`, nearest first. The
+// i-th Point found is no more than 1 + `epsilon` times further away than the true i-th
+// nearest Point. A larger `epsilon` lets the search skip more of the tree, so it is
+// faster. With an `epsilon` of 0 it is the same as Nearest.
+func (t *KDTree) ApproximateNearest(p Point, k int, epsilon float64) []int {
+	if k <= 0 {
+		return []int{}
+	}
+	var found neighbor_heap
+	t.nearest(0, len(t.points), p, k, 1+epsilon, &found)
+	sort.Sort(sort.Reverse(found))
+	nearest := make([]int, len(found))
+	for i, n := range found {
+		nearest[i] = n.index
+	}
+	return nearest
+}
+
+// nearest adds the Points from `lo` up to `hi` to `found` if they are among the `k`
+// nearest to `p` so far. A half of the range is skipped if even `factor` times its
+// distance from `p` is further than the k-th nearest.
+func (t *KDTree) nearest(lo, hi int, p Point, k int, factor float64, found *neighbor_heap) {
+	if hi-lo < 1 {
+		return
+	}
+	mid := lo + (hi-lo)/2
+	candidate := neighbor{p.Minus(t.points[mid]).Magnitude(), t.index[mid]}
+	if len(*found) < k {
+		heap.Push(found, candidate)
+	} else if candidate.less((*found)[0]) {
+		(*found)[0] = candidate
+		heap.Fix(found, 0)
+	}
+
+	difference := t.coordinate(mid, t.axis[mid]) - component(p, t.axis[mid])
+	near_lo, near_hi, far_lo, far_hi := lo, mid, mid+1, hi
+	if difference <= 0 {
+		near_lo, near_hi, far_lo, far_hi = mid+1, hi, lo, mid
+	}
+	t.nearest(near_lo, near_hi, p, k, factor, found)
+	if (len(*found) < k) || (math.Abs(difference)*factor <= (*found)[0].distance) {
+		t.nearest(far_lo, far_hi, p, k, factor, found)
+	}
+}
+
+// Radius returns the indexes of every Point within `radius` of `p`, in no particular
+// order.
+func (t *KDTree) Radius(p Point, radius float64) []int {
+	found := []int{}
+	t.radius(0, len(t.points), p, radius, &found)
+	return found
+}
+
+// radius adds the indexes of the Points from `lo` up to `hi` within `r` of `p` to
+// `found`.
+func (t *KDTree) radius(lo, hi int, p Point, r float64, found *[]int) {
+	if hi-lo < 1 {
+		return
+	}
+	mid := lo + (hi-lo)/2
+	if p.Minus(t.points[mid]).Magnitude() <= r {
+		*found = append(*found, t.index[mid])
+	}
+	target, split := component(p, t.axis[mid]), t.coordinate(mid, t.axis[mid])
+	if target-r <= split {
+		t.radius(lo, mid, p, r, found)
+	}
+	if target+r >= split {
+		t.radius(mid+1, hi, p, r, found)
+	}
+}
+
+// neighbor is a Point found by a nearest neighbor search, with its distance.
+type neighbor struct {
+	distance float64
+	index    int
+}
+
+// less orders neighbors by distance, and then by index.
+func (n neighbor) less(m neighbor) bool {
+	return (n.distance < m.distance) || ((n.distance == m.distance) && (n.index < m.index))
+}
+
+// neighbor_heap is a heap of neighbors with the furthest first, so that it can be
+// replaced when a nearer one is found.
+type neighbor_heap []neighbor
+
+func (h neighbor_heap) Len() int            { return len(h) }
+func (h neighbor_heap) Less(i, j int) bool  { return h[j].less(h[i]) }
+func (h neighbor_heap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighbor_heap) Push(x interface{}) { *h = append(*h, x.(neighbor)) }
+func (h *neighbor_heap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
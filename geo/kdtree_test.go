@@ -0,0 +1,201 @@
+package gogeo
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// brute_force_nearest sorts the indexes of every Point by their distance from `p`, and
+// then by index, as KDTree.Nearest does.
+func brute_force_nearest(points []Point, p Point) []int {
+	indexes := make([]int, len(points))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		a := neighbor{points[indexes[i]].Minus(p).Magnitude(), indexes[i]}
+		b := neighbor{points[indexes[j]].Minus(p).Magnitude(), indexes[j]}
+		return a.less(b)
+	})
+	return indexes
+}
+
+// sorted_ints sorts `a` in place and returns it.
+func sorted_ints(a []int) []int {
+	sort.Ints(a)
+	return a
+}
+
+// check_kdtree compares queries of a KDTree over `points` with testing every Point.
+func check_kdtree(t *testing.T, rng *rand.Rand, points []Point) {
+	t.Helper()
+	tree := NewKDTree(points)
+	if tree.Len() != len(points) {
+		t.Fatalf("Len() = %v, want %v", tree.Len(), len(points))
+	}
+	for query := 0; query < 20; query++ {
+		p := Point{2*rng.Float64() - 0.5, 2*rng.Float64() - 0.5}
+		if (query%2 == 0) && (len(points) > 0) {
+			p = points[rng.Intn(len(points))]
+		}
+		all := brute_force_nearest(points, p)
+
+		k := 1 + rng.Intn(20)
+		want := all
+		if k < len(all) {
+			want = all[:k]
+		}
+		if got := tree.Nearest(p, k); !equal_ints(got, want) {
+			t.Fatalf("Nearest(%v, %v) = %v, want %v", p, k, got, want)
+		}
+
+		epsilon := rng.Float64()
+		approximate := tree.ApproximateNearest(p, k, epsilon)
+		if len(approximate) != len(want) {
+			t.Fatalf("ApproximateNearest(%v, %v, %v) found %v Points, want %v", p, k, epsilon, len(approximate), len(want))
+		}
+		for i, j := range approximate {
+			got, exact := points[j].Minus(p).Magnitude(), points[want[i]].Minus(p).Magnitude()
+			if got > (1+epsilon)*exact {
+				t.Fatalf("ApproximateNearest(%v, %v, %v)[%v] is %v away, want at most %v", p, k, epsilon, i, got, (1+epsilon)*exact)
+			}
+		}
+
+		radius := 0.3 * rng.Float64()
+		var within []int
+		for i, q := range points {
+			if q.Minus(p).Magnitude() <= radius {
+				within = append(within, i)
+			}
+		}
+		if got := sorted_ints(tree.Radius(p, radius)); !equal_ints(got, within) {
+			t.Fatalf("Radius(%v, %v) = %v, want %v", p, radius, got, within)
+		}
+	}
+}
+
+func TestKDTree(t *testing.T) {
+	points := []Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0.5, 0.5}, {3, 3}, {0.5, 0.5}}
+	tree := NewKDTree(points)
+	testCases := []struct {
+		desc string
+		got  []int
+		want []int
+	}{
+		{
+			desc: "Nearest",
+			got:  tree.Nearest(Point{0.9, 0.2}, 1),
+			want: []int{1},
+		},
+		{
+			desc: "Nearest, ties in order of index",
+			got:  tree.Nearest(Point{0.5, 0.5}, 4),
+			want: []int{4, 6, 0, 1},
+		},
+		{
+			desc: "Nearest, more than there are",
+			got:  tree.Nearest(Point{3, 2}, 10),
+			want: []int{5, 3, 1, 4, 6, 2, 0},
+		},
+		{
+			desc: "Nearest, none",
+			got:  tree.Nearest(Point{3, 2}, 0),
+			want: []int{},
+		},
+		{
+			desc: "Radius",
+			got:  sorted_ints(tree.Radius(Point{0, 0}, 1)),
+			want: []int{0, 1, 2, 4, 6},
+		},
+		{
+			desc: "Radius, empty",
+			got:  sorted_ints(tree.Radius(Point{2, 2}, 1)),
+			want: []int{},
+		},
+		{
+			desc: "Empty KDTree",
+			got:  NewKDTree(nil).Nearest(Point{0, 0}, 3),
+			want: []int{},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if !equal_ints(tC.got, tC.want) {
+				t.Errorf("got %v, want %v", tC.got, tC.want)
+			}
+		})
+	}
+}
+
+func TestKDTreeRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+	for trial := 0; trial < 30; trial++ {
+		n := rng.Intn(500)
+		switch trial % 3 {
+		case 0:
+			check_kdtree(t, rng, random_points(rng, n))
+		case 1:
+			// A grid, with many Points at the same distance and on the splitting lines.
+			points := grid_points(1 + rng.Intn(10))
+			for i := range points {
+				points[i] = points[i].Divide(5)
+			}
+			check_kdtree(t, rng, points)
+		default:
+			// Copies of a few Points.
+			few := random_points(rng, 1+rng.Intn(5))
+			var points []Point
+			for i := 0; i < n; i++ {
+				points = append(points, few[rng.Intn(len(few))])
+			}
+			check_kdtree(t, rng, points)
+		}
+	}
+}
+
+func BenchmarkKDTree(b *testing.B) {
+	rng := rand.New(rand.NewSource(30))
+	points := random_points(rng, 100000)
+	tree := NewKDTree(points)
+	p := Point{0.5, 0.5}
+	benchmarks := []struct {
+		desc string
+		run  func()
+	}{
+		{"NewKDTree, 100000 Points", func() { NewKDTree(points) }},
+		{"Nearest 10", func() { tree.Nearest(p, 10) }},
+		{"Nearest 10, every Point", func() {
+			var found neighbor_heap
+			for i, q := range points {
+				candidate := neighbor{q.Minus(p).Magnitude(), i}
+				if len(found) < 10 {
+					found = append(found, candidate)
+					sort.Sort(found)
+				} else if candidate.less(found[0]) {
+					found[0] = candidate
+					sort.Sort(found)
+				}
+			}
+		}},
+		{"ApproximateNearest 10, epsilon 0.5", func() { tree.ApproximateNearest(p, 10, 0.5) }},
+		{"Radius 0.01", func() { tree.Radius(p, 0.01) }},
+		{"Radius 0.01, every Point", func() {
+			var found []int
+			for i, q := range points {
+				if q.Minus(p).Magnitude() <= 0.01 {
+					found = append(found, i)
+				}
+			}
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.run()
+			}
+
+		})
+	}
+}
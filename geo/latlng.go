@@ -0,0 +1,152 @@
+package gogeo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidLatLng is returned for a latitude or longitude that is not finite, or is
+// out of range.
+var ErrInvalidLatLng = errors.New("invalid latitude or longitude")
+
+// EarthRadius is the mean radius of the Earth in meters, which is used for distances on
+// a sphere.
+const EarthRadius = 6371008.8
+
+// LatLng is a position on the surface of the Earth, as a latitude and a longitude in
+// degrees. Latitude is positive to the north of the equator, and longitude is positive
+// to the east of the prime meridian.
+//
+// Bearings are in degrees clockwise from north, and distances are in meters. The
+// methods of LatLng treat the Earth as a sphere with a radius of EarthRadius, which is
+// accurate to about 0.5%. For distances accurate to a millimeter, use the methods of
+// Ellipsoid with WGS84.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// NewLatLng makes a LatLng, returning ErrInvalidLatLng if either angle is not finite,
+// or the latitude is not between -90 and 90. The longitude is wrapped to lie from -180
+// up to, but not including, 180.
+func NewLatLng(lat, lng float64) (LatLng, error) {
+	l := LatLng{lat, lng}
+	if math.IsNaN(lng) || math.IsInf(lng, 0) {
+		return LatLng{}, fmt.Errorf("longitude %v: %w", lng, ErrInvalidLatLng)
+	}
+	l.Lng = wrap_degrees(lng)
+	if err := l.Validate(); err != nil {
+		return LatLng{}, err
+	}
+	return l, nil
+}
+
+// Validate returns ErrInvalidLatLng if either angle is not finite, or the latitude is
+// not between -90 and 90, or the longitude is not between -180 and 180.
+func (l LatLng) Validate() error {
+	if !(math.Abs(l.Lat) <= 90) {
+		return fmt.Errorf("latitude %v: %w", l.Lat, ErrInvalidLatLng)
+	}
+	if !(math.Abs(l.Lng) <= 180) {
+		return fmt.Errorf("longitude %v: %w", l.Lng, ErrInvalidLatLng)
+	}
+	return nil
+}
+
+// Normalize returns the same position with the latitude from -90 to 90, and the
+// longitude from -180 up to, but not including, 180. A latitude past a pole continues
+// down the other side of the Earth, so {95, 10} becomes {85, -170}.
+func (l LatLng) Normalize() LatLng {
+	lat, lng := wrap_degrees(l.Lat), l.Lng
+	if lat > 90 {
+		lat, lng = 180-lat, lng+180
+	} else if lat < -90 {
+		lat, lng = -180-lat, lng+180
+	}
+	return LatLng{lat, wrap_degrees(lng)}
+}
+
+// String formats the LatLng as its latitude and longitude, separated by a comma.
+func (l LatLng) String() string {
+	return fmt.Sprintf("%v,%v", l.Lat, l.Lng)
+}
+
+// HaversineDistance calculates the great-circle distance to `m` in meters, using the
+// haversine formula, which stays accurate for both short and antipodal distances.
+func (l LatLng) HaversineDistance(m LatLng) float64 {
+	lat1, lat2 := radians(l.Lat), radians(m.Lat)
+	sin_lat, sin_lng := math.Sin((lat2-lat1)/2), math.Sin(radians(m.Lng-l.Lng)/2)
+	h := sin_lat*sin_lat + math.Cos(lat1)*math.Cos(lat2)*sin_lng*sin_lng
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// InitialBearing calculates the bearing at `l` of the great circle to `m`, from 0 up to,
+// but not including, 360. The bearing is 0 if the LatLngs are the same.
+func (l LatLng) InitialBearing(m LatLng) float64 {
+	lat1, lat2, dlng := radians(l.Lat), radians(m.Lat), radians(m.Lng-l.Lng)
+	y := math.Sin(dlng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dlng)
+	return bearing_degrees(math.Atan2(y, x))
+}
+
+// FinalBearing calculates the bearing of the great circle from `l` as it arrives at `m`,
+// from 0 up to, but not including, 360. It changes along the way, unless the great
+// circle is the equator or a meridian. The bearing is 0 if the LatLngs are the same.
+func (l LatLng) FinalBearing(m LatLng) float64 {
+	if l == m {
+		return 0
+	}
+	return wrap_bearing(m.InitialBearing(l) + 180)
+}
+
+// Destination calculates where the great circle from `l` with the initial `bearing`
+// ends, after `distance` meters.
+func (l LatLng) Destination(bearing, distance float64) LatLng {
+	lat1, theta, delta := radians(l.Lat), radians(bearing), distance/EarthRadius
+	sin_lat2 := math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta)
+	lat2 := math.Asin(math.Max(-1, math.Min(1, sin_lat2)))
+	dlng := math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*sin_lat2)
+	return LatLng{degrees(lat2), wrap_degrees(l.Lng + degrees(dlng))}
+}
+
+// radians converts an angle in degrees to radians.
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// degrees converts an angle in radians to degrees.
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// wrap_degrees returns the angle equal to `x` from -180 up to, but not including, 180.
+func wrap_degrees(x float64) float64 {
+	if (x >= -180) && (x < 180) {
+		return x
+	}
+	x = math.Mod(x+180, 360)
+	if x < 0 {
+		x += 360
+	}
+	return x - 180
+}
+
+// wrap_bearing returns the angle equal to `x` from 0 up to, but not including, 360.
+func wrap_bearing(x float64) float64 {
+	x = math.Mod(x, 360)
+	if x < 0 {
+		x += 360
+	}
+	// Adding 0 turns -0 into 0, and a tiny negative angle can round up to 360.
+	if x+0 == 360 {
+		return 0
+	}
+	return x + 0
+}
+
+// bearing_degrees converts an angle from north in radians, as returned by math.Atan2,
+// to a bearing in degrees.
+func bearing_degrees(radians float64) float64 {
+	return wrap_bearing(degrees(radians))
+}
@@ -0,0 +1,148 @@
+package gogeo
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// angle_difference returns how far apart two angles in degrees are, the short way
+// around.
+func angle_difference(a, b float64) float64 {
+	return math.Abs(wrap_degrees(a - b))
+}
+
+func TestNewLatLng(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		lat   float64
+		lng   float64
+		want  LatLng
+		error bool
+	}{
+		{desc: "In range", lat: -33.9, lng: 151.2, want: LatLng{-33.9, 151.2}},
+		{desc: "Poles", lat: 90, lng: -180, want: LatLng{90, -180}},
+		{desc: "Longitude wrapped", lat: 10, lng: 190, want: LatLng{10, -170}},
+		{desc: "Longitude of 180", lat: 10, lng: 180, want: LatLng{10, -180}},
+		{desc: "Longitude many times around", lat: 10, lng: -725, want: LatLng{10, -5}},
+		{desc: "Latitude past a pole", lat: 90.5, lng: 0, error: true},
+		{desc: "NaN", lat: math.NaN(), lng: 0, error: true},
+		{desc: "Infinite longitude", lat: 0, lng: math.Inf(-1), error: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := NewLatLng(tC.lat, tC.lng)
+			if tC.error {
+				if !errors.Is(err, ErrInvalidLatLng) {
+					t.Errorf("NewLatLng(%v, %v) = %v, %v, want %v", tC.lat, tC.lng, got, err, ErrInvalidLatLng)
+				}
+				return
+			}
+			if (err != nil) || (got != tC.want) {
+				t.Errorf("NewLatLng(%v, %v) = %v, %v, want %v", tC.lat, tC.lng, got, err, tC.want)
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("%v.Validate() = %v, want nil", got, err)
+			}
+		})
+	}
+
+	if err := (LatLng{0, 180.5}).Validate(); !errors.Is(err, ErrInvalidLatLng) {
+		t.Errorf("Validate() of a longitude of 180.5 = %v, want %v", err, ErrInvalidLatLng)
+	}
+}
+
+func TestLatLngNormalize(t *testing.T) {
+	testCases := []struct {
+		desc string
+		in   LatLng
+		want LatLng
+	}{
+		{"Already normal", LatLng{45, -120}, LatLng{45, -120}},
+		{"Over the north pole", LatLng{95, 10}, LatLng{85, -170}},
+		{"Over the south pole", LatLng{-100, -30}, LatLng{-80, 150}},
+		{"To the south pole from the north", LatLng{270, 0}, LatLng{-90, 0}},
+		{"Once around", LatLng{360 + 30, 360 + 40}, LatLng{30, 40}},
+		{"Longitude of 180", LatLng{0, 180}, LatLng{0, -180}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.in.Normalize(); got != tC.want {
+				t.Errorf("%v.Normalize() = %v, want %v", tC.in, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestHaversineDistance(t *testing.T) {
+	testCases := []struct {
+		desc string
+		a    LatLng
+		b    LatLng
+		want float64
+	}{
+		{"Same place", LatLng{51.5, -0.1}, LatLng{51.5, -0.1}, 0},
+		{"One degree along the equator", LatLng{0, 0}, LatLng{0, 1}, EarthRadius * math.Pi / 180},
+		{"Across the antimeridian", LatLng{0, 179.5}, LatLng{0, -179.5}, EarthRadius * math.Pi / 180},
+		{"Equator to pole", LatLng{0, 30}, LatLng{90, 0}, EarthRadius * math.Pi / 2},
+		{"Antipodes", LatLng{30, 40}, LatLng{-30, -140}, EarthRadius * math.Pi},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.a.HaversineDistance(tC.b); math.Abs(got-tC.want) > 1e-6 {
+				t.Errorf("%v.HaversineDistance(%v) = %v, want %v", tC.a, tC.b, got, tC.want)
+			}
+			if got := tC.b.HaversineDistance(tC.a); math.Abs(got-tC.want) > 1e-6 {
+				t.Errorf("%v.HaversineDistance(%v) = %v, want %v", tC.b, tC.a, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestBearings(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		a       LatLng
+		b       LatLng
+		initial float64
+		final   float64
+	}{
+		{"East along the equator", LatLng{0, 0}, LatLng{0, 10}, 90, 90},
+		{"North along a meridian", LatLng{-10, 5}, LatLng{10, 5}, 0, 0},
+		{"South across the antimeridian", LatLng{10, 180}, LatLng{-10, -180}, 180, 180},
+		{"Same place", LatLng{10, 10}, LatLng{10, 10}, 0, 0},
+		{"Baghdad to Osaka", LatLng{35, 45}, LatLng{35, 135}, 60.16, 119.84},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.a.InitialBearing(tC.b); angle_difference(got, tC.initial) > 0.01 {
+				t.Errorf("%v.InitialBearing(%v) = %v, want %v", tC.a, tC.b, got, tC.initial)
+			}
+			if got := tC.a.FinalBearing(tC.b); angle_difference(got, tC.final) > 0.01 {
+				t.Errorf("%v.FinalBearing(%v) = %v, want %v", tC.a, tC.b, got, tC.final)
+			}
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	rng := rand.New(rand.NewSource(34))
+	for trial := 0; trial < 1000; trial++ {
+		from := LatLng{180*rng.Float64() - 90, 360*rng.Float64() - 180}
+		bearing, distance := 360*rng.Float64(), math.Pi*EarthRadius*rng.Float64()
+		to := from.Destination(bearing, distance)
+		if err := to.Validate(); err != nil {
+			t.Fatalf("%v.Destination(%v, %v) = %v, which is invalid: %v", from, bearing, distance, to, err)
+		}
+		if got := from.HaversineDistance(to); math.Abs(got-distance) > 1e-3 {
+			t.Errorf("%v.Destination(%v, %v) = %v, which is %v away", from, bearing, distance, to, got)
+		}
+		// Bearings are not well defined at the poles, or to the antipode.
+		if (math.Abs(from.Lat) < 89) && (distance < 0.99*math.Pi*EarthRadius) {
+			if got := from.InitialBearing(to); angle_difference(got, bearing) > 1e-6 {
+				t.Errorf("%v.Destination(%v, %v) = %v, which is at a bearing of %v", from, bearing, distance, to, got)
+			}
+		}
+	}
+}
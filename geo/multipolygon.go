@@ -0,0 +1,291 @@
+package gogeo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when validating a PolygonWithHoles or a MultiPolygon.
+var (
+	// ErrNotSimple is returned when a ring is not a simple Polygon.
+	ErrNotSimple = errors.New("ring is not simple")
+	// ErrWrongOrientation is returned when a shell is not counter-clockwise, or a hole
+	// is not clockwise.
+	ErrWrongOrientation = errors.New("ring has the wrong orientation")
+	// ErrHoleOutsideShell is returned when a hole is not inside its shell.
+	ErrHoleOutsideShell = errors.New("hole is not inside the shell")
+	// ErrOverlappingRings is returned when two holes, or two parts of a MultiPolygon,
+	// overlap.
+	ErrOverlappingRings = errors.New("rings overlap")
+)
+
+// PolygonWithHoles is a Polygon, the Shell, with zero or more Holes cut out of it. By
+// convention the Shell is counter-clockwise and the Holes are clockwise. Each Hole must
+// lie inside the Shell, and the Holes must not overlap each other, though rings may
+// touch at single Points.
+type PolygonWithHoles struct {
+	Shell Polygon
+	Holes []Polygon
+}
+
+// Rings returns the Shell followed by the Holes.
+func (p PolygonWithHoles) Rings() []Polygon {
+	return append([]Polygon{p.Shell}, p.Holes...)
+}
+
+// Edges returns the edges of every ring, starting with the Shell.
+func (p PolygonWithHoles) Edges() []LineSegment {
+	var edges []LineSegment
+	for _, ring := range p.Rings() {
+		edges = append(edges, ring.Edges()...)
+	}
+	return edges
+}
+
+// Oriented returns the PolygonWithHoles with the Shell counter-clockwise and the Holes
+// clockwise.
+func (p PolygonWithHoles) Oriented() PolygonWithHoles {
+	holes := make([]Polygon, len(p.Holes))
+	for i, hole := range p.Holes {
+		holes[i] = hole.Clockwise()
+	}
+	return PolygonWithHoles{p.Shell.CounterClockwise(), holes}
+}
+
+// Validate checks that every ring is simple and correctly oriented, that every Hole is
+// inside the Shell, and that no two Holes overlap. It returns nil if the
+// PolygonWithHoles is valid.
+func (p PolygonWithHoles) Validate() error {
+	if !p.Shell.IsSimple() {
+		return fmt.Errorf("shell: %w", ErrNotSimple)
+	}
+	if !p.Shell.IsCounterClockwise() {
+		return fmt.Errorf("shell: %w", ErrWrongOrientation)
+	}
+	for i, hole := range p.Holes {
+		if !hole.IsSimple() {
+			return fmt.Errorf("hole %d: %w", i, ErrNotSimple)
+		}
+		if !hole.IsClockwise() {
+			return fmt.Errorf("hole %d: %w", i, ErrWrongOrientation)
+		}
+		if !ring_within(hole, p.Shell) {
+			return fmt.Errorf("hole %d: %w", i, ErrHoleOutsideShell)
+		}
+		for j := 0; j < i; j++ {
+			if !rings_disjoint(hole, p.Holes[j]) {
+				return fmt.Errorf("holes %d and %d: %w", j, i, ErrOverlappingRings)
+			}
+		}
+	}
+	return nil
+}
+
+// Area is the area of the Shell, less the area of the Holes.
+func (p PolygonWithHoles) Area() float64 {
+	area := p.Shell.Area()
+	for _, hole := range p.Holes {
+		area -= hole.Area()
+	}
+	return area
+}
+
+// LocatePoint classifies a Point `q` as being in the Interior, on the Boundary, or in
+// the Exterior of the PolygonWithHoles. Points inside a Hole are in the Exterior, and
+// Points on the edge of a Hole are on the Boundary.
+func (p PolygonWithHoles) LocatePoint(q Point) Location {
+	location := p.Shell.LocatePoint(q)
+	if location != Interior {
+		return location
+	}
+	for _, hole := range p.Holes {
+		switch hole.LocatePoint(q) {
+		case Interior:
+			return Exterior
+		case Boundary:
+			return Boundary
+		}
+	}
+	return Interior
+}
+
+// ContainsPoint tests if a Point `q` lies inside the PolygonWithHoles or on its
+// boundary.
+func (p PolygonWithHoles) ContainsPoint(q Point) bool {
+	return p.LocatePoint(q) != Exterior
+}
+
+// Intersects will determine if two PolygonWithHoles intersect. They are said to
+// intersect if any point of either, including its boundary, is shared. This is done by
+// checking if any edge of any ring of `p` intersects any edge of any ring of `q`. If
+// none do, then either one lies entirely inside the other, or they are disjoint, which
+// is decided by locating a single vertex of each.
+func (p PolygonWithHoles) Intersects(q PolygonWithHoles) bool {
+	q_edges := q.Edges()
+	for _, p_edge := range p.Edges() {
+		for _, q_edge := range q_edges {
+			if p_edge.Intersects(q_edge) {
+				return true
+			}
+		}
+	}
+
+	if (len(p.Shell.Points) == 0) || (len(q.Shell.Points) == 0) {
+		return false
+	}
+	return p.ContainsPoint(q.Shell.Points[0]) || q.ContainsPoint(p.Shell.Points[0])
+}
+
+// Intersects will determine if two Polygons intersect. They are said to intersect if
+// any point of either, including its boundary, is shared.
+func (p Polygon) Intersects(q Polygon) bool {
+	return PolygonWithHoles{Shell: p}.Intersects(PolygonWithHoles{Shell: q})
+}
+
+// ring_within tests if the ring `inner` lies inside the ring `outer`. The rings may
+// touch at single Points, but no edge of `inner` may cross or run along an edge of
+// `outer`, and no part of `inner` may be outside `outer`.
+func ring_within(inner, outer Polygon) bool {
+	if !rings_only_touch(inner, outer) {
+		return false
+	}
+	for it := inner.EdgeIterator(); it.Next(); {
+		edge := it.Edge()
+		if !outer.ContainsPoint(edge.P1) || !outer.ContainsPoint(edge.P1.Plus(edge.P2).Divide(2)) {
+			return false
+		}
+	}
+	return true
+}
+
+// rings_disjoint tests if the interiors of two rings are disjoint. The rings may touch
+// at single Points, but no edge of one may cross or run along an edge of the other, and
+// no part of either may be inside the other.
+func rings_disjoint(a, b Polygon) bool {
+	if !rings_only_touch(a, b) {
+		return false
+	}
+	for _, pair := range [2][2]Polygon{{a, b}, {b, a}} {
+		for it := pair[0].EdgeIterator(); it.Next(); {
+			edge := it.Edge()
+			if (pair[1].LocatePoint(edge.P1) == Interior) ||
+				(pair[1].LocatePoint(edge.P1.Plus(edge.P2).Divide(2)) == Interior) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// rings_only_touch tests that the edges of two rings meet, if at all, only where a
+// vertex of one lies on the other. Edges that cross, or that overlap along a
+// LineSegment, fail the test.
+func rings_only_touch(a, b Polygon) bool {
+	b_edges := b.Edges()
+	for it := a.EdgeIterator(); it.Next(); {
+		a_edge := it.Edge()
+		for _, b_edge := range b_edges {
+			intersection := a_edge.Intersection(b_edge)
+			switch intersection.Kind {
+			case OverlapIntersection:
+				return false
+			case PointIntersection:
+				q := intersection.Point
+				if !q.Equals(a_edge.P1) && !q.Equals(a_edge.P2) &&
+					!q.Equals(b_edge.P1) && !q.Equals(b_edge.P2) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// MultiPolygon is a collection of PolygonWithHoles, whose interiors do not overlap.
+type MultiPolygon struct {
+	Polygons []PolygonWithHoles
+}
+
+// Oriented returns the MultiPolygon with every Shell counter-clockwise and every Hole
+// clockwise.
+func (m MultiPolygon) Oriented() MultiPolygon {
+	polygons := make([]PolygonWithHoles, len(m.Polygons))
+	for i, polygon := range m.Polygons {
+		polygons[i] = polygon.Oriented()
+	}
+	return MultiPolygon{polygons}
+}
+
+// Validate checks that every part of the MultiPolygon is valid, and that no two parts
+// overlap. It returns nil if the MultiPolygon is valid.
+func (m MultiPolygon) Validate() error {
+	for i, polygon := range m.Polygons {
+		if err := polygon.Validate(); err != nil {
+			return fmt.Errorf("polygon %d: %w", i, err)
+		}
+		for j := 0; j < i; j++ {
+			if polygons_overlap(polygon, m.Polygons[j]) {
+				return fmt.Errorf("polygons %d and %d: %w", j, i, ErrOverlappingRings)
+			}
+		}
+	}
+	return nil
+}
+
+// polygons_overlap tests if the interiors of two valid PolygonWithHoles overlap. Their
+// shells may overlap so long as one part sits entirely inside a Hole of the other.
+func polygons_overlap(p, q PolygonWithHoles) bool {
+	if rings_disjoint(p.Shell, q.Shell) {
+		return false
+	}
+	for _, pair := range [2][2]PolygonWithHoles{{p, q}, {q, p}} {
+		for _, hole := range pair[0].Holes {
+			if ring_within(pair[1].Shell, hole) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Area is the total area of all parts of the MultiPolygon.
+func (m MultiPolygon) Area() float64 {
+	area := 0.0
+	for _, polygon := range m.Polygons {
+		area += polygon.Area()
+	}
+	return area
+}
+
+// LocatePoint classifies a Point `q` as being in the Interior, on the Boundary, or in
+// the Exterior of the MultiPolygon.
+func (m MultiPolygon) LocatePoint(q Point) Location {
+	location := Exterior
+	for _, polygon := range m.Polygons {
+		switch polygon.LocatePoint(q) {
+		case Interior:
+			return Interior
+		case Boundary:
+			location = Boundary
+		}
+	}
+	return location
+}
+
+// ContainsPoint tests if a Point `q` lies inside the MultiPolygon or on its boundary.
+func (m MultiPolygon) ContainsPoint(q Point) bool {
+	return m.LocatePoint(q) != Exterior
+}
+
+// Intersects will determine if two MultiPolygons intersect, i.e. if any part of one
+// intersects any part of the other.
+func (m MultiPolygon) Intersects(n MultiPolygon) bool {
+	for _, p := range m.Polygons {
+		for _, q := range n.Polygons {
+			if p.Intersects(q) {
+				return true
+			}
+		}
+	}
+	return false
+}
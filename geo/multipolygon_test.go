@@ -0,0 +1,350 @@
+package gogeo
+
+import (
+	"errors"
+	"testing"
+)
+
+// square creates a counter-clockwise square with its lower left corner at (x, y).
+func square(x, y, size float64) Polygon {
+	return Polygon{[]Point{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}}
+}
+
+// A 10x10 square with two 2x2 holes.
+var swiss_cheese = PolygonWithHoles{
+	Shell: square(0, 0, 10),
+	Holes: []Polygon{square(2, 2, 2).Reverse(), square(6, 6, 2).Reverse()},
+}
+
+func TestPolygonWithHolesValidate(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    PolygonWithHoles
+		err  error
+	}{
+		{
+			desc: "No holes",
+			p:    PolygonWithHoles{Shell: square(0, 0, 1)},
+			err:  nil,
+		},
+		{
+			desc: "Two holes",
+			p:    swiss_cheese,
+			err:  nil,
+		},
+		{
+			desc: "Hole touching the shell at a vertex",
+			p: PolygonWithHoles{
+				Shell: square(0, 0, 10),
+				Holes: []Polygon{{[]Point{{0, 5}, {2, 6}, {2, 4}}}},
+			},
+			err: nil,
+		},
+		{
+			desc: "Clockwise shell",
+			p:    PolygonWithHoles{Shell: square(0, 0, 1).Reverse()},
+			err:  ErrWrongOrientation,
+		},
+		{
+			desc: "Counter-clockwise hole",
+			p:    PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(2, 2, 2)}},
+			err:  ErrWrongOrientation,
+		},
+		{
+			desc: "Self-intersecting shell",
+			p:    PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 1}, {1, 0}, {0, 1}}}},
+			err:  ErrNotSimple,
+		},
+		{
+			desc: "Hole outside the shell",
+			p:    PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(20, 20, 2).Reverse()}},
+			err:  ErrHoleOutsideShell,
+		},
+		{
+			desc: "Hole crossing the shell",
+			p:    PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(9, 2, 2).Reverse()}},
+			err:  ErrHoleOutsideShell,
+		},
+		{
+			desc: "Hole sharing an edge with the shell",
+			p:    PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(0, 2, 2).Reverse()}},
+			err:  ErrHoleOutsideShell,
+		},
+		{
+			desc: "Hole spanning the notch of an L-shaped shell",
+			p: PolygonWithHoles{
+				Shell: Polygon{[]Point{{0, 0}, {4, 0}, {4, 1}, {1, 1}, {1, 4}, {0, 4}}},
+				Holes: []Polygon{{[]Point{{0.5, 0.5}, {0.5, 3}, {3, 0.5}}}},
+			},
+			err: ErrHoleOutsideShell,
+		},
+		{
+			desc: "Overlapping holes",
+			p: PolygonWithHoles{
+				Shell: square(0, 0, 10),
+				Holes: []Polygon{square(2, 2, 2).Reverse(), square(3, 3, 2).Reverse()},
+			},
+			err: ErrOverlappingRings,
+		},
+		{
+			desc: "Hole inside another hole",
+			p: PolygonWithHoles{
+				Shell: square(0, 0, 10),
+				Holes: []Polygon{square(2, 2, 5).Reverse(), square(3, 3, 1).Reverse()},
+			},
+			err: ErrOverlappingRings,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := tC.p.Validate(); !errors.Is(err, tC.err) || ((err == nil) != (tC.err == nil)) {
+				t.Errorf("Validate() = %v, want %v", err, tC.err)
+			}
+		})
+	}
+}
+
+func TestPolygonWithHolesOriented(t *testing.T) {
+	p := PolygonWithHoles{Shell: square(0, 0, 10).Reverse(), Holes: []Polygon{square(2, 2, 2)}}
+	if err := p.Oriented().Validate(); err != nil {
+		t.Errorf("Oriented().Validate() = %v, want nil", err)
+	}
+}
+
+func TestPolygonWithHolesArea(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    PolygonWithHoles
+		out  float64
+	}{
+		{
+			desc: "No holes",
+			p:    PolygonWithHoles{Shell: square(0, 0, 3)},
+			out:  9,
+		},
+		{
+			desc: "Two holes",
+			p:    swiss_cheese,
+			out:  92,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Area(); got != tC.out {
+				t.Errorf("Area() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolygonWithHolesLocatePoint(t *testing.T) {
+	testCases := []struct {
+		desc string
+		q    Point
+		out  Location
+	}{
+		{
+			desc: "Inside the shell, away from the holes",
+			q:    Point{5, 1},
+			out:  Interior,
+		},
+		{
+			desc: "Inside a hole",
+			q:    Point{3, 3},
+			out:  Exterior,
+		},
+		{
+			desc: "On the edge of a hole",
+			q:    Point{7, 6},
+			out:  Boundary,
+		},
+		{
+			desc: "On the shell",
+			q:    Point{10, 5},
+			out:  Boundary,
+		},
+		{
+			desc: "Outside the shell",
+			q:    Point{11, 5},
+			out:  Exterior,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := swiss_cheese.LocatePoint(tC.q); got != tC.out {
+				t.Errorf("LocatePoint() = %v, want %v", got, tC.out)
+			}
+			if got := swiss_cheese.ContainsPoint(tC.q); got != (tC.out != Exterior) {
+				t.Errorf("ContainsPoint() = %v, want %v", got, tC.out != Exterior)
+			}
+		})
+	}
+}
+
+func TestPolygonWithHolesIntersects(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    PolygonWithHoles
+		q    PolygonWithHoles
+		out  bool
+	}{
+		{
+			desc: "Inside a hole",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(2.5, 2.5, 1)},
+			out:  false,
+		},
+		{
+			desc: "Touching the edge of a hole",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(2, 2, 1)},
+			out:  true,
+		},
+		{
+			desc: "Crossing the edge of a hole",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(3, 3, 2)},
+			out:  true,
+		},
+		{
+			desc: "Inside the shell, between the holes",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(5, 1, 1)},
+			out:  true,
+		},
+		{
+			desc: "Covering the whole polygon",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(-1, -1, 12)},
+			out:  true,
+		},
+		{
+			desc: "Outside the shell",
+			p:    swiss_cheese,
+			q:    PolygonWithHoles{Shell: square(11, 0, 1)},
+			out:  false,
+		},
+		{
+			desc: "Polygon with a hole inside the hole of another",
+			p:    PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(1, 1, 8).Reverse()}},
+			q:    PolygonWithHoles{Shell: square(2, 2, 6), Holes: []Polygon{square(3, 3, 4).Reverse()}},
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Intersects(tC.q); got != tC.out {
+				t.Errorf("Intersects() = %v, want %v", got, tC.out)
+			}
+			if got := tC.q.Intersects(tC.p); got != tC.out {
+				t.Errorf("Intersects() reversed = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func BenchmarkPolygonWithHolesIntersects(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		p    PolygonWithHoles
+		q    PolygonWithHoles
+	}{
+		{"inside a hole", swiss_cheese, PolygonWithHoles{Shell: square(2.5, 2.5, 1)}},
+		{"crossing a hole", swiss_cheese, PolygonWithHoles{Shell: square(3, 3, 2)}},
+		{"outside the shell", swiss_cheese, PolygonWithHoles{Shell: square(11, 0, 1)}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.p.Intersects(bm.q)
+			}
+
+		})
+	}
+}
+
+func TestPolygonIntersects(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		q    Polygon
+		out  bool
+	}{
+		{
+			desc: "Overlapping squares",
+			p:    square(0, 0, 2),
+			q:    square(1, 1, 2),
+			out:  true,
+		},
+		{
+			desc: "One square inside the other",
+			p:    square(0, 0, 10),
+			q:    square(1, 1, 2),
+			out:  true,
+		},
+		{
+			desc: "Square in the notch of an L-shape",
+			p:    l_shape,
+			q:    square(1.25, 1.25, 0.5),
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Intersects(tC.q); got != tC.out {
+				t.Errorf("Intersects() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestMultiPolygon(t *testing.T) {
+	m := MultiPolygon{[]PolygonWithHoles{
+		swiss_cheese,
+		{Shell: square(20, 0, 2)},
+		// An island inside the first hole of swiss_cheese.
+		{Shell: square(2.5, 2.5, 1)},
+	}}
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if got := m.Area(); got != 97 {
+		t.Errorf("Area() = %v, want 97", got)
+	}
+
+	locations := []struct {
+		q   Point
+		out Location
+	}{
+		{Point{1, 1}, Interior},
+		{Point{21, 1}, Interior},
+		{Point{3, 3}, Interior},
+		{Point{2.25, 2.25}, Exterior},
+		{Point{15, 1}, Exterior},
+		{Point{22, 1}, Boundary},
+	}
+	for _, l := range locations {
+		if got := m.LocatePoint(l.q); got != l.out {
+			t.Errorf("LocatePoint(%v) = %v, want %v", l.q, got, l.out)
+		}
+	}
+
+	if !m.Intersects(MultiPolygon{[]PolygonWithHoles{{Shell: square(21, 1, 5)}}}) {
+		t.Errorf("Intersects() = false, want true")
+	}
+	if m.Intersects(MultiPolygon{[]PolygonWithHoles{{Shell: square(12, 0, 5)}}}) {
+		t.Errorf("Intersects() = true, want false")
+	}
+
+	overlapping := MultiPolygon{[]PolygonWithHoles{{Shell: square(0, 0, 2)}, {Shell: square(1, 1, 2)}}}
+	if err := overlapping.Validate(); !errors.Is(err, ErrOverlappingRings) {
+		t.Errorf("Validate() = %v, want %v", err, ErrOverlappingRings)
+	}
+
+	touching := MultiPolygon{[]PolygonWithHoles{{Shell: square(0, 0, 1)}, {Shell: square(1, 1, 1)}}}
+	if err := touching.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,433 @@
+package gogeo
+
+import (
+	"sort"
+)
+
+// BooleanOperation selects how Overlay combines two MultiPolygons.
+type BooleanOperation int
+
+const (
+	// Union keeps the area inside either MultiPolygon.
+	Union BooleanOperation = iota
+	// Intersection keeps the area inside both MultiPolygons.
+	Intersection
+	// Difference keeps the area inside the first MultiPolygon but not the second.
+	Difference
+	// SymmetricDifference keeps the area inside exactly one of the MultiPolygons, i.e.
+	// their XOR.
+	SymmetricDifference
+)
+
+// includes tests if an area inside `a` or not, and inside `b` or not, is kept by the
+// operation.
+func (o BooleanOperation) includes(a, b bool) bool {
+	switch o {
+	case Union:
+		return a || b
+	case Intersection:
+		return a && b
+	case Difference:
+		return a && !b
+	default:
+		return a != b
+	}
+}
+
+// Overlay combines two MultiPolygons with a BooleanOperation, and returns the result as
+// a MultiPolygon with every Shell counter-clockwise and every Hole clockwise.
+//
+// The edges of both are split wherever they cross or touch, forming a planar graph.
+// Every face of that graph is inside a MultiPolygon if its rings wind around it
+// counter-clockwise more often than clockwise, counted by spreading out across the edges
+// from the outside of the graph. The edges with the result on one side and not the other
+// are then joined up into rings. So edges shared by the two MultiPolygons, in either
+// direction, and rings that touch at single Points, are handled without any special
+// cases. Rings in the result that touch at a Point are kept as separate rings, each
+// starting from its lowest vertex, and vertices on a straight line between their
+// neighbors are left out.
+//
+// The orientation of the rings of the MultiPolygons does not matter, as long as their
+// Holes are inside their Shells, and parts that share an edge or overlap each other are
+// merged together.
+//
+// It fails with ErrTooManySplits if the edges cannot be split into pieces that only meet
+// at their ends.
+func Overlay(a, b MultiPolygon, operation BooleanOperation) (MultiPolygon, error) {
+	g, err := new_overlay_graph([2]MultiPolygon{a, b})
+	if err != nil {
+		return MultiPolygon{}, err
+	}
+
+	kept := make([]bool, len(g.origin))
+	for h := range kept {
+		kept[h] = operation.includes(g.winding[0][h] > 0, g.winding[1][h] > 0)
+	}
+	var rings []Polygon
+	used := make([]bool, len(g.origin))
+	for start := range g.origin {
+		if used[start] || !kept[start] || kept[start^1] {
+			continue
+		}
+
+		var ring []Point
+		for h := start; !used[h]; {
+			used[h] = true
+			ring = append(ring, g.points[g.origin[h]])
+			// Turn clockwise around the end of the half-edge, from the way back, until
+			// reaching the next half-edge on the edge of the result.
+			out := g.out[g.origin[h^1]]
+			i := g.position[h^1]
+			for {
+				i = (i + len(out) - 1) % len(out)
+				if kept[out[i]] && !kept[out[i]^1] {
+					break
+				}
+			}
+			h = out[i]
+		}
+		for _, loop := range split_ring(ring) {
+			if loop = clean_ring(loop); loop != nil {
+				rings = append(rings, Polygon{loop})
+			}
+		}
+	}
+	return assemble_polygons(rings), nil
+}
+
+// split_ring splits a ring that passes through the same Point more than once into loops
+// that do not. Each loop starts from its lowest Point, taking the leftmost of any ties.
+func split_ring(ring []Point) [][]Point {
+	var loops [][]Point
+	var stack []Point
+	seen := make(map[Point]int, len(ring))
+	for _, p := range ring {
+		if i, ok := seen[p]; ok {
+			loops = append(loops, append([]Point(nil), stack[i:]...))
+			for _, q := range stack[i+1:] {
+				delete(seen, q)
+			}
+			stack = stack[:i+1]
+			continue
+		}
+		seen[p] = len(stack)
+		stack = append(stack, p)
+	}
+	loops = append(loops, stack)
+
+	for _, loop := range loops {
+		lowest := 0
+		for i, p := range loop {
+			if (p.Y < loop[lowest].Y) || ((p.Y == loop[lowest].Y) && (p.X < loop[lowest].X)) {
+				lowest = i
+			}
+		}
+		rotated := append(append([]Point(nil), loop[lowest:]...), loop[:lowest]...)
+		copy(loop, rotated)
+	}
+	return loops
+}
+
+// Union returns the area inside either `m` or `n`. See Overlay.
+func (m MultiPolygon) Union(n MultiPolygon) (MultiPolygon, error) {
+	return Overlay(m, n, Union)
+}
+
+// Intersection returns the area inside both `m` and `n`. See Overlay.
+func (m MultiPolygon) Intersection(n MultiPolygon) (MultiPolygon, error) {
+	return Overlay(m, n, Intersection)
+}
+
+// Difference returns the area inside `m` but not `n`. See Overlay.
+func (m MultiPolygon) Difference(n MultiPolygon) (MultiPolygon, error) {
+	return Overlay(m, n, Difference)
+}
+
+// SymmetricDifference returns the area inside exactly one of `m` and `n`. See Overlay.
+func (m MultiPolygon) SymmetricDifference(n MultiPolygon) (MultiPolygon, error) {
+	return Overlay(m, n, SymmetricDifference)
+}
+
+// Union returns the area inside either `p` or `q`. See Overlay.
+func (p PolygonWithHoles) Union(q PolygonWithHoles) (MultiPolygon, error) {
+	return Overlay(MultiPolygon{[]PolygonWithHoles{p}}, MultiPolygon{[]PolygonWithHoles{q}}, Union)
+}
+
+// Intersection returns the area inside both `p` and `q`. See Overlay.
+func (p PolygonWithHoles) Intersection(q PolygonWithHoles) (MultiPolygon, error) {
+	return Overlay(MultiPolygon{[]PolygonWithHoles{p}}, MultiPolygon{[]PolygonWithHoles{q}}, Intersection)
+}
+
+// Difference returns the area inside `p` but not `q`. See Overlay.
+func (p PolygonWithHoles) Difference(q PolygonWithHoles) (MultiPolygon, error) {
+	return Overlay(MultiPolygon{[]PolygonWithHoles{p}}, MultiPolygon{[]PolygonWithHoles{q}}, Difference)
+}
+
+// SymmetricDifference returns the area inside exactly one of `p` and `q`. See Overlay.
+func (p PolygonWithHoles) SymmetricDifference(q PolygonWithHoles) (MultiPolygon, error) {
+	return Overlay(MultiPolygon{[]PolygonWithHoles{p}}, MultiPolygon{[]PolygonWithHoles{q}}, SymmetricDifference)
+}
+
+// overlay_graph is the planar graph formed by the edges of two MultiPolygons, split
+// wherever they meet. Each edge `e` is stored as a pair of half-edges running in
+// opposite directions, 2e and 2e+1, so the other half of half-edge `h` is h^1.
+type overlay_graph struct {
+	points []Point
+	// origin holds the vertex that each half-edge starts from.
+	origin []int
+	// out holds the half-edges starting from each vertex, in counter-clockwise order,
+	// and position holds where each half-edge is in that list.
+	out      [][]int
+	position []int
+	// winding holds, for each of the two MultiPolygons, how many times its rings wind
+	// counter-clockwise around the face to the left of each half-edge. The face is inside
+	// the MultiPolygon if that is positive.
+	winding [2][]int
+}
+
+func new_overlay_graph(shapes [2]MultiPolygon) (*overlay_graph, error) {
+	var segments []LineSegment
+	var shape_of []int
+	for s, shape := range shapes {
+		for _, polygon := range shape.Oriented().Polygons {
+			for _, ring := range polygon.Rings() {
+				for it := ring.EdgeIterator(); it.Next(); {
+					segments = append(segments, it.Edge())
+					shape_of = append(shape_of, s)
+				}
+			}
+		}
+	}
+	pieces, origins, err := split_segments(segments)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &overlay_graph{}
+	vertices := make(map[Point]int)
+	vertex := func(p Point) int {
+		v, ok := vertices[p]
+		if !ok {
+			v = len(g.points)
+			vertices[p] = v
+			g.points = append(g.points, p)
+		}
+		return v
+	}
+
+	// Pieces lying on top of each other become one edge. Each edge counts, for each
+	// shape, how many more times the rings of that shape run forwards along it, from its
+	// lower vertex to its higher one, than backwards.
+	edges := make(map[[2]int]int)
+	var counts [2][]int
+	for i, piece := range pieces {
+		u, v := vertex(piece.P1), vertex(piece.P2)
+		key := [2]int{u, v}
+		if u > v {
+			key = [2]int{v, u}
+		}
+		e, ok := edges[key]
+		if !ok {
+			e = len(g.origin) / 2
+			edges[key] = e
+			g.origin = append(g.origin, key[0], key[1])
+			for s := range counts {
+				counts[s] = append(counts[s], 0)
+			}
+		}
+		if s := shape_of[origins[i]]; u < v {
+			counts[s][e]++
+		} else {
+			counts[s][e]--
+		}
+	}
+
+	g.out = make([][]int, len(g.points))
+	for h, v := range g.origin {
+		g.out[v] = append(g.out[v], h)
+	}
+	g.position = make([]int, len(g.origin))
+	for v, out := range g.out {
+		v := v
+		sort.Slice(out, func(i, j int) bool {
+			return angle_less(g.points[v], g.points[g.origin[out[i]^1]], g.points[g.origin[out[j]^1]])
+		})
+		for i, h := range out {
+			g.position[h] = i
+		}
+	}
+
+	g.windings(counts)
+	return g, nil
+}
+
+// windings counts how many times the rings of each shape wind counter-clockwise around
+// the face to the left of each half-edge, given how many more times they run forwards
+// than backwards along each edge. Crossing an edge from its left to its right changes
+// the count by that many, so the counts spread out from the outer face of each connected
+// piece of the graph, which is found by locating its lowest vertex among the other
+// pieces.
+func (g *overlay_graph) windings(counts [2][]int) {
+	face := make([]int, len(g.origin))
+	for h := range face {
+		face[h] = -1
+	}
+	var faces []int
+	for h := range face {
+		for k := h; face[k] < 0; k = g.next(k) {
+			face[k] = len(faces)
+		}
+		if face[h] == len(faces) {
+			faces = append(faces, h)
+		}
+	}
+
+	component := make([]int, len(g.points))
+	for v := range component {
+		component[v] = -1
+	}
+	var lowest []int
+	for start := range g.points {
+		if component[start] >= 0 {
+			continue
+		}
+		c := len(lowest)
+		lowest = append(lowest, start)
+		component[start] = c
+		stack := []int{start}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if p, q := g.points[v], g.points[lowest[c]]; (p.Y < q.Y) || ((p.Y == q.Y) && (p.X < q.X)) {
+				lowest[c] = v
+			}
+			for _, h := range g.out[v] {
+				if w := g.origin[h^1]; component[w] < 0 {
+					component[w] = c
+					stack = append(stack, w)
+				}
+			}
+		}
+	}
+
+	winding := [2][]int{make([]int, len(faces)), make([]int, len(faces))}
+	seen := make([]bool, len(faces))
+	for c, v := range lowest {
+		out := g.out[v]
+		if len(out) == 0 {
+			continue
+		}
+		outer := face[out[len(out)-1]]
+		seen[outer] = true
+		for e := 0; e < len(g.origin)/2; e++ {
+			if component[g.origin[2*e]] == c {
+				continue
+			}
+			a, b, p := g.points[g.origin[2*e]], g.points[g.origin[2*e+1]], g.points[v]
+			crossing := 0
+			if (a.Y <= p.Y) && (p.Y < b.Y) && (Orient2D(a, b, p) > 0) {
+				crossing = 1
+			} else if (b.Y <= p.Y) && (p.Y < a.Y) && (Orient2D(a, b, p) < 0) {
+				crossing = -1
+			}
+			for s := range counts {
+				winding[s][outer] += crossing * counts[s][e]
+			}
+		}
+
+		queue := []int{outer}
+		for len(queue) > 0 {
+			f := queue[0]
+			queue = queue[1:]
+			h := faces[f]
+			for {
+				if other := face[h^1]; !seen[other] {
+					seen[other] = true
+					for s := range counts {
+						delta := counts[s][h/2]
+						if h%2 == 1 {
+							delta = -delta
+						}
+						winding[s][other] = winding[s][f] - delta
+					}
+					queue = append(queue, other)
+				}
+				if h = g.next(h); h == faces[f] {
+					break
+				}
+			}
+		}
+	}
+
+	for s := range counts {
+		g.winding[s] = make([]int, len(g.origin))
+		for h, f := range face {
+			g.winding[s][h] = winding[s][f]
+		}
+	}
+}
+
+// next returns the half-edge that follows `h` around the face to its left.
+func (g *overlay_graph) next(h int) int {
+	out := g.out[g.origin[h^1]]
+	return out[(g.position[h^1]+len(out)-1)%len(out)]
+}
+
+// angle_less tests if the direction from `center` to `p` comes before the direction to
+// `q`, turning counter-clockwise from the positive X axis.
+func angle_less(center, p, q Point) bool {
+	lower := func(r Point) bool {
+		return (r.Y < center.Y) || ((r.Y == center.Y) && (r.X < center.X))
+	}
+	if lower(p) != lower(q) {
+		return lower(q)
+	}
+	return Orient2D(center, p, q) > 0
+}
+
+// assemble_polygons sorts rings that do not cross each other into PolygonWithHoles.
+// Counter-clockwise rings are Shells, and each clockwise ring is a Hole of the smallest
+// Shell around it.
+func assemble_polygons(rings []Polygon) MultiPolygon {
+	var shells, holes []Polygon
+	for _, ring := range rings {
+		if ring.IsCounterClockwise() {
+			shells = append(shells, ring)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+	sort.SliceStable(shells, func(i, j int) bool { return shells[i].Area() < shells[j].Area() })
+
+	polygons := make([]PolygonWithHoles, len(shells))
+	boxes := make([]BoundingBox, len(shells))
+	for i, shell := range shells {
+		polygons[i].Shell = shell
+		boxes[i] = shell.BoundingBox()
+	}
+	for _, hole := range holes {
+		box := hole.BoundingBox()
+		for i, shell := range shells {
+			if boxes[i].Contains(box) && ring_inside(hole, shell) {
+				polygons[i].Holes = append(polygons[i].Holes, hole)
+				break
+			}
+		}
+	}
+	return MultiPolygon{polygons}
+}
+
+// ring_inside tests if the ring `inner` is inside the ring `outer`, given that their
+// edges do not cross, by finding a vertex or edge midpoint of `inner` that is not on
+// `outer`.
+func ring_inside(inner, outer Polygon) bool {
+	for it := inner.EdgeIterator(); it.Next(); {
+		edge := it.Edge()
+		for _, p := range []Point{edge.P1, edge.P1.Plus(edge.P2).Divide(2)} {
+			if location := outer.LocatePoint(p); location != Boundary {
+				return location == Interior
+			}
+		}
+	}
+	return false
+}
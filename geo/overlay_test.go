@@ -0,0 +1,311 @@
+package gogeo
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// multi makes a MultiPolygon with a part for each of the `shells`.
+func multi(shells ...Polygon) MultiPolygon {
+	var m MultiPolygon
+	for _, shell := range shells {
+		m.Polygons = append(m.Polygons, PolygonWithHoles{Shell: shell})
+	}
+	return m
+}
+
+// overlay returns the Overlay of `a` and `b`, failing the test if it returns an error.
+func overlay(t *testing.T, a, b MultiPolygon, operation BooleanOperation) MultiPolygon {
+	t.Helper()
+	result, err := Overlay(a, b, operation)
+	if err != nil {
+		t.Fatalf("Overlay(%v) returned %v", operation, err)
+	}
+	return result
+}
+
+// check_overlay verifies that the result of an Overlay is a valid MultiPolygon, and that
+// Points well away from any boundary are inside it exactly when the operation says so.
+func check_overlay(t *testing.T, rng *rand.Rand, a, b MultiPolygon, operation BooleanOperation, result MultiPolygon) {
+	t.Helper()
+	if err := result.Validate(); err != nil {
+		t.Fatalf("Overlay(%v) = %v is not valid: %v", operation, result, err)
+	}
+
+	var points []Point
+	for _, m := range []MultiPolygon{a, b} {
+		for _, polygon := range m.Polygons {
+			points = append(points, polygon.Shell.Points...)
+		}
+	}
+	box := Polygon{points}.BoundingBox()
+	for i := 0; i < 200; i++ {
+		q := Point{
+			box.Min.X + (box.Max.X-box.Min.X)*rng.Float64(),
+			box.Min.Y + (box.Max.Y-box.Min.Y)*rng.Float64(),
+		}
+		in_a, in_b, got := a.LocatePoint(q), b.LocatePoint(q), result.LocatePoint(q)
+		if (in_a == Boundary) || (in_b == Boundary) || (got == Boundary) {
+			continue
+		}
+		if want := operation.includes(in_a == Interior, in_b == Interior); (got == Interior) != want {
+			t.Fatalf("Overlay(%v) = %v has %v in its %v", operation, result, q, got)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	rng := rand.New(rand.NewSource(15))
+	testCases := []struct {
+		desc      string
+		a, b      MultiPolygon
+		operation BooleanOperation
+		areas     [4]float64
+		polygons  [4]int
+		holes     [4]int
+	}{
+		{
+			desc:     "Overlapping squares",
+			a:        multi(square(0, 0, 2)),
+			b:        multi(square(1, 1, 2)),
+			areas:    [4]float64{7, 1, 3, 6},
+			polygons: [4]int{1, 1, 1, 2},
+		},
+		{
+			desc:     "Squares sharing an edge",
+			a:        multi(square(0, 0, 1)),
+			b:        multi(square(1, 0, 1)),
+			areas:    [4]float64{2, 0, 1, 2},
+			polygons: [4]int{1, 0, 1, 1},
+		},
+		{
+			desc:     "Squares sharing part of an edge",
+			a:        multi(square(0, 0, 2)),
+			b:        multi(square(2, 1, 2)),
+			areas:    [4]float64{8, 0, 4, 8},
+			polygons: [4]int{1, 0, 1, 1},
+		},
+		{
+			desc:     "Squares touching at a corner",
+			a:        multi(square(0, 0, 1)),
+			b:        multi(square(1, 1, 1)),
+			areas:    [4]float64{2, 0, 1, 2},
+			polygons: [4]int{2, 0, 1, 2},
+		},
+		{
+			desc:     "Identical",
+			a:        multi(l_shape),
+			b:        multi(l_shape.Reverse()),
+			areas:    [4]float64{3, 3, 0, 0},
+			polygons: [4]int{1, 1, 0, 0},
+		},
+		{
+			desc:     "Disjoint",
+			a:        multi(square(0, 0, 1)),
+			b:        multi(square(5, 5, 1)),
+			areas:    [4]float64{2, 0, 1, 2},
+			polygons: [4]int{2, 0, 1, 2},
+		},
+		{
+			desc:     "Inside, sharing a corner",
+			a:        multi(square(0, 0, 2)),
+			b:        multi(square(0, 0, 1)),
+			areas:    [4]float64{4, 1, 3, 3},
+			polygons: [4]int{1, 1, 1, 1},
+		},
+		{
+			desc:     "Strictly inside",
+			a:        multi(square(0, 0, 4)),
+			b:        multi(square(1, 1, 1)),
+			areas:    [4]float64{16, 1, 15, 15},
+			polygons: [4]int{1, 1, 1, 1},
+			holes:    [4]int{0, 0, 1, 1},
+		},
+		{
+			desc:     "Inside a hole",
+			a:        MultiPolygon{[]PolygonWithHoles{swiss_cheese}},
+			b:        multi(square(2.5, 2.5, 1)),
+			areas:    [4]float64{93, 0, 92, 93},
+			polygons: [4]int{2, 0, 1, 2},
+			holes:    [4]int{2, 0, 2, 2},
+		},
+		{
+			desc:     "Filling a hole",
+			a:        MultiPolygon{[]PolygonWithHoles{swiss_cheese}},
+			b:        multi(square(2, 2, 2)),
+			areas:    [4]float64{96, 0, 92, 96},
+			polygons: [4]int{1, 0, 1, 1},
+			holes:    [4]int{1, 0, 2, 1},
+		},
+		{
+			desc:     "Across a hole",
+			a:        MultiPolygon{[]PolygonWithHoles{swiss_cheese}},
+			b:        multi(square(1, 2.5, 4)),
+			areas:    [4]float64{95, 13, 79, 82},
+			polygons: [4]int{1, 1, 1, 2},
+			holes:    [4]int{2, 0, 2, 2},
+		},
+		{
+			desc:     "Parts sharing an edge",
+			a:        multi(square(0, 0, 1), square(1, 0, 1)),
+			b:        multi(square(0.5, 0, 1)),
+			areas:    [4]float64{2, 1, 1, 1},
+			polygons: [4]int{1, 1, 2, 2},
+		},
+		{
+			desc:     "Overlapping parts",
+			a:        multi(square(0, 0, 2), square(1, 0, 2), square(3, 3, 1)),
+			b:        multi(square(0, 1, 4)),
+			areas:    [4]float64{19, 4, 3, 15},
+			polygons: [4]int{1, 2, 1, 2},
+		},
+		{
+			desc:     "Part inside another part",
+			a:        multi(square(0, 0, 4), square(1, 1, 1)),
+			b:        multi(square(3, 0, 2)),
+			areas:    [4]float64{18, 2, 14, 16},
+			polygons: [4]int{1, 1, 1, 2},
+		},
+		{
+			desc:     "Overlapping collinear edges",
+			a:        multi(Polygon{[]Point{{0, 0}, {4, 0}, {2, 2}}}),
+			b:        multi(Polygon{[]Point{{1, 0}, {3, 0}, {2, -1}}}),
+			areas:    [4]float64{5, 0, 4, 5},
+			polygons: [4]int{1, 0, 1, 1},
+		},
+		{
+			desc:     "Empty",
+			a:        multi(square(0, 0, 1)),
+			b:        MultiPolygon{},
+			areas:    [4]float64{1, 0, 1, 1},
+			polygons: [4]int{1, 0, 1, 1},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for operation := Union; operation <= SymmetricDifference; operation++ {
+				result := overlay(t, tC.a, tC.b, operation)
+				if !almost_zero(result.Area() - tC.areas[operation]) {
+					t.Errorf("Overlay(%v) has area %v, want %v", operation, result.Area(), tC.areas[operation])
+				}
+				holes := 0
+				for _, polygon := range result.Polygons {
+					holes += len(polygon.Holes)
+				}
+				if (len(result.Polygons) != tC.polygons[operation]) || (holes != tC.holes[operation]) {
+					t.Errorf("Overlay(%v) = %v, want %v Polygons with %v Holes",
+						operation, result, tC.polygons[operation], tC.holes[operation])
+				}
+				check_overlay(t, rng, tC.a, tC.b, operation, result)
+			}
+		})
+	}
+}
+
+func TestOverlayMethods(t *testing.T) {
+	p, q := PolygonWithHoles{Shell: square(0, 0, 2)}, PolygonWithHoles{Shell: square(1, 1, 2)}
+	m, n := multi(p.Shell), multi(q.Shell)
+	testCases := []struct {
+		desc      string
+		method    func() (MultiPolygon, error)
+		operation BooleanOperation
+	}{
+		{"MultiPolygon.Union", func() (MultiPolygon, error) { return m.Union(n) }, Union},
+		{"MultiPolygon.Intersection", func() (MultiPolygon, error) { return m.Intersection(n) }, Intersection},
+		{"MultiPolygon.Difference", func() (MultiPolygon, error) { return m.Difference(n) }, Difference},
+		{"MultiPolygon.SymmetricDifference", func() (MultiPolygon, error) { return m.SymmetricDifference(n) }, SymmetricDifference},
+		{"PolygonWithHoles.Union", func() (MultiPolygon, error) { return p.Union(q) }, Union},
+		{"PolygonWithHoles.Intersection", func() (MultiPolygon, error) { return p.Intersection(q) }, Intersection},
+		{"PolygonWithHoles.Difference", func() (MultiPolygon, error) { return p.Difference(q) }, Difference},
+		{"PolygonWithHoles.SymmetricDifference", func() (MultiPolygon, error) { return p.SymmetricDifference(q) }, SymmetricDifference},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tC.method()
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			want := overlay(t, m, n, tC.operation)
+			if got.Area() != want.Area() || len(got.Polygons) != len(want.Polygons) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+
+	// The Intersection is exactly the square where they overlap.
+	intersection, err := p.Intersection(q)
+	if err != nil {
+		t.Fatalf("Intersection() returned %v", err)
+	}
+	if len(intersection.Polygons) != 1 || !intersection.Polygons[0].Shell.AlmostEquals(square(1, 1, 1)) {
+		t.Errorf("Intersection() = %v, want %v", intersection, square(1, 1, 1))
+	}
+}
+
+func TestOverlayRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(16))
+	for trial := 0; trial < 50; trial++ {
+		var a, b MultiPolygon
+		if trial%2 == 0 {
+			// Random stars, with a hole in each.
+			for i, m := range []*MultiPolygon{&a, &b} {
+				center := Point{0.3 * float64(i), 0.2 * rng.Float64()}
+				m.Polygons = []PolygonWithHoles{{
+					Shell: random_star(rng, center, 0.5, 1, 3+rng.Intn(50)),
+					Holes: []Polygon{random_star(rng, center, 0.1, 0.3, 3+rng.Intn(10)).Reverse()},
+				}}
+			}
+		} else {
+			// Cells of a grid, with many shared edges and corners.
+			for _, m := range []*MultiPolygon{&a, &b} {
+				offset := 0.5 * float64(rng.Intn(2))
+				for cell := 0; cell < 16; cell++ {
+					if rng.Intn(2) == 0 {
+						m.Polygons = append(m.Polygons, PolygonWithHoles{
+							Shell: square(float64(cell%4)+offset, float64(cell/4), 1),
+						})
+					}
+				}
+			}
+		}
+
+		union := overlay(t, a, b, Union)
+		intersection := overlay(t, a, b, Intersection)
+		for operation, result := range []MultiPolygon{
+			union, intersection, overlay(t, a, b, Difference), overlay(t, a, b, SymmetricDifference),
+		} {
+			check_overlay(t, rng, a, b, BooleanOperation(operation), result)
+		}
+
+		// The areas must agree with each other, however the parts of each input overlap.
+		a_area := overlay(t, a, MultiPolygon{}, Union).Area()
+		b_area := overlay(t, b, MultiPolygon{}, Union).Area()
+		if !almost_zero(union.Area() + intersection.Area() - a_area - b_area) {
+			t.Errorf("Union has area %v and Intersection %v, but the inputs have %v and %v",
+				union.Area(), intersection.Area(), a_area, b_area)
+		}
+	}
+}
+
+func BenchmarkOverlay(b *testing.B) {
+	rng := rand.New(rand.NewSource(17))
+	star := multi(random_star(rng, Point{0, 0}, 0.5, 1, 1000))
+	other_star := multi(random_star(rng, Point{0.2, 0.1}, 0.5, 1, 1000))
+	benchmarks := []struct {
+		desc string
+		a, b MultiPolygon
+	}{
+		{"Overlapping squares", multi(square(0, 0, 2)), multi(square(1, 1, 2))},
+		{"Polygon with holes", MultiPolygon{[]PolygonWithHoles{swiss_cheese}}, multi(square(1, 2.5, 4))},
+		{"1000 vertex stars", star, other_star},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Overlay(bm.a, bm.b, Union)
+			}
+
+		})
+	}
+}
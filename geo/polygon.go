@@ -0,0 +1,274 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// Polygon is a simple polygon in 2D space, defined by its vertices in order. The ring is
+// closed implicitly, i.e. the last Point connects back to the first, so the first Point
+// should not be repeated at the end.
+type Polygon struct {
+	Points []Point
+}
+
+// Equals tests if two Polygons have the same vertices in the same order, starting from
+// the same Point.
+func (p Polygon) Equals(q Polygon) bool {
+	if len(p.Points) != len(q.Points) {
+		return false
+	}
+	for i := range p.Points {
+		if !p.Points[i].Equals(q.Points[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// AlmostEquals tests if two Polygons have almost the same vertices in the same order,
+// starting from the same Point.
+func (p Polygon) AlmostEquals(q Polygon) bool {
+	if len(p.Points) != len(q.Points) {
+		return false
+	}
+	for i := range p.Points {
+		if !p.Points[i].AlmostEquals(q.Points[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Edge returns the i-th edge of a Polygon, which runs from the i-th Point to the next
+// one, wrapping around to the first Point after the last.
+func (p Polygon) Edge(i int) LineSegment {
+	return LineSegment{p.Points[i], p.Points[(i+1)%len(p.Points)]}
+}
+
+// Edges returns all edges of a Polygon, in order.
+func (p Polygon) Edges() []LineSegment {
+	edges := make([]LineSegment, len(p.Points))
+	for i := range p.Points {
+		edges[i] = p.Edge(i)
+	}
+	return edges
+}
+
+// EdgeIterator steps through the edges of a Polygon without allocating them all up
+// front. Call Next to advance to each edge in turn, then Edge to get it.
+type EdgeIterator struct {
+	polygon Polygon
+	index   int
+}
+
+// EdgeIterator returns an EdgeIterator positioned before the first edge of the Polygon.
+func (p Polygon) EdgeIterator() *EdgeIterator {
+	return &EdgeIterator{polygon: p, index: -1}
+}
+
+// Next advances to the next edge. It returns false once there are no edges left.
+func (it *EdgeIterator) Next() bool {
+	if it.index < len(it.polygon.Points) {
+		it.index++
+	}
+	return it.index < len(it.polygon.Points)
+}
+
+// Index returns the index of the current edge.
+func (it *EdgeIterator) Index() int {
+	return it.index
+}
+
+// Edge returns the current edge.
+func (it *EdgeIterator) Edge() LineSegment {
+	return it.polygon.Edge(it.index)
+}
+
+// SignedArea is the area of a Polygon, calculated with the shoelace formula. This is the
+// same formula as Triangle.Area, generalized to any number of vertices. The area is
+// positive if the vertices are in counter-clockwise order and negative if they are in
+// clockwise order.
+func (p Polygon) SignedArea() float64 {
+	return signed_area(p.Points)
+}
+
+// Area is the area of a Polygon, regardless of its winding order.
+func (p Polygon) Area() float64 {
+	return math.Abs(p.SignedArea())
+}
+
+// Perimeter is the total length of the edges of a Polygon.
+func (p Polygon) Perimeter() float64 {
+	perimeter := 0.0
+	for it := p.EdgeIterator(); it.Next(); {
+		perimeter += it.Edge().Length()
+	}
+	return perimeter
+}
+
+// Centroid is the center of mass of a Polygon. If the Polygon has no area, the average
+// of its vertices is returned instead.
+func (p Polygon) Centroid() Point {
+	if len(p.Points) == 0 {
+		return Point{math.NaN(), math.NaN()}
+	}
+
+	// Measure relative to the first Point to reduce cancellation.
+	origin := p.Points[0]
+	twice_area := 0.0
+	sum := Point{0, 0}
+	for i := 1; i < len(p.Points)-1; i++ {
+		a := p.Points[i].Minus(origin)
+		b := p.Points[i+1].Minus(origin)
+		cross := a.CrossProduct(b)
+		twice_area += cross
+		sum = sum.Plus(a.Plus(b).Times(cross))
+	}
+
+	if twice_area == 0 {
+		mean := Point{0, 0}
+		for _, point := range p.Points {
+			mean = mean.Plus(point.Minus(origin))
+		}
+		return origin.Plus(mean.Divide(float64(len(p.Points))))
+	}
+	return origin.Plus(sum.Divide(3 * twice_area))
+}
+
+// winding returns +1 if the vertices of a Polygon are in counter-clockwise order, -1 if
+// they are in clockwise order, and 0 if the Polygon has no area. Rather than relying on
+// the sign of the area, it checks the turn at the lowest vertex with the exact Orient2D
+// predicate. That vertex is always convex, so the turn there matches the winding of the
+// whole Polygon.
+func (p Polygon) winding() int {
+	n := len(p.Points)
+	if n < 3 {
+		return 0
+	}
+
+	lowest := 0
+	for i, point := range p.Points {
+		if (point.Y < p.Points[lowest].Y) ||
+			((point.Y == p.Points[lowest].Y) && (point.X < p.Points[lowest].X)) {
+			lowest = i
+		}
+	}
+
+	// Step past any repeats of the lowest Point to find its real neighbors.
+	v := p.Points[lowest]
+	previous := (lowest + n - 1) % n
+	for (previous != lowest) && p.Points[previous].Equals(v) {
+		previous = (previous + n - 1) % n
+	}
+	next := (lowest + 1) % n
+	for (next != lowest) && p.Points[next].Equals(v) {
+		next = (next + 1) % n
+	}
+
+	if turn := sign(Orient2D(p.Points[previous], v, p.Points[next])); turn != 0 {
+		return turn
+	}
+	return sign(p.SignedArea())
+}
+
+// IsCounterClockwise tests if the vertices of a Polygon are in counter-clockwise order.
+func (p Polygon) IsCounterClockwise() bool {
+	return p.winding() > 0
+}
+
+// IsClockwise tests if the vertices of a Polygon are in clockwise order.
+func (p Polygon) IsClockwise() bool {
+	return p.winding() < 0
+}
+
+// Reverse returns a Polygon with the vertices in the opposite order.
+func (p Polygon) Reverse() Polygon {
+	points := make([]Point, len(p.Points))
+	for i, point := range p.Points {
+		points[len(p.Points)-1-i] = point
+	}
+	return Polygon{points}
+}
+
+// CounterClockwise returns the Polygon with its vertices in counter-clockwise order,
+// reversing them if necessary.
+func (p Polygon) CounterClockwise() Polygon {
+	if p.IsClockwise() {
+		return p.Reverse()
+	}
+	return p
+}
+
+// Clockwise returns the Polygon with its vertices in clockwise order, reversing them if
+// necessary.
+func (p Polygon) Clockwise() Polygon {
+	if p.IsCounterClockwise() {
+		return p.Reverse()
+	}
+	return p
+}
+
+// IsSimple tests if a Polygon is simple, i.e. it has at least three vertices, encloses
+// some area, and no two of its edges touch except adjacent edges at their shared vertex.
+func (p Polygon) IsSimple() bool {
+	n := len(p.Points)
+	if (n < 3) || (p.winding() == 0) {
+		return false
+	}
+
+	edges := p.Edges()
+	for i := 0; i < n; i++ {
+		if edges[i].P1.Equals(edges[i].P2) {
+			return false
+		}
+		for j := i + 1; j < n; j++ {
+			adjacent := (j == i+1) || ((i == 0) && (j == n-1))
+			if !adjacent {
+				if edges[i].Intersects(edges[j]) {
+					return false
+				}
+				continue
+			}
+			// Adjacent edges may only share their common vertex.
+			if intersection := edges[i].Intersection(edges[j]); intersection.Kind != PointIntersection {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// LocatePoint classifies a Point `q` as being in the Interior, on the Boundary, or in
+// the Exterior of the Polygon. It calculates the winding number of the Polygon around
+// `q`, which works for either winding order. Every test is made with the exact Orient2D
+// predicate, so Points on an edge are always found to be on the Boundary.
+func (p Polygon) LocatePoint(q Point) Location {
+	winding_number := 0
+	for it := p.EdgeIterator(); it.Next(); {
+		edge := it.Edge()
+		side := sign(Orient2D(edge.P1, edge.P2, q))
+		if (side == 0) && edge.collinear_point_within(q) {
+			return Boundary
+		}
+
+		if edge.P1.Y <= q.Y {
+			if (edge.P2.Y > q.Y) && (side > 0) {
+				// An upward crossing with `q` to the left of the edge.
+				winding_number++
+			}
+		} else if (edge.P2.Y <= q.Y) && (side < 0) {
+			// A downward crossing with `q` to the right of the edge.
+			winding_number--
+		}
+	}
+
+	if winding_number != 0 {
+		return Interior
+	}
+	return Exterior
+}
+
+// ContainsPoint tests if a Point `q` lies inside the Polygon or on its boundary.
+func (p Polygon) ContainsPoint(q Point) bool {
+	return p.LocatePoint(q) != Exterior
+}
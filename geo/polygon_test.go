@@ -0,0 +1,427 @@
+package gogeo
+
+import (
+	"math"
+	"testing"
+)
+
+// Shapes used throughout the Polygon tests.
+var (
+	unit_square = Polygon{[]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}
+	// An L-shape with a notch cut out of the top right.
+	l_shape = Polygon{[]Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}
+)
+
+func TestPolygonEdges(t *testing.T) {
+	edges := unit_square.Edges()
+	want := []LineSegment{
+		{Point{0, 0}, Point{1, 0}},
+		{Point{1, 0}, Point{1, 1}},
+		{Point{1, 1}, Point{0, 1}},
+		{Point{0, 1}, Point{0, 0}},
+	}
+	if len(edges) != len(want) {
+		t.Fatalf("Edges() = %v, want %v", edges, want)
+	}
+	for i := range want {
+		if !edges[i].Equals(want[i]) {
+			t.Errorf("Edges()[%v] = %v, want %v", i, edges[i], want[i])
+		}
+	}
+
+	i := 0
+	for it := unit_square.EdgeIterator(); it.Next(); i++ {
+		if it.Index() != i || !it.Edge().Equals(want[i]) {
+			t.Errorf("EdgeIterator edge %v = %v, want %v", it.Index(), it.Edge(), want[i])
+		}
+	}
+	if i != len(want) {
+		t.Errorf("EdgeIterator visited %v edges, want %v", i, len(want))
+	}
+
+	if (Polygon{}).EdgeIterator().Next() {
+		t.Errorf("EdgeIterator on an empty Polygon has an edge")
+	}
+}
+
+func TestPolygonSignedArea(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		out  float64
+	}{
+		{
+			desc: "Counter-clockwise unit square",
+			p:    unit_square,
+			out:  1,
+		},
+		{
+			desc: "Clockwise unit square",
+			p:    unit_square.Reverse(),
+			out:  -1,
+		},
+		{
+			desc: "L-shape",
+			p:    l_shape,
+			out:  3,
+		},
+		{
+			desc: "Agrees with Triangle.Area",
+			p:    Polygon{[]Point{{0, 0}, {3, 0}, {3, 4}}},
+			out:  Triangle{Point{0, 0}, Point{3, 0}, Point{3, 4}}.Area(),
+		},
+		{
+			desc: "Far from the origin",
+			p:    Polygon{[]Point{{1e8, 1e8}, {1e8 + 1, 1e8}, {1e8 + 1, 1e8 + 1}, {1e8, 1e8 + 1}}},
+			out:  1,
+		},
+		{
+			desc: "Fewer than three vertices",
+			p:    Polygon{[]Point{{0, 0}, {1, 1}}},
+			out:  0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.SignedArea(); got != tC.out {
+				t.Errorf("SignedArea() = %v, want %v", got, tC.out)
+			}
+			if got := tC.p.Area(); got != math.Abs(tC.out) {
+				t.Errorf("Area() = %v, want %v", got, math.Abs(tC.out))
+			}
+		})
+	}
+}
+
+func BenchmarkPolygonArea(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		p    Polygon
+	}{
+		{"unit square", unit_square},
+		{"L-shape", l_shape},
+		{"1000-gon", regular_polygon(Point{0, 0}, 1, 1000)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.p.Area()
+			}
+
+		})
+	}
+}
+
+// regular_polygon creates a counter-clockwise regular polygon with `n` vertices.
+func regular_polygon(center Point, radius float64, n int) Polygon {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = center.Plus(Point{radius, 0}.Rotate(2 * math.Pi * float64(i) / float64(n)))
+	}
+	return Polygon{points}
+}
+
+func TestPolygonPerimeter(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		out  float64
+	}{
+		{
+			desc: "Unit square",
+			p:    unit_square,
+			out:  4,
+		},
+		{
+			desc: "L-shape",
+			p:    l_shape,
+			out:  8,
+		},
+		{
+			desc: "3-4-5 triangle",
+			p:    Polygon{[]Point{{0, 0}, {3, 0}, {3, 4}}},
+			out:  12,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Perimeter(); got != tC.out {
+				t.Errorf("Perimeter() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolygonCentroid(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		out  Point
+	}{
+		{
+			desc: "Unit square",
+			p:    unit_square,
+			out:  Point{0.5, 0.5},
+		},
+		{
+			desc: "Clockwise unit square",
+			p:    unit_square.Reverse(),
+			out:  Point{0.5, 0.5},
+		},
+		{
+			desc: "L-shape",
+			p:    l_shape,
+			out:  Point{5.0 / 6, 5.0 / 6},
+		},
+		{
+			desc: "Triangle",
+			p:    Polygon{[]Point{{0, 0}, {3, 0}, {0, 3}}},
+			out:  Point{1, 1},
+		},
+		{
+			desc: "No area",
+			p:    Polygon{[]Point{{0, 0}, {1, 1}, {2, 2}}},
+			out:  Point{1, 1},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Centroid(); !got.AlmostEquals(tC.out) {
+				t.Errorf("Centroid() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func BenchmarkPolygonCentroid(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		p    Polygon
+	}{
+		{"unit square", unit_square},
+		{"1000-gon", regular_polygon(Point{0, 0}, 1, 1000)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.p.Centroid()
+			}
+
+		})
+	}
+}
+
+func TestPolygonWindingOrder(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		ccw  bool
+		cw   bool
+	}{
+		{
+			desc: "Counter-clockwise square",
+			p:    unit_square,
+			ccw:  true,
+		},
+		{
+			desc: "Clockwise square",
+			p:    unit_square.Reverse(),
+			cw:   true,
+		},
+		{
+			desc: "Counter-clockwise L-shape starting at a reflex vertex",
+			p:    Polygon{[]Point{{1, 1}, {1, 2}, {0, 2}, {0, 0}, {2, 0}, {2, 1}}},
+			ccw:  true,
+		},
+		{
+			desc: "Repeated lowest vertex",
+			p:    Polygon{[]Point{{0, 0}, {0, 0}, {1, 0}, {1, 1}}},
+			ccw:  true,
+		},
+		{
+			desc: "Collinear vertices have no winding",
+			p:    Polygon{[]Point{{0, 0}, {1, 1}, {2, 2}}},
+		},
+		{
+			desc: "Nearly collinear vertices",
+			p:    Polygon{[]Point{{0.5, nudge(0.5, 1)}, {12, 12}, {24, 24}}},
+			ccw:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.IsCounterClockwise(); got != tC.ccw {
+				t.Errorf("IsCounterClockwise() = %v, want %v", got, tC.ccw)
+			}
+			if got := tC.p.IsClockwise(); got != tC.cw {
+				t.Errorf("IsClockwise() = %v, want %v", got, tC.cw)
+			}
+			if (tC.ccw || tC.cw) && !tC.p.CounterClockwise().IsCounterClockwise() {
+				t.Errorf("CounterClockwise() = %v is not counter-clockwise", tC.p.CounterClockwise())
+			}
+			if (tC.ccw || tC.cw) && !tC.p.Clockwise().IsClockwise() {
+				t.Errorf("Clockwise() = %v is not clockwise", tC.p.Clockwise())
+			}
+		})
+	}
+}
+
+func TestPolygonIsSimple(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		out  bool
+	}{
+		{
+			desc: "Square",
+			p:    unit_square,
+			out:  true,
+		},
+		{
+			desc: "L-shape",
+			p:    l_shape,
+			out:  true,
+		},
+		{
+			desc: "Bow tie",
+			p:    Polygon{[]Point{{0, 0}, {1, 1}, {1, 0}, {0, 1}}},
+			out:  false,
+		},
+		{
+			desc: "Vertex touching another edge",
+			p:    Polygon{[]Point{{0, 0}, {2, 0}, {2, 2}, {1, 0}, {0, 2}}},
+			out:  false,
+		},
+		{
+			desc: "Spike folding back on itself",
+			p:    Polygon{[]Point{{0, 0}, {2, 0}, {1, 0}, {1, 1}}},
+			out:  false,
+		},
+		{
+			desc: "Repeated vertex",
+			p:    Polygon{[]Point{{0, 0}, {1, 0}, {1, 0}, {1, 1}}},
+			out:  false,
+		},
+		{
+			desc: "Too few vertices",
+			p:    Polygon{[]Point{{0, 0}, {1, 0}}},
+			out:  false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.IsSimple(); got != tC.out {
+				t.Errorf("IsSimple() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolygonLocatePoint(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    Polygon
+		q    Point
+		out  Location
+	}{
+		{
+			desc: "Inside the square",
+			p:    unit_square,
+			q:    Point{0.5, 0.5},
+			out:  Interior,
+		},
+		{
+			desc: "Inside the clockwise square",
+			p:    unit_square.Reverse(),
+			q:    Point{0.5, 0.5},
+			out:  Interior,
+		},
+		{
+			desc: "On an edge of the square",
+			p:    unit_square,
+			q:    Point{1, 0.3},
+			out:  Boundary,
+		},
+		{
+			desc: "On a vertex of the square",
+			p:    unit_square,
+			q:    Point{0, 1},
+			out:  Boundary,
+		},
+		{
+			desc: "Outside the square, level with the top edge",
+			p:    unit_square,
+			q:    Point{2, 1},
+			out:  Exterior,
+		},
+		{
+			desc: "Outside the square, level with a vertex",
+			p:    unit_square,
+			q:    Point{-1, 0},
+			out:  Exterior,
+		},
+		{
+			desc: "In the notch of the L-shape",
+			p:    l_shape,
+			q:    Point{1.5, 1.5},
+			out:  Exterior,
+		},
+		{
+			desc: "In the lower arm of the L-shape",
+			p:    l_shape,
+			q:    Point{1.5, 0.5},
+			out:  Interior,
+		},
+		{
+			desc: "On the reflex vertex of the L-shape",
+			p:    l_shape,
+			q:    Point{1, 1},
+			out:  Boundary,
+		},
+		{
+			desc: "Level with the reflex vertex of the L-shape",
+			p:    l_shape,
+			q:    Point{0.5, 1},
+			out:  Interior,
+		},
+		{
+			desc: "Just outside a diagonal edge",
+			p:    Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}},
+			q:    Point{0.5, nudge(0.5, 1)},
+			out:  Exterior,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.LocatePoint(tC.q); got != tC.out {
+				t.Errorf("LocatePoint() = %v, want %v", got, tC.out)
+			}
+			if got := tC.p.ContainsPoint(tC.q); got != (tC.out != Exterior) {
+				t.Errorf("ContainsPoint() = %v, want %v", got, tC.out != Exterior)
+			}
+		})
+	}
+}
+
+func BenchmarkPolygonLocatePoint(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		p    Polygon
+		q    Point
+	}{
+		{"inside the square", unit_square, Point{0.5, 0.5}},
+		{"outside the L-shape", l_shape, Point{1.5, 1.5}},
+		{"inside the 1000-gon", regular_polygon(Point{0, 0}, 1, 1000), Point{0.1, 0.2}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.p.LocatePoint(bm.q)
+			}
+
+		})
+	}
+}
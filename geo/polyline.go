@@ -0,0 +1,170 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// Polyline is a chain of LineSegments in 2D space, defined by its vertices in order.
+// Unlike a Polygon, the last Point does not connect back to the first unless it is
+// repeated.
+type Polyline struct {
+	Points []Point
+}
+
+// Equals tests if two Polylines have the same vertices in the same order.
+func (l Polyline) Equals(m Polyline) bool {
+	return Polygon(l).Equals(Polygon(m))
+}
+
+// AlmostEquals tests if two Polylines have almost the same vertices in the same order.
+func (l Polyline) AlmostEquals(m Polyline) bool {
+	return Polygon(l).AlmostEquals(Polygon(m))
+}
+
+// Segment returns the i-th LineSegment of a Polyline, which runs from the i-th Point to
+// the next one.
+func (l Polyline) Segment(i int) LineSegment {
+	return LineSegment{l.Points[i], l.Points[i+1]}
+}
+
+// Segments returns all LineSegments of a Polyline, in order.
+func (l Polyline) Segments() []LineSegment {
+	if len(l.Points) < 2 {
+		return nil
+	}
+	segments := make([]LineSegment, len(l.Points)-1)
+	for i := range segments {
+		segments[i] = l.Segment(i)
+	}
+	return segments
+}
+
+// Length is the total length of the LineSegments of a Polyline.
+func (l Polyline) Length() float64 {
+	length := 0.0
+	for i := 0; i < len(l.Points)-1; i++ {
+		length += l.Segment(i).Length()
+	}
+	return length
+}
+
+// IsClosed tests if the last Point of a Polyline is the same as the first.
+func (l Polyline) IsClosed() bool {
+	return (len(l.Points) > 1) && l.Points[0].Equals(l.Points[len(l.Points)-1])
+}
+
+// Reverse returns a Polyline with the vertices in the opposite order.
+func (l Polyline) Reverse() Polyline {
+	return Polyline(Polygon(l).Reverse())
+}
+
+// Interpolate finds the Point a given distance along a Polyline, measured from its first
+// Point. Distances beyond either end are clamped to that end.
+func (l Polyline) Interpolate(distance float64) Point {
+	if len(l.Points) == 0 {
+		return Point{math.NaN(), math.NaN()}
+	}
+	if distance <= 0 {
+		return l.Points[0]
+	}
+
+	for i := 0; i < len(l.Points)-1; i++ {
+		segment := l.Segment(i)
+		length := segment.Length()
+		if distance < length {
+			return segment.P1.Plus(segment.P2.Minus(segment.P1).Times(distance / length))
+		}
+		distance -= length
+	}
+	return l.Points[len(l.Points)-1]
+}
+
+// Project finds the Point on a Polyline that is closest to `q`, and how far along the
+// Polyline that Point lies. If several Points are equally close, the one nearest the
+// start of the Polyline is used.
+func (l Polyline) Project(q Point) (distance float64, nearest Point) {
+	if len(l.Points) == 0 {
+		return math.NaN(), Point{math.NaN(), math.NaN()}
+	}
+	if len(l.Points) == 1 {
+		return 0, l.Points[0]
+	}
+
+	best_gap := math.Inf(1)
+	travelled := 0.0
+	for i := 0; i < len(l.Points)-1; i++ {
+		segment := l.Segment(i)
+		closest := segment.ClosestPoint(q)
+		if gap := q.Minus(closest).Magnitude(); gap < best_gap {
+			best_gap = gap
+			nearest = closest
+			distance = travelled + closest.Minus(segment.P1).Magnitude()
+		}
+		travelled += segment.Length()
+	}
+	return distance, nearest
+}
+
+// SubLine extracts the part of a Polyline between two distances along it, measured from
+// its first Point. Distances beyond either end are clamped to that end. If `start` is
+// greater than `end`, the result runs in the opposite direction to the Polyline. If
+// they are equal, the result holds a single Point.
+func (l Polyline) SubLine(start, end float64) Polyline {
+	if start > end {
+		return l.SubLine(end, start).Reverse()
+	}
+	if len(l.Points) == 0 {
+		return Polyline{}
+	}
+	if start == end {
+		return Polyline{[]Point{l.Interpolate(start)}}
+	}
+
+	points := []Point{l.Interpolate(start)}
+	travelled := 0.0
+	for i := 0; i < len(l.Points)-1; i++ {
+		travelled += l.Segment(i).Length()
+		if travelled >= end {
+			break
+		}
+		if travelled > start {
+			points = append(points, l.Points[i+1])
+		}
+	}
+	if last := l.Interpolate(end); !last.Equals(points[len(points)-1]) {
+		points = append(points, last)
+	}
+	return Polyline{points}
+}
+
+// SelfIntersection records that the i-th and j-th LineSegments of a Polyline meet.
+type SelfIntersection struct {
+	I            int
+	J            int
+	Intersection LineSegmentIntersection
+}
+
+// SelfIntersections finds every place a Polyline touches itself, other than where
+// consecutive LineSegments share a vertex. Consecutive LineSegments that double back
+// over each other are reported. For a closed Polyline, the shared first and last Point
+// is not reported either. The LineSegments are swept as by Intersections.
+func (l Polyline) SelfIntersections() []SelfIntersection {
+	var intersections []SelfIntersection
+	segments := l.Segments()
+	closed := l.IsClosed()
+	for _, found := range Intersections(segments) {
+		i, j := found.I, found.J
+		consecutive := (j == i+1) || (closed && (i == 0) && (j == len(segments)-1))
+		if consecutive && (found.Intersection.Kind == PointIntersection) {
+			continue
+		}
+		intersections = append(intersections, SelfIntersection{i, j, found.Intersection})
+	}
+	return intersections
+}
+
+// IsSimple tests if a Polyline never touches itself, other than where consecutive
+// LineSegments share a vertex.
+func (l Polyline) IsSimple() bool {
+	return len(l.SelfIntersections()) == 0
+}
@@ -0,0 +1,333 @@
+package gogeo
+
+import (
+	"testing"
+)
+
+// A staircase of three unit steps, with total length 6.
+var staircase = Polyline{[]Point{{0, 0}, {1, 0}, {1, 1}, {2, 1}, {2, 2}, {3, 2}, {3, 3}}}
+
+func TestPolylineLength(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    Polyline
+		out  float64
+	}{
+		{
+			desc: "Staircase",
+			l:    staircase,
+			out:  6,
+		},
+		{
+			desc: "Single segment",
+			l:    Polyline{[]Point{{0, 0}, {3, 4}}},
+			out:  5,
+		},
+		{
+			desc: "Single Point",
+			l:    Polyline{[]Point{{1, 1}}},
+			out:  0,
+		},
+		{
+			desc: "Empty",
+			l:    Polyline{},
+			out:  0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.Length(); got != tC.out {
+				t.Errorf("Length() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolylineInterpolate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		l        Polyline
+		distance float64
+		out      Point
+	}{
+		{
+			desc:     "Start",
+			l:        staircase,
+			distance: 0,
+			out:      Point{0, 0},
+		},
+		{
+			desc:     "Half way along the first segment",
+			l:        staircase,
+			distance: 0.5,
+			out:      Point{0.5, 0},
+		},
+		{
+			desc:     "Exactly at a vertex",
+			l:        staircase,
+			distance: 2,
+			out:      Point{1, 1},
+		},
+		{
+			desc:     "Along a vertical segment",
+			l:        staircase,
+			distance: 3.25,
+			out:      Point{2, 1.25},
+		},
+		{
+			desc:     "End",
+			l:        staircase,
+			distance: 6,
+			out:      Point{3, 3},
+		},
+		{
+			desc:     "Before the start",
+			l:        staircase,
+			distance: -1,
+			out:      Point{0, 0},
+		},
+		{
+			desc:     "Past the end",
+			l:        staircase,
+			distance: 100,
+			out:      Point{3, 3},
+		},
+		{
+			desc:     "Diagonal segment",
+			l:        Polyline{[]Point{{0, 0}, {3, 4}}},
+			distance: 2.5,
+			out:      Point{1.5, 2},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.Interpolate(tC.distance); !got.AlmostEquals(tC.out) {
+				t.Errorf("Interpolate() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func BenchmarkPolylineInterpolate(b *testing.B) {
+	benchmarks := []struct {
+		desc     string
+		l        Polyline
+		distance float64
+	}{
+		{"start of the staircase", staircase, 0.5},
+		{"end of the staircase", staircase, 5.5},
+		{"middle of a 1000 segment line", Polyline(regular_polygon(Point{0, 0}, 1, 1001)), 3},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.l.Interpolate(bm.distance)
+			}
+
+		})
+	}
+}
+
+func TestPolylineProject(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		l        Polyline
+		q        Point
+		distance float64
+		nearest  Point
+	}{
+		{
+			desc:     "Below the first segment",
+			l:        staircase,
+			q:        Point{0.5, -1},
+			distance: 0.5,
+			nearest:  Point{0.5, 0},
+		},
+		{
+			desc:     "Beside a vertical segment",
+			l:        staircase,
+			q:        Point{2.5, 1.5},
+			distance: 3.5,
+			nearest:  Point{2, 1.5},
+		},
+		{
+			desc:     "On a vertex",
+			l:        staircase,
+			q:        Point{2, 2},
+			distance: 4,
+			nearest:  Point{2, 2},
+		},
+		{
+			desc:     "Beyond the end",
+			l:        staircase,
+			q:        Point{5, 5},
+			distance: 6,
+			nearest:  Point{3, 3},
+		},
+		{
+			desc:     "Equally close to two segments picks the earlier",
+			l:        Polyline{[]Point{{0, 0}, {2, 0}, {2, 2}}},
+			q:        Point{3, -1},
+			distance: 2,
+			nearest:  Point{2, 0},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			distance, nearest := tC.l.Project(tC.q)
+			if !almost_zero(distance-tC.distance) || !nearest.AlmostEquals(tC.nearest) {
+				t.Errorf("Project() = %v, %v, want %v, %v", distance, nearest, tC.distance, tC.nearest)
+			}
+			if got := tC.l.Interpolate(distance); !got.AlmostEquals(nearest) {
+				t.Errorf("Interpolate(Project()) = %v, want %v", got, nearest)
+			}
+		})
+	}
+}
+
+func BenchmarkPolylineProject(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		l    Polyline
+		q    Point
+	}{
+		{"beside the staircase", staircase, Point{2.5, 1.5}},
+		{"inside a 1000 segment circle", Polyline(regular_polygon(Point{0, 0}, 1, 1001)), Point{0.1, 0.2}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.l.Project(bm.q)
+			}
+
+		})
+	}
+}
+
+func TestPolylineSubLine(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		l     Polyline
+		start float64
+		end   float64
+		out   Polyline
+	}{
+		{
+			desc:  "Whole line",
+			l:     staircase,
+			start: 0,
+			end:   6,
+			out:   staircase,
+		},
+		{
+			desc:  "Within one segment",
+			l:     staircase,
+			start: 0.25,
+			end:   0.75,
+			out:   Polyline{[]Point{{0.25, 0}, {0.75, 0}}},
+		},
+		{
+			desc:  "Across several segments",
+			l:     staircase,
+			start: 0.5,
+			end:   3.5,
+			out:   Polyline{[]Point{{0.5, 0}, {1, 0}, {1, 1}, {2, 1}, {2, 1.5}}},
+		},
+		{
+			desc:  "Between two vertices",
+			l:     staircase,
+			start: 1,
+			end:   3,
+			out:   Polyline{[]Point{{1, 0}, {1, 1}, {2, 1}}},
+		},
+		{
+			desc:  "Reversed",
+			l:     staircase,
+			start: 3.5,
+			end:   0.5,
+			out:   Polyline{[]Point{{2, 1.5}, {2, 1}, {1, 1}, {1, 0}, {0.5, 0}}},
+		},
+		{
+			desc:  "Clamped to the ends",
+			l:     Polyline{[]Point{{0, 0}, {1, 0}}},
+			start: -5,
+			end:   5,
+			out:   Polyline{[]Point{{0, 0}, {1, 0}}},
+		},
+		{
+			desc:  "Single Point",
+			l:     staircase,
+			start: 2,
+			end:   2,
+			out:   Polyline{[]Point{{1, 1}}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.SubLine(tC.start, tC.end); !got.AlmostEquals(tC.out) {
+				t.Errorf("SubLine() = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestPolylineReverse(t *testing.T) {
+	l := Polyline{[]Point{{0, 0}, {1, 0}, {1, 1}}}
+	want := Polyline{[]Point{{1, 1}, {1, 0}, {0, 0}}}
+	if got := l.Reverse(); !got.Equals(want) {
+		t.Errorf("Reverse() = %v, want %v", got, want)
+	}
+	if !l.Equals(Polyline{[]Point{{0, 0}, {1, 0}, {1, 1}}}) {
+		t.Errorf("Reverse() modified the original Polyline: %v", l)
+	}
+}
+
+func TestPolylineSelfIntersections(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		l     Polyline
+		pairs [][2]int
+	}{
+		{
+			desc: "Staircase",
+			l:    staircase,
+		},
+		{
+			desc:  "Figure of eight",
+			l:     Polyline{[]Point{{0, 0}, {1, 1}, {1, 0}, {0, 1}}},
+			pairs: [][2]int{{0, 2}},
+		},
+		{
+			desc:  "Doubles back on itself",
+			l:     Polyline{[]Point{{0, 0}, {2, 0}, {1, 0}}},
+			pairs: [][2]int{{0, 1}},
+		},
+		{
+			desc: "Closed square",
+			l:    Polyline{[]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+		},
+		{
+			desc:  "Returns to touch an earlier vertex",
+			l:     Polyline{[]Point{{0, 0}, {2, 0}, {2, 2}, {1, 0}}},
+			pairs: [][2]int{{0, 2}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := tC.l.SelfIntersections()
+			if len(got) != len(tC.pairs) {
+				t.Fatalf("SelfIntersections() = %v, want pairs %v", got, tC.pairs)
+			}
+			for i, pair := range tC.pairs {
+				if got[i].I != pair[0] || got[i].J != pair[1] {
+					t.Errorf("SelfIntersections()[%v] = %v, want pair %v", i, got[i], pair)
+				}
+			}
+			if simple := tC.l.IsSimple(); simple != (len(tC.pairs) == 0) {
+				t.Errorf("IsSimple() = %v, want %v", simple, len(tC.pairs) == 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package gogeo
+
+import (
+	"math"
+)
+
+// The predicates in this file follow Jonathan Shewchuk's "Adaptive Precision
+// Floating-Point Arithmetic and Fast Robust Geometric Predicates". Each predicate first
+// evaluates its determinant in ordinary floating point arithmetic and compares the
+// result against a forward error bound. Only when the answer is too close to zero to be
+// trusted does it fall back to exact arithmetic on floating point expansions, so the
+// sign returned is always correct while the common case stays cheap.
+
+// epsilon is half an ulp of 1.0, the largest relative rounding error of a single
+// floating point operation.
+const epsilon = 1.0 / (1 << 53)
+
+// Error bounds for the filtered stages of the predicates.
+const (
+	ccw_err_bound_a = (3.0 + 16.0*epsilon) * epsilon
+	ccw_err_bound_b = (2.0 + 12.0*epsilon) * epsilon
+	icc_err_bound_a = (10.0 + 96.0*epsilon) * epsilon
+	dst_err_bound_a = (8.0 + 64.0*epsilon) * epsilon
+)
+
+// Orient2D reports the orientation of the Point `c` relative to the directed line
+// through `a` and `b`. The result is positive if `a`, `b` and `c` are in
+// counter-clockwise order, negative if they are in clockwise order and zero if they are
+// collinear. The sign of the result is exact; its magnitude is approximately twice the
+// signed area of the triangle `abc`.
+func Orient2D(a, b, c Point) float64 {
+	det_left := (a.X - c.X) * (b.Y - c.Y)
+	det_right := (a.Y - c.Y) * (b.X - c.X)
+	det := det_left - det_right
+
+	var det_sum float64
+	if det_left > 0 {
+		if det_right <= 0 {
+			return det
+		}
+		det_sum = det_left + det_right
+	} else if det_left < 0 {
+		if det_right >= 0 {
+			return det
+		}
+		det_sum = -det_left - det_right
+	} else {
+		return det
+	}
+
+	err_bound := ccw_err_bound_a * det_sum
+	if (det >= err_bound) || (-det >= err_bound) {
+		return det
+	}
+	return orient2d_adapt(a, b, c, det_sum)
+}
+
+// orient2d_adapt is the slow path of Orient2D. It first evaluates the determinant of the
+// rounded coordinate differences exactly, which is enough whenever those differences
+// were themselves computed without error. Otherwise it evaluates the full determinant
+// exactly.
+func orient2d_adapt(a, b, c Point, det_sum float64) float64 {
+	acx := a.X - c.X
+	bcx := b.X - c.X
+	acy := a.Y - c.Y
+	bcy := b.Y - c.Y
+
+	b_exp := two_two_diff(acx, bcy, acy, bcx)
+	det := estimate(b_exp)
+	err_bound := ccw_err_bound_b * det_sum
+	if (det >= err_bound) || (-det >= err_bound) {
+		return det
+	}
+
+	if two_diff_tail(a.X, c.X, acx) == 0 && two_diff_tail(b.X, c.X, bcx) == 0 &&
+		two_diff_tail(a.Y, c.Y, acy) == 0 && two_diff_tail(b.Y, c.Y, bcy) == 0 {
+		return det
+	}
+
+	return orient2d_exact(a, b, c)
+}
+
+// orient2d_exact evaluates the orientation determinant without any rounding error and
+// returns the most significant component of the result.
+func orient2d_exact(a, b, c Point) float64 {
+	ab := two_two_diff(a.X, b.Y, b.X, a.Y)
+	bc := two_two_diff(b.X, c.Y, c.X, b.Y)
+	ca := two_two_diff(c.X, a.Y, a.X, c.Y)
+	return most_significant(expansion_sum(expansion_sum(ab, bc), ca))
+}
+
+// InCircle reports where the Point `d` lies relative to the circle passing through `a`,
+// `b` and `c`, which must be in counter-clockwise order. The result is positive if `d`
+// lies inside the circle, negative if it lies outside and zero if the four Points are
+// cocircular. If `a`, `b` and `c` are in clockwise order the sign is reversed. Like
+// Orient2D, the sign of the result is exact.
+func InCircle(a, b, c, d Point) float64 {
+	adx := a.X - d.X
+	bdx := b.X - d.X
+	cdx := c.X - d.X
+	ady := a.Y - d.Y
+	bdy := b.Y - d.Y
+	cdy := c.Y - d.Y
+
+	bdx_cdy := bdx * cdy
+	cdx_bdy := cdx * bdy
+	a_lift := adx*adx + ady*ady
+
+	cdx_ady := cdx * ady
+	adx_cdy := adx * cdy
+	b_lift := bdx*bdx + bdy*bdy
+
+	adx_bdy := adx * bdy
+	bdx_ady := bdx * ady
+	c_lift := cdx*cdx + cdy*cdy
+
+	det := a_lift*(bdx_cdy-cdx_bdy) +
+		b_lift*(cdx_ady-adx_cdy) +
+		c_lift*(adx_bdy-bdx_ady)
+
+	permanent := (math.Abs(bdx_cdy)+math.Abs(cdx_bdy))*a_lift +
+		(math.Abs(cdx_ady)+math.Abs(adx_cdy))*b_lift +
+		(math.Abs(adx_bdy)+math.Abs(bdx_ady))*c_lift
+	err_bound := icc_err_bound_a * permanent
+	if (det > err_bound) || (-det > err_bound) {
+		return det
+	}
+	return incircle_exact(a, b, c, d)
+}
+
+// compare_distances reports which of `p` and `q` is closer to `c`. The result is
+// negative if `p` is closer, positive if `q` is closer and zero if they are the same
+// distance away. Like the other predicates the sign is exact, so sorting by it never
+// puts a Point ahead of a closer one because of rounding.
+func compare_distances(c, p, q Point) int {
+	pdx, pdy := p.X-c.X, p.Y-c.Y
+	qdx, qdy := q.X-c.X, q.Y-c.Y
+	p_distance := pdx*pdx + pdy*pdy
+	q_distance := qdx*qdx + qdy*qdy
+
+	err_bound := dst_err_bound_a * (p_distance + q_distance)
+	if det := p_distance - q_distance; (det > err_bound) || (-det > err_bound) {
+		return sign(det)
+	}
+	// Points on a grid often tie exactly, and then every step above may have been
+	// exact, which is much cheaper to check than to fall back on expansions.
+	if rounding_free(c, p, pdx, pdy) && rounding_free(c, q, qdx, qdy) {
+		return sign(p_distance - q_distance)
+	}
+	exact := expansion_sum(squared_distance_exact(c, p), negate_expansion(squared_distance_exact(c, q)))
+	return sign(most_significant(exact))
+}
+
+// rounding_free tests if `dx` and `dy`, the offsets of `p` from `c`, and the square of
+// the distance between them were all calculated without rounding.
+func rounding_free(c, p Point, dx, dy float64) bool {
+	dx2, dy2 := dx*dx, dy*dy
+	_, tail := two_sum(dx2, dy2)
+	return (two_diff_tail(p.X, c.X, dx) == 0) && (two_diff_tail(p.Y, c.Y, dy) == 0) &&
+		(math.FMA(dx, dx, -dx2) == 0) && (math.FMA(dy, dy, -dy2) == 0) && (tail == 0)
+}
+
+// squared_distance_exact calculates the square of the distance from `c` to `p` exactly
+// as an expansion.
+func squared_distance_exact(c, p Point) []float64 {
+	square := func(a, b float64) []float64 {
+		x := a - b
+		difference := []float64{two_diff_tail(a, b, x), x}
+		return expansion_sum(scale_expansion(difference, difference[0]), scale_expansion(difference, x))
+	}
+	return expansion_sum(square(p.X, c.X), square(p.Y, c.Y))
+}
+
+// incircle_exact evaluates the incircle determinant without any rounding error and
+// returns the most significant component of the result. It expands the 4x4 lifted
+// determinant along the lifted column so no coordinate differences need to be formed.
+func incircle_exact(a, b, c, d Point) float64 {
+	ab := two_two_diff(a.X, b.Y, b.X, a.Y)
+	bc := two_two_diff(b.X, c.Y, c.X, b.Y)
+	cd := two_two_diff(c.X, d.Y, d.X, c.Y)
+	da := two_two_diff(d.X, a.Y, a.X, d.Y)
+	ac := two_two_diff(a.X, c.Y, c.X, a.Y)
+	bd := two_two_diff(b.X, d.Y, d.X, b.Y)
+
+	// Each of these is the orientation of three of the four Points.
+	bcd := expansion_sum(expansion_sum(bc, cd), negate_expansion(bd))
+	cda := expansion_sum(expansion_sum(cd, da), ac)
+	dab := expansion_sum(expansion_sum(da, ab), bd)
+	abc := expansion_sum(expansion_sum(ab, bc), negate_expansion(ac))
+
+	a_det := lifted_term(bcd, a)
+	b_det := negate_expansion(lifted_term(cda, b))
+	c_det := lifted_term(dab, c)
+	d_det := negate_expansion(lifted_term(abc, d))
+
+	return most_significant(expansion_sum(expansion_sum(a_det, b_det), expansion_sum(c_det, d_det)))
+}
+
+// lifted_term multiplies the expansion `e` by p.X² + p.Y² exactly.
+func lifted_term(e []float64, p Point) []float64 {
+	x_term := scale_expansion(scale_expansion(e, p.X), p.X)
+	y_term := scale_expansion(scale_expansion(e, p.Y), p.Y)
+	return expansion_sum(x_term, y_term)
+}
+
+// two_sum computes the sum of `a` and `b` as the rounded result `x` and the rounding
+// error `y`, such that a + b = x + y exactly.
+func two_sum(a, b float64) (x, y float64) {
+	x = a + b
+	b_virtual := x - a
+	a_virtual := x - b_virtual
+	b_roundoff := b - b_virtual
+	a_roundoff := a - a_virtual
+	y = a_roundoff + b_roundoff
+	return x, y
+}
+
+// two_diff_tail returns the rounding error of x = a - b.
+func two_diff_tail(a, b, x float64) float64 {
+	b_virtual := a - x
+	a_virtual := x + b_virtual
+	b_roundoff := b_virtual - b
+	a_roundoff := a - a_virtual
+	return a_roundoff + b_roundoff
+}
+
+// two_product computes the product of `a` and `b` as the rounded result `x` and the
+// rounding error `y`, such that a * b = x + y exactly.
+func two_product(a, b float64) (x, y float64) {
+	x = a * b
+	y = math.FMA(a, b, -x)
+	return x, y
+}
+
+// two_two_diff computes a*b - c*d exactly as an expansion.
+func two_two_diff(a, b, c, d float64) []float64 {
+	ab, ab_err := two_product(a, b)
+	cd, cd_err := two_product(c, d)
+	return expansion_sum([]float64{ab_err, ab}, []float64{-cd_err, -cd})
+}
+
+// An expansion is a sum of non-overlapping float64 components stored in increasing
+// order of magnitude. Zero components are removed wherever they are produced.
+
+// expansion_sum adds the expansions `e` and `f`. It adds each component of `f` to `e`
+// in turn, carrying it up through the components of `e` with two_sum, within a single
+// slice, so that the exact stages allocate once per sum rather than once per component.
+func expansion_sum(e, f []float64) []float64 {
+	if len(f) == 0 {
+		return e
+	}
+	h := make([]float64, len(e), len(e)+len(f))
+	copy(h, e)
+	for _, f_i := range f {
+		q, k := f_i, 0
+		for _, h_i := range h {
+			var small float64
+			q, small = two_sum(q, h_i)
+			if small != 0 {
+				h[k] = small
+				k++
+			}
+		}
+		h = h[:k]
+		if q != 0 || k == 0 {
+			h = append(h, q)
+		}
+	}
+	return h
+}
+
+// scale_expansion multiplies the expansion `e` by the scalar `b`.
+func scale_expansion(e []float64, b float64) []float64 {
+	h := make([]float64, 0, 2*len(e))
+	if len(e) == 0 {
+		return h
+	}
+	q, h_i := two_product(e[0], b)
+	if h_i != 0 {
+		h = append(h, h_i)
+	}
+	for _, e_i := range e[1:] {
+		product, product_err := two_product(e_i, b)
+		var sum float64
+		sum, h_i = two_sum(q, product_err)
+		if h_i != 0 {
+			h = append(h, h_i)
+		}
+		q, h_i = two_sum(product, sum)
+		if h_i != 0 {
+			h = append(h, h_i)
+		}
+	}
+	if q != 0 || len(h) == 0 {
+		h = append(h, q)
+	}
+	return h
+}
+
+// negate_expansion returns a copy of `e` with every component negated.
+func negate_expansion(e []float64) []float64 {
+	h := make([]float64, len(e))
+	for i, e_i := range e {
+		h[i] = -e_i
+	}
+	return h
+}
+
+// estimate approximates the value of an expansion by summing its components.
+func estimate(e []float64) float64 {
+	sum := 0.0
+	for _, e_i := range e {
+		sum += e_i
+	}
+	return sum
+}
+
+// most_significant returns the largest component of an expansion, which has the same
+// sign as the value of the expansion.
+func most_significant(e []float64) float64 {
+	for i := len(e) - 1; i >= 0; i-- {
+		if e[i] != 0 {
+			return e[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,433 @@
+package gogeo
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// exact_orient_sign computes the sign of Orient2D with rational arithmetic. It is used
+// as an oracle for the adaptive predicates.
+func exact_orient_sign(a, b, c Point) int {
+	r := func(f float64) *big.Rat { return new(big.Rat).SetFloat64(f) }
+	acx := new(big.Rat).Sub(r(a.X), r(c.X))
+	bcy := new(big.Rat).Sub(r(b.Y), r(c.Y))
+	acy := new(big.Rat).Sub(r(a.Y), r(c.Y))
+	bcx := new(big.Rat).Sub(r(b.X), r(c.X))
+	left := new(big.Rat).Mul(acx, bcy)
+	right := new(big.Rat).Mul(acy, bcx)
+	return left.Cmp(right)
+}
+
+// exact_incircle_sign computes the sign of InCircle with rational arithmetic.
+func exact_incircle_sign(a, b, c, d Point) int {
+	r := func(f float64) *big.Rat { return new(big.Rat).SetFloat64(f) }
+	sub := func(x, y float64) *big.Rat { return new(big.Rat).Sub(r(x), r(y)) }
+	mul := func(x, y *big.Rat) *big.Rat { return new(big.Rat).Mul(x, y) }
+	add := func(x, y *big.Rat) *big.Rat { return new(big.Rat).Add(x, y) }
+	adx, ady := sub(a.X, d.X), sub(a.Y, d.Y)
+	bdx, bdy := sub(b.X, d.X), sub(b.Y, d.Y)
+	cdx, cdy := sub(c.X, d.X), sub(c.Y, d.Y)
+	a_lift := add(mul(adx, adx), mul(ady, ady))
+	b_lift := add(mul(bdx, bdx), mul(bdy, bdy))
+	c_lift := add(mul(cdx, cdx), mul(cdy, cdy))
+	det := mul(a_lift, new(big.Rat).Sub(mul(bdx, cdy), mul(cdx, bdy)))
+	det = add(det, mul(b_lift, new(big.Rat).Sub(mul(cdx, ady), mul(adx, cdy))))
+	det = add(det, mul(c_lift, new(big.Rat).Sub(mul(adx, bdy), mul(bdx, ady))))
+	return det.Sign()
+}
+
+// exact_intersects is the same test as LineSegment.Intersects, evaluated with rational
+// arithmetic.
+func exact_intersects(l1, l2 LineSegment) bool {
+	o1 := exact_orient_sign(l1.P1, l1.P2, l2.P1)
+	o2 := exact_orient_sign(l1.P1, l1.P2, l2.P2)
+	o3 := exact_orient_sign(l2.P1, l2.P2, l1.P1)
+	o4 := exact_orient_sign(l2.P1, l2.P2, l1.P2)
+	if (o1 != o2) && (o3 != o4) {
+		return true
+	}
+	return (o1 == 0 && l1.collinear_point_within(l2.P1)) ||
+		(o2 == 0 && l1.collinear_point_within(l2.P2)) ||
+		(o3 == 0 && l2.collinear_point_within(l1.P1)) ||
+		(o4 == 0 && l2.collinear_point_within(l1.P2))
+}
+
+// nudge moves `x` by `n` ulps.
+func nudge(x float64, n int) float64 {
+	for ; n > 0; n-- {
+		x = math.Nextafter(x, math.Inf(1))
+	}
+	for ; n < 0; n++ {
+		x = math.Nextafter(x, math.Inf(-1))
+	}
+	return x
+}
+
+func TestOrient2D(t *testing.T) {
+	testCases := []struct {
+		desc string
+		a    Point
+		b    Point
+		c    Point
+		out  int
+	}{
+		{
+			desc: "counter-clockwise",
+			a:    Point{0, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			out:  1,
+		},
+		{
+			desc: "clockwise",
+			a:    Point{0, 0},
+			b:    Point{0, 1},
+			c:    Point{1, 0},
+			out:  -1,
+		},
+		{
+			desc: "collinear",
+			a:    Point{0, 0},
+			b:    Point{1, 1},
+			c:    Point{2, 2},
+			out:  0,
+		},
+		{
+			desc: "collinear with decimal coordinates",
+			a:    Point{0.1, 0.1},
+			b:    Point{0.2, 0.2},
+			c:    Point{0.3, 0.3},
+			out:  0,
+		},
+		{
+			desc: "one ulp above the line y = x",
+			a:    Point{0.5, nudge(0.5, 1)},
+			b:    Point{12, 12},
+			c:    Point{24, 24},
+			out:  1,
+		},
+		{
+			desc: "one ulp below the line y = x",
+			a:    Point{0.5, nudge(0.5, -1)},
+			b:    Point{12, 12},
+			c:    Point{24, 24},
+			out:  -1,
+		},
+		{
+			desc: "repeated point",
+			a:    Point{3.7, -1.2},
+			b:    Point{3.7, -1.2},
+			c:    Point{8, 9},
+			out:  0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := sign(Orient2D(tC.a, tC.b, tC.c)); got != tC.out {
+				t.Errorf("Orient2D() sign = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestOrient2DNearDegenerateGrid(t *testing.T) {
+	// Shewchuk's classic failure case for the naive determinant: a grid of Points a few
+	// ulps around (0.5, 0.5), tested against the line through (12, 12) and (24, 24).
+	b := Point{12, 12}
+	c := Point{24, 24}
+	for i := -32; i <= 32; i++ {
+		for j := -32; j <= 32; j++ {
+			a := Point{nudge(0.5, i), nudge(0.5, j)}
+			want := exact_orient_sign(a, b, c)
+			if got := sign(Orient2D(a, b, c)); got != want {
+				t.Fatalf("Orient2D(%v, %v, %v) sign = %v, want %v", a, b, c, got, want)
+			}
+		}
+	}
+}
+
+func TestOrient2DPermutations(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		// Points close to a common line, so the filter fails often.
+		a := Point{rng.Float64(), rng.Float64()}
+		d := Point{rng.Float64() - 0.5, rng.Float64() - 0.5}
+		b := a.Plus(d.Times(float64(rng.Intn(1000))))
+		c := a.Plus(d.Times(rng.Float64() * 1000))
+		want := exact_orient_sign(a, b, c)
+		orientations := []int{
+			sign(Orient2D(a, b, c)), sign(Orient2D(b, c, a)), sign(Orient2D(c, a, b)),
+			-sign(Orient2D(b, a, c)), -sign(Orient2D(a, c, b)), -sign(Orient2D(c, b, a)),
+		}
+		for _, got := range orientations {
+			if got != want {
+				t.Fatalf("Orient2D(%v, %v, %v) sign = %v, want %v", a, b, c, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkOrient2D(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		a    Point
+		b    Point
+		c    Point
+	}{
+		{"well conditioned", Point{0, 0}, Point{1, 0}, Point{0, 1}},
+		{"exactly collinear", Point{0.1, 0.1}, Point{0.2, 0.2}, Point{0.3, 0.3}},
+		{"one ulp off the line", Point{0.5, nudge(0.5, 1)}, Point{12, 12}, Point{24, 24}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Orient2D(bm.a, bm.b, bm.c)
+			}
+
+		})
+	}
+}
+
+func TestInCircle(t *testing.T) {
+	testCases := []struct {
+		desc string
+		a    Point
+		b    Point
+		c    Point
+		d    Point
+		out  int
+	}{
+		{
+			desc: "inside",
+			a:    Point{0, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			d:    Point{0.25, 0.25},
+			out:  1,
+		},
+		{
+			desc: "outside",
+			a:    Point{0, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			d:    Point{2, 2},
+			out:  -1,
+		},
+		{
+			desc: "on the circle",
+			a:    Point{0, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			d:    Point{1, 1},
+			out:  0,
+		},
+		{
+			desc: "clockwise triangle reverses the sign",
+			a:    Point{0, 0},
+			b:    Point{0, 1},
+			c:    Point{1, 0},
+			d:    Point{0.25, 0.25},
+			out:  -1,
+		},
+		{
+			desc: "one ulp inside the circle",
+			a:    Point{-1, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			d:    Point{0, nudge(-1, 1)},
+			out:  1,
+		},
+		{
+			desc: "one ulp outside the circle",
+			a:    Point{-1, 0},
+			b:    Point{1, 0},
+			c:    Point{0, 1},
+			d:    Point{0, nudge(-1, -1)},
+			out:  -1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := sign(InCircle(tC.a, tC.b, tC.c, tC.d)); got != tC.out {
+				t.Errorf("InCircle() sign = %v, want %v", got, tC.out)
+			}
+		})
+	}
+}
+
+func TestInCircleNearDegenerate(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 5000; i++ {
+		// Four Points on a circle, with the last nudged by a few ulps.
+		center := Point{rng.Float64() * 10, rng.Float64() * 10}
+		radius := rng.Float64()*5 + 0.1
+		on_circle := func() Point {
+			return center.Plus(Point{radius, 0}.Rotate(rng.Float64() * 2 * math.Pi))
+		}
+		a, b, c, d := on_circle(), on_circle(), on_circle(), on_circle()
+		d = Point{nudge(d.X, rng.Intn(5)-2), nudge(d.Y, rng.Intn(5)-2)}
+		want := exact_incircle_sign(a, b, c, d)
+		if got := sign(InCircle(a, b, c, d)); got != want {
+			t.Fatalf("InCircle(%v, %v, %v, %v) sign = %v, want %v", a, b, c, d, got, want)
+		}
+	}
+}
+
+// exact_distance_order compares the distances of `p` and `q` from `c` with rational
+// arithmetic.
+func exact_distance_order(c, p, q Point) int {
+	r := func(f float64) *big.Rat { return new(big.Rat).SetFloat64(f) }
+	squared := func(p Point) *big.Rat {
+		dx := new(big.Rat).Sub(r(p.X), r(c.X))
+		dy := new(big.Rat).Sub(r(p.Y), r(c.Y))
+		return new(big.Rat).Add(new(big.Rat).Mul(dx, dx), new(big.Rat).Mul(dy, dy))
+	}
+	return squared(p).Cmp(squared(q))
+}
+
+func TestCompareDistancesNearDegenerate(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	for i := 0; i < 5000; i++ {
+		// Two Points the same distance from the center, with the second nudged by a few
+		// ulps.
+		c := Point{rng.Float64() * 10, rng.Float64() * 10}
+		p := c.Plus(Point{rng.Float64()*5 + 0.1, 0}.Rotate(rng.Float64() * 2 * math.Pi))
+		q := Point{2*c.X - p.X, 2*c.Y - p.Y}
+		q = Point{nudge(q.X, rng.Intn(5)-2), nudge(q.Y, rng.Intn(5)-2)}
+		want := exact_distance_order(c, p, q)
+		if got := compare_distances(c, p, q); got != want {
+			t.Fatalf("compare_distances(%v, %v, %v) = %v, want %v", c, p, q, got, want)
+		}
+	}
+}
+
+// expansion_value sums the components of an expansion with rational arithmetic.
+func expansion_value(e []float64) *big.Rat {
+	sum := new(big.Rat)
+	for _, e_i := range e {
+		sum.Add(sum, new(big.Rat).SetFloat64(e_i))
+	}
+	return sum
+}
+
+// lowest_bit returns the value of the least significant set bit of `x`, which must not
+// be zero.
+func lowest_bit(x float64) float64 {
+	fraction, exponent := math.Frexp(math.Abs(x))
+	mantissa := uint64(math.Ldexp(fraction, 53))
+	return math.Ldexp(1, exponent-53+bits.TrailingZeros64(mantissa))
+}
+
+// check_expansion verifies that `e` is an expansion: its components are non-overlapping
+// and in increasing order of magnitude, and none of them is zero, unless it is the only
+// one.
+func check_expansion(t *testing.T, name string, e []float64) {
+	t.Helper()
+	if len(e) == 0 {
+		t.Fatalf("%v = %v has no components", name, e)
+	}
+	for i, e_i := range e {
+		if (e_i == 0) && (len(e) > 1) {
+			t.Fatalf("%v = %v has a zero component", name, e)
+		}
+		if (i > 0) && (math.Abs(e[i-1]) >= lowest_bit(e_i)) {
+			t.Fatalf("%v = %v has overlapping components %v and %v", name, e, e[i-1], e_i)
+		}
+	}
+}
+
+// random_expansion builds an expansion from components with widely spread exponents, so
+// that it has many components left after rounding.
+func random_expansion(rng *rand.Rand) []float64 {
+	e := []float64{0}
+	for i := rng.Intn(8); i >= 0; i-- {
+		e = expansion_sum(e, []float64{math.Ldexp(rng.Float64()-0.5, rng.Intn(120)-60)})
+	}
+	return e
+}
+
+func TestExpansionSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(21))
+	for i := 0; i < 2000; i++ {
+		e, f := random_expansion(rng), random_expansion(rng)
+		if i%10 == 0 {
+			// Sums that cancel out, entirely or in their largest components.
+			f = negate_expansion(e)
+			if i%20 == 0 {
+				f = expansion_sum(f, []float64{math.Ldexp(1, -70)})
+			}
+		}
+		e_value, f_value := expansion_value(e), expansion_value(f)
+		e_copy := append([]float64(nil), e...)
+
+		sum := expansion_sum(e, f)
+		check_expansion(t, "expansion_sum()", sum)
+		if want := new(big.Rat).Add(e_value, f_value); expansion_value(sum).Cmp(want) != 0 {
+			t.Fatalf("expansion_sum(%v, %v) = %v, want %v", e, f, sum, want)
+		}
+		for k := range e {
+			if e[k] != e_copy[k] {
+				t.Fatalf("expansion_sum() changed its argument %v to %v", e_copy, e)
+			}
+		}
+	}
+
+	// The sum is built in a single slice, however many components `f` has.
+	e, f := []float64{math.Ldexp(1, -80), 1}, []float64{math.Ldexp(3, -100), math.Ldexp(1, -40), 1e10}
+	if allocs := testing.AllocsPerRun(100, func() { expansion_sum(e, f) }); allocs > 1 {
+		t.Errorf("expansion_sum() made %v allocations, want 1", allocs)
+	}
+}
+
+func BenchmarkInCircle(b *testing.B) {
+	benchmarks := []struct {
+		desc string
+		a    Point
+		b    Point
+		c    Point
+		d    Point
+	}{
+		{"well conditioned", Point{0, 0}, Point{1, 0}, Point{0, 1}, Point{0.25, 0.25}},
+		{"cocircular", Point{0, 0}, Point{1, 0}, Point{0, 1}, Point{1, 1}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				InCircle(bm.a, bm.b, bm.c, bm.d)
+			}
+
+		})
+	}
+}
+
+func TestLineSegmentIntersectsNearDegenerate(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 10000; i++ {
+		// The second segment has an endpoint within a few ulps of the first segment.
+		l1 := LineSegment{
+			Point{rng.Float64(), rng.Float64()},
+			Point{rng.Float64() * 100, rng.Float64() * 100},
+		}
+		on_l1 := l1.P1.Plus(l1.P2.Minus(l1.P1).Times(rng.Float64()))
+		near := Point{nudge(on_l1.X, rng.Intn(9)-4), nudge(on_l1.Y, rng.Intn(9)-4)}
+		l2 := LineSegment{near, Point{rng.Float64() * 100, rng.Float64() * 100}}
+
+		want := exact_intersects(l1, l2)
+		answers := []bool{
+			l1.Intersects(l2),
+			l2.Intersects(l1),
+			LineSegment{l1.P2, l1.P1}.Intersects(l2),
+			l1.Intersects(LineSegment{l2.P2, l2.P1}),
+		}
+		for _, got := range answers {
+			if got != want {
+				t.Fatalf("Intersects(%v, %v) = %v, want %v", l1, l2, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package gogeo
+
+import (
+	"fmt"
+	"math"
+)
+
+// Projection converts between positions on the Earth and Points in a plane, so that
+// the planar algorithms, such as LineSegment.Intersects, can be applied to geographic
+// data. Points are in meters, with X to the east and Y to the north, so orientation is
+// kept: counter-clockwise rings stay counter-clockwise. Project and Unproject are
+// inverses of each other within the area that a Projection is meant for.
+type Projection interface {
+	// Project returns the Point in the plane for a LatLng.
+	Project(l LatLng) Point
+	// Unproject returns the LatLng for a Point in the plane.
+	Unproject(p Point) LatLng
+}
+
+// MaxWebMercatorLatitude is the latitude at which the WebMercator plane ends, making
+// it square. LatLngs further north or south are projected as if they were at this
+// latitude.
+var MaxWebMercatorLatitude = degrees(math.Atan(math.Sinh(math.Pi)))
+
+// WebMercator is the spherical Mercator projection used by web maps, EPSG:3857. It
+// treats the WGS84 Ellipsoid as a sphere with the equatorial radius, so it is conformal
+// only to within 0.7%, and distances are stretched by 1/cos(latitude). Use it to place
+// data on web map tiles, rather than to measure.
+type WebMercator struct{}
+
+// Project returns the WebMercator Point for a LatLng.
+func (WebMercator) Project(l LatLng) Point {
+	lat := math.Max(-MaxWebMercatorLatitude, math.Min(MaxWebMercatorLatitude, l.Lat))
+	return Point{
+		WGS84.A * radians(wrap_degrees(l.Lng)),
+		WGS84.A * math.Log(math.Tan(math.Pi/4+radians(lat)/2)),
+	}
+}
+
+// Unproject returns the LatLng for a WebMercator Point.
+func (WebMercator) Unproject(p Point) LatLng {
+	return LatLng{
+		degrees(2*math.Atan(math.Exp(p.Y/WGS84.A)) - math.Pi/2),
+		wrap_degrees(degrees(p.X / WGS84.A)),
+	}
+}
+
+// Equirectangular is a local projection that scales latitude and longitude linearly,
+// around an Origin that is projected to {0, 0}. Longitudes are shrunk by the cosine of
+// the latitude of the Origin, so that distances near it are correct in every direction
+// on a sphere with a radius of EarthRadius. It is fast, and accurate to about 0.5% for
+// distances within a few tens of kilometers of the Origin, away from the poles.
+type Equirectangular struct {
+	Origin LatLng
+}
+
+// Project returns the Equirectangular Point for a LatLng.
+func (e Equirectangular) Project(l LatLng) Point {
+	return Point{
+		EarthRadius * radians(wrap_degrees(l.Lng-e.Origin.Lng)) * math.Cos(radians(e.Origin.Lat)),
+		EarthRadius * radians(l.Lat-e.Origin.Lat),
+	}
+}
+
+// Unproject returns the LatLng for an Equirectangular Point.
+func (e Equirectangular) Unproject(p Point) LatLng {
+	return LatLng{
+		e.Origin.Lat + degrees(p.Y/EarthRadius),
+		wrap_degrees(e.Origin.Lng + degrees(p.X/(EarthRadius*math.Cos(radians(e.Origin.Lat))))),
+	}
+}
+
+// ENU projects onto the plane that touches the Ellipsoid at the Origin, with X to the
+// east and Y to the north of it, as in East-North-Up coordinates. Each LatLng is moved
+// straight down onto the plane, so the height above it, Up, is dropped. Distances from
+// the Origin are kept in every direction, except that a distance d comes out about
+// d³/6R² short, where R is the radius of the Earth: 4 millimeters in 10 kilometers. A
+// zero Ellipsoid uses WGS84. Points further than the horizon from the Origin unproject
+// to NaN.
+type ENU struct {
+	Origin    LatLng
+	Ellipsoid Ellipsoid
+}
+
+// Project returns the ENU Point for a LatLng.
+func (e ENU) Project(l LatLng) Point {
+	ellipsoid := e.ellipsoid()
+	east, north, _ := e.axes()
+	d := ellipsoid.cartesian(l).minus(ellipsoid.cartesian(e.Origin))
+	return Point{d.dot(east), d.dot(north)}
+}
+
+// Unproject returns the LatLng for an ENU Point, on the side of the Ellipsoid facing the
+// plane.
+func (e ENU) Unproject(p Point) LatLng {
+	ellipsoid := e.ellipsoid()
+	a, b := ellipsoid.A, ellipsoid.A*(1-ellipsoid.F)
+	east, north, up := e.axes()
+	on_plane := ellipsoid.cartesian(e.Origin).plus(east.times(p.X)).plus(north.times(p.Y))
+
+	// Solve for the t that puts on_plane + t up on the Ellipsoid, (x² + y²)/a² + z²/b² = 1,
+	// taking the root nearest the plane in a way that is accurate when it is small.
+	qa := (up.x*up.x+up.y*up.y)/(a*a) + up.z*up.z/(b*b)
+	qb := 2 * ((on_plane.x*up.x+on_plane.y*up.y)/(a*a) + on_plane.z*up.z/(b*b))
+	qc := (on_plane.x*on_plane.x+on_plane.y*on_plane.y)/(a*a) + on_plane.z*on_plane.z/(b*b) - 1
+	discriminant := qb*qb - 4*qa*qc
+	if discriminant < 0 {
+		return LatLng{math.NaN(), math.NaN()}
+	}
+	t := 2 * qc / (-qb - math.Sqrt(discriminant))
+	return ellipsoid.geodetic(on_plane.plus(up.times(t)))
+}
+
+// ellipsoid returns the Ellipsoid, using WGS84 if it is zero.
+func (e ENU) ellipsoid() Ellipsoid {
+	if e.Ellipsoid == (Ellipsoid{}) {
+		return WGS84
+	}
+	return e.Ellipsoid
+}
+
+// axes returns the unit vectors pointing east, north and up at the Origin.
+func (e ENU) axes() (vector3, vector3, vector3) {
+	slat, clat := math.Sincos(radians(e.Origin.Lat))
+	slng, clng := math.Sincos(radians(e.Origin.Lng))
+	east := vector3{-slng, clng, 0}
+	north := vector3{-slat * clng, -slat * slng, clat}
+	up := vector3{clat * clng, clat * slng, slat}
+	return east, north, up
+}
+
+// vector3 is a vector in Earth-centered, Earth-fixed coordinates, with x towards
+// latitude and longitude 0, y towards longitude 90 on the equator, and z towards the
+// north pole.
+type vector3 struct {
+	x, y, z float64
+}
+
+func (v vector3) plus(w vector3) vector3  { return vector3{v.x + w.x, v.y + w.y, v.z + w.z} }
+func (v vector3) minus(w vector3) vector3 { return vector3{v.x - w.x, v.y - w.y, v.z - w.z} }
+func (v vector3) times(f float64) vector3 { return vector3{f * v.x, f * v.y, f * v.z} }
+func (v vector3) dot(w vector3) float64   { return v.x*w.x + v.y*w.y + v.z*w.z }
+
+// cartesian returns the Earth-centered, Earth-fixed position of a LatLng on the surface
+// of the Ellipsoid.
+func (e Ellipsoid) cartesian(l LatLng) vector3 {
+	e_sq := e.F * (2 - e.F)
+	slat, clat := math.Sincos(radians(l.Lat))
+	slng, clng := math.Sincos(radians(l.Lng))
+	// The radius of curvature in the prime vertical.
+	n := e.A / math.Sqrt(1-e_sq*slat*slat)
+	return vector3{n * clat * clng, n * clat * slng, n * (1 - e_sq) * slat}
+}
+
+// geodetic returns the LatLng of an Earth-centered, Earth-fixed position on the surface
+// of the Ellipsoid.
+func (e Ellipsoid) geodetic(v vector3) LatLng {
+	e_sq := e.F * (2 - e.F)
+	// On the surface, z / p = (1 - e²) tan(latitude), where p is the distance from the
+	// polar axis.
+	lat := math.Atan2(v.z, (1-e_sq)*math.Hypot(v.x, v.y))
+	return LatLng{degrees(lat), wrap_degrees(degrees(math.Atan2(v.y, v.x)))}
+}
+
+// ProjectShape projects every vertex of a shape, whose Points hold a longitude in X and
+// a latitude in Y, as in GeoJSON and Well-Known Text, and returns the same type of shape
+// in the plane. It returns ErrUnsupportedShape for types of shape it does not know.
+func ProjectShape(projection Projection, shape Bounded) (Bounded, error) {
+	return map_shape(shape, func(p Point) Point {
+		return projection.Project(LatLng{p.Y, p.X})
+	})
+}
+
+// UnprojectShape is the inverse of ProjectShape, returning a shape whose Points hold a
+// longitude in X and a latitude in Y.
+func UnprojectShape(projection Projection, shape Bounded) (Bounded, error) {
+	return map_shape(shape, func(p Point) Point {
+		l := projection.Unproject(p)
+		return Point{l.Lng, l.Lat}
+	})
+}
+
+// map_shape returns a copy of a shape with `f` applied to every vertex. Empty Points are
+// kept empty.
+func map_shape(shape Bounded, f func(Point) Point) (Bounded, error) {
+	vertex := func(p Point) Point {
+		if math.IsNaN(p.X) && math.IsNaN(p.Y) {
+			return p
+		}
+		return f(p)
+	}
+	points := func(ps []Point) []Point {
+		if ps == nil {
+			return nil
+		}
+		mapped := make([]Point, len(ps))
+		for i, p := range ps {
+			mapped[i] = vertex(p)
+		}
+		return mapped
+	}
+	polygon := func(p PolygonWithHoles) PolygonWithHoles {
+		mapped := PolygonWithHoles{Shell: Polygon{points(p.Shell.Points)}}
+		for _, hole := range p.Holes {
+			mapped.Holes = append(mapped.Holes, Polygon{points(hole.Points)})
+		}
+		return mapped
+	}
+
+	switch s := shape.(type) {
+	case Point:
+		return vertex(s), nil
+	case LineSegment:
+		return LineSegment{vertex(s.P1), vertex(s.P2)}, nil
+	case Polyline:
+		return Polyline{points(s.Points)}, nil
+	case Triangle:
+		return Triangle{vertex(s.P1), vertex(s.P2), vertex(s.P3)}, nil
+	case Polygon:
+		return Polygon{points(s.Points)}, nil
+	case PolygonWithHoles:
+		return polygon(s), nil
+	case MultiPoint:
+		return MultiPoint{points(s.Points)}, nil
+	case MultiPolyline:
+		var mapped MultiPolyline
+		for _, l := range s.Polylines {
+			mapped.Polylines = append(mapped.Polylines, Polyline{points(l.Points)})
+		}
+		return mapped, nil
+	case MultiPolygon:
+		var mapped MultiPolygon
+		for _, p := range s.Polygons {
+			mapped.Polygons = append(mapped.Polygons, polygon(p))
+		}
+		return mapped, nil
+	case GeometryCollection:
+		var mapped GeometryCollection
+		for _, g := range s.Geometries {
+			m, err := map_shape(g, f)
+			if err != nil {
+				return nil, err
+			}
+			mapped.Geometries = append(mapped.Geometries, m)
+		}
+		return mapped, nil
+	default:
+		return nil, fmt.Errorf("%T: %w", shape, ErrUnsupportedShape)
+	}
+}
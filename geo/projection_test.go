@@ -0,0 +1,218 @@
+package gogeo
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestWebMercator(t *testing.T) {
+	edge := math.Pi * WGS84.A
+	testCases := []struct {
+		desc string
+		in   LatLng
+		want Point
+	}{
+		{"Origin", LatLng{0, 0}, Point{0, 0}},
+		{"Quarter of the way around", LatLng{0, 90}, Point{edge / 2, 0}},
+		{"Antimeridian", LatLng{0, 180}, Point{-edge, 0}},
+		{"Top left corner", LatLng{MaxWebMercatorLatitude, -180}, Point{-edge, edge}},
+		{"Clamped near the north pole", LatLng{89, 45}, Point{edge / 4, edge}},
+		{"Clamped at the south pole", LatLng{-90, -45}, Point{-edge / 4, -edge}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := WebMercator{}.Project(tC.in)
+			if (math.Abs(got.X-tC.want.X) > 1e-6) || (math.Abs(got.Y-tC.want.Y) > 1e-6) {
+				t.Errorf("Project(%v) = %v, want %v", tC.in, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestProjectionRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(36))
+	wellington, _ := UTMFor(LatLng{-41.29, 174.78})
+	testCases := []struct {
+		desc       string
+		projection Projection
+		// near returns a random LatLng where the projection is meant to be used.
+		near func() LatLng
+	}{
+		{
+			desc:       "Web Mercator",
+			projection: WebMercator{},
+			near: func() LatLng {
+				return LatLng{170*rng.Float64() - 85, 360*rng.Float64() - 180}
+			},
+		},
+		{
+			desc:       "Equirectangular",
+			projection: Equirectangular{LatLng{-33.86, 151.21}},
+			near: func() LatLng {
+				return LatLng{-33.86 + rng.NormFloat64(), 151.21 + rng.NormFloat64()}
+			},
+		},
+		{
+			desc:       "Equirectangular across the antimeridian",
+			projection: Equirectangular{LatLng{-17.7, 179.9}},
+			near: func() LatLng {
+				return LatLng{-17.7 + rng.NormFloat64(), 179.9 + rng.NormFloat64()}.Normalize()
+			},
+		},
+		{
+			desc:       "ENU",
+			projection: ENU{Origin: LatLng{46.55, 7.98}},
+			near: func() LatLng {
+				return LatLng{46.55 + rng.NormFloat64(), 7.98 + rng.NormFloat64()}
+			},
+		},
+		{
+			desc:       "ENU at the north pole",
+			projection: ENU{Origin: LatLng{90, 0}},
+			near: func() LatLng {
+				return LatLng{90 - 5*rng.Float64(), 360*rng.Float64() - 180}
+			},
+		},
+		{
+			desc:       "ENU on a sphere",
+			projection: ENU{Origin: LatLng{-1, -78}, Ellipsoid: Ellipsoid{A: EarthRadius}},
+			near: func() LatLng {
+				return LatLng{-1 + 10*rng.NormFloat64(), -78 + 10*rng.NormFloat64()}
+			},
+		},
+		{
+			desc:       "UTM",
+			projection: wellington,
+			near: func() LatLng {
+				return LatLng{-41.29 + 5*rng.NormFloat64(), 174.78 + rng.NormFloat64()}.Normalize()
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for trial := 0; trial < 1000; trial++ {
+				l := tC.near()
+				p := tC.projection.Project(l)
+				got := tC.projection.Unproject(p)
+				// Longitude is not well defined at the poles.
+				if (math.Abs(got.Lat-l.Lat) > 1e-9) ||
+					((math.Abs(l.Lat) < 90) && (angle_difference(got.Lng, l.Lng)*math.Cos(radians(l.Lat)) > 1e-9)) {
+					t.Fatalf("Project(%v) = %v, which unprojects to %v", l, p, got)
+				}
+			}
+		})
+	}
+
+	if got := (ENU{Origin: LatLng{0, 0}}).Unproject(Point{0, 2 * WGS84.A}); !math.IsNaN(got.Lat) {
+		t.Errorf("Unproject() beyond the horizon = %v, want NaN", got)
+	}
+}
+
+func TestLocalProjectionDistances(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	origin := LatLng{51.48, -0.01}
+	equirectangular, enu := Equirectangular{origin}, ENU{Origin: origin}
+	for trial := 0; trial < 1000; trial++ {
+		l := LatLng{origin.Lat + 0.2*rng.NormFloat64(), origin.Lng + 0.2*rng.NormFloat64()}
+
+		want := origin.HaversineDistance(l)
+		if got := equirectangular.Project(l).Magnitude(); math.Abs(got-want) > 0.005*want {
+			t.Errorf("Equirectangular.Project(%v) is %v from the origin, want %v", l, got, want)
+		}
+
+		// ENU drops the curve of the Earth, and a little more in the direction the
+		// Ellipsoid is flatter.
+		want = WGS84.Inverse(origin, l).Distance
+		short := want * want * want / (5 * WGS84.A * WGS84.A)
+		if got := enu.Project(l).Magnitude(); (got > want+1e-6) || (got < want-short) {
+			t.Errorf("ENU.Project(%v) is %v from the origin, want %v", l, got, want)
+		}
+		// Bearings are kept, to within the convergence of the meridians.
+		p := enu.Project(l)
+		if got, want := bearing_degrees(math.Atan2(p.X, p.Y)), WGS84.Inverse(origin, l).InitialBearing; angle_difference(got, want) > 0.01 {
+			t.Errorf("ENU.Project(%v) = %v, at a bearing of %v, want %v", l, p, got, want)
+		}
+	}
+}
+
+func TestProjectShape(t *testing.T) {
+	projection, _ := UTMFor(LatLng{40.78, -73.97})
+	// Central Park, with a lake.
+	park := PolygonWithHoles{
+		Shell: Polygon{[]Point{{-73.9731, 40.7644}, {-73.9582, 40.8006}, {-73.9493, 40.7969}, {-73.9641, 40.7681}}},
+		Holes: []Polygon{{[]Point{{-73.963, 40.784}, {-73.958, 40.786}, {-73.961, 40.790}}}},
+	}
+	testCases := []struct {
+		desc  string
+		shape Bounded
+	}{
+		{"Point", Point{-73.97, 40.78}},
+		{"Empty Point", Point{math.NaN(), math.NaN()}},
+		{"LineSegment", LineSegment{Point{-73.97, 40.78}, Point{-73.96, 40.79}}},
+		{"Polyline", Polyline{park.Shell.Points}},
+		{"Triangle", Triangle{park.Holes[0].Points[0], park.Holes[0].Points[1], park.Holes[0].Points[2]}},
+		{"Polygon", park.Shell},
+		{"PolygonWithHoles", park},
+		{"MultiPoint", MultiPoint{park.Shell.Points}},
+		{"Empty MultiPoint", MultiPoint{}},
+		{"MultiPolyline", MultiPolyline{[]Polyline{{park.Shell.Points}, {park.Holes[0].Points}}}},
+		{"MultiPolygon", MultiPolygon{[]PolygonWithHoles{park, {Shell: park.Holes[0]}}}},
+		{"GeometryCollection", GeometryCollection{[]Bounded{Point{-73.97, 40.78}, park}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			projected, err := ProjectShape(projection, tC.shape)
+			if err != nil {
+				t.Fatalf("ProjectShape() returned %v", err)
+			}
+			if reflect.TypeOf(projected) != reflect.TypeOf(tC.shape) {
+				t.Fatalf("ProjectShape() returned a %T, want a %T", projected, tC.shape)
+			}
+			got, err := UnprojectShape(projection, projected)
+			if err != nil {
+				t.Fatalf("UnprojectShape() returned %v", err)
+			}
+			if want := shape_points(tC.shape); !reflect.DeepEqual(round_points(shape_points(got)), round_points(want)) {
+				t.Errorf("UnprojectShape(ProjectShape()) = %v, want %v", got, tC.shape)
+			}
+		})
+	}
+
+	// The park is still clockwise, with about the same area in any local projection.
+	projected, _ := ProjectShape(projection, park.Shell)
+	local, _ := ProjectShape(Equirectangular{LatLng{40.78, -73.97}}, park.Shell)
+	got, want := projected.(Polygon).SignedArea(), local.(Polygon).SignedArea()
+	if (want > 0) || (math.Abs(got-want) > 0.01*math.Abs(want)) {
+		t.Errorf("SignedArea() of the projected park = %v, want about %v", got, want)
+	}
+
+	if _, err := ProjectShape(projection, circle{Point{0, 0}, 1}); !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("ProjectShape() of a circle returned %v, want %v", err, ErrUnsupportedShape)
+	}
+}
+
+// shape_points returns every vertex of a shape, in order.
+func shape_points(shape Bounded) []Point {
+	var points []Point
+	map_shape(shape, func(p Point) Point {
+		points = append(points, p)
+		return p
+	})
+	return points
+}
+
+// round_points rounds the coordinates of Points to about a millimeter on the Earth, and
+// makes empty Points equal.
+func round_points(points []Point) []Point {
+	rounded := make([]Point, len(points))
+	for i, p := range points {
+		if math.IsNaN(p.X) {
+			continue
+		}
+		rounded[i] = Point{math.Round(p.X*1e8) / 1e8, math.Round(p.Y*1e8) / 1e8}
+	}
+	return rounded
+}
@@ -0,0 +1,392 @@
+package gogeo
+
+import (
+	"math"
+	"sort"
+)
+
+// QuadtreeSplit selects where a Quadtree splits a leaf that has too many Points.
+type QuadtreeSplit int
+
+const (
+	// SplitAtCenter splits a leaf into four equal quadrants, making a region quadtree.
+	// The tree covers a square that doubles in size whenever a Point is added outside
+	// it. Inserting and removing Points never moves the others.
+	SplitAtCenter QuadtreeSplit = iota
+	// SplitAtMedian splits a leaf at the median x and y of its Points, making a point
+	// quadtree. It adapts to clustered Points, but the shape of the tree depends on the
+	// order the Points are added in, so it suits Points added all at once by NewQuadtree.
+	SplitAtMedian
+)
+
+// Default QuadtreeOptions.
+const (
+	default_bucket_size = 8
+	default_max_depth   = 20
+)
+
+// QuadtreeOptions configure a Quadtree. The zero value splits at the center, with up to
+// 8 Points in each leaf and at most 20 levels.
+type QuadtreeOptions struct {
+	Split QuadtreeSplit
+	// BucketSize is the number of Points a leaf holds before it is split.
+	BucketSize int
+	// MaxDepth is the number of times the root may be split. Leaves at this depth hold
+	// any number of Points, so that many copies of a Point do not split forever.
+	MaxDepth int
+}
+
+// Quadtree is a spatial index of Points, which divides the plane into four quadrants,
+// and each quadrant into four more, wherever there are more Points than fit in a leaf.
+// Unlike an RTree, Points can be inserted and removed cheaply, which suits dense Points
+// that change often. Points must be finite, and the same Point may be added more than
+// once. Use NewQuadtree to create one.
+type Quadtree struct {
+	options QuadtreeOptions
+	root    *quad_node
+	// box is the region covered by the root, which includes its lower and left edges
+	// but not its upper and right edges. It is empty until the root is first split
+	// at its center, and is the whole plane when splitting at medians.
+	box  BoundingBox
+	size int
+}
+
+// quad_node is a node of a Quadtree. A leaf holds its Points, and any other node splits
+// its region into four children at `center`, in Z-order: south west, south east, north
+// west and north east.
+type quad_node struct {
+	points   []Point
+	center   Point
+	children *[4]*quad_node
+	// count is the number of Points beneath the node.
+	count int
+}
+
+// NewQuadtree builds a Quadtree holding the `points`. With SplitAtMedian, the Points
+// are split at medians of all of them, so the tree is balanced.
+func NewQuadtree(points []Point, options QuadtreeOptions) *Quadtree {
+	if options.BucketSize <= 0 {
+		options.BucketSize = default_bucket_size
+	}
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = default_max_depth
+	}
+	t := &Quadtree{options: options, root: &quad_node{}, box: Polygon{}.BoundingBox()}
+	if options.Split == SplitAtMedian {
+		t.box = BoundingBox{Point{math.Inf(-1), math.Inf(-1)}, Point{math.Inf(1), math.Inf(1)}}
+	}
+	t.root.points = append(t.root.points, points...)
+	t.root.count, t.size = len(points), len(points)
+	t.split_root()
+	return t
+}
+
+// Len returns the number of Points in the Quadtree.
+func (t *Quadtree) Len() int {
+	return t.size
+}
+
+// Insert adds a Point to the Quadtree, splitting the leaf it lands in if that is full.
+// It takes O(depth) time.
+func (t *Quadtree) Insert(p Point) {
+	t.size++
+	if t.box.IsEmpty() {
+		t.root.points = append(t.root.points, p)
+		t.root.count++
+		t.split_root()
+		return
+	}
+	t.cover(p)
+	t.root.insert(p, t.box, 0, t.options)
+}
+
+// split_root splits the root for the first time, once it has too many Points. With
+// SplitAtCenter, this fixes the region covered by the tree, as a square around the Points
+// in the root. Its side is a power of two, and its corner a multiple of that, so the
+// quadrants line up with round numbers.
+func (t *Quadtree) split_root() {
+	if t.root.count <= t.options.BucketSize {
+		return
+	}
+	if t.box.IsEmpty() {
+		bounds := Polyline{t.root.points}.BoundingBox()
+		side := 1.0
+		if extent := math.Max(bounds.Max.X-bounds.Min.X, bounds.Max.Y-bounds.Min.Y); extent > 0 {
+			side = math.Exp2(math.Ceil(math.Log2(extent)))
+		}
+		min := Point{math.Floor(bounds.Min.X/side) * side, math.Floor(bounds.Min.Y/side) * side}
+		t.box = BoundingBox{min, min.Plus(Point{side, side})}
+		for _, p := range t.root.points {
+			t.cover(p)
+		}
+	}
+	t.root.split(t.box, 0, t.options)
+}
+
+// cover doubles the region covered by the Quadtree until it includes `p`. The old root
+// becomes one quadrant of the new one.
+func (t *Quadtree) cover(p Point) {
+	for !half_open_contains(t.box, p) {
+		side := t.box.Max.X - t.box.Min.X
+		if math.IsInf(side, 0) {
+			return
+		}
+		var center Point
+		box := t.box
+		if p.X < box.Min.X {
+			center.X, box.Min.X = box.Min.X, box.Min.X-side
+		} else {
+			center.X, box.Max.X = box.Max.X, box.Max.X+side
+		}
+		if p.Y < box.Min.Y {
+			center.Y, box.Min.Y = box.Min.Y, box.Min.Y-side
+		} else {
+			center.Y, box.Max.Y = box.Max.Y, box.Max.Y+side
+		}
+		if t.root.children != nil {
+			root := &quad_node{center: center, children: &[4]*quad_node{{}, {}, {}, {}}, count: t.root.count}
+			root.children[quadrant(center, t.box.Min)] = t.root
+			t.root = root
+		}
+		t.box = box
+	}
+}
+
+// half_open_contains tests if `p` is in the BoundingBox `b`, including its lower and
+// left edges but not its upper and right edges.
+func half_open_contains(b BoundingBox, p Point) bool {
+	return (b.Min.X <= p.X) && (p.X < b.Max.X) && (b.Min.Y <= p.Y) && (p.Y < b.Max.Y)
+}
+
+// quadrant returns which child of a node split at `center` the Point `p` belongs to.
+// Points on the dividing lines go to the north and east.
+func quadrant(center, p Point) int {
+	q := 0
+	if p.X >= center.X {
+		q |= 1
+	}
+	if p.Y >= center.Y {
+		q |= 2
+	}
+	return q
+}
+
+// child_box returns the region of child `q` of a node covering `box` split at `center`.
+func child_box(box BoundingBox, center Point, q int) BoundingBox {
+	if q&1 == 0 {
+		box.Max.X = center.X
+	} else {
+		box.Min.X = center.X
+	}
+	if q&2 == 0 {
+		box.Max.Y = center.Y
+	} else {
+		box.Min.Y = center.Y
+	}
+	return box
+}
+
+// insert adds `p` beneath the node covering `box`, at `depth` below the root.
+func (n *quad_node) insert(p Point, box BoundingBox, depth int, options QuadtreeOptions) {
+	n.count++
+	if n.children == nil {
+		n.points = append(n.points, p)
+		n.split(box, depth, options)
+		return
+	}
+	q := quadrant(n.center, p)
+	n.children[q].insert(p, child_box(box, n.center, q), depth+1, options)
+}
+
+// split turns a leaf covering `box` with too many Points into a node with four children,
+// and splits those in turn, unless it is as deep as allowed.
+func (n *quad_node) split(box BoundingBox, depth int, options QuadtreeOptions) {
+	if (len(n.points) <= options.BucketSize) || (depth >= options.MaxDepth) {
+		return
+	}
+	if options.Split == SplitAtMedian {
+		n.center = median(n.points)
+	} else {
+		n.center = box.Min.Plus(box.Max).Divide(2)
+	}
+	n.children = &[4]*quad_node{{}, {}, {}, {}}
+	for _, p := range n.points {
+		child := n.children[quadrant(n.center, p)]
+		child.points = append(child.points, p)
+		child.count++
+	}
+	n.points = nil
+	for q, child := range n.children {
+		child.split(child_box(box, n.center, q), depth+1, options)
+	}
+}
+
+// median returns the Point with the median x and the median y of the `points`.
+func median(points []Point) Point {
+	xs, ys := make([]float64, len(points)), make([]float64, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = p.X, p.Y
+	}
+	sort.Float64s(xs)
+	sort.Float64s(ys)
+	return Point{xs[len(xs)/2], ys[len(ys)/2]}
+}
+
+// Remove removes one copy of the Point `p` from the Quadtree, returning whether it was
+// found. A node left with few enough Points to fit in a leaf becomes a leaf again. It
+// takes O(depth) time.
+func (t *Quadtree) Remove(p Point) bool {
+	if !t.root.remove(p, t.options) {
+		return false
+	}
+	t.size--
+	return true
+}
+
+// remove removes one copy of `p` from beneath the node.
+func (n *quad_node) remove(p Point, options QuadtreeOptions) bool {
+	if n.children == nil {
+		for i, q := range n.points {
+			if q.Equals(p) {
+				n.points = append(n.points[:i], n.points[i+1:]...)
+				n.count--
+				return true
+			}
+		}
+		return false
+	}
+	if !n.children[quadrant(n.center, p)].remove(p, options) {
+		return false
+	}
+	n.count--
+	if n.count <= options.BucketSize {
+		n.points = n.collect(nil)
+		n.children = nil
+	}
+	return true
+}
+
+// collect appends every Point beneath the node to `points`, in Z-order.
+func (n *quad_node) collect(points []Point) []Point {
+	if n.children == nil {
+		return append(points, n.points...)
+	}
+	for _, child := range n.children {
+		points = child.collect(points)
+	}
+	return points
+}
+
+// Range returns every Point of the Quadtree inside `box`, including on its edges, in
+// Z-order.
+func (t *Quadtree) Range(box BoundingBox) []Point {
+	var found []Point
+	t.root.search(t.box, box, func(p Point) bool {
+		return box.ContainsPoint(p)
+	}, &found)
+	return found
+}
+
+// Radius returns every Point of the Quadtree within `radius` of `center`, in Z-order.
+func (t *Quadtree) Radius(center Point, radius float64) []Point {
+	var found []Point
+	box := BoundingBox{center.Minus(Point{radius, radius}), center.Plus(Point{radius, radius})}
+	t.root.search(t.box, box, func(p Point) bool {
+		return p.Minus(center).Magnitude() <= radius
+	}, &found)
+	return found
+}
+
+// search adds the Points beneath the node, which covers `region`, that are inside `box`
+// and pass `keep` to `found`.
+func (n *quad_node) search(region, box BoundingBox, keep func(p Point) bool, found *[]Point) {
+	if n.children == nil {
+		for _, p := range n.points {
+			if keep(p) {
+				*found = append(*found, p)
+			}
+		}
+		return
+	}
+	for q, child := range n.children {
+		if r := child_box(region, n.center, q); (child.count > 0) && r.Intersects(box) {
+			child.search(r, box, keep, found)
+		}
+	}
+}
+
+// Nearest returns the Point of the Quadtree closest to `p`, and false if the Quadtree
+// is empty. The quadrants are searched nearest first, skipping any further away than
+// the closest Point found so far.
+func (t *Quadtree) Nearest(p Point) (Point, bool) {
+	var nearest Point
+	distance := math.Inf(1)
+	t.root.nearest(t.box, p, &nearest, &distance)
+	return nearest, t.size > 0
+}
+
+// nearest updates `nearest` and `distance` with the Points beneath the node, which
+// covers `region`.
+func (n *quad_node) nearest(region BoundingBox, p Point, nearest *Point, distance *float64) {
+	if n.children == nil {
+		for _, q := range n.points {
+			if d := q.Minus(p).Magnitude(); d < *distance {
+				*nearest, *distance = q, d
+			}
+		}
+		return
+	}
+	// Start with the quadrant holding `p`, then the two beside it, then the one opposite.
+	first := quadrant(n.center, p)
+	for _, q := range []int{first, first ^ 1, first ^ 2, first ^ 3} {
+		child, r := n.children[q], child_box(region, n.center, q)
+		if (child.count > 0) && (r.DistanceToPoint(p) < *distance) {
+			child.nearest(r, p, nearest, distance)
+		}
+	}
+}
+
+// QuadtreeIterator steps through the Points of a Quadtree in Z-order: the Points of each
+// quadrant come before those of the next, in the order south west, south east, north
+// west and north east, all the way down the tree. Points in the same leaf are visited
+// in the order they were added. Call Next to advance to each Point in turn, then Point
+// to get it. The Quadtree must not be changed while iterating over it.
+type QuadtreeIterator struct {
+	// stack holds the nodes still to visit, with the next on top.
+	stack []*quad_node
+	leaf  *quad_node
+	index int
+}
+
+// Iterator returns a QuadtreeIterator positioned before the first Point of the Quadtree.
+func (t *Quadtree) Iterator() *QuadtreeIterator {
+	return &QuadtreeIterator{stack: []*quad_node{t.root}}
+}
+
+// Next advances to the next Point. It returns false once there are no Points left.
+func (it *QuadtreeIterator) Next() bool {
+	it.index++
+	for (it.leaf == nil) || (it.index >= len(it.leaf.points)) {
+		if len(it.stack) == 0 {
+			it.leaf = nil
+			return false
+		}
+		n := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+		if n.children == nil {
+			it.leaf, it.index = n, 0
+			continue
+		}
+		for q := 3; q >= 0; q-- {
+			if n.children[q].count > 0 {
+				it.stack = append(it.stack, n.children[q])
+			}
+		}
+	}
+	return true
+}
+
+// Point returns the current Point.
+func (it *QuadtreeIterator) Point() Point {
+	return it.leaf.points[it.index]
+}
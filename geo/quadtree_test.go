@@ -0,0 +1,237 @@
+package gogeo
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// sorted_points sorts a copy of `points` by less_xy, so that query results can be
+// compared.
+func sorted_points(points []Point) []Point {
+	sorted := append([]Point{}, points...)
+	sort.Slice(sorted, func(i, j int) bool { return less_xy(sorted[i], sorted[j]) })
+	return sorted
+}
+
+// equal_points tests if two slices hold the same Points in the same order.
+func equal_points(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// check_quadtree compares queries of a Quadtree holding `points` with testing every
+// Point.
+func check_quadtree(t *testing.T, rng *rand.Rand, tree *Quadtree, points []Point) {
+	t.Helper()
+	if tree.Len() != len(points) {
+		t.Fatalf("Len() = %v, want %v", tree.Len(), len(points))
+	}
+	var iterated []Point
+	for it := tree.Iterator(); it.Next(); {
+		iterated = append(iterated, it.Point())
+	}
+	if !equal_points(sorted_points(iterated), sorted_points(points)) {
+		t.Fatalf("Iterator() visited %v Points, want %v", len(iterated), len(points))
+	}
+
+	for query := 0; query < 20; query++ {
+		min := Point{3 * rng.Float64(), 3 * rng.Float64()}
+		box := BoundingBox{min, min.Plus(Point{rng.Float64(), rng.Float64()})}
+		center, radius := Point{3 * rng.Float64(), 3 * rng.Float64()}, rng.Float64()
+		var in_box, in_radius []Point
+		for _, p := range points {
+			if box.ContainsPoint(p) {
+				in_box = append(in_box, p)
+			}
+			if p.Minus(center).Magnitude() <= radius {
+				in_radius = append(in_radius, p)
+			}
+		}
+		if got := sorted_points(tree.Range(box)); !equal_points(got, sorted_points(in_box)) {
+			t.Fatalf("Range(%v) = %v, want %v", box, got, in_box)
+		}
+		if got := sorted_points(tree.Radius(center, radius)); !equal_points(got, sorted_points(in_radius)) {
+			t.Fatalf("Radius(%v, %v) = %v, want %v", center, radius, got, in_radius)
+		}
+
+		nearest, ok := tree.Nearest(center)
+		if ok != (len(points) > 0) {
+			t.Fatalf("Nearest(%v) found a Point = %v, want %v", center, ok, len(points) > 0)
+		}
+		for _, p := range points {
+			if p.Minus(center).Magnitude() < nearest.Minus(center).Magnitude() {
+				t.Fatalf("Nearest(%v) = %v, but %v is closer", center, nearest, p)
+			}
+		}
+	}
+}
+
+func TestQuadtree(t *testing.T) {
+	points := []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {0, 3}, {3, 0}, {1.5, 1.5}, {1.5, 1.5}}
+	for _, split := range []QuadtreeSplit{SplitAtCenter, SplitAtMedian} {
+		tree := NewQuadtree(points, QuadtreeOptions{Split: split, BucketSize: 1})
+		nearest, _ := tree.Nearest(Point{2.9, 0.2})
+		testCases := []struct {
+			desc string
+			got  []Point
+			want []Point
+		}{
+			{
+				desc: "Range",
+				got:  tree.Range(BoundingBox{Point{1, 1}, Point{2, 3}}),
+				want: []Point{{1, 1}, {1.5, 1.5}, {1.5, 1.5}, {2, 2}},
+			},
+			{
+				desc: "Range, empty",
+				got:  tree.Range(BoundingBox{Point{0.1, 0.1}, Point{0.9, 0.9}}),
+				want: []Point{},
+			},
+			{
+				desc: "Radius",
+				got:  tree.Radius(Point{0, 0}, 1.5),
+				want: []Point{{0, 0}, {1, 1}},
+			},
+			{
+				desc: "Nearest",
+				got:  []Point{nearest},
+				want: []Point{{3, 0}},
+			},
+		}
+		for _, tC := range testCases {
+			t.Run(tC.desc, func(t *testing.T) {
+				if got := sorted_points(tC.got); !equal_points(got, sorted_points(tC.want)) {
+					t.Errorf("got %v, want %v", got, tC.want)
+				}
+			})
+		}
+	}
+}
+
+func TestQuadtreeZOrder(t *testing.T) {
+	// With one Point in each leaf, a 4x4 grid is visited in Z-order.
+	var points []Point
+	for y := 3; y >= 0; y-- {
+		for x := 3; x >= 0; x-- {
+			points = append(points, Point{float64(x) + 0.5, float64(y) + 0.5})
+		}
+	}
+	tree := NewQuadtree(points, QuadtreeOptions{BucketSize: 1})
+	z_order := []int{0, 1, 4, 5, 2, 3, 6, 7, 8, 9, 12, 13, 10, 11, 14, 15}
+	var got []Point
+	for it := tree.Iterator(); it.Next(); {
+		got = append(got, it.Point())
+	}
+	for i, k := range z_order {
+		if want := (Point{float64(k%4) + 0.5, float64(k/4) + 0.5}); (i >= len(got)) || !got[i].Equals(want) {
+			t.Fatalf("Iterator() visited %v, want %v at %v", got, want, i)
+		}
+	}
+}
+
+func TestQuadtreeDuplicates(t *testing.T) {
+	// Copies of the same Point cannot be split apart, and stay in one leaf at MaxDepth.
+	tree := NewQuadtree(nil, QuadtreeOptions{BucketSize: 2, MaxDepth: 5})
+	for i := 0; i < 100; i++ {
+		tree.Insert(Point{1, 1})
+	}
+	tree.Insert(Point{2, 2})
+	if got := len(tree.Radius(Point{1, 1}, 0)); got != 100 {
+		t.Errorf("Radius() found %v copies, want 100", got)
+	}
+	for i := 0; i < 100; i++ {
+		if !tree.Remove(Point{1, 1}) {
+			t.Fatalf("Remove() did not find copy %v", i)
+		}
+	}
+	if tree.Remove(Point{1, 1}) || (tree.Len() != 1) {
+		t.Errorf("Remove() found more copies than were added")
+	}
+}
+
+func TestQuadtreeRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(27))
+	for trial := 0; trial < 20; trial++ {
+		options := QuadtreeOptions{Split: QuadtreeSplit(trial % 2), BucketSize: 1 + rng.Intn(8)}
+		var points []Point
+		random_point := func() Point {
+			// Some Points on a coarse grid, to share coordinates with the splits.
+			if rng.Intn(3) == 0 {
+				return Point{float64(rng.Intn(4)), float64(rng.Intn(4))}
+			}
+			return Point{3 * rng.Float64(), 3 * rng.Float64()}
+		}
+		for i, n := 0, rng.Intn(300); i < n; i++ {
+			points = append(points, random_point())
+		}
+		tree := NewQuadtree(points, options)
+		check_quadtree(t, rng, tree, points)
+
+		// Insert Points, some of them outside the region covered so far, and then
+		// remove some.
+		for i, n := 0, rng.Intn(300); i < n; i++ {
+			p := random_point()
+			if i%50 == 0 {
+				p = p.Times(-10)
+			}
+			points = append(points, p)
+			tree.Insert(p)
+		}
+		check_quadtree(t, rng, tree, points)
+		for i := 0; (i < 250) && (len(points) > 0); i++ {
+			k := rng.Intn(len(points))
+			if !tree.Remove(points[k]) {
+				t.Fatalf("Remove(%v) did not find it", points[k])
+			}
+			points = append(points[:k], points[k+1:]...)
+		}
+		if tree.Remove(Point{-1, 5}) {
+			t.Fatalf("Remove() removed a Point that was never added")
+		}
+		check_quadtree(t, rng, tree, points)
+	}
+}
+
+func BenchmarkQuadtree(b *testing.B) {
+	rng := rand.New(rand.NewSource(28))
+	points := random_points(rng, 10000)
+	tree := NewQuadtree(points, QuadtreeOptions{})
+	box := BoundingBox{Point{0.4, 0.4}, Point{0.45, 0.45}}
+	benchmarks := []struct {
+		desc string
+		run  func()
+	}{
+		{"NewQuadtree, 10000 Points", func() { NewQuadtree(points, QuadtreeOptions{}) }},
+		{"NewQuadtree, 10000 Points, split at medians", func() { NewQuadtree(points, QuadtreeOptions{Split: SplitAtMedian}) }},
+		{"Insert and Remove", func() {
+			tree.Insert(Point{0.5, 0.5})
+			tree.Remove(Point{0.5, 0.5})
+		}},
+		{"Range", func() { tree.Range(box) }},
+		{"Nearest", func() { tree.Nearest(Point{0.5, 0.5}) }},
+		{"Nearest, every Point", func() {
+			nearest := points[0]
+			for _, p := range points {
+				if p.Minus(Point{0.5, 0.5}).Magnitude() < nearest.Minus(Point{0.5, 0.5}).Magnitude() {
+					nearest = p
+				}
+			}
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.run()
+			}
+
+		})
+	}
+}
@@ -0,0 +1,521 @@
+package gogeo
+
+import (
+	"container/heap"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// The number of entries in each node of an RTree. Only the root may have fewer than
+// rtree_min_entries, apart from nodes at the edges of a bulk loaded RTree.
+const (
+	rtree_max_entries = 16
+	rtree_min_entries = 6
+)
+
+// RTree is a spatial index of Bounded shapes, which finds the shapes near a Point or
+// intersecting a region without testing every one of them. Each node of the tree holds
+// up to 16 entries, with the BoundingBox of everything beneath each.
+//
+// An RTree built all at once by NewRTree is packed with the Sort-Tile-Recursive
+// algorithm. Shapes can also be added and removed one at a time, with Insert and
+// Delete, as in Guttman's original R-tree. The zero value is an empty RTree.
+type RTree struct {
+	root   *rtree_node
+	height int
+	size   int
+}
+
+// rtree_node is a node of an RTree. The entries of a leaf hold shapes, and those of
+// any other node hold its children.
+type rtree_node struct {
+	leaf    bool
+	entries []rtree_entry
+}
+
+// rtree_entry is the BoundingBox of a shape stored in a leaf, or of everything beneath
+// a child node.
+type rtree_entry struct {
+	box   BoundingBox
+	child *rtree_node
+	item  Bounded
+}
+
+// NewRTree builds an RTree holding the `items`, packed with the Sort-Tile-Recursive
+// algorithm. The entries are sorted by the x coordinate of their centers, cut into
+// about √(n/16) vertical slices, and each slice is sorted by y and cut into nodes of 16.
+// The nodes are then packed the same way, until a single root is left. This takes
+// O(n log n) time, and makes a tree that is faster to search than one built by Insert.
+func NewRTree(items []Bounded) *RTree {
+	t := &RTree{size: len(items)}
+	if len(items) == 0 {
+		return t
+	}
+	entries := make([]rtree_entry, len(items))
+	for i, item := range items {
+		entries[i] = rtree_entry{box: item.BoundingBox(), item: item}
+	}
+	for leaf := true; ; leaf = false {
+		nodes := str_pack(entries, leaf)
+		t.height++
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			return t
+		}
+		entries = make([]rtree_entry, len(nodes))
+		for i, node := range nodes {
+			entries[i] = rtree_entry{box: node.bounds(), child: node}
+		}
+	}
+}
+
+// str_pack packs `entries` into nodes by the Sort-Tile-Recursive algorithm.
+func str_pack(entries []rtree_entry, leaf bool) []*rtree_node {
+	center := func(e rtree_entry) Point {
+		return e.box.Min.Plus(e.box.Max).Divide(2)
+	}
+	nodes := int(math.Ceil(float64(len(entries)) / rtree_max_entries))
+	slice_size := rtree_max_entries * int(math.Ceil(math.Sqrt(float64(nodes))))
+	sort.Slice(entries, func(i, j int) bool { return center(entries[i]).X < center(entries[j]).X })
+
+	var packed []*rtree_node
+	for start := 0; start < len(entries); start += slice_size {
+		slice := entries[start:]
+		if len(slice) > slice_size {
+			slice = slice[:slice_size]
+		}
+		sort.Slice(slice, func(i, j int) bool { return center(slice[i]).Y < center(slice[j]).Y })
+		for len(slice) > 0 {
+			n := rtree_max_entries
+			if len(slice) < n {
+				n = len(slice)
+			}
+			node := &rtree_node{leaf: leaf, entries: make([]rtree_entry, n)}
+			copy(node.entries, slice)
+			packed = append(packed, node)
+			slice = slice[n:]
+		}
+	}
+	return packed
+}
+
+// Len returns the number of shapes in the RTree.
+func (t *RTree) Len() int {
+	return t.size
+}
+
+// Insert adds a shape to the RTree. It is added to the leaf whose BoundingBox grows the
+// least, and full nodes are split in two with Guttman's quadratic split. This takes
+// O(log n) time.
+func (t *RTree) Insert(item Bounded) {
+	t.insert(rtree_entry{box: item.BoundingBox(), item: item})
+	t.size++
+}
+
+// insert adds a shape's entry to the leaves of the RTree, growing a new root if the old
+// one splits.
+func (t *RTree) insert(e rtree_entry) {
+	if t.root == nil {
+		t.root, t.height = &rtree_node{leaf: true}, 1
+	}
+	if split := t.root.insert(e, t.height-1); split != nil {
+		t.root = &rtree_node{entries: []rtree_entry{
+			{box: t.root.bounds(), child: t.root},
+			{box: split.bounds(), child: split},
+		}}
+		t.height++
+	}
+}
+
+// insert adds a shape's entry to the leaf `depth` levels below the node. If the node
+// overflows, it is split, and the new node is returned to be added to its parent.
+func (n *rtree_node) insert(e rtree_entry, depth int) *rtree_node {
+	if depth == 0 {
+		n.entries = append(n.entries, e)
+	} else {
+		i := n.choose(e.box)
+		child := n.entries[i].child
+		split := child.insert(e, depth-1)
+		n.entries[i].box = child.bounds()
+		if split != nil {
+			n.entries = append(n.entries, rtree_entry{box: split.bounds(), child: split})
+		}
+	}
+	if len(n.entries) > rtree_max_entries {
+		return n.split()
+	}
+	return nil
+}
+
+// choose finds the entry whose BoundingBox grows the least to include `box`, taking the
+// smallest in a tie.
+func (n *rtree_node) choose(box BoundingBox) int {
+	best, best_growth, best_area := 0, math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		area := e.box.Area()
+		growth := e.box.Union(box).Area() - area
+		if (growth < best_growth) || ((growth == best_growth) && (area < best_area)) {
+			best, best_growth, best_area = i, growth, area
+		}
+	}
+	return best
+}
+
+// split divides the entries of an overflowing node in two with Guttman's quadratic
+// split, keeping one group and returning a new node holding the other. The two entries
+// that would waste the most area together start the groups, and the rest are added
+// one at a time, taking the entry with the strongest preference for a group next.
+func (n *rtree_node) split() *rtree_node {
+	entries := n.entries
+	first, second, worst := 0, 1, math.Inf(-1)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			waste := entries[i].box.Union(entries[j].box).Area() - entries[i].box.Area() - entries[j].box.Area()
+			if waste > worst {
+				first, second, worst = i, j, waste
+			}
+		}
+	}
+
+	groups := [2][]rtree_entry{{entries[first]}, {entries[second]}}
+	boxes := [2]BoundingBox{entries[first].box, entries[second].box}
+	var remaining []rtree_entry
+	for i, e := range entries {
+		if (i != first) && (i != second) {
+			remaining = append(remaining, e)
+		}
+	}
+	for len(remaining) > 0 {
+		// A group that needs every remaining entry to reach the minimum takes them all.
+		if len(groups[0])+len(remaining) == rtree_min_entries {
+			groups[0] = append(groups[0], remaining...)
+			break
+		}
+		if len(groups[1])+len(remaining) == rtree_min_entries {
+			groups[1] = append(groups[1], remaining...)
+			break
+		}
+
+		next, preference := 0, math.Inf(-1)
+		var growth [2]float64
+		for i, e := range remaining {
+			g0 := boxes[0].Union(e.box).Area() - boxes[0].Area()
+			g1 := boxes[1].Union(e.box).Area() - boxes[1].Area()
+			if d := math.Abs(g0 - g1); d > preference {
+				next, preference, growth = i, d, [2]float64{g0, g1}
+			}
+		}
+		g := 0
+		switch {
+		case growth[1] < growth[0]:
+			g = 1
+		case growth[1] > growth[0]:
+		case boxes[1].Area() < boxes[0].Area():
+			g = 1
+		case (boxes[1].Area() == boxes[0].Area()) && (len(groups[1]) < len(groups[0])):
+			g = 1
+		}
+		groups[g] = append(groups[g], remaining[next])
+		boxes[g] = boxes[g].Union(remaining[next].box)
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	n.entries = groups[0]
+	return &rtree_node{leaf: n.leaf, entries: groups[1]}
+}
+
+// bounds returns the BoundingBox of every entry of the node.
+func (n *rtree_node) bounds() BoundingBox {
+	b := Polygon{}.BoundingBox()
+	for _, e := range n.entries {
+		b = b.Union(e.box)
+	}
+	return b
+}
+
+// Delete removes a shape from the RTree, returning whether it was found. The shape is
+// matched as by reflect.DeepEqual, and if it was added more than once, only one copy
+// is removed. Nodes left with too few entries are removed, and their shapes inserted
+// again. This takes O(log n) time.
+func (t *RTree) Delete(item Bounded) bool {
+	if t.root == nil {
+		return false
+	}
+	var orphans []rtree_entry
+	if !t.root.delete(item, item.BoundingBox(), &orphans) {
+		return false
+	}
+	t.size--
+	for !t.root.leaf && (len(t.root.entries) == 1) {
+		t.root = t.root.entries[0].child
+		t.height--
+	}
+	for _, e := range orphans {
+		t.insert(e)
+	}
+	return true
+}
+
+// delete removes a shape with BoundingBox `box` from beneath the node. The shapes of any
+// child left with too few entries are added to `orphans`, and the child is removed.
+func (n *rtree_node) delete(item Bounded, box BoundingBox, orphans *[]rtree_entry) bool {
+	for i, e := range n.entries {
+		if !e.box.Contains(box) {
+			continue
+		}
+		if n.leaf {
+			if reflect.DeepEqual(e.item, item) {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+			continue
+		}
+		if !e.child.delete(item, box, orphans) {
+			continue
+		}
+		if len(e.child.entries) < rtree_min_entries {
+			e.child.collect(orphans)
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			n.entries[i].box = e.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// collect adds the entries of every shape beneath the node to `entries`.
+func (n *rtree_node) collect(entries *[]rtree_entry) {
+	if n.leaf {
+		*entries = append(*entries, n.entries...)
+		return
+	}
+	for _, e := range n.entries {
+		e.child.collect(entries)
+	}
+}
+
+// Search returns every shape in the RTree whose BoundingBox intersects `box`, in no
+// particular order.
+func (t *RTree) Search(box BoundingBox) []Bounded {
+	var found []Bounded
+	if t.root != nil {
+		t.root.search(box, func(item Bounded) {
+			found = append(found, item)
+		})
+	}
+	return found
+}
+
+// search calls `visit` for every shape beneath the node whose BoundingBox intersects
+// `box`.
+func (n *rtree_node) search(box BoundingBox, visit func(item Bounded)) {
+	for _, e := range n.entries {
+		if !e.box.Intersects(box) {
+			continue
+		}
+		if n.leaf {
+			visit(e.item)
+		} else {
+			e.child.search(box, visit)
+		}
+	}
+}
+
+// Intersecting returns every shape in the RTree that intersects `shape`, including
+// those that only touch it, in no particular order. The BoundingBoxes in the tree are
+// used to filter out shapes that cannot intersect, and the rest are tested exactly
+// with ShapesIntersect.
+func (t *RTree) Intersecting(shape Bounded) []Bounded {
+	var found []Bounded
+	if t.root != nil {
+		t.root.search(shape.BoundingBox(), func(item Bounded) {
+			if ShapesIntersect(shape, item) {
+				found = append(found, item)
+			}
+		})
+	}
+	return found
+}
+
+// Nearest returns the `k` shapes in the RTree closest to the Point `p`, nearest first,
+// or every shape if there are fewer than `k`. The distance to a shape is 0 if it covers
+// `p`, and otherwise the shortest distance to its edges.
+//
+// The tree is searched best first, by keeping a queue of the nodes and shapes still to
+// visit, ordered by the distance to their BoundingBoxes. A shape is only measured
+// exactly once it reaches the front of the queue.
+func (t *RTree) Nearest(p Point, k int) []Bounded {
+	var found []Bounded
+	if (t.root == nil) || (k <= 0) {
+		return found
+	}
+	queue := nearest_queue{{distance: t.root.bounds().DistanceToPoint(p), entry: rtree_entry{child: t.root}}}
+	for (len(queue) > 0) && (len(found) < k) {
+		q := heap.Pop(&queue).(queued_entry)
+		switch {
+		case q.entry.child != nil:
+			for _, e := range q.entry.child.entries {
+				heap.Push(&queue, queued_entry{distance: e.box.DistanceToPoint(p), entry: e})
+			}
+		case q.exact:
+			found = append(found, q.entry.item)
+		default:
+			heap.Push(&queue, queued_entry{distance: distance_to_shape(q.entry.item, p), entry: q.entry, exact: true})
+		}
+	}
+	return found
+}
+
+// queued_entry is an entry of an RTree waiting to be visited by Nearest. The distance
+// is to its BoundingBox, or, if `exact`, to the shape itself.
+type queued_entry struct {
+	distance float64
+	entry    rtree_entry
+	exact    bool
+}
+
+// nearest_queue is a heap of queued_entry, with the nearest first.
+type nearest_queue []queued_entry
+
+func (q nearest_queue) Len() int            { return len(q) }
+func (q nearest_queue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q nearest_queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nearest_queue) Push(x interface{}) { *q = append(*q, x.(queued_entry)) }
+func (q *nearest_queue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// shape_outline is a shape from this package broken into its edges, with a test of
+// which Points it covers for shapes that have an area. A Point is an edge from itself to
+// itself. Shapes from outside the package are treated as their BoundingBox.
+type shape_outline struct {
+	edges  []LineSegment
+	covers func(p Point) bool
+}
+
+func outline(shape Bounded) shape_outline {
+	switch s := shape.(type) {
+	case Point:
+		return shape_outline{edges: []LineSegment{{s, s}}}
+	case LineSegment:
+		return shape_outline{edges: []LineSegment{s}}
+	case Polyline:
+		if len(s.Points) == 1 {
+			return shape_outline{edges: []LineSegment{{s.Points[0], s.Points[0]}}}
+		}
+		return shape_outline{edges: s.Segments()}
+	case Triangle:
+		edges := s.Edges()
+		return shape_outline{edges: edges[:], covers: s.ContainsPoint}
+	case Polygon:
+		return shape_outline{edges: s.Edges(), covers: s.ContainsPoint}
+	case PolygonWithHoles:
+		return shape_outline{edges: s.Edges(), covers: s.ContainsPoint}
+	case MultiPolygon:
+		var edges []LineSegment
+		for _, p := range s.Polygons {
+			edges = append(edges, p.Edges()...)
+		}
+		return shape_outline{edges: edges, covers: s.ContainsPoint}
+	case MultiPoint:
+		edges := make([]LineSegment, len(s.Points))
+		for i, p := range s.Points {
+			edges[i] = LineSegment{p, p}
+		}
+		return shape_outline{edges: edges}
+	case MultiPolyline:
+		var edges []LineSegment
+		for _, l := range s.Polylines {
+			edges = append(edges, outline(l).edges...)
+		}
+		return shape_outline{edges: edges}
+	case GeometryCollection:
+		var edges []LineSegment
+		var covers []func(p Point) bool
+		for _, g := range s.Geometries {
+			o := outline(g)
+			edges = append(edges, o.edges...)
+			if o.covers != nil {
+				covers = append(covers, o.covers)
+			}
+		}
+		if len(covers) == 0 {
+			return shape_outline{edges: edges}
+		}
+		return shape_outline{edges: edges, covers: func(p Point) bool {
+			for _, c := range covers {
+				if c(p) {
+					return true
+				}
+			}
+			return false
+		}}
+	}
+	box := shape.BoundingBox()
+	if box.IsEmpty() {
+		return shape_outline{}
+	}
+	return outline(box.Polygon())
+}
+
+// ShapesIntersect tests if two shapes share any Point, including when they only touch.
+// Two LineSegments or two Triangles are tested with their own Intersects methods. Other
+// shapes intersect if any of their edges do, or if one covers a Point of the other.
+func ShapesIntersect(a, b Bounded) bool {
+	switch a := a.(type) {
+	case LineSegment:
+		if b, ok := b.(LineSegment); ok {
+			return a.Intersects(b)
+		}
+	case Triangle:
+		if b, ok := b.(Triangle); ok {
+			return a.Intersects(b)
+		}
+	}
+
+	outline_a, outline_b := outline(a), outline(b)
+	for _, edge_a := range outline_a.edges {
+		box := edge_a.BoundingBox()
+		for _, edge_b := range outline_b.edges {
+			if box.Intersects(edge_b.BoundingBox()) && edge_a.Intersects(edge_b) {
+				return true
+			}
+		}
+	}
+	// With no edges crossing, each separate part of one shape is either entirely
+	// covered by the other or entirely outside it, so one Point of every edge is tested
+	// for the sake of shapes made of several parts.
+	return covers_any(outline_a, outline_b.edges) || covers_any(outline_b, outline_a.edges)
+}
+
+// covers_any tests if a shape_outline covers the start of any of the `edges`.
+func covers_any(o shape_outline, edges []LineSegment) bool {
+	if o.covers == nil {
+		return false
+	}
+	for _, edge := range edges {
+		if o.covers(edge.P1) {
+			return true
+		}
+	}
+	return false
+}
+
+// distance_to_shape returns the shortest distance from the Point `p` to any Point of a
+// shape, which is 0 if the shape covers `p`.
+func distance_to_shape(shape Bounded, p Point) float64 {
+	o := outline(shape)
+	if (o.covers != nil) && o.covers(p) {
+		return 0
+	}
+	distance := math.Inf(1)
+	for _, edge := range o.edges {
+		distance = math.Min(distance, edge.DistanceToPoint(p))
+	}
+	return distance
+}
@@ -0,0 +1,271 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// shape_indexes finds where each of the `found` shapes is in `shapes`, in increasing
+// order, so that query results can be compared.
+func shape_indexes(shapes, found []Bounded) []int {
+	indexes := []int{}
+	for _, f := range found {
+		for i, s := range shapes {
+			if reflect.DeepEqual(s, f) {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// random_shape makes a small random Point, LineSegment or Triangle in the unit square.
+func random_shape(rng *rand.Rand) Bounded {
+	p := Point{rng.Float64(), rng.Float64()}
+	offset := func() Point { return Point{0.1 * (rng.Float64() - 0.5), 0.1 * (rng.Float64() - 0.5)} }
+	switch rng.Intn(3) {
+	case 0:
+		return p
+	case 1:
+		return LineSegment{p, p.Plus(offset())}
+	default:
+		return Triangle{p, p.Plus(offset()), p.Plus(offset())}
+	}
+}
+
+// check_rtree compares queries of an RTree holding `shapes` with testing every shape.
+func check_rtree(t *testing.T, rng *rand.Rand, tree *RTree, shapes []Bounded) {
+	t.Helper()
+	if tree.Len() != len(shapes) {
+		t.Fatalf("Len() = %v, want %v", tree.Len(), len(shapes))
+	}
+	for query := 0; query < 20; query++ {
+		min := Point{rng.Float64(), rng.Float64()}
+		box := BoundingBox{min, min.Plus(Point{0.3 * rng.Float64(), 0.3 * rng.Float64()})}
+		var want []int
+		for i, s := range shapes {
+			if s.BoundingBox().Intersects(box) {
+				want = append(want, i)
+			}
+		}
+		if got := shape_indexes(shapes, tree.Search(box)); !equal_ints(got, want) {
+			t.Fatalf("Search(%v) found %v, want %v", box, got, want)
+		}
+
+		shape := random_shape(rng)
+		want = nil
+		for i, s := range shapes {
+			if ShapesIntersect(shape, s) {
+				want = append(want, i)
+			}
+		}
+		if got := shape_indexes(shapes, tree.Intersecting(shape)); !equal_ints(got, want) {
+			t.Fatalf("Intersecting(%v) found %v, want %v", shape, got, want)
+		}
+
+		// The distances of the nearest shapes must match the k smallest distances.
+		p, k := Point{rng.Float64(), rng.Float64()}, 1+rng.Intn(10)
+		var distances []float64
+		for _, s := range shapes {
+			distances = append(distances, distance_to_shape(s, p))
+		}
+		sort.Float64s(distances)
+		nearest := tree.Nearest(p, k)
+		if want := int(math.Min(float64(k), float64(len(shapes)))); len(nearest) != want {
+			t.Fatalf("Nearest(%v, %v) found %v shapes, want %v", p, k, len(nearest), want)
+		}
+		for i, s := range nearest {
+			if got := distance_to_shape(s, p); got != distances[i] {
+				t.Fatalf("Nearest(%v, %v)[%v] = %v at %v, want distance %v", p, k, i, s, got, distances[i])
+			}
+		}
+	}
+}
+
+func TestRTree(t *testing.T) {
+	shapes := []Bounded{
+		Point{1, 1},
+		LineSegment{Point{0, 3}, Point{3, 0}},
+		Triangle{Point{4, 0}, Point{6, 0}, Point{5, 2}},
+		Polyline{[]Point{{0, 5}, {1, 5}, {1, 6}, {2, 6}, {2, 7}, {3, 7}, {3, 8}}},
+		PolygonWithHoles{
+			Shell: square(10, 0, 10),
+			Holes: []Polygon{square(12, 2, 2).Reverse(), square(16, 6, 2).Reverse()},
+		},
+		multi(square(0, 10, 1), square(3, 10, 1)),
+	}
+	tree := NewRTree(shapes)
+	testCases := []struct {
+		desc string
+		got  []Bounded
+		want []int
+	}{
+		{
+			desc: "Search, empty",
+			got:  tree.Search(BoundingBox{Point{7, 7}, Point{8, 8}}),
+			want: []int{},
+		},
+		{
+			desc: "Search",
+			got:  tree.Search(BoundingBox{Point{0, 0}, Point{4, 1}}),
+			want: []int{0, 1, 2},
+		},
+		{
+			desc: "Intersecting, boxes overlap but shapes do not",
+			got:  tree.Intersecting(LineSegment{Point{0, 0}, Point{0.5, 0.5}}),
+			want: []int{},
+		},
+		{
+			desc: "Intersecting, touching",
+			got:  tree.Intersecting(LineSegment{Point{3.5, 0}, Point{4, 0}}),
+			want: []int{2},
+		},
+		{
+			desc: "Intersecting, inside a Triangle",
+			got:  tree.Intersecting(Point{5, 1}),
+			want: []int{2},
+		},
+		{
+			desc: "Intersecting, in a Hole",
+			got:  tree.Intersecting(Triangle{Point{12.5, 2.5}, Point{13.5, 2.5}, Point{13, 3.5}}),
+			want: []int{},
+		},
+		{
+			desc: "Intersecting, around a Point",
+			got:  tree.Intersecting(square(0, 0, 2)),
+			want: []int{0, 1},
+		},
+		{
+			desc: "Intersecting, a Polyline crossing a MultiPolygon",
+			got:  tree.Intersecting(Polyline{[]Point{{0.5, 9}, {0.5, 12}, {3.5, 12}, {3.5, 9}}}),
+			want: []int{5},
+		},
+		{
+			desc: "Intersecting, a GeometryCollection with a part inside a Triangle",
+			got:  tree.Intersecting(GeometryCollection{[]Bounded{Point{8, 8}, MultiPoint{[]Point{{9, 9}, {5, 1}}}}}),
+			want: []int{2},
+		},
+		{
+			desc: "Intersecting, a MultiPolyline",
+			got:  tree.Intersecting(MultiPolyline{[]Polyline{{[]Point{{7, 0}, {7, 1}}}, {[]Point{{0.5, 5.5}, {0.5, 4}}}}}),
+			want: []int{3},
+		},
+		{
+			desc: "Nearest",
+			got:  tree.Nearest(Point{1, 1.5}, 2),
+			want: []int{0, 1},
+		},
+		{
+			desc: "Nearest, inside a PolygonWithHoles",
+			got:  tree.Nearest(Point{11, 1}, 1),
+			want: []int{4},
+		},
+		{
+			desc: "Nearest, more than there are",
+			got:  tree.Nearest(Point{0, 0}, 10),
+			want: []int{0, 1, 2, 3, 4, 5},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := shape_indexes(shapes, tC.got); !equal_ints(got, tC.want) {
+				t.Errorf("found %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestRTreeEmpty(t *testing.T) {
+	var tree RTree
+	if got := tree.Search(BoundingBox{Point{0, 0}, Point{1, 1}}); len(got) != 0 {
+		t.Errorf("Search() = %v, want nothing", got)
+	}
+	if got := tree.Nearest(Point{0, 0}, 3); len(got) != 0 {
+		t.Errorf("Nearest() = %v, want nothing", got)
+	}
+	if tree.Delete(Point{0, 0}) {
+		t.Errorf("Delete() = true, want false")
+	}
+	tree.Insert(Point{0, 0})
+	if !tree.Delete(Point{0, 0}) || (tree.Len() != 0) {
+		t.Errorf("Delete() did not remove the only Point")
+	}
+}
+
+func TestRTreeRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(25))
+	for trial := 0; trial < 20; trial++ {
+		// Build the tree from a bulk load and inserts, and then delete some shapes.
+		var shapes []Bounded
+		for i, n := 0, rng.Intn(500); i < n; i++ {
+			shapes = append(shapes, random_shape(rng))
+		}
+		tree := NewRTree(shapes)
+		check_rtree(t, rng, tree, shapes)
+
+		for i, n := 0, rng.Intn(500); i < n; i++ {
+			shape := random_shape(rng)
+			shapes = append(shapes, shape)
+			tree.Insert(shape)
+		}
+		check_rtree(t, rng, tree, shapes)
+
+		for i := 0; (i < 400) && (len(shapes) > 0); i++ {
+			k := rng.Intn(len(shapes))
+			if !tree.Delete(shapes[k]) {
+				t.Fatalf("Delete(%v) did not find it", shapes[k])
+			}
+			shapes = append(shapes[:k], shapes[k+1:]...)
+		}
+		if tree.Delete(Point{-1, -1}) {
+			t.Fatalf("Delete() removed a Point that was never added")
+		}
+		check_rtree(t, rng, tree, shapes)
+	}
+}
+
+func BenchmarkRTree(b *testing.B) {
+	rng := rand.New(rand.NewSource(26))
+	var shapes []Bounded
+	for i := 0; i < 10000; i++ {
+		shapes = append(shapes, random_shape(rng))
+	}
+	tree := NewRTree(shapes)
+	box := BoundingBox{Point{0.4, 0.4}, Point{0.45, 0.45}}
+	benchmarks := []struct {
+		desc string
+		run  func()
+	}{
+		{"NewRTree, 10000 shapes", func() { NewRTree(shapes) }},
+		{"Insert, 10000 shapes", func() {
+			var t RTree
+			for _, s := range shapes {
+				t.Insert(s)
+			}
+		}},
+		{"Search", func() { tree.Search(box) }},
+		{"Search, every shape", func() {
+			var found []Bounded
+			for _, s := range shapes {
+				if s.BoundingBox().Intersects(box) {
+					found = append(found, s)
+				}
+			}
+		}},
+		{"Nearest 10", func() { tree.Nearest(Point{0.5, 0.5}, 10) }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.run()
+			}
+
+		})
+	}
+}
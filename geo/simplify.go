@@ -0,0 +1,348 @@
+package gogeo
+
+import (
+	"container/heap"
+)
+
+// SimplifyAlgorithm selects the algorithm used by Simplify.
+type SimplifyAlgorithm int
+
+const (
+	// DouglasPeucker keeps the ends of a line, and then, for as long as some vertex
+	// between two kept vertices is further than the tolerance from the LineSegment joining
+	// them, keeps the furthest such vertex as well. It usually takes O(n log n) time, and
+	// O(n²) in the worst case.
+	DouglasPeucker SimplifyAlgorithm = iota
+	// VisvalingamWhyatt repeatedly removes the vertex that makes the Triangle of smallest
+	// Area with its two neighbors, for as long as that Area is no more than the
+	// tolerance. The tolerance is then an area rather than a distance. It takes
+	// O(n log n) time.
+	VisvalingamWhyatt
+)
+
+// SimplifyOptions configure Simplify. The zero value uses DouglasPeucker and does not
+// preserve topology.
+type SimplifyOptions struct {
+	Algorithm SimplifyAlgorithm
+	// PreserveTopology only removes vertices if the LineSegment that replaces them does
+	// not touch any other LineSegment being simplified, and no other vertex lies in the
+	// area between them. So a simple line or ring stays simple, rings do not cross each
+	// other, and Holes stay inside their Shells, at the cost of keeping some vertices
+	// that are within the tolerance. Each replacement is checked against every other
+	// LineSegment, so this takes O(n²) time.
+	PreserveTopology bool
+}
+
+// Simplify returns a Polyline with fewer vertices that stays within the `tolerance` of
+// the original, keeping its first and last Points. A closed Polyline is simplified like
+// a ring, and stays closed. See SimplifyOptions.
+func (l Polyline) Simplify(tolerance float64, options SimplifyOptions) Polyline {
+	if l.IsClosed() {
+		ring := Polygon{l.Points[:len(l.Points)-1]}.Simplify(tolerance, options)
+		return Polyline{append(ring.Points, ring.Points[0])}
+	}
+	s := new_simplifier(tolerance, options)
+	s.add(l.Points, false)
+	return Polyline{s.simplify()[0]}
+}
+
+// Simplify returns a Polygon with fewer vertices that stays within the `tolerance` of
+// the original. The first vertex is always kept, and so are at least three vertices in
+// all. Without PreserveTopology, the result may cross itself. See SimplifyOptions.
+func (p Polygon) Simplify(tolerance float64, options SimplifyOptions) Polygon {
+	s := new_simplifier(tolerance, options)
+	s.add(p.Points, true)
+	return Polygon{s.simplify()[0]}
+}
+
+// Simplify returns a PolygonWithHoles with each ring simplified as by Polygon.Simplify.
+// With PreserveTopology, the rings are simplified together, so that none of them cross
+// and the Holes stay inside the Shell.
+func (p PolygonWithHoles) Simplify(tolerance float64, options SimplifyOptions) PolygonWithHoles {
+	simplified := MultiPolygon{[]PolygonWithHoles{p}}.Simplify(tolerance, options)
+	return simplified.Polygons[0]
+}
+
+// Simplify returns a MultiPolygon with each ring simplified as by Polygon.Simplify.
+// With PreserveTopology, the rings of every part are simplified together, so that none
+// of them cross, the Holes stay inside their Shells, and the parts do not overlap.
+func (m MultiPolygon) Simplify(tolerance float64, options SimplifyOptions) MultiPolygon {
+	s := new_simplifier(tolerance, options)
+	for _, polygon := range m.Polygons {
+		for _, ring := range polygon.Rings() {
+			s.add(ring.Points, true)
+		}
+	}
+	rings := s.simplify()
+
+	simplified := MultiPolygon{make([]PolygonWithHoles, len(m.Polygons))}
+	for i, polygon := range m.Polygons {
+		simplified.Polygons[i].Shell = Polygon{rings[0]}
+		for range polygon.Holes {
+			simplified.Polygons[i].Holes = append(simplified.Polygons[i].Holes, Polygon{rings[1]})
+			rings = rings[1:]
+		}
+		rings = rings[1:]
+	}
+	return simplified
+}
+
+// simplifier removes vertices from a set of lines and rings that are simplified
+// together. The vertices still kept on each are linked to each other, so that the
+// current LineSegments can be found as vertices are removed.
+type simplifier struct {
+	tolerance float64
+	options   SimplifyOptions
+	lines     []simplified_line
+}
+
+// simplified_line is a line or ring being simplified. A ring repeats its first Point at
+// the end, and its first vertex is always kept.
+type simplified_line struct {
+	points []Point
+	ring   bool
+	// next and previous link each vertex to its neighbors that are still kept, or hold
+	// -1 past the ends.
+	next     []int
+	previous []int
+	// kept is the number of different vertices kept, which must not drop below two for
+	// a line or three for a ring.
+	kept int
+}
+
+func new_simplifier(tolerance float64, options SimplifyOptions) *simplifier {
+	return &simplifier{tolerance: tolerance, options: options}
+}
+
+// add adds a line, or a ring, to be simplified.
+func (s *simplifier) add(points []Point, ring bool) {
+	line := simplified_line{ring: ring, kept: len(points)}
+	line.points = append(line.points, points...)
+	if ring && (len(points) > 0) {
+		line.points = append(line.points, points[0])
+	}
+	n := len(line.points)
+	line.next = make([]int, n)
+	line.previous = make([]int, n)
+	for i := range line.points {
+		line.next[i] = i + 1
+		line.previous[i] = i - 1
+	}
+	if n > 0 {
+		line.next[n-1] = -1
+	}
+	s.lines = append(s.lines, line)
+}
+
+// simplify removes vertices with the chosen algorithm, and returns the Points kept on
+// each line, without repeating the first Point of a ring.
+func (s *simplifier) simplify() [][]Point {
+	switch s.options.Algorithm {
+	case VisvalingamWhyatt:
+		s.visvalingam_whyatt()
+	default:
+		for r, line := range s.lines {
+			last := len(line.points) - 1
+			if !line.ring {
+				s.douglas_peucker(r, 0, last)
+				continue
+			}
+			if line.kept < 3 {
+				continue
+			}
+			// A ring is split in two at its first vertex and the vertex furthest from it.
+			furthest := 0
+			for i, p := range line.points {
+				if p.Minus(line.points[0]).Magnitude() > line.points[furthest].Minus(line.points[0]).Magnitude() {
+					furthest = i
+				}
+			}
+			s.douglas_peucker(r, 0, furthest)
+			s.douglas_peucker(r, furthest, last)
+		}
+	}
+
+	simplified := make([][]Point, len(s.lines))
+	for r, line := range s.lines {
+		simplified[r] = []Point{}
+		for i := line.first(); i >= 0; i = line.next[i] {
+			if !line.ring || (i < len(line.points)-1) {
+				simplified[r] = append(simplified[r], line.points[i])
+			}
+		}
+	}
+	return simplified
+}
+
+// douglas_peucker simplifies the part of line `r` from vertex `i` to vertex `j`, none of
+// which has been removed yet.
+func (s *simplifier) douglas_peucker(r, i, j int) {
+	if j-i < 2 {
+		return
+	}
+	points := s.lines[r].points
+	segment := LineSegment{points[i], points[j]}
+	furthest, furthest_distance := i+1, -1.0
+	for k := i + 1; k < j; k++ {
+		if distance := segment.DistanceToPoint(points[k]); distance > furthest_distance {
+			furthest, furthest_distance = k, distance
+		}
+	}
+	if (furthest_distance <= s.tolerance) && s.can_replace(r, i, j, j-i-1) {
+		s.replace(r, i, j)
+		return
+	}
+	s.douglas_peucker(r, i, furthest)
+	s.douglas_peucker(r, furthest, j)
+}
+
+// visvalingam_whyatt removes vertices from all the lines at once, always taking the one
+// that makes the smallest Triangle with its neighbors next.
+func (s *simplifier) visvalingam_whyatt() {
+	var queue vertex_queue
+	// version counts the changes to the neighbors of each vertex, so that entries in the
+	// queue for a Triangle that no longer exists can be skipped.
+	version := make([][]int, len(s.lines))
+	push := func(r, i int) {
+		line := s.lines[r]
+		if (line.previous[i] < 0) || (line.next[i] < 0) {
+			return
+		}
+		version[r][i]++
+		area := Triangle{line.points[line.previous[i]], line.points[i], line.points[line.next[i]]}.Area()
+		heap.Push(&queue, queued_vertex{area, r, i, version[r][i]})
+	}
+	for r, line := range s.lines {
+		version[r] = make([]int, len(line.points))
+		for i := range line.points {
+			push(r, i)
+		}
+	}
+
+	for len(queue) > 0 {
+		v := heap.Pop(&queue).(queued_vertex)
+		if v.area > s.tolerance {
+			break
+		}
+		if v.version != version[v.r][v.i] {
+			continue
+		}
+		// A vertex that cannot be removed now may still be removed once one of its
+		// neighbors has been, which pushes it again.
+		line := s.lines[v.r]
+		previous, next := line.previous[v.i], line.next[v.i]
+		if s.can_replace(v.r, previous, next, 1) {
+			s.replace(v.r, previous, next)
+			version[v.r][v.i]++
+			push(v.r, previous)
+			push(v.r, next)
+		}
+	}
+}
+
+// can_replace tests if the `removed` vertices of line `r` between vertices `i` and `j`
+// can be replaced by a single LineSegment. Unless topology is preserved, the only
+// limit is on the number of vertices kept.
+func (s *simplifier) can_replace(r, i, j, removed int) bool {
+	line := s.lines[r]
+	if minimum := 2 + btoi(line.ring); line.kept-removed < minimum {
+		return false
+	}
+	if !s.options.PreserveTopology {
+		return true
+	}
+
+	replacement := LineSegment{line.points[i], line.points[j]}
+	replacement_box := replacement.BoundingBox()
+	// The area between the vertices being removed and the replacement.
+	var section []Point
+	for k := i; k != j; k = line.next[k] {
+		section = append(section, line.points[k])
+	}
+	section = append(section, line.points[j])
+	section_box := Polygon{section}.BoundingBox()
+
+	// The LineSegments on either side of the replacement may only touch it at the vertex
+	// they share with it.
+	before, after := line.previous[i], line.next[j]
+	if line.ring && (i == 0) {
+		before = line.previous[len(line.points)-1]
+	}
+	if line.ring && (j == len(line.points)-1) {
+		after = line.next[0]
+	}
+
+	for r2, other := range s.lines {
+		last := len(other.points) - 1
+		for k := other.first(); k >= 0; k = other.next[k] {
+			same := (r2 == r) && (((i <= k) && (k <= j)) || (line.ring && (k == 0) && (j == last)))
+			if !same && ((k < last) || !other.ring) && section_box.ContainsPoint(other.points[k]) &&
+				(Polygon{section}.LocatePoint(other.points[k]) != Exterior) {
+				return false
+			}
+
+			next := other.next[k]
+			if (next < 0) || ((r2 == r) && (i <= k) && (next <= j)) {
+				continue
+			}
+			segment := LineSegment{other.points[k], other.points[next]}
+			if !segment.BoundingBox().Intersects(replacement_box) || !segment.Intersects(replacement) {
+				continue
+			}
+			adjacent := (r2 == r) && (((k == before) && (next == i || (line.ring && i == 0 && next == last))) ||
+				((next == after) && (k == j || (line.ring && j == last && k == 0))))
+			if !adjacent || (segment.Intersection(replacement).Kind != PointIntersection) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// first returns the first vertex of the line, or -1 if it has none.
+func (line simplified_line) first() int {
+	if len(line.points) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// replace removes every vertex of line `r` between vertices `i` and `j`.
+func (s *simplifier) replace(r, i, j int) {
+	line := &s.lines[r]
+	for k := line.next[i]; k != j; k = line.next[k] {
+		line.kept--
+	}
+	line.next[i] = j
+	line.previous[j] = i
+}
+
+// btoi converts a bool to 1 or 0.
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// queued_vertex is a vertex waiting to be removed by visvalingam_whyatt, with the Area
+// of the Triangle it makes with its neighbors.
+type queued_vertex struct {
+	area    float64
+	r, i    int
+	version int
+}
+
+// vertex_queue is a heap of queued_vertex, with the smallest Area first.
+type vertex_queue []queued_vertex
+
+func (q vertex_queue) Len() int            { return len(q) }
+func (q vertex_queue) Less(i, j int) bool  { return q[i].area < q[j].area }
+func (q vertex_queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *vertex_queue) Push(x interface{}) { *q = append(*q, x.(queued_vertex)) }
+func (q *vertex_queue) Pop() interface{} {
+	old := *q
+	v := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return v
+}
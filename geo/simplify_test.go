@@ -0,0 +1,188 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	topology := SimplifyOptions{PreserveTopology: true}
+	vw := SimplifyOptions{Algorithm: VisvalingamWhyatt}
+	circle := regular_polygon(Point{0, 0}, 1, 32)
+	// The bump at (5, 1) is within the tolerance, but the line would cross itself if it
+	// were removed.
+	folded := Polyline{[]Point{{0, 0}, {5, 1}, {10, 0}, {10, -3}, {5, 0.5}, {0, -3}}}
+	// The Hole is in the peak of the Shell, which is within the tolerance.
+	peaked := PolygonWithHoles{
+		Shell: Polygon{[]Point{{0, 0}, {10, 0}, {10, 10}, {5, 11}, {0, 10}}},
+		Holes: []Polygon{{[]Point{{4.5, 10.2}, {5, 10.7}, {5.5, 10.2}}}},
+	}
+	testCases := []struct {
+		desc string
+		got  Polygon
+		want Polygon
+	}{
+		{
+			desc: "Nearly straight",
+			got:  Polygon(Polyline{[]Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0}}}.Simplify(0.5, SimplifyOptions{})),
+			want: Polygon{[]Point{{0, 0}, {3, 0}}},
+		},
+		{
+			desc: "Spike",
+			got:  Polygon(Polyline{[]Point{{0, 0}, {1, 0}, {2, 5}, {3, 0}, {4, 0}}}.Simplify(1, SimplifyOptions{})),
+			want: Polygon{[]Point{{0, 0}, {2, 5}, {4, 0}}},
+		},
+		{
+			desc: "Two Points",
+			got:  Polygon(Polyline{[]Point{{0, 0}, {1, 1}}}.Simplify(5, SimplifyOptions{})),
+			want: Polygon{[]Point{{0, 0}, {1, 1}}},
+		},
+		{
+			desc: "Empty",
+			got:  Polygon(Polyline{}.Simplify(1, SimplifyOptions{})),
+			want: Polygon{[]Point{}},
+		},
+		{
+			desc: "Closed Polyline",
+			got: Polygon(Polyline{[]Point{
+				{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}, {1, 2}, {0, 2}, {0, 1}, {0, 0},
+			}}.Simplify(0.1, SimplifyOptions{})),
+			want: Polygon{[]Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}},
+		},
+		{
+			desc: "Ring keeps three vertices",
+			got:  circle.Simplify(10, SimplifyOptions{}),
+			want: Polygon{[]Point{circle.Points[0], circle.Points[16], circle.Points[24]}},
+		},
+		{
+			desc: "Folded line",
+			got:  Polygon(folded.Simplify(1.2, SimplifyOptions{})),
+			want: Polygon{[]Point{{0, 0}, {10, 0}, {10, -3}, {5, 0.5}, {0, -3}}},
+		},
+		{
+			desc: "Folded line, preserving topology",
+			got:  Polygon(folded.Simplify(1.2, topology)),
+			want: Polygon(folded),
+		},
+		{
+			desc: "Hole in a peak",
+			got:  peaked.Simplify(2, SimplifyOptions{}).Shell,
+			want: Polygon{[]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		},
+		{
+			desc: "Hole in a peak, preserving topology",
+			got:  peaked.Simplify(2, topology).Shell,
+			want: peaked.Shell,
+		},
+		{
+			desc: "Visvalingam-Whyatt",
+			got:  Polygon(Polyline{[]Point{{0, 0}, {1, 0.1}, {2, 0}, {3, 1}, {4, 0}}}.Simplify(0.2, vw)),
+			want: Polygon{[]Point{{0, 0}, {2, 0}, {3, 1}, {4, 0}}},
+		},
+		{
+			desc: "Visvalingam-Whyatt, ring keeps three vertices",
+			got:  Polygon{[]Point{{0, 0}, {4, 0}, {3, 1}, {0, 2}}}.Simplify(10, vw),
+			want: Polygon{[]Point{{0, 0}, {4, 0}, {0, 2}}},
+		},
+		{
+			desc: "Visvalingam-Whyatt, folded line",
+			got:  Polygon(folded.Simplify(6, vw)),
+			want: Polygon{[]Point{{0, 0}, {10, 0}, {10, -3}, {5, 0.5}, {0, -3}}},
+		},
+		{
+			desc: "Visvalingam-Whyatt, folded line, preserving topology",
+			got:  Polygon(folded.Simplify(6, SimplifyOptions{Algorithm: VisvalingamWhyatt, PreserveTopology: true})),
+			want: Polygon(folded),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if !tC.got.Equals(tC.want) {
+				t.Errorf("Simplify() = %v, want %v", tC.got, tC.want)
+			}
+		})
+	}
+}
+
+func TestSimplifyRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(21))
+	for trial := 0; trial < 50; trial++ {
+		// Two stars with large holes, close enough for simplification to make their
+		// rings cross.
+		var m MultiPolygon
+		for _, center := range []Point{{0, 0}, {2.05, 0}} {
+			m.Polygons = append(m.Polygons, PolygonWithHoles{
+				Shell: random_star(rng, center, 0.5, 1, 40+rng.Intn(100)),
+				Holes: []Polygon{random_star(rng, center, 0.3, 0.49, 3+rng.Intn(50)).Reverse()},
+			})
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("%v is not valid: %v", m, err)
+		}
+		tolerance := 0.5 * rng.Float64()
+		for _, options := range []SimplifyOptions{
+			{PreserveTopology: true},
+			{Algorithm: VisvalingamWhyatt, PreserveTopology: true},
+		} {
+			area := tolerance
+			if options.Algorithm == VisvalingamWhyatt {
+				area = tolerance * tolerance
+			}
+			simplified := m.Simplify(area, options)
+			if err := simplified.Validate(); err != nil {
+				t.Fatalf("Simplify(%v) = %v is not valid: %v", m, simplified, err)
+			}
+			if options.Algorithm != DouglasPeucker {
+				continue
+			}
+
+			// Every vertex removed is within the tolerance of what replaced it.
+			for i, polygon := range m.Polygons {
+				for j, ring := range polygon.Rings() {
+					simplified_ring := simplified.Polygons[i].Rings()[j]
+					for _, p := range ring.Points {
+						d := math.Inf(1)
+						for _, edge := range simplified_ring.Edges() {
+							d = math.Min(d, edge.DistanceToPoint(p))
+						}
+						if d > tolerance {
+							t.Fatalf("Simplify(%v) = %v is %v from %v", tolerance, simplified_ring, d, p)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSimplify(b *testing.B) {
+	rng := rand.New(rand.NewSource(22))
+	track := Polyline{[]Point{{0, 0}}}
+	for i := 0; i < 10000; i++ {
+		track.Points = append(track.Points, track.Points[i].Plus(Point{rng.Float64(), rng.Float64() - 0.5}))
+	}
+	star := PolygonWithHoles{
+		Shell: random_star(rng, Point{0, 0}, 0.5, 1, 10000),
+		Holes: []Polygon{random_star(rng, Point{0, 0}, 0.3, 0.45, 1000).Reverse()},
+	}
+	benchmarks := []struct {
+		desc     string
+		simplify func()
+	}{
+		{"10000 Point track", func() { track.Simplify(1, SimplifyOptions{}) }},
+		{"10000 Point track, preserving topology", func() { track.Simplify(1, SimplifyOptions{PreserveTopology: true}) }},
+		{"10000 Point track, Visvalingam-Whyatt", func() { track.Simplify(1, SimplifyOptions{Algorithm: VisvalingamWhyatt}) }},
+		{"10000 vertex star", func() { star.Simplify(0.1, SimplifyOptions{}) }},
+		{"10000 vertex star, preserving topology", func() { star.Simplify(0.1, SimplifyOptions{PreserveTopology: true}) }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.simplify()
+			}
+
+		})
+	}
+}
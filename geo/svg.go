@@ -0,0 +1,327 @@
+package gogeo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Style controls how a shape or label is drawn in an SVG. The zero value draws black
+// lines 1 pixel wide, with no fill.
+type Style struct {
+	// Stroke is the color of lines and Points, as any SVG color such as "red" or
+	// "#ff8000". Empty uses black.
+	Stroke string
+	// StrokeWidth is the width of lines in pixels. Zero uses 1.
+	StrokeWidth float64
+	// Fill is the color inside Triangles and polygons, and of label text. Empty leaves
+	// shapes unfilled, and draws text in the Stroke color.
+	Fill string
+	// Opacity is how opaque the Fill is, from 0 to 1. Zero uses 1.
+	Opacity float64
+	// Dashed draws lines dashed instead of solid.
+	Dashed bool
+	// PointRadius is the radius in pixels of the dot drawn for each Point. Zero uses 3.
+	PointRadius float64
+	// FontSize is the height in pixels of label text. Zero uses 12.
+	FontSize float64
+}
+
+// SVGOptions control the size of an SVG, and which region of the plane it shows.
+type SVGOptions struct {
+	// Width and Height are the size of the image in pixels. Zero uses 800.
+	Width  float64
+	Height float64
+	// Margin is the space in pixels left around the Viewport. Zero uses 20.
+	Margin float64
+	// Viewport is the region of the plane to show. If it is nil, the image is fitted
+	// to the BoundingBox of everything drawn.
+	Viewport *BoundingBox
+}
+
+// SVG draws shapes and labels into a Scalable Vector Graphics image, for looking at
+// geometry while debugging. Shapes are drawn in the order they are added, so later
+// ones are on top. The plane is scaled equally in x and y to fit the image, and flipped
+// so that y increases upwards, as it does in the plane, rather than downwards as it
+// does in SVG. Line widths, Point sizes and text are in pixels, so they are the same
+// size however far the image is scaled.
+type SVG struct {
+	options SVGOptions
+	items   []svg_item
+}
+
+// svg_item is a shape or a label waiting to be drawn.
+type svg_item struct {
+	shape Bounded
+	label string
+	style Style
+}
+
+// NewSVG makes an empty SVG with the given options.
+func NewSVG(options SVGOptions) *SVG {
+	if options.Width == 0 {
+		options.Width = 800
+	}
+	if options.Height == 0 {
+		options.Height = 800
+	}
+	if options.Margin == 0 {
+		options.Margin = 20
+	}
+	return &SVG{options: options}
+}
+
+// Add draws shapes in the given style. Points are drawn as dots, LineSegments and
+// Polylines as lines, and Triangles and polygons as closed outlines, filled if the
+// style has a Fill, with Holes left unfilled. The parts of MultiPoints, MultiPolylines,
+// MultiPolygons and GeometryCollections are drawn the same way. Any other type of shape
+// is drawn as its BoundingBox.
+func (s *SVG) Add(style Style, shapes ...Bounded) {
+	for _, shape := range shapes {
+		s.items = append(s.items, svg_item{shape: shape, style: style})
+	}
+}
+
+// Label writes `text` with its lower left corner at the Point `p`. Empty text is
+// ignored.
+func (s *SVG) Label(p Point, text string, style Style) {
+	if text == "" {
+		return
+	}
+	s.items = append(s.items, svg_item{shape: p, label: text, style: style})
+}
+
+// Len returns the number of shapes and labels that have been added.
+func (s *SVG) Len() int {
+	return len(s.items)
+}
+
+// WriteTo writes the SVG document to `w`, and returns the number of bytes written.
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	s.render(&b)
+	return b.WriteTo(w)
+}
+
+// WriteFile writes the SVG document to the file at `path`, replacing it if it exists.
+func (s *SVG) WriteFile(path string) error {
+	var b bytes.Buffer
+	s.render(&b)
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+// String returns the SVG document.
+func (s *SVG) String() string {
+	var b bytes.Buffer
+	s.render(&b)
+	return b.String()
+}
+
+// svg_transform maps Points of the plane to pixels.
+type svg_transform struct {
+	scale  float64
+	origin Point
+	height float64
+}
+
+// apply returns the pixel that `p` is drawn at.
+func (t svg_transform) apply(p Point) Point {
+	return Point{t.origin.X + t.scale*p.X, t.height - (t.origin.Y + t.scale*p.Y)}
+}
+
+// transform fits the Viewport, or everything drawn, inside the margins of the image.
+func (s *SVG) transform() svg_transform {
+	var box BoundingBox
+	if s.options.Viewport != nil {
+		box = *s.options.Viewport
+	} else {
+		box = Polygon{}.BoundingBox()
+		for _, item := range s.items {
+			if b := item.shape.BoundingBox(); !b.IsEmpty() {
+				box = box.Union(b)
+			}
+		}
+	}
+	if box.IsEmpty() {
+		box = BoundingBox{}
+	}
+
+	// Leave at least 1 pixel for the inner width and height, however wide the margins.
+	width := math.Max(1, s.options.Width-2*s.options.Margin)
+	height := math.Max(1, s.options.Height-2*s.options.Margin)
+	dx, dy := box.Max.X-box.Min.X, box.Max.Y-box.Min.Y
+	scale := 1.0
+	switch {
+	case (dx > 0) && (dy > 0):
+		scale = math.Min(width/dx, height/dy)
+	case dx > 0:
+		scale = width / dx
+	case dy > 0:
+		scale = height / dy
+	}
+	// Center the box in the image.
+	center := box.Min.Plus(box.Max).Divide(2)
+	origin := Point{s.options.Width / 2, s.options.Height / 2}.Minus(center.Times(scale))
+	return svg_transform{scale: scale, origin: origin, height: s.options.Height}
+}
+
+// render writes the whole SVG document.
+func (s *SVG) render(b *bytes.Buffer) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n",
+		svg_number(s.options.Width), svg_number(s.options.Height), svg_number(s.options.Width), svg_number(s.options.Height))
+	t := s.transform()
+	for _, item := range s.items {
+		if item.label != "" {
+			render_label(b, t, item.shape.(Point), item.label, item.style)
+		} else {
+			render_shape(b, t, item.shape, item.style)
+		}
+	}
+	b.WriteString("</svg>\n")
+}
+
+// render_label writes a <text> element.
+func render_label(b *bytes.Buffer, t svg_transform, p Point, text string, style Style) {
+	if check_finite(p) != nil {
+		return
+	}
+	at := t.apply(p)
+	color := style.Fill
+	if color == "" {
+		color = style.Stroke
+	}
+	if color == "" {
+		color = "black"
+	}
+	size := style.FontSize
+	if size == 0 {
+		size = 12
+	}
+	fmt.Fprintf(b, `<text x="%v" y="%v" font-family="sans-serif" font-size="%v" fill="%v">`,
+		svg_number(at.X), svg_number(at.Y), svg_number(size), svg_escape(color))
+	xml.EscapeText(b, []byte(text))
+	b.WriteString("</text>\n")
+}
+
+// render_shape writes the elements that draw a shape.
+func render_shape(b *bytes.Buffer, t svg_transform, shape Bounded, style Style) {
+	switch s := shape.(type) {
+	case Point:
+		if check_finite(s) != nil {
+			return
+		}
+		at := t.apply(s)
+		radius := style.PointRadius
+		if radius == 0 {
+			radius = 3
+		}
+		color := style.Stroke
+		if color == "" {
+			color = "black"
+		}
+		fmt.Fprintf(b, `<circle cx="%v" cy="%v" r="%v" fill="%v"/>`+"\n",
+			svg_number(at.X), svg_number(at.Y), svg_number(radius), svg_escape(color))
+	case LineSegment:
+		render_path(b, t, [][]Point{{s.P1, s.P2}}, false, Style{Stroke: style.Stroke, StrokeWidth: style.StrokeWidth, Dashed: style.Dashed})
+	case Polyline:
+		render_path(b, t, [][]Point{s.Points}, false, Style{Stroke: style.Stroke, StrokeWidth: style.StrokeWidth, Dashed: style.Dashed})
+	case Triangle:
+		render_path(b, t, [][]Point{{s.P1, s.P2, s.P3}}, true, style)
+	case Polygon:
+		render_path(b, t, [][]Point{s.Points}, true, style)
+	case PolygonWithHoles:
+		var rings [][]Point
+		for _, ring := range s.Rings() {
+			rings = append(rings, ring.Points)
+		}
+		render_path(b, t, rings, true, style)
+	case MultiPolygon:
+		var rings [][]Point
+		for _, p := range s.Polygons {
+			for _, ring := range p.Rings() {
+				rings = append(rings, ring.Points)
+			}
+		}
+		render_path(b, t, rings, true, style)
+	case MultiPoint:
+		for _, p := range s.Points {
+			render_shape(b, t, p, style)
+		}
+	case MultiPolyline:
+		for _, l := range s.Polylines {
+			render_shape(b, t, l, style)
+		}
+	case GeometryCollection:
+		for _, g := range s.Geometries {
+			render_shape(b, t, g, style)
+		}
+	default:
+		if box := shape.BoundingBox(); !box.IsEmpty() {
+			render_shape(b, t, box.Polygon(), style)
+		}
+	}
+}
+
+// render_path writes a <path> element through the `paths`, closing each if `closed`.
+// Closed paths are filled by the even-odd rule, so that Holes are left unfilled.
+func render_path(b *bytes.Buffer, t svg_transform, paths [][]Point, closed bool, style Style) {
+	var d strings.Builder
+	for _, path := range paths {
+		command := "M"
+		for _, p := range path {
+			if check_finite(p) != nil {
+				continue
+			}
+			at := t.apply(p)
+			fmt.Fprintf(&d, "%v%v %v ", command, svg_number(at.X), svg_number(at.Y))
+			command = "L"
+		}
+		if closed && (command == "L") {
+			d.WriteString("Z ")
+		}
+	}
+	if d.Len() == 0 {
+		return
+	}
+
+	stroke, width, fill := style.Stroke, style.StrokeWidth, style.Fill
+	if stroke == "" {
+		stroke = "black"
+	}
+	if width == 0 {
+		width = 1
+	}
+	if fill == "" {
+		fill = "none"
+	}
+	fmt.Fprintf(b, `<path d="%v" stroke="%v" stroke-width="%v" fill="%v"`,
+		strings.TrimSpace(d.String()), svg_escape(stroke), svg_number(width), svg_escape(fill))
+	if closed {
+		b.WriteString(` fill-rule="evenodd"`)
+	}
+	if style.Opacity != 0 {
+		fmt.Fprintf(b, ` fill-opacity="%v"`, svg_number(style.Opacity))
+	}
+	if style.Dashed {
+		fmt.Fprintf(b, ` stroke-dasharray="%v"`, svg_number(4*width))
+	}
+	b.WriteString("/>\n")
+}
+
+// svg_number formats a pixel coordinate to a hundredth of a pixel.
+func svg_number(x float64) string {
+	// Adding 0 turns -0 into 0.
+	return strconv.FormatFloat(math.Round(x*100)/100+0, 'f', -1, 64)
+}
+
+// svg_escape escapes a string for use in an attribute.
+func svg_escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package gogeo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// debug_svg writes a scene to a file that outlives the test, and logs where it is, so
+// that a failing case can be looked at.
+func debug_svg(t *testing.T, scene *SVG) {
+	t.Helper()
+	f, err := ioutil.TempFile("", strings.ReplaceAll(t.Name(), "/", "_")+"-*.svg")
+	if err != nil {
+		t.Logf("could not write an SVG: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := scene.WriteTo(f); err != nil {
+		t.Logf("could not write an SVG: %v", err)
+		return
+	}
+	t.Logf("wrote a picture of the case to %v", f.Name())
+}
+
+func TestSVG(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		viewport *BoundingBox
+		draw     func(s *SVG)
+		want     []string
+		avoid    []string
+	}{
+		{
+			desc: "Fitted and flipped",
+			draw: func(s *SVG) { s.Add(Style{}, unit_square) },
+			want: []string{`<path d="M10 90 L90 90 L90 10 L10 10 Z" stroke="black" stroke-width="1" fill="none" fill-rule="evenodd"/>`},
+		},
+		{
+			desc: "Scaled equally, and centered",
+			draw: func(s *SVG) { s.Add(Style{}, LineSegment{Point{0, 0}, Point{4, 2}}) },
+			want: []string{`<path d="M10 70 L90 30" stroke="black" stroke-width="1" fill="none"/>`},
+		},
+		{
+			desc: "A single Point in the center",
+			draw: func(s *SVG) { s.Add(Style{Stroke: "red", PointRadius: 2}, Point{5, 5}) },
+			want: []string{`<circle cx="50" cy="50" r="2" fill="red"/>`},
+		},
+		{
+			desc:     "Viewport",
+			viewport: &BoundingBox{Point{0, 0}, Point{4, 4}},
+			draw:     func(s *SVG) { s.Add(Style{}, Polyline{[]Point{{0, 0}, {1, 1}, {2, 2}}}) },
+			want:     []string{`d="M10 90 L30 70 L50 50"`},
+		},
+		{
+			desc: "Styled, with Holes",
+			draw: func(s *SVG) {
+				s.Add(Style{Stroke: "#00f", StrokeWidth: 2, Fill: "blue", Opacity: 0.5, Dashed: true}, swiss_cheese)
+			},
+			want: []string{
+				`d="M10 90 L90 90 L90 10 L10 10 Z M26 58 L42 58 L42 74 L26 74 Z M58 26 L74 26 L74 42 L58 42 Z"`,
+				`stroke="#00f" stroke-width="2" fill="blue" fill-rule="evenodd" fill-opacity="0.5" stroke-dasharray="8"`,
+			},
+		},
+		{
+			desc: "Collections",
+			draw: func(s *SVG) {
+				s.Add(Style{}, GeometryCollection{[]Bounded{MultiPoint{[]Point{{0, 0}, {1, 1}}}, staircase}})
+			},
+			want: []string{`<circle cx="10" cy="90"`, `<circle cx="36.67" cy="63.33"`, `<path d="M10 90 L36.67 90`},
+		},
+		{
+			desc: "Other shapes as their BoundingBox",
+			draw: func(s *SVG) { s.Add(Style{}, circle{Point{0, 0}, 1}) },
+			want: []string{`d="M10 90 L90 90 L90 10 L10 10 Z"`},
+		},
+		{
+			desc: "Labels, escaped",
+			draw: func(s *SVG) {
+				s.Add(Style{}, unit_square)
+				s.Label(Point{0.5, 0.5}, "a < b & c", Style{Stroke: "green", FontSize: 10})
+				s.Label(Point{5, 5}, "", Style{})
+			},
+			want:  []string{`<text x="50" y="50" font-family="sans-serif" font-size="10" fill="green">a &lt; b &amp; c</text>`},
+			avoid: []string{"<circle"},
+		},
+		{
+			desc:  "Empty shapes",
+			draw:  func(s *SVG) { s.Add(Style{}, Polygon{}, MultiPolygon{}, Polyline{}) },
+			avoid: []string{"<path"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := NewSVG(SVGOptions{Width: 100, Height: 100, Margin: 10, Viewport: tC.viewport})
+			tC.draw(s)
+			got := s.String()
+			if !strings.HasPrefix(got, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100" viewBox="0 0 100 100">`) ||
+				!strings.HasSuffix(got, "</svg>\n") {
+				t.Errorf("String() = %v, want an <svg> element", got)
+			}
+			for _, want := range tC.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("String() = %v, want it to contain %v", got, want)
+				}
+			}
+			for _, avoid := range tC.avoid {
+				if strings.Contains(got, avoid) {
+					t.Errorf("String() = %v, want it not to contain %v", got, avoid)
+				}
+			}
+		})
+	}
+}
+
+func TestSVGWriteFile(t *testing.T) {
+	s := NewSVG(SVGOptions{})
+	s.Add(Style{Fill: "orange"}, regular_polygon(Point{0, 0}, 1, 6))
+	path := filepath.Join(t.TempDir(), "hexagon.svg")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() returned %v", err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() returned %v", err)
+	}
+	if string(got) != s.String() {
+		t.Errorf("WriteFile() wrote %s, want %s", got, s.String())
+	}
+}
@@ -0,0 +1,205 @@
+package gogeo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned when choosing a UTM projection.
+var (
+	// ErrInvalidUTMZone is returned for a UTM zone that is not from 1 to 60.
+	ErrInvalidUTMZone = errors.New("invalid UTM zone")
+	// ErrOutsideUTM is returned for a latitude outside the -80 to 84 degrees that UTM
+	// covers. The polar regions use the Universal Polar Stereographic projection instead.
+	ErrOutsideUTM = errors.New("latitude is outside UTM")
+)
+
+// TransverseMercator is the Mercator projection of an Ellipsoid wrapped around a
+// meridian rather than the equator. It is conformal, and its scale is exact along the
+// CentralMeridian, growing with the distance x from it as about 1 + x²/2R². It uses
+// Krüger's series to sixth order in the third flattening, as given by Karney, which are
+// accurate to a few nanometers within 4000 kilometers of the CentralMeridian.
+type TransverseMercator struct {
+	// CentralMeridian is the longitude in degrees of the meridian that is projected to
+	// X = FalseEasting. The equator is projected to Y = FalseNorthing.
+	CentralMeridian float64
+	// Scale multiplies every distance in the plane. Zero uses 1.
+	Scale float64
+	// FalseEasting and FalseNorthing are added to X and Y, to keep them positive.
+	FalseEasting  float64
+	FalseNorthing float64
+	// Ellipsoid is the shape of the Earth. Zero uses WGS84.
+	Ellipsoid Ellipsoid
+}
+
+// NewUTM returns the projection of a Universal Transverse Mercator zone, from 1 to 60,
+// in the northern or southern hemisphere. Each zone is 6 degrees of longitude wide,
+// starting from zone 1 at -180, and is projected with a Scale of 0.9996, so that the
+// scale is exact about 180 kilometers either side of the central meridian. UTM is meant
+// for latitudes from -80 to 84.
+func NewUTM(zone int, north bool) (TransverseMercator, error) {
+	if (zone < 1) || (zone > 60) {
+		return TransverseMercator{}, fmt.Errorf("zone %v: %w", zone, ErrInvalidUTMZone)
+	}
+	utm := TransverseMercator{
+		CentralMeridian: float64(6*zone - 183),
+		Scale:           0.9996,
+		FalseEasting:    500000,
+		Ellipsoid:       WGS84,
+	}
+	if !north {
+		utm.FalseNorthing = 10000000
+	}
+	return utm, nil
+}
+
+// UTMZone returns the UTM zone that a LatLng is in, including the wider zones 32V over
+// southern Norway, and 31X, 33X, 35X and 37X over Svalbard. The latitude is not checked,
+// so a LatLng in the polar regions, which UTM does not cover, gets the zone of its
+// longitude.
+func UTMZone(l LatLng) int {
+	lng := wrap_degrees(l.Lng)
+	zone := int(math.Floor((lng+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	switch {
+	case (l.Lat >= 56) && (l.Lat < 64) && (lng >= 3) && (lng < 12):
+		return 32
+	case (l.Lat >= 72) && (l.Lat <= 84) && (lng >= 0) && (lng < 42):
+		return []int{31, 33, 35, 37}[int(lng+3)/12]
+	}
+	return zone
+}
+
+// UTMFor returns the UTM projection for the zone and hemisphere that a LatLng is in. It
+// returns ErrOutsideUTM if the latitude is not from -80 to 84.
+func UTMFor(l LatLng) (TransverseMercator, error) {
+	if !((l.Lat >= -80) && (l.Lat <= 84)) {
+		return TransverseMercator{}, fmt.Errorf("%v: %w", l, ErrOutsideUTM)
+	}
+	return NewUTM(UTMZone(l), l.Lat >= 0)
+}
+
+// Project returns the TransverseMercator Point for a LatLng.
+func (t TransverseMercator) Project(l LatLng) Point {
+	s := t.series()
+	lambda := radians(wrap_degrees(l.Lng - t.CentralMeridian))
+	slam, clam := math.Sincos(lambda)
+
+	// τ' is the tangent of the conformal latitude, which gives the position (ξ', η') on
+	// the transverse Mercator projection of a sphere.
+	tau_prime := s.conformal_tan(math.Tan(radians(l.Lat)))
+	xi_prime := math.Atan2(tau_prime, clam)
+	eta_prime := math.Asinh(slam / math.Hypot(tau_prime, clam))
+
+	// Krüger's series then map the sphere onto the Ellipsoid.
+	xi, eta := xi_prime, eta_prime
+	for j := 1; j <= 6; j++ {
+		s2, c2 := math.Sincos(2 * float64(j) * xi_prime)
+		xi += s.alpha[j] * s2 * math.Cosh(2*float64(j)*eta_prime)
+		eta += s.alpha[j] * c2 * math.Sinh(2*float64(j)*eta_prime)
+	}
+	k := t.scale() * s.rectifying_radius
+	return Point{t.FalseEasting + k*eta, t.FalseNorthing + k*xi}
+}
+
+// Unproject returns the LatLng for a TransverseMercator Point.
+func (t TransverseMercator) Unproject(p Point) LatLng {
+	s := t.series()
+	k := t.scale() * s.rectifying_radius
+	xi, eta := (p.Y-t.FalseNorthing)/k, (p.X-t.FalseEasting)/k
+
+	xi_prime, eta_prime := xi, eta
+	for j := 1; j <= 6; j++ {
+		s2, c2 := math.Sincos(2 * float64(j) * xi)
+		xi_prime -= s.beta[j] * s2 * math.Cosh(2*float64(j)*eta)
+		eta_prime -= s.beta[j] * c2 * math.Sinh(2*float64(j)*eta)
+	}
+
+	sxi, cxi := math.Sincos(xi_prime)
+	sinh_eta := math.Sinh(eta_prime)
+	tau_prime := sxi / math.Hypot(sinh_eta, cxi)
+	lambda := math.Atan2(sinh_eta, cxi)
+	return LatLng{
+		degrees(math.Atan(s.geodetic_tan(tau_prime))),
+		wrap_degrees(t.CentralMeridian + degrees(lambda)),
+	}
+}
+
+// scale returns the Scale, using 1 if it is zero.
+func (t TransverseMercator) scale() float64 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+// krueger_series holds the coefficients of Krüger's series for an Ellipsoid.
+type krueger_series struct {
+	// eccentricity is the first eccentricity, e.
+	eccentricity float64
+	// rectifying_radius is A, the radius of the sphere with the same meridian length.
+	rectifying_radius float64
+	// alpha and beta are the coefficients of the forward and inverse series, from 1.
+	alpha [7]float64
+	beta  [7]float64
+}
+
+// series returns the coefficients of Krüger's series for the Ellipsoid.
+func (t TransverseMercator) series() krueger_series {
+	e := t.Ellipsoid
+	if e == (Ellipsoid{}) {
+		e = WGS84
+	}
+	n := e.F / (2 - e.F)
+	n2, n3 := n*n, n*n*n
+	n4, n5, n6 := n2*n2, n2*n3, n3*n3
+	return krueger_series{
+		eccentricity:      math.Sqrt(e.F * (2 - e.F)),
+		rectifying_radius: e.A / (1 + n) * (1 + n2/4 + n4/64 + n6/256),
+		alpha: [7]float64{
+			0,
+			n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+			13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+			61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+			49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+			34729*n5/80640 - 3418889*n6/1995840,
+			212378941 * n6 / 319334400,
+		},
+		beta: [7]float64{
+			0,
+			n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+			n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+			17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+			4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+			4583*n5/161280 - 108847*n6/3991680,
+			20648693 * n6 / 638668800,
+		},
+	}
+}
+
+// conformal_tan returns the tangent of the conformal latitude, τ', for the tangent of a
+// geodetic latitude, τ.
+func (s krueger_series) conformal_tan(tau float64) float64 {
+	e := s.eccentricity
+	sigma := math.Sinh(e * math.Atanh(e*tau/math.Sqrt(1+tau*tau)))
+	return tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+}
+
+// geodetic_tan inverts conformal_tan by Newton's method, as Karney does.
+func (s krueger_series) geodetic_tan(tau_prime float64) float64 {
+	e_sq := s.eccentricity * s.eccentricity
+	tau := tau_prime
+	for i := 0; i < 10; i++ {
+		got := s.conformal_tan(tau)
+		step := (tau_prime - got) / math.Sqrt(1+got*got) *
+			(1 + (1-e_sq)*tau*tau) / ((1 - e_sq) * math.Sqrt(1+tau*tau))
+		tau += step
+		if !(math.Abs(step) > 1e-12*math.Max(1, math.Abs(tau))) {
+			break
+		}
+	}
+	return tau
+}
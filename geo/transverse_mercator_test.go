@@ -0,0 +1,134 @@
+package gogeo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTransverseMercator(t *testing.T) {
+	zone31, _ := NewUTM(31, true)
+	zone31_south, _ := NewUTM(31, false)
+	// The length of the meridian from the equator to a latitude of 45.
+	arc := WGS84.Inverse(LatLng{0, 3}, LatLng{45, 3}).Distance
+	testCases := []struct {
+		desc       string
+		projection TransverseMercator
+		in         LatLng
+		want       Point
+	}{
+		{"Central meridian on the equator", zone31, LatLng{0, 3}, Point{500000, 0}},
+		{"Western edge of zone 31", zone31, LatLng{0, 0}, Point{166021.4431, 0}},
+		{"Eastern edge of zone 31", zone31, LatLng{0, 6}, Point{833978.5569, 0}},
+		{"Central meridian in the north", zone31, LatLng{45, 3}, Point{500000, 0.9996 * arc}},
+		{"Central meridian in the south", zone31_south, LatLng{-45, 3}, Point{500000, 10000000 - 0.9996*arc}},
+		{"Default scale and ellipsoid", TransverseMercator{CentralMeridian: 3}, LatLng{45, 3}, Point{0, arc}},
+		{
+			desc:       "Sphere",
+			projection: TransverseMercator{Ellipsoid: Ellipsoid{A: EarthRadius}},
+			in:         LatLng{30, 0},
+			want:       Point{0, EarthRadius * math.Pi / 6},
+		},
+		{
+			// On a sphere, the equator is projected as it is by Mercator.
+			desc:       "Sphere along the equator",
+			projection: TransverseMercator{Ellipsoid: Ellipsoid{A: EarthRadius}},
+			in:         LatLng{0, 60},
+			want:       Point{EarthRadius * math.Atanh(math.Sin(math.Pi/3)), 0},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := tC.projection.Project(tC.in)
+			if (math.Abs(got.X-tC.want.X) > 1e-4) || (math.Abs(got.Y-tC.want.Y) > 1e-4) {
+				t.Errorf("Project(%v) = %v, want %v", tC.in, got, tC.want)
+			}
+			if back := tC.projection.Unproject(got); (math.Abs(back.Lat-tC.in.Lat) > 1e-9) ||
+				(angle_difference(back.Lng, tC.in.Lng) > 1e-9) {
+				t.Errorf("Unproject(%v) = %v, want %v", got, back, tC.in)
+			}
+		})
+	}
+}
+
+func TestUTMZone(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		in    LatLng
+		zone  int
+		north bool
+	}{
+		{"First zone", LatLng{10, -180}, 1, true},
+		{"Last zone", LatLng{-10, 179.9}, 60, false},
+		{"Longitude of 180", LatLng{-10, 180}, 1, false},
+		{"Greenwich", LatLng{51.48, 0}, 31, true},
+		{"Just west of Greenwich", LatLng{51.48, -0.01}, 30, true},
+		{"Equator", LatLng{0, 0}, 31, true},
+		{"Bergen, in the wide zone 32V", LatLng{60.39, 5.32}, 32, true},
+		{"Shetland, west of zone 32V", LatLng{60.39, 2.9}, 31, true},
+		{"Trondheim, north of zone 32V", LatLng{64.5, 5.32}, 31, true},
+		{"Svalbard, zone 31X", LatLng{79, 8}, 31, true},
+		{"Longyearbyen, zone 33X", LatLng{78.22, 15.65}, 33, true},
+		{"Svalbard, zone 35X", LatLng{80, 25}, 35, true},
+		{"Franz Josef Land, zone 37X", LatLng{80.5, 41}, 37, true},
+		{"East of Svalbard", LatLng{80.5, 43}, 38, true},
+		{"Southern limit", LatLng{-80, 0}, 31, false},
+		{"Northern limit", LatLng{84, 0}, 31, true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := UTMZone(tC.in); got != tC.zone {
+				t.Fatalf("UTMZone(%v) = %v, want %v", tC.in, got, tC.zone)
+			}
+			want, _ := NewUTM(tC.zone, tC.north)
+			if got, err := UTMFor(tC.in); (err != nil) || (got != want) {
+				t.Errorf("UTMFor(%v) = %+v, %v, want %+v", tC.in, got, err, want)
+			}
+			// Every zone can be used a little outside of its edges.
+			p := want.Project(tC.in)
+			if (p.X < 100000) || (p.X > 900000) || (p.Y < 0) || (p.Y > 10000000) {
+				t.Errorf("UTMFor(%v).Project() = %v, which is outside the zone", tC.in, p)
+			}
+		})
+	}
+
+	for _, l := range []LatLng{{-85, 0}, {-80.01, 10}, {84.01, 10}, {90, 0}, {math.NaN(), 0}} {
+		if _, err := UTMFor(l); !errors.Is(err, ErrOutsideUTM) {
+			t.Errorf("UTMFor(%v) returned %v, want %v", l, err, ErrOutsideUTM)
+		}
+	}
+
+	for _, zone := range []int{0, 61, -5} {
+		if _, err := NewUTM(zone, true); !errors.Is(err, ErrInvalidUTMZone) {
+			t.Errorf("NewUTM(%v) returned %v, want %v", zone, err, ErrInvalidUTMZone)
+		}
+	}
+}
+
+func TestTransverseMercatorIntersects(t *testing.T) {
+	// A GPS track heads due north across the shortest path between two points on the
+	// same latitude in Norway. The path bulges about 400 meters north of the straight
+	// line in degrees between them, so the track only crosses it in a conformal
+	// projection, where short paths stay straight.
+	west, east := LatLng{60, 8}, LatLng{60, 10}
+	south, north := LatLng{60.002, 9}, LatLng{60.006, 9}
+	in_degrees := func(l LatLng) Point { return Point{l.Lng, l.Lat} }
+	if (LineSegment{in_degrees(west), in_degrees(east)}).Intersects(LineSegment{in_degrees(south), in_degrees(north)}) {
+		t.Fatalf("Intersects() in degrees = true, want false")
+	}
+
+	projection, _ := UTMFor(south)
+	path := LineSegment{projection.Project(west), projection.Project(east)}
+	track := LineSegment{projection.Project(south), projection.Project(north)}
+	crossing := path.Intersection(track)
+	if crossing.Kind != PointIntersection {
+		t.Fatalf("Intersection() in UTM = %+v, want a Point", crossing)
+	}
+
+	// The crossing is where the path is furthest north, halfway along it.
+	g := WGS84.Inverse(west, east)
+	want, _ := WGS84.Direct(west, g.InitialBearing, g.Distance/2)
+	if got := projection.Unproject(crossing.Point); WGS84.Inverse(got, want).Distance > 1 {
+		t.Errorf("Intersection() in UTM is at %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,687 @@
+package gogeo
+
+import (
+	"sort"
+)
+
+// TriangulationAlgorithm selects the algorithm used by Triangulate.
+type TriangulationAlgorithm int
+
+const (
+	// EarClipping joins each Hole to the Shell with a bridge, making a single ring, and
+	// then repeatedly cuts off an ear, a triangle formed by three consecutive vertices
+	// with no other vertex inside it. It takes O(n²) time.
+	EarClipping TriangulationAlgorithm = iota
+	// MonotonePartition sweeps down the Polygon, adding diagonals that split it into
+	// pieces which no horizontal line crosses more than twice, and then triangulates
+	// each piece in a single pass. It takes O(n log n) time.
+	MonotonePartition
+)
+
+// Triangulate splits the PolygonWithHoles into counter-clockwise Triangles whose
+// vertices are vertices of the PolygonWithHoles. Vertices that repeat the one before
+// them, or lie on the line through their neighbors, are dropped first, as they add no
+// area. Every Triangle is cut from what remains of a ring by removing a single vertex,
+// so the Areas of the Triangles always sum to the Area of the PolygonWithHoles, up to
+// floating point rounding.
+//
+// The PolygonWithHoles should be valid (see Validate), though the orientation of its
+// rings does not matter.
+func (p PolygonWithHoles) Triangulate(algorithm TriangulationAlgorithm) []Triangle {
+	p = p.Oriented()
+	shell := clean_ring(p.Shell.Points)
+	if shell == nil {
+		return nil
+	}
+	var holes [][]Point
+	for _, hole := range p.Holes {
+		if ring := clean_ring(hole.Points); ring != nil {
+			holes = append(holes, ring)
+		}
+	}
+
+	switch algorithm {
+	case MonotonePartition:
+		return monotone_triangulation(shell, holes)
+	default:
+		return ear_clipping(shell, holes)
+	}
+}
+
+// Triangulate splits the Polygon into counter-clockwise Triangles. See
+// PolygonWithHoles.Triangulate.
+func (p Polygon) Triangulate(algorithm TriangulationAlgorithm) []Triangle {
+	return PolygonWithHoles{Shell: p}.Triangulate(algorithm)
+}
+
+// Triangulate splits each part of the MultiPolygon into counter-clockwise Triangles. See
+// PolygonWithHoles.Triangulate.
+func (m MultiPolygon) Triangulate(algorithm TriangulationAlgorithm) []Triangle {
+	var triangles []Triangle
+	for _, polygon := range m.Polygons {
+		triangles = append(triangles, polygon.Triangulate(algorithm)...)
+	}
+	return triangles
+}
+
+// clean_ring returns the Points of a ring without those that repeat the Point before
+// them, or that lie on the line through their neighbors. It returns nil if fewer than
+// three Points are left, as the ring then has no area.
+func clean_ring(ring []Point) []Point {
+	cleaned := make([]Point, 0, len(ring))
+	for _, p := range ring {
+		for n := len(cleaned); n > 0; n = len(cleaned) {
+			if !cleaned[n-1].Equals(p) && ((n == 1) || (Orient2D(cleaned[n-2], cleaned[n-1], p) != 0)) {
+				break
+			}
+			cleaned = cleaned[:n-1]
+		}
+		cleaned = append(cleaned, p)
+	}
+
+	// The same again, across the join from the end of the ring back to its start.
+	for n := len(cleaned); n >= 3; n = len(cleaned) {
+		if cleaned[n-1].Equals(cleaned[0]) || (Orient2D(cleaned[n-2], cleaned[n-1], cleaned[0]) == 0) {
+			cleaned = cleaned[:n-1]
+		} else if Orient2D(cleaned[n-1], cleaned[0], cleaned[1]) == 0 {
+			cleaned = cleaned[1:]
+		} else {
+			break
+		}
+	}
+	if len(cleaned) < 3 {
+		return nil
+	}
+	return cleaned
+}
+
+// ring_list holds rings as doubly linked lists of vertices, with the interior to the
+// left of each ring, so that vertices can be removed and rings joined in constant time.
+type ring_list struct {
+	points []Point
+	prev   []int
+	next   []int
+}
+
+// add_ring adds a ring to the list, and returns the index of its first vertex.
+func (l *ring_list) add_ring(ring []Point) int {
+	start := len(l.points)
+	for i, p := range ring {
+		l.points = append(l.points, p)
+		l.prev = append(l.prev, start+(i+len(ring)-1)%len(ring))
+		l.next = append(l.next, start+(i+1)%len(ring))
+	}
+	return start
+}
+
+// remove takes vertex `i` out of its ring.
+func (l *ring_list) remove(i int) {
+	l.next[l.prev[i]] = l.next[i]
+	l.prev[l.next[i]] = l.prev[i]
+}
+
+// bridge joins the ring holding vertex `b` into the ring holding vertex `a`, with a pair
+// of edges between them. Both vertices are copied, so that the joined ring runs from `a`
+// to `b`, all the way around the ring of `b` to the copy of `b`, and then on from the
+// copy of `a`.
+func (l *ring_list) bridge(a, b int) {
+	a2, b2 := len(l.points), len(l.points)+1
+	l.points = append(l.points, l.points[a], l.points[b])
+	l.prev = append(l.prev, b2, l.prev[b])
+	l.next = append(l.next, l.next[a], a2)
+
+	l.prev[l.next[a]] = a2
+	l.next[l.prev[b]] = b2
+	l.next[a] = b
+	l.prev[b] = a
+}
+
+// locally_inside tests if the direction from vertex `i` to `q` lies within the interior
+// angle of its ring at `i`.
+func (l *ring_list) locally_inside(i int, q Point) bool {
+	prev, p, next := l.points[l.prev[i]], l.points[i], l.points[l.next[i]]
+	if Orient2D(prev, p, next) > 0 {
+		return (Orient2D(p, next, q) >= 0) && (Orient2D(p, prev, q) <= 0)
+	}
+	return (Orient2D(p, next, q) >= 0) || (Orient2D(p, prev, q) <= 0)
+}
+
+// visible tests if the segment from `p` to `q` crosses no edge of any ring, and passes
+// through no vertex on the way.
+func (l *ring_list) visible(p, q Point) bool {
+	segment := LineSegment{p, q}
+	for i, u := range l.points {
+		v := l.points[l.next[i]]
+		uv := sign(Orient2D(p, q, u))
+		if (uv == 0) && !u.Equals(p) && !u.Equals(q) && segment.collinear_point_within(u) {
+			return false
+		}
+		if (uv*sign(Orient2D(p, q, v)) < 0) &&
+			(sign(Orient2D(u, v, p))*sign(Orient2D(u, v, q)) < 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// ear_clipping triangulates a shell with holes by bridging each hole into the shell,
+// from its leftmost vertex, and then clipping ears from the single ring that is left.
+func ear_clipping(shell []Point, holes [][]Point) []Triangle {
+	l := &ring_list{}
+	outer := l.add_ring(shell)
+	leftmost := make([]int, len(holes))
+	for i, hole := range holes {
+		start := l.add_ring(hole)
+		leftmost[i] = start
+		for j := range hole {
+			if less_xy(hole[j], hole[leftmost[i]-start]) {
+				leftmost[i] = start + j
+			}
+		}
+	}
+
+	// Working from left to right, every hole still to be bridged lies to the right of
+	// the current one.
+	sort.Slice(leftmost, func(i, j int) bool {
+		return less_xy(l.points[leftmost[i]], l.points[leftmost[j]])
+	})
+	for _, h := range leftmost {
+		l.bridge_hole(outer, h)
+	}
+	return l.clip_ears(outer)
+}
+
+// bridge_hole joins the ring holding vertex `h` into the ring holding vertex `outer`, at
+// the nearest vertex that can see `h`.
+func (l *ring_list) bridge_hole(outer, h int) {
+	var candidates []int
+	for i := outer; ; {
+		candidates = append(candidates, i)
+		if i = l.next[i]; i == outer {
+			break
+		}
+	}
+	p := l.points[h]
+	distances := make([]float64, len(l.points))
+	for _, i := range candidates {
+		distances[i] = l.points[i].Minus(p).Magnitude()
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distances[candidates[i]] < distances[candidates[j]]
+	})
+
+	for _, i := range candidates {
+		if l.locally_inside(i, p) && l.locally_inside(h, l.points[i]) && l.visible(l.points[i], p) {
+			l.bridge(i, h)
+			return
+		}
+	}
+	// There is always a visible vertex when the rings are valid.
+	l.bridge(candidates[0], h)
+}
+
+// clip_ears triangulates the ring holding vertex `ear` by cutting off one ear at a time.
+func (l *ring_list) clip_ears(ear int) []Triangle {
+	triangles := make([]Triangle, 0, len(l.points))
+	stop := ear
+	for l.prev[ear] != l.next[ear] {
+		a, c := l.prev[ear], l.next[ear]
+		orientation := Orient2D(l.points[a], l.points[ear], l.points[c])
+		if (orientation == 0) || ((orientation > 0) && l.is_ear(a, ear, c)) {
+			// A vertex on the line through its neighbors can simply be removed.
+			if orientation > 0 {
+				triangles = append(triangles, Triangle{l.points[a], l.points[ear], l.points[c]})
+			}
+			l.remove(ear)
+			ear, stop = c, c
+			continue
+		}
+
+		if ear = c; ear != stop {
+			continue
+		}
+		// There is no ear, which only happens when the rings are not valid. Cutting off
+		// any convex vertex keeps the total area right.
+		for ear = l.next[stop]; (ear != stop) &&
+			(Orient2D(l.points[l.prev[ear]], l.points[ear], l.points[l.next[ear]]) <= 0); ear = l.next[ear] {
+		}
+		a, c = l.prev[ear], l.next[ear]
+		if Orient2D(l.points[a], l.points[ear], l.points[c]) <= 0 {
+			break
+		}
+		triangles = append(triangles, Triangle{l.points[a], l.points[ear], l.points[c]})
+		l.remove(ear)
+		ear, stop = c, c
+	}
+	return triangles
+}
+
+// is_ear tests if the convex vertex `b`, between `a` and `c`, is an ear: no other vertex
+// of its ring lies inside the triangle they form, or on its boundary. Copies of `a`, `b`
+// and `c` made by bridges are allowed at the corners.
+func (l *ring_list) is_ear(a, b, c int) bool {
+	triangle := Triangle{l.points[a], l.points[b], l.points[c]}
+	box := triangle.BoundingBox()
+	for i := l.next[c]; i != a; i = l.next[i] {
+		p := l.points[i]
+		if !box.ContainsPoint(p) {
+			continue
+		}
+		switch triangle.LocatePoint(p) {
+		case Interior:
+			return false
+		case Boundary:
+			if !p.Equals(triangle.P1) && !p.Equals(triangle.P2) && !p.Equals(triangle.P3) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// monotone_triangulation triangulates a shell with holes by splitting it into monotone
+// pieces, and then triangulating each piece.
+func monotone_triangulation(shell []Point, holes [][]Point) []Triangle {
+	l := &ring_list{}
+	l.add_ring(shell)
+	for _, hole := range holes {
+		l.add_ring(hole)
+	}
+
+	triangles := make([]Triangle, 0, len(l.points)+2*len(holes))
+	for _, piece := range l.monotone_pieces(l.monotone_diagonals()) {
+		triangles = l.triangulate_monotone(piece, triangles)
+	}
+	return triangles
+}
+
+// above tests if vertex `i` is reached before vertex `j` by a line sweeping down the
+// plane: it is higher, or at the same height and further left.
+func (l *ring_list) above(i, j int) bool {
+	p, q := l.points[i], l.points[j]
+	if p.Y != q.Y {
+		return p.Y > q.Y
+	}
+	if p.X != q.X {
+		return p.X < q.X
+	}
+	return i < j
+}
+
+// monotone_diagonals sweeps down the rings, and finds the diagonals that split them
+// into monotone pieces (de Berg et al., Computational Geometry, chapter 3). A split
+// vertex, with the interior above it, is joined to a vertex above, and a merge vertex,
+// with the interior below it, is joined to a vertex below.
+func (l *ring_list) monotone_diagonals() [][2]int {
+	order := make([]int, len(l.points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return l.above(order[i], order[j]) })
+
+	// Each edge is known by the vertex it starts from. The helper of an edge is the
+	// lowest vertex passed so far with that edge directly to its left.
+	helper := make([]int, len(l.points))
+	merge := make([]bool, len(l.points))
+	status := new_sweep_status(l)
+	var diagonals [][2]int
+	join_merge := func(v, e int) {
+		if (e != -1) && merge[helper[e]] {
+			diagonals = append(diagonals, [2]int{v, helper[e]})
+		}
+	}
+
+	for _, v := range order {
+		prev, next := l.prev[v], l.next[v]
+		prev_above, next_above := l.above(prev, v), l.above(next, v)
+		convex := Orient2D(l.points[prev], l.points[v], l.points[next]) > 0
+
+		switch {
+		case !prev_above && !next_above && convex:
+			// A start vertex.
+			status.insert(v)
+			helper[v] = v
+		case !prev_above && !next_above:
+			// A split vertex.
+			if e := status.left_of(v); e != -1 {
+				diagonals = append(diagonals, [2]int{v, helper[e]})
+				helper[e] = v
+			}
+			status.insert(v)
+			helper[v] = v
+		case prev_above && next_above && convex:
+			// An end vertex.
+			join_merge(v, prev)
+			status.remove(prev)
+		case prev_above && next_above:
+			// A merge vertex.
+			merge[v] = true
+			join_merge(v, prev)
+			status.remove(prev)
+			if e := status.left_of(v); e != -1 {
+				join_merge(v, e)
+				helper[e] = v
+			}
+		case prev_above:
+			// A vertex with the interior to its right.
+			join_merge(v, prev)
+			status.remove(prev)
+			status.insert(v)
+			helper[v] = v
+		default:
+			// A vertex with the interior to its left.
+			if e := status.left_of(v); e != -1 {
+				join_merge(v, e)
+				helper[e] = v
+			}
+		}
+	}
+	return diagonals
+}
+
+// sweep_status holds the edges crossed by the sweep line, in order from west to east,
+// as a treap. Each edge is known by the vertex it starts from, and runs down to the next
+// vertex of its ring, so the interior of the rings is to its east.
+type sweep_status struct {
+	l     *ring_list
+	root  int
+	left  []int
+	right []int
+}
+
+func new_sweep_status(l *ring_list) *sweep_status {
+	return &sweep_status{l, -1, make([]int, len(l.points)), make([]int, len(l.points))}
+}
+
+// priority is the treap priority of edge `e`. Scrambling the index is enough to keep the
+// treap balanced, without depending on a random number generator.
+func (s *sweep_status) priority(e int) uint32 {
+	return uint32(e) * 2654435761
+}
+
+// west_of tests if edge `f` is west of edge `e`. The two must not cross, and must both
+// be crossed by the sweep line.
+func (s *sweep_status) west_of(f, e int) bool {
+	if f == e {
+		return false
+	}
+	f1, f2 := s.l.points[f], s.l.points[s.l.next[f]]
+	e1, e2 := s.l.points[e], s.l.points[s.l.next[e]]
+
+	// Both edges run down the plane, so east is to their left.
+	side_1, side_2 := sign(Orient2D(f1, f2, e1)), sign(Orient2D(f1, f2, e2))
+	if (side_1 >= 0) && (side_2 >= 0) && (side_1+side_2 > 0) {
+		return true
+	}
+	if (side_1 <= 0) && (side_2 <= 0) && (side_1+side_2 < 0) {
+		return false
+	}
+	if (side_1 == 0) && (side_2 == 0) {
+		return f < e
+	}
+	// The ends of `e` are on either side of the line through `f`, so `f` must lie to
+	// one side of `e`.
+	return sign(Orient2D(e1, e2, f1))+sign(Orient2D(e1, e2, f2)) < 0
+}
+
+// split splits the treap rooted at `t` into the edges west of `e`, and the rest.
+func (s *sweep_status) split(t, e int) (int, int) {
+	if t == -1 {
+		return -1, -1
+	}
+	if s.west_of(t, e) {
+		west, rest := s.split(s.right[t], e)
+		s.right[t] = west
+		return t, rest
+	}
+	west, rest := s.split(s.left[t], e)
+	s.left[t] = rest
+	return west, t
+}
+
+// join joins two treaps, where every edge of `a` is west of every edge of `b`.
+func (s *sweep_status) join(a, b int) int {
+	if a == -1 {
+		return b
+	}
+	if b == -1 {
+		return a
+	}
+	if s.priority(a) > s.priority(b) {
+		s.right[a] = s.join(s.right[a], b)
+		return a
+	}
+	s.left[b] = s.join(a, s.left[b])
+	return b
+}
+
+// insert adds edge `e` to the status.
+func (s *sweep_status) insert(e int) {
+	s.left[e], s.right[e] = -1, -1
+	west, rest := s.split(s.root, e)
+	s.root = s.join(s.join(west, e), rest)
+}
+
+// remove takes edge `e` out of the status.
+func (s *sweep_status) remove(e int) {
+	west, rest := s.split(s.root, e)
+	// Edge `e` is the westernmost of the rest, so has no left subtree once it is found.
+	parent := -1
+	t := rest
+	for (t != -1) && (t != e) {
+		parent, t = t, s.left[t]
+	}
+	if t == -1 {
+		s.root = s.join(west, rest)
+		return
+	}
+	if parent == -1 {
+		rest = s.right[e]
+	} else {
+		s.left[parent] = s.right[e]
+	}
+	s.root = s.join(west, rest)
+}
+
+// left_of finds the edge directly to the west of vertex `v`, or through it, or -1 if
+// there is none.
+func (s *sweep_status) left_of(v int) int {
+	p := s.l.points[v]
+	found := -1
+	for t := s.root; t != -1; {
+		if Orient2D(s.l.points[t], s.l.points[s.l.next[t]], p) >= 0 {
+			found, t = t, s.right[t]
+		} else {
+			t = s.left[t]
+		}
+	}
+	return found
+}
+
+// monotone_pieces splits the rings along the diagonals, and returns the vertices of
+// each piece in counter-clockwise order.
+func (l *ring_list) monotone_pieces(diagonals [][2]int) [][]int {
+	// The diagonals from each vertex, in counter-clockwise order starting from the edge
+	// to the next vertex of its ring. The interior angle at the vertex runs from that
+	// edge around to the edge from the previous vertex.
+	out := make([][]int, len(l.points))
+	for _, d := range diagonals {
+		out[d[0]] = append(out[d[0]], d[1])
+		out[d[1]] = append(out[d[1]], d[0])
+	}
+	for v, ends := range out {
+		if len(ends) > 1 {
+			v := v
+			sort.Slice(ends, func(i, j int) bool { return l.turns_before(v, ends[i], ends[j]) })
+		}
+	}
+
+	// Each piece is traced out by always taking the next edge clockwise from the one
+	// just followed, so that the piece stays to the left. Edges are given by a vertex
+	// and an index into its diagonals, with -1 for the edge of its ring.
+	ring_used := make([]bool, len(l.points))
+	used := make([][]bool, len(l.points))
+	for v := range out {
+		used[v] = make([]bool, len(out[v]))
+	}
+	var pieces [][]int
+	trace := func(v, k int) {
+		var piece []int
+		for {
+			var w, j int
+			if k == -1 {
+				if ring_used[v] {
+					break
+				}
+				ring_used[v] = true
+				w = l.next[v]
+				j = len(out[w])
+			} else {
+				if used[v][k] {
+					break
+				}
+				used[v][k] = true
+				w = out[v][k]
+				j = sort.Search(len(out[w]), func(i int) bool { return !l.turns_before(w, out[w][i], v) })
+			}
+			piece = append(piece, v)
+			v, k = w, j-1
+		}
+		pieces = append(pieces, piece)
+	}
+
+	for v := range out {
+		trace(v, -1)
+		for k := range out[v] {
+			trace(v, k)
+		}
+	}
+
+	// Tracing from an edge that was already used gives an empty piece.
+	filled := pieces[:0]
+	for _, piece := range pieces {
+		if len(piece) > 0 {
+			filled = append(filled, piece)
+		}
+	}
+	return filled
+}
+
+// turns_before tests if, turning counter-clockwise around vertex `v` from the edge to
+// the next vertex of its ring, the direction to vertex `a` comes before the direction
+// to vertex `b`.
+func (l *ring_list) turns_before(v, a, b int) bool {
+	center, reference := l.points[v], l.points[l.next[v]]
+	half_a := half_turn(center, reference, l.points[a])
+	half_b := half_turn(center, reference, l.points[b])
+	if half_a != half_b {
+		return half_a < half_b
+	}
+	return Orient2D(center, l.points[a], l.points[b]) > 0
+}
+
+// half_turn is 0 if turning counter-clockwise around `center`, from the direction of
+// `reference` to the direction of `p`, takes less than half a turn, and 1 otherwise.
+func half_turn(center, reference, p Point) int {
+	orientation := Orient2D(center, reference, p)
+	if orientation > 0 {
+		return 0
+	}
+	if orientation < 0 {
+		return 1
+	}
+	// The directions are along the same line, and the same if they agree in sign.
+	if (sign(p.X-center.X) == sign(reference.X-center.X)) &&
+		(sign(p.Y-center.Y) == sign(reference.Y-center.Y)) {
+		return 0
+	}
+	return 1
+}
+
+// triangulate_monotone triangulates a monotone piece, given by its vertices in
+// counter-clockwise order, and appends the Triangles to `triangles`. It sweeps down the
+// piece, keeping a stack of the vertices passed that still need Triangles, which always
+// form a reflex chain along one side of the piece.
+func (l *ring_list) triangulate_monotone(piece []int, triangles []Triangle) []Triangle {
+	n := len(piece)
+	if n < 3 {
+		return triangles
+	}
+	add := func(a, b, c int) {
+		if Orient2D(l.points[a], l.points[b], l.points[c]) > 0 {
+			triangles = append(triangles, Triangle{l.points[a], l.points[b], l.points[c]})
+		}
+	}
+
+	top, bottom := 0, 0
+	for i, v := range piece {
+		if l.above(v, piece[top]) {
+			top = i
+		}
+		if l.above(piece[bottom], v) {
+			bottom = i
+		}
+	}
+
+	// Merge the two sides into one list from top to bottom. Counter-clockwise from the
+	// top, the vertices run down the left side, and then back up the right side.
+	order := make([]int, 0, n)
+	on_left := make([]bool, 0, n)
+	order, on_left = append(order, piece[top]), append(on_left, true)
+	for i, j := (top+1)%n, (top+n-1)%n; (i != bottom) || (j != bottom); {
+		if (j == bottom) || ((i != bottom) && l.above(piece[i], piece[j])) {
+			order, on_left = append(order, piece[i]), append(on_left, true)
+			i = (i + 1) % n
+		} else {
+			order, on_left = append(order, piece[j]), append(on_left, false)
+			j = (j + n - 1) % n
+		}
+	}
+	order = append(order, piece[bottom])
+
+	stack := []int{0, 1}
+	for k := 2; k < n-1; k++ {
+		u := order[k]
+		if on_left[k] != on_left[stack[len(stack)-1]] {
+			// The vertex can see every vertex on the stack, across the piece.
+			for s := len(stack) - 1; s > 0; s-- {
+				if on_left[k] {
+					add(u, order[stack[s]], order[stack[s-1]])
+				} else {
+					add(u, order[stack[s-1]], order[stack[s]])
+				}
+			}
+			stack = append(stack[:0], k-1, k)
+			continue
+		}
+
+		// The vertex is on the same side as the stack, and can see back along it as
+		// far as the chain turns towards the interior.
+		last := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for len(stack) > 0 {
+			a, b := order[last], order[stack[len(stack)-1]]
+			if on_left[k] && (Orient2D(l.points[b], l.points[a], l.points[u]) > 0) {
+				add(u, b, a)
+			} else if !on_left[k] && (Orient2D(l.points[u], l.points[a], l.points[b]) > 0) {
+				add(u, a, b)
+			} else {
+				break
+			}
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, last, k)
+	}
+
+	// The bottom vertex can see every vertex left on the stack.
+	u := order[n-1]
+	for s := len(stack) - 1; s > 0; s-- {
+		if on_left[stack[len(stack)-1]] {
+			add(u, order[stack[s-1]], order[stack[s]])
+		} else {
+			add(u, order[stack[s]], order[stack[s-1]])
+		}
+	}
+	return triangles
+}
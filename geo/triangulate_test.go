@@ -0,0 +1,203 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// random_star creates a random star-shaped Polygon around `center`, with `n` vertices at
+// distances between `inner` and `outer` from it.
+func random_star(rng *rand.Rand, center Point, inner, outer float64, n int) Polygon {
+	points := make([]Point, n)
+	for i := range points {
+		radius := inner + (outer-inner)*rng.Float64()
+		points[i] = center.Plus(Point{radius, 0}.Rotate(2 * math.Pi * float64(i) / float64(n)))
+	}
+	return Polygon{points}
+}
+
+// check_triangulation verifies that the Triangles are counter-clockwise, lie inside the
+// PolygonWithHoles, do not overlap each other, and cover all of its area.
+func check_triangulation(t *testing.T, p PolygonWithHoles, triangles []Triangle) {
+	t.Helper()
+
+	area := 0.0
+	for i, triangle := range triangles {
+		if Orient2D(triangle.P1, triangle.P2, triangle.P3) <= 0 {
+			t.Fatalf("%v is not counter-clockwise", triangle)
+		}
+		area += triangle.Area()
+
+		centroid := triangle.P1.Plus(triangle.P2).Plus(triangle.P3).Divide(3)
+		if p.LocatePoint(centroid) != Interior {
+			t.Fatalf("%v is outside the PolygonWithHoles", triangle)
+		}
+		for _, other := range triangles[:i] {
+			if _, overlap := triangle.Overlap(other); overlap > 1e-12 {
+				t.Fatalf("%v and %v overlap", triangle, other)
+			}
+		}
+	}
+	if want := p.Area(); math.Abs(area-want) > 1e-9*math.Max(1, want) {
+		t.Errorf("Triangles have area %v, want %v", area, want)
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		p         PolygonWithHoles
+		triangles int
+	}{
+		{
+			desc:      "Empty",
+			p:         PolygonWithHoles{},
+			triangles: 0,
+		},
+		{
+			desc:      "Triangle",
+			p:         PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}}},
+			triangles: 1,
+		},
+		{
+			desc:      "Square",
+			p:         PolygonWithHoles{Shell: unit_square},
+			triangles: 2,
+		},
+		{
+			desc:      "Repeated and collinear vertices",
+			p:         PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {0.5, 0}, {1, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0.5}, {0, 0}}}},
+			triangles: 2,
+		},
+		{
+			desc:      "Collinear",
+			p:         PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 1}, {2, 2}}}},
+			triangles: 0,
+		},
+		{
+			desc:      "Clockwise L-shape",
+			p:         PolygonWithHoles{Shell: l_shape.Reverse()},
+			triangles: 4,
+		},
+		{
+			desc:      "Regular polygon",
+			p:         PolygonWithHoles{Shell: regular_polygon(Point{1, 2}, 3, 20)},
+			triangles: 18,
+		},
+		{
+			desc: "Teeth on the top and bottom",
+			p: PolygonWithHoles{Shell: Polygon{[]Point{
+				{0, 0}, {1, 1}, {2, 0}, {3, 1}, {4, 0}, {4, 3}, {3, 2}, {2, 3}, {1, 2}, {0, 3},
+			}}},
+			triangles: 8,
+		},
+		{
+			desc:      "Polygon with holes",
+			p:         swiss_cheese,
+			triangles: 14,
+		},
+		{
+			desc:      "Counter-clockwise holes",
+			p:         PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{square(2, 2, 2), square(6, 6, 2)}},
+			triangles: 14,
+		},
+		{
+			desc: "Hole touching the shell",
+			p: PolygonWithHoles{
+				Shell: square(0, 0, 10),
+				Holes: []Polygon{{[]Point{{0, 5}, {2, 6}, {2, 4}}}},
+			},
+			// The shell is pinched at the Point it touches, so is used twice.
+			triangles: 6,
+		},
+	}
+	for _, tC := range testCases {
+		for _, algorithm := range []TriangulationAlgorithm{EarClipping, MonotonePartition} {
+			t.Run(tC.desc, func(t *testing.T) {
+				triangles := tC.p.Triangulate(algorithm)
+				if len(triangles) != tC.triangles {
+					t.Errorf("Triangulate(%v) has %v Triangles, want %v", algorithm, len(triangles), tC.triangles)
+				}
+				check_triangulation(t, tC.p, triangles)
+			})
+		}
+	}
+}
+
+func TestTriangulateGridOfHoles(t *testing.T) {
+	// Square holes in a 3 by 3 grid, lined up with each other, so that many vertices are
+	// at the same height, and bridges between holes can run along their edges.
+	p := PolygonWithHoles{Shell: square(0, 0, 9)}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			p.Holes = append(p.Holes, square(float64(1+3*i), float64(1+3*j), 1).Reverse())
+		}
+	}
+	check_triangulation(t, p, p.Triangulate(EarClipping))
+	check_triangulation(t, p, p.Triangulate(MonotonePartition))
+}
+
+func TestTriangulateRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	for trial := 0; trial < 50; trial++ {
+		// A star-shaped shell, with up to four star-shaped holes well inside it.
+		p := PolygonWithHoles{Shell: random_star(rng, Point{0, 0}, 0.6, 1, 5+rng.Intn(100))}
+		for _, center := range []Point{{-0.2, -0.2}, {0.2, -0.2}, {-0.2, 0.2}, {0.2, 0.2}}[:rng.Intn(5)] {
+			p.Holes = append(p.Holes, random_star(rng, center, 0.05, 0.15, 3+rng.Intn(20)).Reverse())
+		}
+
+		for _, algorithm := range []TriangulationAlgorithm{EarClipping, MonotonePartition} {
+			triangles := p.Triangulate(algorithm)
+			vertices := len(p.Shell.Points)
+			for _, hole := range p.Holes {
+				vertices += len(hole.Points)
+			}
+			if want := vertices + 2*len(p.Holes) - 2; len(triangles) != want {
+				t.Errorf("Triangulate(%v) has %v Triangles, want %v", algorithm, len(triangles), want)
+			}
+			check_triangulation(t, p, triangles)
+		}
+	}
+}
+
+func TestMultiPolygonTriangulate(t *testing.T) {
+	m := MultiPolygon{[]PolygonWithHoles{swiss_cheese, {Shell: square(20, 0, 1)}}}
+	triangles := m.Triangulate(MonotonePartition)
+	if len(triangles) != 16 {
+		t.Errorf("Triangulate() has %v Triangles, want 16", len(triangles))
+	}
+	area := 0.0
+	for _, triangle := range triangles {
+		area += triangle.Area()
+	}
+	if area != m.Area() {
+		t.Errorf("Triangles have area %v, want %v", area, m.Area())
+	}
+}
+
+func BenchmarkTriangulate(b *testing.B) {
+	rng := rand.New(rand.NewSource(14))
+	star := PolygonWithHoles{Shell: random_star(rng, Point{0, 0}, 0.5, 1, 1000)}
+	big_star := PolygonWithHoles{Shell: random_star(rng, Point{0, 0}, 0.5, 1, 100000)}
+	benchmarks := []struct {
+		desc      string
+		p         PolygonWithHoles
+		algorithm TriangulationAlgorithm
+	}{
+		{"Polygon with holes, ear clipping", swiss_cheese, EarClipping},
+		{"Polygon with holes, monotone partition", swiss_cheese, MonotonePartition},
+		{"1000 vertex star, ear clipping", star, EarClipping},
+		{"1000 vertex star, monotone partition", star, MonotonePartition},
+		{"100000 vertex star, monotone partition", big_star, MonotonePartition},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.p.Triangulate(bm.algorithm)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package gogeo
+
+import (
+	"sort"
+)
+
+// Voronoi is the Voronoi diagram of a set of Points, the sites, clipped to a
+// BoundingBox. The cell of a site is the region of the BoundingBox that is at least as
+// close to that site as to any other.
+type Voronoi struct {
+	// Delaunay is the Delaunay triangulation of the sites, from which the diagram is
+	// derived.
+	Delaunay *Delaunay
+	// Bounds is the BoundingBox that every cell is clipped to.
+	Bounds BoundingBox
+	// Cells holds the cell of each site, as a counter-clockwise Polygon, in the same
+	// order as Delaunay.Points. The cell is empty for a duplicate of an earlier site, for
+	// a site that is not a vertex of the triangulation because its coordinates are not
+	// finite or are too large, and for a site whose cell lies outside Bounds.
+	Cells []Polygon
+	// Edges holds the edges shared by two cells.
+	Edges []VoronoiEdge
+	// neighbors holds, for each site, the sites whose cells share an edge with its own.
+	neighbors [][]int
+}
+
+// VoronoiEdge is an edge shared by the cells of two sites.
+type VoronoiEdge struct {
+	// Sites holds the indexes of the two sites, the lower one first. The lower site is
+	// to the left of Edge.
+	Sites [2]int
+	Edge  LineSegment
+}
+
+// NewVoronoi calculates the Voronoi diagram of `points`, clipped to `bounds`.
+func NewVoronoi(points []Point, bounds BoundingBox) *Voronoi {
+	return NewDelaunay(points).Voronoi(bounds)
+}
+
+// Voronoi calculates the Voronoi diagram of the Points of a Delaunay triangulation,
+// clipped to `bounds`. Two sites can only share a cell edge if they share an edge of the
+// triangulation, so each cell is found by clipping `bounds` to the half of the plane
+// closer to its site than to each of those neighbors in turn.
+func (d *Delaunay) Voronoi(bounds BoundingBox) *Voronoi {
+	v := &Voronoi{
+		Delaunay:  d,
+		Bounds:    bounds,
+		Cells:     make([]Polygon, len(d.Points)),
+		neighbors: make([][]int, len(d.Points)),
+	}
+	if bounds.IsEmpty() {
+		return v
+	}
+
+	candidates, used := d.site_neighbors()
+	for site, p := range d.Points {
+		if !used[site] {
+			continue
+		}
+
+		cell := make([]voronoi_corner, 0, 8)
+		for _, corner := range bounds.Polygon().Points {
+			cell = append(cell, voronoi_corner{corner, -1})
+		}
+		for _, other := range candidates[site] {
+			cell = clip_cell(cell, p, d.Points[other], other)
+		}
+
+		polygon := make([]Point, len(cell))
+		for i, corner := range cell {
+			polygon[i] = corner.p
+		}
+		if signed_area(polygon) <= 0 {
+			continue
+		}
+		v.Cells[site] = Polygon{polygon}
+
+		// Each edge is recorded once, from the cell of the lower site.
+		for i, corner := range cell {
+			if corner.across > site {
+				edge := LineSegment{corner.p, cell[(i+1)%len(cell)].p}
+				v.Edges = append(v.Edges, VoronoiEdge{[2]int{site, corner.across}, edge})
+			}
+		}
+	}
+
+	for _, e := range v.Edges {
+		v.neighbors[e.Sites[0]] = append(v.neighbors[e.Sites[0]], e.Sites[1])
+		v.neighbors[e.Sites[1]] = append(v.neighbors[e.Sites[1]], e.Sites[0])
+	}
+	for _, neighbors := range v.neighbors {
+		sort.Ints(neighbors)
+	}
+	return v
+}
+
+// Neighbors returns the indexes of the sites whose cells share an edge with the cell of
+// `site`, in increasing order.
+func (v *Voronoi) Neighbors(site int) []int {
+	return v.neighbors[site]
+}
+
+// site_neighbors finds, for each Point of a Delaunay triangulation, the Points whose
+// Voronoi cells could share an edge with its own, and which Points are sites at all
+// rather than duplicates. An edge of the triangulation between two triangles with the
+// same circumcircle, as happens with four cocircular Points, is left out, since the
+// cells on either side of it only meet at a single Point.
+func (d *Delaunay) site_neighbors() ([][]int, []bool) {
+	neighbors := make([][]int, len(d.Points))
+	used := make([]bool, len(d.Points))
+	connect := func(a, b int) {
+		neighbors[a] = append(neighbors[a], b)
+		neighbors[b] = append(neighbors[b], a)
+	}
+
+	if len(d.Vertices) == 0 {
+		// All the Points lie on one line, in order along the Hull.
+		for i, site := range d.Hull {
+			used[site] = true
+			if i > 0 {
+				connect(d.Hull[i-1], site)
+			}
+		}
+		return neighbors, used
+	}
+
+	for t, v := range d.Vertices {
+		for i := 0; i < 3; i++ {
+			used[v[i]] = true
+			neighbor := d.Neighbors[t][i]
+			if neighbor == -1 {
+				connect(v[i], v[(i+1)%3])
+				continue
+			}
+			if neighbor < t {
+				continue
+			}
+			// The vertex of the neighbor that is not on the shared edge.
+			opposite := d.Vertices[neighbor][0]
+			for _, w := range d.Vertices[neighbor] {
+				if (w != v[i]) && (w != v[(i+1)%3]) {
+					opposite = w
+				}
+			}
+			if InCircle(d.Points[v[0]], d.Points[v[1]], d.Points[v[2]], d.Points[opposite]) != 0 {
+				connect(v[i], v[(i+1)%3])
+			}
+		}
+	}
+	return neighbors, used
+}
+
+// voronoi_corner is a corner of a Voronoi cell, and the site across the edge of the
+// cell from this corner to the next one, or -1 if that edge is on the BoundingBox.
+type voronoi_corner struct {
+	p      Point
+	across int
+}
+
+// clip_cell clips a convex, counter-clockwise cell around `site` to the half of the
+// plane that is at least as close to `site` as to `other`. Any new edge along the
+// bisector is marked with `across`.
+func clip_cell(cell []voronoi_corner, site, other Point, across int) []voronoi_corner {
+	if len(cell) == 0 {
+		return cell
+	}
+
+	// The bisector, directed so that `site` is to its left.
+	middle := site.Plus(other).Divide(2)
+	direction := other.Minus(site)
+	bisector := LineSegment{middle, middle.Plus(Point{-direction.Y, direction.X})}
+	sides := make([]int, len(cell))
+	for i, corner := range cell {
+		sides[i] = sign(Orient2D(bisector.P1, bisector.P2, corner.p))
+	}
+
+	clipped := make([]voronoi_corner, 0, len(cell)+1)
+	add := func(corner voronoi_corner) {
+		if (len(clipped) > 0) && clipped[len(clipped)-1].p.Equals(corner.p) {
+			// The edge between them has no length, so only the later edge is kept.
+			clipped[len(clipped)-1].across = corner.across
+			return
+		}
+		clipped = append(clipped, corner)
+	}
+
+	for i, current := range cell {
+		j := (i + len(cell) - 1) % len(cell)
+		previous := cell[j]
+		next_side := sides[(i+1)%len(cell)]
+
+		if sides[j]*sides[i] < 0 {
+			crossing := LineSegment{previous.p, current.p}.line_crossing(bisector)
+			if sides[j] > 0 {
+				// Leaving the half-plane, so the cell continues along the bisector.
+				add(voronoi_corner{crossing, across})
+			} else {
+				// Entering the half-plane, part way along the edge from `previous`.
+				add(voronoi_corner{crossing, previous.across})
+			}
+		}
+		if sides[i] > 0 {
+			add(current)
+		} else if sides[i] == 0 {
+			if next_side < 0 {
+				// Leaving the half-plane right at this corner.
+				current.across = across
+			}
+			add(current)
+		}
+	}
+
+	if (len(clipped) > 1) && clipped[0].p.Equals(clipped[len(clipped)-1].p) {
+		clipped = clipped[:len(clipped)-1]
+	}
+	return clipped
+}
@@ -0,0 +1,216 @@
+package gogeo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewVoronoi(t *testing.T) {
+	box := BoundingBox{Point{-1, -1}, Point{2, 2}}
+	testCases := []struct {
+		desc      string
+		points    []Point
+		bounds    BoundingBox
+		cells     []Polygon
+		neighbors [][]int
+	}{
+		{
+			desc:      "Single Point",
+			points:    []Point{{0.5, 0.5}},
+			bounds:    box,
+			cells:     []Polygon{box.Polygon()},
+			neighbors: [][]int{nil},
+		},
+		{
+			desc:   "Two Points",
+			points: []Point{{0, 0}, {1, 0}},
+			bounds: box,
+			cells: []Polygon{
+				{[]Point{{-1, -1}, {0.5, -1}, {0.5, 2}, {-1, 2}}},
+				{[]Point{{0.5, -1}, {2, -1}, {2, 2}, {0.5, 2}}},
+			},
+			neighbors: [][]int{{1}, {0}},
+		},
+		{
+			desc:   "Site with a NaN coordinate",
+			points: []Point{{0, 0}, {math.NaN(), 0}, {1, 0}},
+			bounds: box,
+			cells: []Polygon{
+				{[]Point{{-1, -1}, {0.5, -1}, {0.5, 2}, {-1, 2}}},
+				{},
+				{[]Point{{0.5, -1}, {2, -1}, {2, 2}, {0.5, 2}}},
+			},
+			neighbors: [][]int{{2}, nil, {0}},
+		},
+		{
+			desc:   "Collinear Points",
+			points: []Point{{1, 1}, {0, 0}, {2, 2}},
+			bounds: BoundingBox{Point{0, 0}, Point{2, 2}},
+			cells: []Polygon{
+				{[]Point{{0, 1}, {1, 0}, {2, 0}, {2, 1}, {1, 2}, {0, 2}}},
+				{[]Point{{0, 1}, {0, 0}, {1, 0}}},
+				{[]Point{{2, 1}, {2, 2}, {1, 2}}},
+			},
+			neighbors: [][]int{{1, 2}, {0}, {0}},
+		},
+		{
+			desc:   "Square, with cocircular corners",
+			points: unit_square.Points,
+			bounds: box,
+			cells: []Polygon{
+				{[]Point{{-1, 0.5}, {-1, -1}, {0.5, -1}, {0.5, 0.5}}},
+				{[]Point{{0.5, 0.5}, {0.5, -1}, {2, -1}, {2, 0.5}}},
+				{[]Point{{0.5, 0.5}, {2, 0.5}, {2, 2}, {0.5, 2}}},
+				{[]Point{{-1, 0.5}, {0.5, 0.5}, {0.5, 2}, {-1, 2}}},
+			},
+			neighbors: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}},
+		},
+		{
+			desc:   "Duplicate Point",
+			points: []Point{{0, 0}, {1, 0}, {0, 0}},
+			bounds: box,
+			cells: []Polygon{
+				{[]Point{{-1, -1}, {0.5, -1}, {0.5, 2}, {-1, 2}}},
+				{[]Point{{0.5, -1}, {2, -1}, {2, 2}, {0.5, 2}}},
+				{},
+			},
+			neighbors: [][]int{{1}, {0}, nil},
+		},
+		{
+			desc:   "Site outside the bounds",
+			points: []Point{{0, 0}, {0, 1}, {10, 0}},
+			bounds: BoundingBox{Point{-1, -1}, Point{1, 1}},
+			cells: []Polygon{
+				{[]Point{{-1, 0.5}, {-1, -1}, {1, -1}, {1, 0.5}}},
+				{[]Point{{-1, 0.5}, {1, 0.5}, {1, 1}, {-1, 1}}},
+				{},
+			},
+			neighbors: [][]int{{1}, {0}, nil},
+		},
+		{
+			desc:      "Empty bounds",
+			points:    []Point{{0, 0}, {1, 0}},
+			bounds:    BoundingBox{Point{1, 1}, Point{0, 0}},
+			cells:     []Polygon{{}, {}},
+			neighbors: [][]int{nil, nil},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			v := NewVoronoi(tC.points, tC.bounds)
+			for site, cell := range tC.cells {
+				if got := v.Cells[site]; !got.AlmostEquals(cell) {
+					t.Errorf("Cells[%v] = %v, want %v", site, got, cell)
+				}
+				if got := v.Neighbors(site); !equal_ints(got, tC.neighbors[site]) {
+					t.Errorf("Neighbors(%v) = %v, want %v", site, got, tC.neighbors[site])
+				}
+			}
+		})
+	}
+}
+
+// equal_ints tests if two slices hold the same ints in the same order.
+func equal_ints(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVoronoiGrid(t *testing.T) {
+	// Every cell of a grid is a unit square, and the cells share an edge with the sites
+	// directly above, below, left and right.
+	v := NewVoronoi(grid_points(5), BoundingBox{Point{-0.5, -0.5}, Point{4.5, 4.5}})
+	for site, cell := range v.Cells {
+		if !almost_zero(cell.Area() - 1) {
+			t.Errorf("Cells[%v] = %v, want area 1", site, cell)
+		}
+	}
+	if got := v.Neighbors(12); !equal_ints(got, []int{7, 11, 13, 17}) {
+		t.Errorf("Neighbors(12) = %v, want [7 11 13 17]", got)
+	}
+	if len(v.Edges) != 40 {
+		t.Errorf("got %v Edges, want 40", len(v.Edges))
+	}
+}
+
+func TestVoronoiRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	bounds := BoundingBox{Point{0.1, 0.1}, Point{0.9, 0.9}}
+	for trial := 0; trial < 10; trial++ {
+		points := random_points(rng, 100)
+		v := NewVoronoi(points, bounds)
+
+		// The cells partition the bounds.
+		area := 0.0
+		for _, cell := range v.Cells {
+			if len(cell.Points) > 0 && (!cell.IsCounterClockwise() || !cell.IsSimple()) {
+				t.Fatalf("cell %v is not a counter-clockwise simple Polygon", cell)
+			}
+			area += cell.Area()
+		}
+		if !almost_zero(area - 0.64) {
+			t.Errorf("cells have total area %v, want 0.64", area)
+		}
+
+		// Each Point in the bounds is in the cell of its nearest site.
+		for i := 0; i < 100; i++ {
+			q := Point{0.1 + 0.8*rng.Float64(), 0.1 + 0.8*rng.Float64()}
+			nearest, nearest_distance, second_distance := -1, math.Inf(1), math.Inf(1)
+			for site, p := range points {
+				if distance := q.Minus(p).Magnitude(); distance < nearest_distance {
+					nearest, nearest_distance, second_distance = site, distance, nearest_distance
+				} else if distance < second_distance {
+					second_distance = distance
+				}
+			}
+			if second_distance-nearest_distance < 1e-9 {
+				continue
+			}
+			if v.Cells[nearest].LocatePoint(q) != Interior {
+				t.Fatalf("%v is not inside the cell of its nearest site %v", q, points[nearest])
+			}
+		}
+
+		// Each edge lies along the bisector of its sites, which are neighbors.
+		for _, e := range v.Edges {
+			a, b := points[e.Sites[0]], points[e.Sites[1]]
+			for _, p := range []Point{e.Edge.P1, e.Edge.P2} {
+				if !almost_zero(p.Minus(a).Magnitude() - p.Minus(b).Magnitude()) {
+					t.Fatalf("edge %v is not on the bisector of %v and %v", e, a, b)
+				}
+			}
+			if Orient2D(e.Edge.P1, e.Edge.P2, a) <= 0 {
+				t.Fatalf("site %v is not to the left of edge %v", a, e)
+			}
+		}
+	}
+}
+
+func BenchmarkNewVoronoi(b *testing.B) {
+	rng := rand.New(rand.NewSource(9))
+	bounds := BoundingBox{Point{0, 0}, Point{1, 1}}
+	benchmarks := []struct {
+		desc   string
+		points []Point
+	}{
+		{"1000 random Points", random_points(rng, 1000)},
+		{"100000 random Points", random_points(rng, 100000)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewVoronoi(bm.points, bounds)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package gogeo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrInvalidWKB is returned, with a description of the problem, for malformed
+// Well-Known Binary. Binary that ends too soon returns io.ErrUnexpectedEOF instead.
+var ErrInvalidWKB = errors.New("invalid WKB")
+
+// The geometry type codes of Well-Known Binary.
+const (
+	wkb_point              = 1
+	wkb_linestring         = 2
+	wkb_polygon            = 3
+	wkb_multipoint         = 4
+	wkb_multilinestring    = 5
+	wkb_multipolygon       = 6
+	wkb_geometrycollection = 7
+	wkb_triangle           = 17
+)
+
+// The flags that EWKB adds to the geometry type, for Z and M coordinates and an SRID.
+const (
+	ewkb_z    = 0x80000000
+	ewkb_m    = 0x40000000
+	ewkb_srid = 0x20000000
+)
+
+// WKBOptions control how shapes are written as Well-Known Binary.
+type WKBOptions struct {
+	// BigEndian writes the most significant byte of each number first. By default the
+	// least significant byte is first, as on most machines.
+	BigEndian bool
+	// SRID is the identifier of the spatial reference system of the coordinates. If it
+	// is not 0, the shape is written in the extended EWKB format of PostGIS, with the
+	// SRID before the coordinates. Otherwise it is written as ISO WKB.
+	SRID uint32
+}
+
+// MarshalWKB encodes a shape as Well-Known Binary. The types written are those of
+// FormatWKT, with an empty Point having NaN coordinates. It returns ErrUnsupportedShape
+// for any other type, ErrNotFinite for other infinite or NaN coordinates,
+// ErrTooFewPoints for a Polyline with a single Point or a ring with fewer than 3
+// Points, and ErrTooDeep for GeometryCollections nested more deeply than ReadWKB
+// reads.
+func MarshalWKB(shape Bounded, options WKBOptions) ([]byte, error) {
+	w := wkb_writer{order: binary.LittleEndian, marker: 1}
+	if options.BigEndian {
+		w.order, w.marker = binary.BigEndian, 0
+	}
+	if err := w.geometry(shape, options.SRID); err != nil {
+		return nil, err
+	}
+	return w.data, nil
+}
+
+// WriteWKB writes a shape to `w` as Well-Known Binary, as MarshalWKB encodes it.
+// Nothing is written if the shape cannot be encoded.
+func WriteWKB(w io.Writer, shape Bounded, options WKBOptions) error {
+	data, err := MarshalWKB(shape, options)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// UnmarshalWKB decodes a shape from Well-Known Binary, as ReadWKB does, and checks that
+// nothing follows it.
+func UnmarshalWKB(data []byte) (Bounded, uint32, error) {
+	r := bytes.NewReader(data)
+	shape, srid, err := ReadWKB(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.Len() > 0 {
+		return nil, 0, fmt.Errorf("wkb: %v bytes after the end of the shape: %w", r.Len(), ErrInvalidWKB)
+	}
+	return shape, srid, nil
+}
+
+// ReadWKB reads one shape of Well-Known Binary from `r`, and returns it with its SRID,
+// which is 0 unless it was EWKB with an SRID. Both byte orders are read, in ISO WKB or
+// EWKB. The types read are those of ParseWKT, and rings must be closed in the same way.
+// Only 2D coordinates are supported.
+//
+// Nothing is read past the end of the shape, so shapes written one after another can
+// be read back one at a time. It returns io.EOF if `r` has nothing left to read,
+// io.ErrUnexpectedEOF if it ends part way through a shape, and an error wrapping
+// ErrInvalidWKB if the binary is malformed. No more memory is allocated than the bytes
+// read need, however large the counts in malformed binary are.
+func ReadWKB(r io.Reader) (Bounded, uint32, error) {
+	reader := wkb_reader{r: r}
+	shape, srid, err := reader.geometry(0, 0)
+	if (err == io.ErrUnexpectedEOF) && (reader.offset == 0) {
+		return nil, 0, io.EOF
+	}
+	if err == io.ErrUnexpectedEOF {
+		return nil, 0, fmt.Errorf("wkb: byte %v: %w", reader.offset, err)
+	}
+	return shape, srid, err
+}
+
+// wkb_reader reads Well-Known Binary, keeping track of how far into it it is.
+type wkb_reader struct {
+	r      io.Reader
+	offset int64
+	order  binary.ByteOrder
+	buffer [16]byte
+}
+
+// invalid returns an error wrapping ErrInvalidWKB, at the byte `offset`.
+func (r *wkb_reader) invalid(offset int64, format string, args ...interface{}) error {
+	return fmt.Errorf("wkb: byte %v: %v: %w", offset, fmt.Sprintf(format, args...), ErrInvalidWKB)
+}
+
+// read reads the next `n` bytes, up to 16, returning io.ErrUnexpectedEOF if they are
+// not all there.
+func (r *wkb_reader) read(n int) ([]byte, error) {
+	read, err := io.ReadFull(r.r, r.buffer[:n])
+	r.offset += int64(read)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return r.buffer[:n], err
+}
+
+// uint32 reads a 4 byte unsigned integer.
+func (r *wkb_reader) uint32() (uint32, error) {
+	b, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint32(b), nil
+}
+
+// point reads a pair of coordinates.
+func (r *wkb_reader) point() (Point, error) {
+	b, err := r.read(16)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{math.Float64frombits(r.order.Uint64(b)), math.Float64frombits(r.order.Uint64(b[8:]))}, nil
+}
+
+// points reads a count followed by that many finite Points. The slice grows as Points
+// are read, rather than trusting the count.
+func (r *wkb_reader) points() ([]Point, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	var points []Point
+	if n > 0 {
+		points = make([]Point, 0, int(math.Min(float64(n), 1024)))
+	}
+	for i := uint32(0); i < n; i++ {
+		start := r.offset
+		p, err := r.point()
+		if err != nil {
+			return nil, err
+		}
+		if check_finite(p) != nil {
+			return nil, r.invalid(start, "coordinate %v is not finite", p)
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// rings reads a count followed by that many closed rings, and returns them without
+// their closing Points.
+func (r *wkb_reader) rings() ([]Polygon, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	var rings []Polygon
+	for i := uint32(0); i < n; i++ {
+		start := r.offset
+		points, err := r.points()
+		switch {
+		case err != nil:
+			return nil, err
+		case len(points) < 4:
+			return nil, r.invalid(start, "ring has %v points, at least 4 are needed", len(points))
+		case points[0] != points[len(points)-1]:
+			return nil, r.invalid(start, "ring is not closed")
+		}
+		rings = append(rings, Polygon{points[:len(points)-1]})
+	}
+	return rings, nil
+}
+
+// geometry reads a shape, starting with its byte order, type, and SRID if it has one.
+// It is inside `depth` GeometryCollections, and must have the type `want` unless that
+// is 0.
+func (r *wkb_reader) geometry(depth int, want uint32) (Bounded, uint32, error) {
+	start := r.offset
+	b, err := r.read(1)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch b[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, 0, r.invalid(start, "byte order %v is neither 0 nor 1", b[0])
+	}
+	code, err := r.uint32()
+	if err != nil {
+		return nil, 0, err
+	}
+	if (code&(ewkb_z|ewkb_m) != 0) || ((1000 <= code%ewkb_srid) && (code%ewkb_srid < 4000)) {
+		return nil, 0, r.invalid(start, "geometry type %#x has Z or M coordinates, only 2D is supported", code)
+	}
+	var srid uint32
+	if code&ewkb_srid != 0 {
+		if srid, err = r.uint32(); err != nil {
+			return nil, 0, err
+		}
+		code &^= ewkb_srid
+	}
+	if (want != 0) && (code != want) {
+		return nil, 0, r.invalid(start, "geometry type %v cannot be part of this collection", code)
+	}
+
+	var shape Bounded
+	switch code {
+	case wkb_point:
+		start := r.offset
+		p, err := r.point()
+		if err != nil {
+			return nil, 0, err
+		}
+		if (check_finite(p) != nil) && !(math.IsNaN(p.X) && math.IsNaN(p.Y)) {
+			return nil, 0, r.invalid(start, "coordinate %v is not finite", p)
+		}
+		shape = p
+	case wkb_linestring:
+		points, err := r.points()
+		if err != nil {
+			return nil, 0, err
+		}
+		if check_line(points) != nil {
+			return nil, 0, r.invalid(start, "LineString has 1 point, it must have none or at least 2")
+		}
+		shape = Polyline{points}
+	case wkb_polygon, wkb_triangle:
+		rings, err := r.rings()
+		if err != nil {
+			return nil, 0, err
+		}
+		shape = polygon_from_rings(rings)
+		if code == wkb_triangle {
+			if (len(rings) != 1) || (len(rings[0].Points) != 3) {
+				return nil, 0, r.invalid(start, "a triangle must be a single ring of 4 points")
+			}
+			shape = Triangle{rings[0].Points[0], rings[0].Points[1], rings[0].Points[2]}
+		}
+	case wkb_multipoint:
+		var m MultiPoint
+		err = r.members(depth, wkb_point, func(member Bounded) {
+			m.Points = append(m.Points, member.(Point))
+		})
+		shape = m
+	case wkb_multilinestring:
+		var m MultiPolyline
+		err = r.members(depth, wkb_linestring, func(member Bounded) {
+			m.Polylines = append(m.Polylines, member.(Polyline))
+		})
+		shape = m
+	case wkb_multipolygon:
+		var m MultiPolygon
+		err = r.members(depth, wkb_polygon, func(member Bounded) {
+			m.Polygons = append(m.Polygons, member.(PolygonWithHoles))
+		})
+		shape = m
+	case wkb_geometrycollection:
+		if depth == max_collection_depth {
+			return nil, 0, r.invalid(start, "geometry collection is nested more than %v deep", max_collection_depth)
+		}
+		var g GeometryCollection
+		err = r.members(depth+1, 0, func(member Bounded) {
+			g.Geometries = append(g.Geometries, member)
+		})
+		shape = g
+	default:
+		return nil, 0, r.invalid(start, "unknown geometry type %v", code)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return shape, srid, nil
+}
+
+// members reads a count followed by that many shapes inside `depth`
+// GeometryCollections, each of the type `want` unless it is 0, and passes each to `add`.
+func (r *wkb_reader) members(depth int, want uint32, add func(member Bounded)) error {
+	n, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		member, _, err := r.geometry(depth, want)
+		if err != nil {
+			return err
+		}
+		add(member)
+	}
+	return nil
+}
+
+// polygon_from_rings makes a PolygonWithHoles from its rings, the first being the
+// Shell. With no rings, it is empty.
+func polygon_from_rings(rings []Polygon) PolygonWithHoles {
+	if len(rings) == 0 {
+		return PolygonWithHoles{}
+	}
+	polygon := PolygonWithHoles{Shell: rings[0]}
+	if len(rings) > 1 {
+		polygon.Holes = rings[1:]
+	}
+	return polygon
+}
+
+// wkb_writer builds up Well-Known Binary in one byte order.
+type wkb_writer struct {
+	data   []byte
+	order  binary.ByteOrder
+	marker byte
+	buffer [8]byte
+	// depth counts the GeometryCollections around the shape being written.
+	depth int
+}
+
+// uint32 writes a 4 byte unsigned integer.
+func (w *wkb_writer) uint32(n uint32) {
+	w.order.PutUint32(w.buffer[:4], n)
+	w.data = append(w.data, w.buffer[:4]...)
+}
+
+// point writes a pair of coordinates.
+func (w *wkb_writer) point(p Point) {
+	for _, x := range [2]float64{p.X, p.Y} {
+		w.order.PutUint64(w.buffer[:], math.Float64bits(x))
+		w.data = append(w.data, w.buffer[:]...)
+	}
+}
+
+// points writes a count followed by that many finite Points.
+func (w *wkb_writer) points(points []Point) error {
+	w.uint32(uint32(len(points)))
+	for _, p := range points {
+		if err := check_finite(p); err != nil {
+			return err
+		}
+		w.point(p)
+	}
+	return nil
+}
+
+// rings writes a count followed by that many rings, each with its first Point
+// repeated at the end.
+func (w *wkb_writer) rings(rings []Polygon) error {
+	w.uint32(uint32(len(rings)))
+	for _, ring := range rings {
+		points, err := closed_ring(ring)
+		if err != nil {
+			return err
+		}
+		if err := w.points(points); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// header writes the byte order, the geometry type, and the SRID if it is not 0.
+func (w *wkb_writer) header(code uint32, srid uint32) {
+	w.data = append(w.data, w.marker)
+	if srid == 0 {
+		w.uint32(code)
+		return
+	}
+	w.uint32(code | ewkb_srid)
+	w.uint32(srid)
+}
+
+// geometry writes a shape, with an SRID unless it is 0.
+func (w *wkb_writer) geometry(shape Bounded, srid uint32) error {
+	switch s := shape.(type) {
+	case Point:
+		if math.IsNaN(s.X) && math.IsNaN(s.Y) {
+			// The quiet NaN that other libraries write for an empty Point.
+			nan := math.Float64frombits(0x7ff8000000000000)
+			s = Point{nan, nan}
+		} else if err := check_finite(s); err != nil {
+			return err
+		}
+		w.header(wkb_point, srid)
+		w.point(s)
+	case LineSegment:
+		w.header(wkb_linestring, srid)
+		return w.points([]Point{s.P1, s.P2})
+	case Polyline:
+		if err := check_line(s.Points); err != nil {
+			return err
+		}
+		w.header(wkb_linestring, srid)
+		return w.points(s.Points)
+	case Triangle:
+		w.header(wkb_triangle, srid)
+		return w.rings([]Polygon{{[]Point{s.P1, s.P2, s.P3}}})
+	case Polygon:
+		return w.geometry(PolygonWithHoles{Shell: s}, srid)
+	case PolygonWithHoles:
+		w.header(wkb_polygon, srid)
+		if len(s.Shell.Points) == 0 {
+			w.uint32(0)
+			return nil
+		}
+		return w.rings(s.Rings())
+	case MultiPoint:
+		w.header(wkb_multipoint, srid)
+		w.uint32(uint32(len(s.Points)))
+		for _, p := range s.Points {
+			if err := w.geometry(p, 0); err != nil {
+				return err
+			}
+		}
+	case MultiPolyline:
+		w.header(wkb_multilinestring, srid)
+		w.uint32(uint32(len(s.Polylines)))
+		for _, l := range s.Polylines {
+			if err := w.geometry(l, 0); err != nil {
+				return err
+			}
+		}
+	case MultiPolygon:
+		w.header(wkb_multipolygon, srid)
+		w.uint32(uint32(len(s.Polygons)))
+		for _, p := range s.Polygons {
+			if err := w.geometry(p, 0); err != nil {
+				return err
+			}
+		}
+	case GeometryCollection:
+		if w.depth == max_collection_depth {
+			return ErrTooDeep
+		}
+		w.depth++
+		w.header(wkb_geometrycollection, srid)
+		w.uint32(uint32(len(s.Geometries)))
+		for _, g := range s.Geometries {
+			if err := w.geometry(g, 0); err != nil {
+				return err
+			}
+		}
+		w.depth--
+	default:
+		return fmt.Errorf("%T: %w", shape, ErrUnsupportedShape)
+	}
+	return nil
+}
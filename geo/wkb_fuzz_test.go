@@ -0,0 +1,50 @@
+//go:build go1.18
+// +build go1.18
+
+package gogeo
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func FuzzUnmarshalWKB(f *testing.F) {
+	seeds := []Bounded{
+		Point{1, 2},
+		Point{math.NaN(), math.NaN()},
+		Polyline{[]Point{{0, 0}, {1, 1}, {2, 0}}},
+		Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+		swiss_cheese,
+		MultiPoint{[]Point{{1, 2}, {3, 4}}},
+		MultiPolyline{[]Polyline{staircase, {}}},
+		multi(square(0, 0, 1), square(3, -2, 1)),
+		GeometryCollection{[]Bounded{Point{1, 2}, GeometryCollection{[]Bounded{staircase}}}},
+	}
+	for i, shape := range seeds {
+		data, err := MarshalWKB(shape, WKBOptions{BigEndian: i%2 == 0, SRID: uint32(i)})
+		if err != nil {
+			f.Fatalf("MarshalWKB(%v) returned %v", shape, err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Malformed binary must return an error rather than panic, and anything that
+		// decodes must encode again and decode to the same shape.
+		shape, srid, err := UnmarshalWKB(data)
+		if err != nil {
+			return
+		}
+		encoded, err := MarshalWKB(shape, WKBOptions{SRID: srid})
+		if err != nil {
+			t.Fatalf("MarshalWKB(%v) returned %v", shape, err)
+		}
+		decoded, _, err := UnmarshalWKB(encoded)
+		if err != nil {
+			t.Fatalf("UnmarshalWKB(%X) returned %v", encoded, err)
+		}
+		if again, _ := MarshalWKB(decoded, WKBOptions{SRID: srid}); !bytes.Equal(again, encoded) {
+			t.Errorf("UnmarshalWKB(%X) = %v, want %v", encoded, decoded, shape)
+		}
+	})
+}
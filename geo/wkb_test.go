@@ -0,0 +1,267 @@
+package gogeo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// from_hex decodes hexadecimal, ignoring spaces.
+func from_hex(s string) []byte {
+	data, err := hex.DecodeString(string(bytes.ReplaceAll([]byte(s), []byte(" "), nil)))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestMarshalWKB(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		shape   Bounded
+		options WKBOptions
+		want    string
+	}{
+		{
+			desc:  "Point",
+			shape: Point{1, 2},
+			want:  "01 01000000 000000000000F03F 0000000000000040",
+		},
+		{
+			desc:    "Point, big endian",
+			shape:   Point{1, 2},
+			options: WKBOptions{BigEndian: true},
+			want:    "00 00000001 3FF0000000000000 4000000000000000",
+		},
+		{
+			desc:    "Point, EWKB with an SRID",
+			shape:   Point{1, 2},
+			options: WKBOptions{SRID: 4326},
+			want:    "01 01000020 E6100000 000000000000F03F 0000000000000040",
+		},
+		{
+			desc:  "Point, empty",
+			shape: Point{math.NaN(), math.NaN()},
+			want:  "01 01000000 000000000000F87F 000000000000F87F",
+		},
+		{
+			desc:  "LineSegment",
+			shape: LineSegment{Point{0, 0}, Point{1, 1}},
+			want:  "01 02000000 02000000 0000000000000000 0000000000000000 000000000000F03F 000000000000F03F",
+		},
+		{
+			desc:  "Triangle",
+			shape: Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}},
+			want: "01 11000000 01000000 04000000 0000000000000000 0000000000000000 000000000000F03F 0000000000000000" +
+				"0000000000000000 000000000000F03F 0000000000000000 0000000000000000",
+		},
+		{
+			desc:  "PolygonWithHoles, empty",
+			shape: PolygonWithHoles{},
+			want:  "01 03000000 00000000",
+		},
+		{
+			desc:    "MultiPoint, big endian with an SRID",
+			shape:   MultiPoint{[]Point{{1, 2}}},
+			options: WKBOptions{BigEndian: true, SRID: 3857},
+			want:    "00 20000004 00000F11 00000001 00 00000001 3FF0000000000000 4000000000000000",
+		},
+		{
+			desc:  "GeometryCollection",
+			shape: GeometryCollection{[]Bounded{Polyline{}, MultiPolygon{}}},
+			want:  "01 07000000 02000000 01 02000000 00000000 01 06000000 00000000",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := MarshalWKB(tC.shape, tC.options)
+			if err != nil {
+				t.Fatalf("MarshalWKB(%v) returned %v", tC.shape, err)
+			}
+			if want := from_hex(tC.want); !bytes.Equal(got, want) {
+				t.Errorf("MarshalWKB(%v) = %X, want %X", tC.shape, got, want)
+			}
+		})
+	}
+
+	if _, err := MarshalWKB(Polyline{[]Point{{0, 0}, {math.Inf(-1), 0}}}, WKBOptions{}); !errors.Is(err, ErrNotFinite) {
+		t.Errorf("MarshalWKB() of an infinite coordinate returned %v, want %v", err, ErrNotFinite)
+	}
+	if _, err := MarshalWKB(Polyline{[]Point{{1, 2}}}, WKBOptions{}); !errors.Is(err, ErrTooFewPoints) {
+		t.Errorf("MarshalWKB() of a Polyline with one Point returned %v, want %v", err, ErrTooFewPoints)
+	}
+	if _, err := MarshalWKB(circle{Point{0, 0}, 1}, WKBOptions{}); !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("MarshalWKB() of a circle returned %v, want %v", err, ErrUnsupportedShape)
+	}
+	if _, err := MarshalWKB(nested_collection(max_collection_depth+1), WKBOptions{}); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("MarshalWKB() of a deeply nested GeometryCollection returned %v, want %v", err, ErrTooDeep)
+	}
+}
+
+func TestUnmarshalWKB(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		data  string
+		want  Bounded
+		srid  uint32
+		error error
+	}{
+		{
+			desc: "Point, big endian",
+			data: "00 00000001 3FF0000000000000 4000000000000000",
+			want: Point{1, 2},
+		},
+		{
+			desc: "Point, EWKB with an SRID",
+			data: "01 01000020 E6100000 000000000000F03F 0000000000000040",
+			want: Point{1, 2},
+			srid: 4326,
+		},
+		{
+			desc: "Polygon, closing Point dropped",
+			data: "01 03000000 01000000 04000000 0000000000000000 0000000000000000 000000000000F03F 0000000000000000" +
+				"0000000000000000 000000000000F03F 0000000000000000 0000000000000000",
+			want: PolygonWithHoles{Shell: Polygon{[]Point{{0, 0}, {1, 0}, {0, 1}}}},
+		},
+		{
+			desc: "MultiPoint, members in both byte orders",
+			data: "01 04000000 02000000 00 00000001 3FF0000000000000 4000000000000000 01 01000000 000000000000F03F 0000000000000040",
+			want: MultiPoint{[]Point{{1, 2}, {1, 2}}},
+		},
+		{
+			desc:  "Empty",
+			data:  "",
+			error: io.ErrUnexpectedEOF,
+		},
+		{
+			desc:  "Truncated",
+			data:  "01 01000000 000000000000F03F",
+			error: io.ErrUnexpectedEOF,
+		},
+		{
+			desc:  "Count larger than the data",
+			data:  "01 02000000 FFFFFFFF 0000000000000000 0000000000000000",
+			error: io.ErrUnexpectedEOF,
+		},
+		{
+			desc:  "Trailing bytes",
+			data:  "01 01000000 000000000000F03F 0000000000000040 00",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "Unknown byte order",
+			data:  "02 01000000 000000000000F03F 0000000000000040",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "Unknown type",
+			data:  "01 08000000 00000000",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "ISO Z coordinates",
+			data:  "01 E9030000 000000000000F03F 0000000000000040 0000000000000840",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "EWKB M coordinates",
+			data:  "01 01000040 000000000000F03F 0000000000000040 0000000000000840",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "LineString with one Point",
+			data:  "01 02000000 01000000 000000000000F03F 0000000000000040",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "Infinite coordinate",
+			data:  "01 02000000 01000000 000000000000F07F 0000000000000000",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc: "Ring not closed",
+			data: "01 03000000 01000000 04000000 0000000000000000 0000000000000000 000000000000F03F 0000000000000000" +
+				"0000000000000000 000000000000F03F 000000000000F03F 000000000000F03F",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "MultiPoint holding a LineString",
+			data:  "01 04000000 01000000 01 02000000 00000000",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "MultiPoint holding a MultiPoint",
+			data:  "01 04000000 01000000 01 04000000 00000000",
+			error: ErrInvalidWKB,
+		},
+		{
+			desc:  "Deeply nested GeometryCollection",
+			data:  strings.Repeat("01 07000000 01000000 ", 100000) + "01 07000000 00000000",
+			error: ErrInvalidWKB,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, srid, err := UnmarshalWKB(from_hex(tC.data))
+			if !errors.Is(err, tC.error) || ((err == nil) != (tC.error == nil)) {
+				t.Fatalf("UnmarshalWKB() returned %v, want %v", err, tC.error)
+			}
+			if !reflect.DeepEqual(got, tC.want) || (srid != tC.srid) {
+				t.Errorf("UnmarshalWKB() = %v, %v, want %v, %v", got, srid, tC.want, tC.srid)
+			}
+		})
+	}
+}
+
+func TestReadWKBStream(t *testing.T) {
+	shapes := []Bounded{Point{1, 2}, Polyline{[]Point{{0, 0}, {3, 4}}}, multi(square(0, 0, 1))}
+	var stream bytes.Buffer
+	for i, shape := range shapes {
+		if err := WriteWKB(&stream, shape, WKBOptions{BigEndian: i%2 == 0, SRID: uint32(i)}); err != nil {
+			t.Fatalf("WriteWKB(%v) returned %v", shape, err)
+		}
+	}
+	for i, want := range shapes {
+		got, srid, err := ReadWKB(&stream)
+		if (err != nil) || !reflect.DeepEqual(got, want) || (srid != uint32(i)) {
+			t.Fatalf("ReadWKB() = %v, %v, %v, want %v, %v", got, srid, err, want, i)
+		}
+	}
+	if _, _, err := ReadWKB(&stream); err != io.EOF {
+		t.Errorf("ReadWKB() at the end returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(32))
+	for trial := 0; trial < 500; trial++ {
+		shape := random_geometry(rng, 3)
+		options := WKBOptions{BigEndian: rng.Intn(2) == 0}
+		if rng.Intn(2) == 0 {
+			options.SRID = rng.Uint32()
+		}
+		data, err := MarshalWKB(shape, options)
+		if err != nil {
+			t.Fatalf("MarshalWKB(%v) returned %v", shape, err)
+		}
+		got, srid, err := UnmarshalWKB(data)
+		if err != nil {
+			t.Fatalf("UnmarshalWKB(%X) returned %v", data, err)
+		}
+		if !reflect.DeepEqual(got, shape) || (srid != options.SRID) {
+			t.Fatalf("UnmarshalWKB(%X) = %v, %v, want %v, %v", data, got, srid, shape, options.SRID)
+		}
+
+		// Every shorter prefix ends too soon.
+		cut := rng.Intn(len(data))
+		if _, _, err := UnmarshalWKB(data[:cut]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("UnmarshalWKB(%X) returned %v, want %v", data[:cut], err, io.ErrUnexpectedEOF)
+		}
+	}
+}
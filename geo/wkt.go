@@ -0,0 +1,606 @@
+package gogeo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Errors returned when encoding a shape as text or binary.
+var (
+	// ErrUnsupportedShape is returned for a shape whose type cannot be encoded.
+	ErrUnsupportedShape = errors.New("shape type is not supported")
+	// ErrNotFinite is returned for a coordinate that is infinite or NaN, apart from the
+	// NaN coordinates of an empty Point.
+	ErrNotFinite = errors.New("coordinate is not finite")
+	// ErrTooFewPoints is returned for a LineString with a single Point, or a ring with
+	// fewer than 3 Points.
+	ErrTooFewPoints = errors.New("too few points")
+	// ErrTooDeep is returned for GeometryCollections nested more than 100 deep.
+	ErrTooDeep = errors.New("collections are nested too deep")
+)
+
+// max_collection_depth limits how deeply GeometryCollections can be nested when they
+// are read or written, so that input nested without bound cannot exhaust the stack.
+const max_collection_depth = 100
+
+// WKTError describes malformed Well-Known Text, and where in the text it was found.
+type WKTError struct {
+	// Line and Column locate the start of the offending token, counting from 1.
+	// Columns count characters, not bytes.
+	Line   int
+	Column int
+	// Message describes what is wrong.
+	Message string
+}
+
+func (e *WKTError) Error() string {
+	return fmt.Sprintf("wkt: line %v, column %v: %v", e.Line, e.Column, e.Message)
+}
+
+// ParseWKT reads a shape from its Well-Known Text. Keywords are case-insensitive, and
+// the types read are
+//
+//	POINT               Point, which is Point{NaN, NaN} when EMPTY
+//	LINESTRING          Polyline
+//	POLYGON             PolygonWithHoles, with the first ring as the Shell
+//	TRIANGLE            Triangle
+//	MULTIPOINT          MultiPoint
+//	MULTILINESTRING     MultiPolyline
+//	MULTIPOLYGON        MultiPolygon
+//	GEOMETRYCOLLECTION  GeometryCollection
+//
+// A LINESTRING must be EMPTY or have at least 2 Points. Rings must be closed, and the
+// repeated closing Point is dropped. Their orientation is kept as it was written.
+// GEOMETRYCOLLECTIONs can be nested up to 100 deep. Only 2D coordinates are supported,
+// so Z and M coordinates are an error. Malformed text returns a *WKTError.
+func ParseWKT(text string) (Bounded, error) {
+	p := &wkt_parser{text: text, line: 1, column: 1}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	shape, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != wkt_eof {
+		return nil, p.unexpected("end of text")
+	}
+	return shape, nil
+}
+
+// wkt_token_kind is the kind of a token of Well-Known Text.
+type wkt_token_kind int
+
+const (
+	wkt_eof wkt_token_kind = iota
+	wkt_word
+	wkt_number
+	wkt_symbol
+)
+
+// wkt_token is a keyword, number, or one of "(", ")" and ",".
+type wkt_token struct {
+	kind   wkt_token_kind
+	text   string
+	value  float64
+	line   int
+	column int
+}
+
+// wkt_parser reads Well-Known Text by recursive descent, with one token of lookahead.
+type wkt_parser struct {
+	text   string
+	offset int
+	line   int
+	column int
+	token  wkt_token
+	// depth counts the GEOMETRYCOLLECTIONs around the current token.
+	depth int
+}
+
+// error_at returns a *WKTError at the start of `token`.
+func (p *wkt_parser) error_at(token wkt_token, format string, args ...interface{}) error {
+	return &WKTError{Line: token.line, Column: token.column, Message: fmt.Sprintf(format, args...)}
+}
+
+// unexpected returns a *WKTError for the current token, when `want` was expected.
+func (p *wkt_parser) unexpected(want string) error {
+	if p.token.kind == wkt_eof {
+		return p.error_at(p.token, "expected %v, found end of text", want)
+	}
+	return p.error_at(p.token, "expected %v, found %q", want, p.token.text)
+}
+
+// next reads the following token into p.token.
+func (p *wkt_parser) next() error {
+	r, size := p.peek()
+	for (size > 0) && unicode.IsSpace(r) {
+		p.advance(r, size)
+		r, size = p.peek()
+	}
+	p.token = wkt_token{line: p.line, column: p.column}
+	start := p.offset
+	switch {
+	case size == 0:
+		p.token.kind = wkt_eof
+		return nil
+	case (r == '(') || (r == ')') || (r == ','):
+		p.advance(r, size)
+		p.token.kind = wkt_symbol
+	case unicode.IsLetter(r):
+		for (size > 0) && (unicode.IsLetter(r) || unicode.IsDigit(r) || (r == '_')) {
+			p.advance(r, size)
+			r, size = p.peek()
+		}
+		p.token.kind = wkt_word
+	case ('0' <= r && r <= '9') || strings.ContainsRune("+-.", r):
+		for (size > 0) && (('0' <= r && r <= '9') || strings.ContainsRune("+-.eE", r)) {
+			p.advance(r, size)
+			r, size = p.peek()
+		}
+		p.token.kind = wkt_number
+	default:
+		return p.error_at(p.token, "unexpected character %q", r)
+	}
+	p.token.text = p.text[start:p.offset]
+
+	if p.token.kind == wkt_number {
+		value, err := strconv.ParseFloat(p.token.text, 64)
+		if err != nil {
+			return p.error_at(p.token, "invalid number %q", p.token.text)
+		}
+		p.token.value = value
+	}
+	return nil
+}
+
+// peek returns the next character of the text and its size, which is 0 at the end.
+func (p *wkt_parser) peek() (rune, int) {
+	return utf8.DecodeRuneInString(p.text[p.offset:])
+}
+
+// advance moves past the character `r`, keeping track of the line and column.
+func (p *wkt_parser) advance(r rune, size int) {
+	p.offset += size
+	if r == '\n' {
+		p.line, p.column = p.line+1, 1
+	} else {
+		p.column++
+	}
+}
+
+// is_symbol tests if the current token is the symbol `s`.
+func (p *wkt_parser) is_symbol(s string) bool {
+	return (p.token.kind == wkt_symbol) && (p.token.text == s)
+}
+
+// expect moves past the symbol `s`, or returns an error if the current token is not it.
+func (p *wkt_parser) expect(s string) error {
+	if !p.is_symbol(s) {
+		return p.unexpected(strconv.Quote(s))
+	}
+	return p.next()
+}
+
+// begin moves past either the keyword EMPTY, returning true, or an opening parenthesis.
+func (p *wkt_parser) begin() (bool, error) {
+	if (p.token.kind == wkt_word) && strings.EqualFold(p.token.text, "EMPTY") {
+		return true, p.next()
+	}
+	if !p.is_symbol("(") {
+		return false, p.unexpected(`"(" or EMPTY`)
+	}
+	return false, p.next()
+}
+
+// list reads items separated by commas, calling `item` for each, up to the closing
+// parenthesis.
+func (p *wkt_parser) list(item func() error) error {
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if !p.is_symbol(",") {
+			return p.expect(")")
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+}
+
+// wkt_types are the keywords of the shapes ParseWKT can read.
+var wkt_types = []string{
+	"POINT", "LINESTRING", "POLYGON", "TRIANGLE", "MULTIPOINT", "MULTILINESTRING",
+	"MULTIPOLYGON", "GEOMETRYCOLLECTION",
+}
+
+// geometry reads a keyword followed by the text of that type of shape.
+func (p *wkt_parser) geometry() (Bounded, error) {
+	keyword := p.token
+	if keyword.kind != wkt_word {
+		return nil, p.unexpected("a geometry type")
+	}
+	name := strings.ToUpper(keyword.text)
+	known := false
+	for _, t := range wkt_types {
+		known = known || (name == t)
+		if (name == t+"Z") || (name == t+"M") || (name == t+"ZM") {
+			return nil, p.error_at(keyword, "%v has Z or M coordinates, only 2D is supported", keyword.text)
+		}
+	}
+	if !known {
+		return nil, p.error_at(keyword, "unknown geometry type %q", keyword.text)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == wkt_word {
+		switch strings.ToUpper(p.token.text) {
+		case "Z", "M", "ZM":
+			return nil, p.error_at(p.token, "%v has Z or M coordinates, only 2D is supported", keyword.text)
+		}
+	}
+
+	switch name {
+	case "POINT":
+		return p.point_text()
+	case "LINESTRING":
+		return p.polyline_text()
+	case "POLYGON":
+		return p.polygon_text()
+	case "TRIANGLE":
+		return p.triangle_text(keyword)
+	case "MULTIPOINT":
+		return p.multipoint_text()
+	case "MULTILINESTRING":
+		var m MultiPolyline
+		err := p.collection(func() error {
+			polyline, err := p.polyline_text()
+			m.Polylines = append(m.Polylines, polyline)
+			return err
+		})
+		return m, err
+	case "MULTIPOLYGON":
+		var m MultiPolygon
+		err := p.collection(func() error {
+			polygon, err := p.polygon_text()
+			m.Polygons = append(m.Polygons, polygon)
+			return err
+		})
+		return m, err
+	default:
+		if p.depth == max_collection_depth {
+			return nil, p.error_at(keyword, "%v is nested more than %v deep", keyword.text, max_collection_depth)
+		}
+		p.depth++
+		var g GeometryCollection
+		err := p.collection(func() error {
+			shape, err := p.geometry()
+			g.Geometries = append(g.Geometries, shape)
+			return err
+		})
+		p.depth--
+		return g, err
+	}
+}
+
+// collection reads either EMPTY or a list of members, calling `member` for each.
+func (p *wkt_parser) collection(member func() error) error {
+	empty, err := p.begin()
+	if empty || (err != nil) {
+		return err
+	}
+	return p.list(member)
+}
+
+// coordinate reads the x and y coordinates of a Point.
+func (p *wkt_parser) coordinate() (Point, error) {
+	var xy [2]float64
+	for i := range xy {
+		if p.token.kind != wkt_number {
+			return Point{}, p.unexpected("a number")
+		}
+		xy[i] = p.token.value
+		if err := p.next(); err != nil {
+			return Point{}, err
+		}
+	}
+	if p.token.kind == wkt_number {
+		return Point{}, p.error_at(p.token, "found a third coordinate, only 2D is supported")
+	}
+	return Point{xy[0], xy[1]}, nil
+}
+
+// point_text reads the coordinates of a Point in parentheses, or EMPTY.
+func (p *wkt_parser) point_text() (Point, error) {
+	empty, err := p.begin()
+	if empty || (err != nil) {
+		return Point{math.NaN(), math.NaN()}, err
+	}
+	point, err := p.coordinate()
+	if err != nil {
+		return Point{}, err
+	}
+	return point, p.expect(")")
+}
+
+// linestring_text reads a list of Points in parentheses, or EMPTY.
+func (p *wkt_parser) linestring_text() ([]Point, error) {
+	var points []Point
+	err := p.collection(func() error {
+		point, err := p.coordinate()
+		points = append(points, point)
+		return err
+	})
+	return points, err
+}
+
+// polyline_text reads a LineString, which is either EMPTY or has at least 2 Points.
+func (p *wkt_parser) polyline_text() (Polyline, error) {
+	start := p.token
+	points, err := p.linestring_text()
+	if (err == nil) && (len(points) == 1) {
+		return Polyline{}, p.error_at(start, "linestring has 1 point, at least 2 are needed")
+	}
+	return Polyline{points}, err
+}
+
+// ring_text reads a closed ring, and returns it without its closing Point.
+func (p *wkt_parser) ring_text() (Polygon, error) {
+	start := p.token
+	points, err := p.linestring_text()
+	switch {
+	case err != nil:
+		return Polygon{}, err
+	case len(points) < 4:
+		return Polygon{}, p.error_at(start, "ring has %v points, at least 4 are needed", len(points))
+	case points[0] != points[len(points)-1]:
+		return Polygon{}, p.error_at(start, "ring is not closed")
+	}
+	return Polygon{points[:len(points)-1]}, nil
+}
+
+// polygon_text reads a list of rings, the first being the Shell, or EMPTY.
+func (p *wkt_parser) polygon_text() (PolygonWithHoles, error) {
+	var rings []Polygon
+	err := p.collection(func() error {
+		ring, err := p.ring_text()
+		rings = append(rings, ring)
+		return err
+	})
+	if err != nil {
+		return PolygonWithHoles{}, err
+	}
+	return polygon_from_rings(rings), nil
+}
+
+// triangle_text reads a single closed ring of 3 Points.
+func (p *wkt_parser) triangle_text(keyword wkt_token) (Triangle, error) {
+	start := p.token
+	polygon, err := p.polygon_text()
+	switch {
+	case err != nil:
+		return Triangle{}, err
+	case len(polygon.Shell.Points) == 0:
+		return Triangle{}, p.error_at(keyword, "a Triangle cannot be EMPTY")
+	case (len(polygon.Shell.Points) != 3) || (len(polygon.Holes) > 0):
+		return Triangle{}, p.error_at(start, "a triangle must be a single ring of 4 points")
+	}
+	ring := polygon.Shell.Points
+	return Triangle{ring[0], ring[1], ring[2]}, nil
+}
+
+// multipoint_text reads a list of Points, each either in parentheses, bare, or EMPTY.
+func (p *wkt_parser) multipoint_text() (MultiPoint, error) {
+	var m MultiPoint
+	err := p.collection(func() error {
+		var point Point
+		var err error
+		if p.is_symbol("(") || (p.token.kind == wkt_word) {
+			point, err = p.point_text()
+		} else {
+			point, err = p.coordinate()
+		}
+		m.Points = append(m.Points, point)
+		return err
+	})
+	return m, err
+}
+
+// FormatWKT writes a shape as Well-Known Text, without spaces after keywords or commas,
+// as in "POLYGON((0 0,1 0,0 1,0 0))". Every type that ParseWKT reads can be written, as
+// well as a LineSegment as a LINESTRING and a Polygon as a POLYGON with no holes.
+// Coordinates are written with the fewest digits that read back as the same float64.
+//
+// It returns ErrUnsupportedShape for any other type, ErrNotFinite for infinite or NaN
+// coordinates, ErrTooFewPoints for a Polyline with a single Point or a ring with fewer
+// than 3 Points, and ErrTooDeep for GeometryCollections nested more deeply than
+// ParseWKT reads.
+func FormatWKT(shape Bounded) (string, error) {
+	var w wkt_writer
+	if err := w.geometry(shape); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// wkt_writer builds up Well-Known Text.
+type wkt_writer struct {
+	strings.Builder
+	// depth counts the GeometryCollections around the shape being written.
+	depth int
+}
+
+// geometry writes a shape with its keyword.
+func (w *wkt_writer) geometry(shape Bounded) error {
+	switch s := shape.(type) {
+	case Point:
+		w.WriteString("POINT")
+		return w.point_text(s)
+	case LineSegment:
+		w.WriteString("LINESTRING")
+		return w.points([]Point{s.P1, s.P2})
+	case Polyline:
+		w.WriteString("LINESTRING")
+		return w.line(s.Points)
+	case Triangle:
+		w.WriteString("TRIANGLE(")
+		if err := w.ring(Polygon{[]Point{s.P1, s.P2, s.P3}}); err != nil {
+			return err
+		}
+		w.WriteByte(')')
+	case Polygon:
+		w.WriteString("POLYGON")
+		return w.polygon_text(PolygonWithHoles{Shell: s})
+	case PolygonWithHoles:
+		w.WriteString("POLYGON")
+		return w.polygon_text(s)
+	case MultiPoint:
+		w.WriteString("MULTIPOINT")
+		return w.collection(len(s.Points), func(i int) error { return w.point_text(s.Points[i]) })
+	case MultiPolyline:
+		w.WriteString("MULTILINESTRING")
+		return w.collection(len(s.Polylines), func(i int) error { return w.line(s.Polylines[i].Points) })
+	case MultiPolygon:
+		w.WriteString("MULTIPOLYGON")
+		return w.collection(len(s.Polygons), func(i int) error { return w.polygon_text(s.Polygons[i]) })
+	case GeometryCollection:
+		if w.depth == max_collection_depth {
+			return ErrTooDeep
+		}
+		w.depth++
+		w.WriteString("GEOMETRYCOLLECTION")
+		err := w.collection(len(s.Geometries), func(i int) error { return w.geometry(s.Geometries[i]) })
+		w.depth--
+		return err
+	default:
+		return fmt.Errorf("%T: %w", shape, ErrUnsupportedShape)
+	}
+	return nil
+}
+
+// collection writes `n` members in parentheses, calling `member` for each, or EMPTY.
+func (w *wkt_writer) collection(n int, member func(i int) error) error {
+	if n == 0 {
+		w.empty()
+		return nil
+	}
+	w.WriteByte('(')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if err := member(i); err != nil {
+			return err
+		}
+	}
+	w.WriteByte(')')
+	return nil
+}
+
+// empty writes the keyword EMPTY, after a space if it follows another keyword.
+func (w *wkt_writer) empty() {
+	if text := w.String(); unicode.IsLetter(rune(text[len(text)-1])) {
+		w.WriteByte(' ')
+	}
+	w.WriteString("EMPTY")
+}
+
+// coordinate writes the x and y coordinates of a Point.
+func (w *wkt_writer) coordinate(p Point) error {
+	if err := check_finite(p); err != nil {
+		return err
+	}
+	w.WriteString(format_coordinate(p.X))
+	w.WriteByte(' ')
+	w.WriteString(format_coordinate(p.Y))
+	return nil
+}
+
+// format_coordinate formats a number with the fewest digits that parse back to it, in
+// decimal unless it is very large or very small.
+func format_coordinate(x float64) string {
+	if a := math.Abs(x); (a == 0) || ((1e-6 <= a) && (a < 1e21)) {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// point_text writes a Point in parentheses, or EMPTY if both coordinates are NaN.
+func (w *wkt_writer) point_text(p Point) error {
+	if math.IsNaN(p.X) && math.IsNaN(p.Y) {
+		w.empty()
+		return nil
+	}
+	w.WriteByte('(')
+	if err := w.coordinate(p); err != nil {
+		return err
+	}
+	w.WriteByte(')')
+	return nil
+}
+
+// points writes a list of Points in parentheses, or EMPTY.
+func (w *wkt_writer) points(points []Point) error {
+	return w.collection(len(points), func(i int) error { return w.coordinate(points[i]) })
+}
+
+// line writes the Points of a LineString, which must not have just one.
+func (w *wkt_writer) line(points []Point) error {
+	if err := check_line(points); err != nil {
+		return err
+	}
+	return w.points(points)
+}
+
+// ring writes a ring in parentheses, repeating its first Point at the end.
+func (w *wkt_writer) ring(ring Polygon) error {
+	points, err := closed_ring(ring)
+	if err != nil {
+		return err
+	}
+	return w.points(points)
+}
+
+// polygon_text writes the rings of a PolygonWithHoles, or EMPTY if its Shell has no
+// Points.
+func (w *wkt_writer) polygon_text(p PolygonWithHoles) error {
+	if len(p.Shell.Points) == 0 {
+		w.empty()
+		return nil
+	}
+	rings := p.Rings()
+	return w.collection(len(rings), func(i int) error { return w.ring(rings[i]) })
+}
+
+// check_finite returns ErrNotFinite if either coordinate of `p` is infinite or NaN.
+func check_finite(p Point) error {
+	if math.IsInf(p.X, 0) || math.IsNaN(p.X) || math.IsInf(p.Y, 0) || math.IsNaN(p.Y) {
+		return fmt.Errorf("%v: %w", p, ErrNotFinite)
+	}
+	return nil
+}
+
+// check_line returns ErrTooFewPoints if a LineString has a single Point. It must have
+// none, or at least 2.
+func check_line(points []Point) error {
+	if len(points) == 1 {
+		return ErrTooFewPoints
+	}
+	return nil
+}
+
+// closed_ring returns the Points of a ring with the first repeated at the end, as text
+// and binary formats store them, or ErrTooFewPoints if it has fewer than 3.
+func closed_ring(ring Polygon) ([]Point, error) {
+	if len(ring.Points) < 3 {
+		return nil, ErrTooFewPoints
+	}
+	return append(append([]Point{}, ring.Points...), ring.Points[0]), nil
+}
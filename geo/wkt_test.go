@@ -0,0 +1,287 @@
+package gogeo
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// circle is a shape from outside the package, which cannot be encoded.
+type circle struct {
+	center Point
+	radius float64
+}
+
+func (c circle) BoundingBox() BoundingBox {
+	r := Point{c.radius, c.radius}
+	return BoundingBox{c.center.Minus(r), c.center.Plus(r)}
+}
+
+func TestParseWKT(t *testing.T) {
+	testCases := []struct {
+		desc string
+		text string
+		want Bounded
+	}{
+		{"Point", "POINT (1 2)", Point{1, 2}},
+		{"Point, lower case and no spaces", "point(-1.5 2e3)", Point{-1.5, 2000}},
+		{"LineString", "LINESTRING(0 0, 1 1, 2 0)", Polyline{[]Point{{0, 0}, {1, 1}, {2, 0}}}},
+		{"LineString, empty", "LINESTRING EMPTY", Polyline{}},
+		{
+			"Polygon with a hole",
+			"POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,2 4,4 4,4 2,2 2))",
+			PolygonWithHoles{Shell: square(0, 0, 10), Holes: []Polygon{{[]Point{{2, 2}, {2, 4}, {4, 4}, {4, 2}}}}},
+		},
+		{"Polygon, empty", "Polygon Empty", PolygonWithHoles{}},
+		{"Triangle", "TRIANGLE((0 0,1 0,0 1,0 0))", Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}},
+		{"MultiPoint", "MULTIPOINT((1 2),(3 4))", MultiPoint{[]Point{{1, 2}, {3, 4}}}},
+		{"MultiPoint, without parentheses", "MULTIPOINT(1 2, 3 4)", MultiPoint{[]Point{{1, 2}, {3, 4}}}},
+		{
+			"MultiLineString",
+			"MULTILINESTRING((0 0,1 1),EMPTY)",
+			MultiPolyline{[]Polyline{{[]Point{{0, 0}, {1, 1}}}, {}}},
+		},
+		{
+			"MultiPolygon",
+			"MULTIPOLYGON(((0 0,1 0,1 1,0 1,0 0)),((3 -2,4 -2,4 -1,3 -1,3 -2)))",
+			multi(square(0, 0, 1), square(3, -2, 1)),
+		},
+		{"MultiPolygon, empty", "MULTIPOLYGON EMPTY", MultiPolygon{}},
+		{
+			"GeometryCollection, nested",
+			"GEOMETRYCOLLECTION(POINT(1 2),GEOMETRYCOLLECTION(LINESTRING(0 0,1 1)),GEOMETRYCOLLECTION EMPTY)",
+			GeometryCollection{[]Bounded{
+				Point{1, 2},
+				GeometryCollection{[]Bounded{Polyline{[]Point{{0, 0}, {1, 1}}}}},
+				GeometryCollection{},
+			}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := ParseWKT(tC.text)
+			if err != nil {
+				t.Fatalf("ParseWKT(%q) returned %v", tC.text, err)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("ParseWKT(%q) = %v, want %v", tC.text, got, tC.want)
+			}
+		})
+	}
+
+	got, err := ParseWKT("POINT EMPTY")
+	if p, ok := got.(Point); (err != nil) || !ok || !math.IsNaN(p.X) || !math.IsNaN(p.Y) {
+		t.Errorf("ParseWKT(POINT EMPTY) = %v, %v, want a Point of NaNs", got, err)
+	}
+}
+
+func TestParseWKTErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		text   string
+		line   int
+		column int
+	}{
+		{"Empty text", "", 1, 1},
+		{"Unknown type", "CIRCLE(0 0)", 1, 1},
+		{"Missing parenthesis", "POINT 1 2", 1, 7},
+		{"Missing coordinate", "POINT(1)", 1, 8},
+		{"Invalid number", "POINT(1 2.3.4)", 1, 9},
+		{"Unexpected character", "POINT(1 2);", 1, 11},
+		{"Trailing text", "POINT(1 2) POINT(3 4)", 1, 12},
+		{"Z coordinates", "POINT Z (1 2 3)", 1, 7},
+		{"Z coordinates, in the keyword", "\n  POINTZ(1 2 3)", 2, 3},
+		{"Third coordinate", "LINESTRING(0 0,\n1 1 1)", 2, 5},
+		{"LineString with one Point", "LINESTRING(1 2)", 1, 11},
+		{"MultiLineString with one Point", "MULTILINESTRING((0 0,1 1),(1 2))", 1, 27},
+		{"Ring not closed", "POLYGON((0 0,1 0,1 1,0 1))", 1, 9},
+		{"Ring too short", "POLYGON((0 0,1 0,0 0))", 1, 9},
+		{"Triangle with 4 corners", "TRIANGLE((0 0,1 0,1 1,0 1,0 0))", 1, 9},
+		{"Unclosed GeometryCollection", "GEOMETRYCOLLECTION(POINT(1 2),\n\tLINESTRING(0 0,1 1)", 2, 21},
+		{"Columns count characters", "POINT(é 2)", 1, 7},
+		{
+			"Deeply nested GeometryCollection",
+			strings.Repeat("GEOMETRYCOLLECTION(", 100000) + "POINT(1 2)" + strings.Repeat(")", 100000),
+			1, 1 + max_collection_depth*len("GEOMETRYCOLLECTION("),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := ParseWKT(tC.text)
+			var wkt_err *WKTError
+			if !errors.As(err, &wkt_err) {
+				t.Fatalf("ParseWKT(%q) returned %v, want a *WKTError", tC.text, err)
+			}
+			if (wkt_err.Line != tC.line) || (wkt_err.Column != tC.column) {
+				t.Errorf("ParseWKT(%q) returned %v, want line %v, column %v", tC.text, err, tC.line, tC.column)
+			}
+		})
+	}
+}
+
+func TestFormatWKT(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		shape Bounded
+		want  string
+	}{
+		{"Point", Point{1, -2.5}, "POINT(1 -2.5)"},
+		{"Point, empty", Point{math.NaN(), math.NaN()}, "POINT EMPTY"},
+		{"Point, large and small", Point{1e300, -5e-324}, "POINT(1e+300 -5e-324)"},
+		{"LineSegment", LineSegment{Point{0, 0}, Point{0.1, 0.2}}, "LINESTRING(0 0,0.1 0.2)"},
+		{"Polyline, empty", Polyline{}, "LINESTRING EMPTY"},
+		{"Triangle", Triangle{Point{0, 0}, Point{1, 0}, Point{0, 1}}, "TRIANGLE((0 0,1 0,0 1,0 0))"},
+		{"Polygon", unit_square, "POLYGON((0 0,1 0,1 1,0 1,0 0))"},
+		{"PolygonWithHoles, empty", PolygonWithHoles{}, "POLYGON EMPTY"},
+		{
+			"MultiPoint, with an empty Point",
+			MultiPoint{[]Point{{1, 2}, {math.NaN(), math.NaN()}}},
+			"MULTIPOINT((1 2),EMPTY)",
+		},
+		{
+			"MultiPolyline",
+			MultiPolyline{[]Polyline{{[]Point{{0, 0}, {1, 1}}}, {}}},
+			"MULTILINESTRING((0 0,1 1),EMPTY)",
+		},
+		{"MultiPolygon, empty", MultiPolygon{}, "MULTIPOLYGON EMPTY"},
+		{
+			"GeometryCollection",
+			GeometryCollection{[]Bounded{Point{1, 2}, GeometryCollection{}}},
+			"GEOMETRYCOLLECTION(POINT(1 2),GEOMETRYCOLLECTION EMPTY)",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got, err := FormatWKT(tC.shape); (err != nil) || (got != tC.want) {
+				t.Errorf("FormatWKT(%v) = %q, %v, want %q", tC.shape, got, err, tC.want)
+			}
+		})
+	}
+}
+
+func TestFormatWKTErrors(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		shape Bounded
+		err   error
+	}{
+		{"Unsupported type", circle{Point{0, 0}, 1}, ErrUnsupportedShape},
+		{"Infinite coordinate", Point{math.Inf(1), 0}, ErrNotFinite},
+		{"NaN coordinate", Polyline{[]Point{{0, 0}, {math.NaN(), 1}}}, ErrNotFinite},
+		{"Polyline with one Point", Polyline{[]Point{{0, 0}}}, ErrTooFewPoints},
+		{"Ring too short", Polygon{[]Point{{0, 0}, {1, 1}}}, ErrTooFewPoints},
+		{"Nested", GeometryCollection{[]Bounded{Point{1, 1}, circle{Point{0, 0}, 1}}}, ErrUnsupportedShape},
+		{"Deeply nested GeometryCollection", nested_collection(max_collection_depth + 1), ErrTooDeep},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := FormatWKT(tC.shape); !errors.Is(err, tC.err) {
+				t.Errorf("FormatWKT(%v) returned %v, want %v", tC.shape, err, tC.err)
+			}
+		})
+	}
+}
+
+// nested_collection makes a Point inside `depth` nested GeometryCollections.
+func nested_collection(depth int) Bounded {
+	var shape Bounded = Point{1, 2}
+	for i := 0; i < depth; i++ {
+		shape = GeometryCollection{[]Bounded{shape}}
+	}
+	return shape
+}
+
+// random_coordinate makes a random finite float64, of any magnitude.
+func random_coordinate(rng *rand.Rand) float64 {
+	for {
+		if x := math.Float64frombits(rng.Uint64()); !math.IsInf(x, 0) && !math.IsNaN(x) {
+			return x
+		}
+	}
+}
+
+// random_geometry makes a random shape of any type that ParseWKT returns, nested up to
+// `depth` GeometryCollections deep.
+func random_geometry(rng *rand.Rand, depth int) Bounded {
+	points := func(n int) []Point {
+		var points []Point
+		for i := 0; i < n; i++ {
+			points = append(points, Point{random_coordinate(rng), random_coordinate(rng)})
+		}
+		return points
+	}
+	// A LineString has no Points, or at least 2.
+	line := func() Polyline {
+		if n := rng.Intn(5); n > 0 {
+			return Polyline{points(n + 1)}
+		}
+		return Polyline{}
+	}
+	polygon := func() PolygonWithHoles {
+		var p PolygonWithHoles
+		if rng.Intn(5) > 0 {
+			p.Shell = Polygon{points(3 + rng.Intn(5))}
+			for i, n := 0, rng.Intn(3); i < n; i++ {
+				p.Holes = append(p.Holes, Polygon{points(3 + rng.Intn(5))})
+			}
+		}
+		return p
+	}
+
+	kinds := 7
+	if depth > 0 {
+		kinds = 8
+	}
+	switch rng.Intn(kinds) {
+	case 0:
+		return points(1)[0]
+	case 1:
+		return line()
+	case 2:
+		return polygon()
+	case 3:
+		p := points(3)
+		return Triangle{p[0], p[1], p[2]}
+	case 4:
+		return MultiPoint{points(rng.Intn(5))}
+	case 5:
+		var m MultiPolyline
+		for i, n := 0, rng.Intn(4); i < n; i++ {
+			m.Polylines = append(m.Polylines, line())
+		}
+		return m
+	case 6:
+		var m MultiPolygon
+		for i, n := 0, rng.Intn(4); i < n; i++ {
+			m.Polygons = append(m.Polygons, polygon())
+		}
+		return m
+	default:
+		var g GeometryCollection
+		for i, n := 0, rng.Intn(4); i < n; i++ {
+			g.Geometries = append(g.Geometries, random_geometry(rng, depth-1))
+		}
+		return g
+	}
+}
+
+func TestWKTRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	for trial := 0; trial < 500; trial++ {
+		shape := random_geometry(rng, 3)
+		text, err := FormatWKT(shape)
+		if err != nil {
+			t.Fatalf("FormatWKT(%v) returned %v", shape, err)
+		}
+		got, err := ParseWKT(text)
+		if err != nil {
+			t.Fatalf("ParseWKT(%q) returned %v", text, err)
+		}
+		if !reflect.DeepEqual(got, shape) {
+			t.Fatalf("ParseWKT(%q) = %v, want %v", text, got, shape)
+		}
+	}
+}
@@ -1,51 +1,585 @@
+// Command gogeo runs geometry operations from the shell. Each command reads geometries
+// as Well-Known Text or GeoJSON from files, or from standard input, and writes its
+// result to standard output:
+//
+//	gogeo <command> [flags] [file ...]
+//
+// Run "gogeo help" for the list of commands, and "gogeo <command> -h" for their flags.
+//
+// The exit status is 0 on success, 1 when "intersect -test" finds that the geometries do
+// not intersect, and 2 for bad arguments, unreadable input or a failed operation, so the
+// commands can be used in shell pipelines and conditions.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
 
 	gogeo "github.com/natemcintosh/gogeo/geo"
 )
 
+// err_false is returned by a command that ran correctly, but whose answer is no.
+var err_false = errors.New("false")
+
+// err_usage is returned when the flags of a command could not be parsed. The flag
+// package has already reported why.
+var err_usage = errors.New("bad usage")
+
+// command is one of the operations that gogeo can run.
+type command struct {
+	name    string
+	summary string
+	// run parses the flags of the command from `args`, and then runs it.
+	run func(c *cli, flags *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"intersect", "intersect the areas of the geometries, or test if they intersect", run_intersect},
+	{"area", "write the area of each geometry", run_area},
+	{"hull", "write the convex hull of the points of all the geometries", run_hull},
+	{"triangulate", "split each geometry into triangles", run_triangulate},
+	{"convert", "write the geometries in another format", run_convert},
+	{"simplify", "remove vertices from lines and polygons, within a tolerance", run_simplify},
+}
+
+// cli holds the streams that a command reads from and writes to.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
 func main() {
-	// p1 := gogeo.Point{X: 0, Y: 0}
-	// p2 := gogeo.Point{X: 1, Y: 1}
-	// p3 := gogeo.Point{X: 1, Y: 0}
-	// p4 := gogeo.Point{X: 2, Y: 1}
-	// p5 := gogeo.Point{X: 0.5, Y: 0}
-	// p6 := gogeo.Point{X: 0.5, Y: 1}
-	// l1 := gogeo.LineSegment{P1: p1, P2: p2}
-	// l2 := gogeo.LineSegment{P1: p3, P2: p4}
-	// l3 := gogeo.LineSegment{P1: p5, P2: p6}
-	// fmt.Println(l1.Intersects(l2))
-	l1 := gogeo.LineSegment{
-		P1: gogeo.Point{X: 0, Y: 0},
-		P2: gogeo.Point{X: 1, Y: 1},
-	}
-
-	l2 := gogeo.LineSegment{
-		P1: gogeo.Point{X: 0.9, Y: 0.9},
-		P2: gogeo.Point{X: 1.1, Y: 1.1},
-	}
-
-	l1_translated := l1.Minus(l1.P1)
-	l2_translated := l2.Minus(l1.P1)
-	fmt.Printf("l1_translated: %v\n", l1_translated)
-	fmt.Printf("l2_translated: %v\n", l2_translated)
-
-	// Rotate all points so that segment 1 is aligned with the x-axis.
-	angle_to_rotate_through := -l1_translated.Angle()
-	l1_rotated := l1_translated.RotateAboutOrigin(angle_to_rotate_through)
-	any_difference := gogeo.Point{X: 0, Y: l1_rotated.P2.Y}
-
-	l2_rotated := l2_translated.RotateAboutOrigin(angle_to_rotate_through)
-	fmt.Printf("l1_rotated: %v\n", l1_rotated)
-	fmt.Printf("l2_rotated: %v\n", l2_rotated)
-
-	fmt.Printf("any difference: %v\n", any_difference)
-	l2_with_diff := l2_rotated.Minus(any_difference)
-	fmt.Printf("l2_with_diff: %v\n", l2_with_diff)
-
-	// Find the x-intercept of segment 2
-	l2_x_intercept := l2_rotated.XIntercept()
-	fmt.Printf("l2_x_intercept: %v\n", l2_x_intercept)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command named by the first of the `args`, and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.usage()
+		return 2
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		c.usage()
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		flags := flag.NewFlagSet("gogeo "+cmd.name, flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		flags.Usage = func() {
+			fmt.Fprintf(stderr, "usage: gogeo %v [flags] [file ...]\n\nThe %v command will %v.\n\n", cmd.name, cmd.name, cmd.summary)
+			flags.PrintDefaults()
+		}
+		err := cmd.run(c, flags, args[1:])
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, err_false):
+			return 1
+		case errors.Is(err, err_usage):
+			return 2
+		default:
+			fmt.Fprintf(stderr, "gogeo %v: %v\n", cmd.name, err)
+			return 2
+		}
+	}
+	fmt.Fprintf(stderr, "gogeo: unknown command %q\n\n", args[0])
+	c.usage()
+	return 2
+}
+
+// usage lists the commands.
+func (c *cli) usage() {
+	fmt.Fprint(c.stderr, `usage: gogeo <command> [flags] [file ...]
+
+Geometries are read from each file, or from standard input if there are none or a file
+is "-". Well-Known Text is read one geometry per line. GeoJSON may hold one or more
+geometries, Features or FeatureCollections.
+
+Commands:
+`)
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-12v %v\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(c.stderr, "\nRun \"gogeo <command> -h\" for the flags of a command.\n")
+}
+
+// parse parses the flags, adding -from, and reads the geometries from the files that
+// follow them.
+func (c *cli) parse(flags *flag.FlagSet, args []string) ([]gogeo.Bounded, error) {
+	from := flags.String("from", "auto", "input `format`: auto, wkt or geojson")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, err_usage
+	}
+	if err := check_choice("-from", *from, "auto", "wkt", "geojson"); err != nil {
+		return nil, err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	var shapes []gogeo.Bounded
+	for _, path := range paths {
+		var data []byte
+		var err error
+		name := path
+		if path == "-" {
+			name = "standard input"
+			data, err = ioutil.ReadAll(c.stdin)
+		} else {
+			data, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		read, err := read_shapes(data, *from)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", name, err)
+		}
+		shapes = append(shapes, read...)
+	}
+	return shapes, nil
+}
+
+// output_flag adds the -to flag, for the format that shapes are written in.
+func output_flag(flags *flag.FlagSet) *string {
+	return flags.String("to", "wkt", "output `format`: wkt, geojson or svg")
+}
+
+// write writes `shapes` to standard output in the `format`: Well-Known Text or GeoJSON
+// one geometry per line, or a single SVG image showing them all.
+func (c *cli) write(shapes []gogeo.Bounded, format string) error {
+	if err := check_choice("-to", format, "wkt", "geojson", "svg"); err != nil {
+		return err
+	}
+	if format == "svg" {
+		scene := gogeo.NewSVG(gogeo.SVGOptions{})
+		scene.Add(gogeo.Style{Stroke: "#1f4e79", Fill: "#9ecae1", Opacity: 0.6}, shapes...)
+		_, err := scene.WriteTo(c.stdout)
+		return err
+	}
+
+	var b bytes.Buffer
+	for _, shape := range shapes {
+		if format == "geojson" {
+			data, err := gogeo.MarshalGeoJSON(shape)
+			if err != nil {
+				return err
+			}
+			b.Write(data)
+		} else {
+			text, err := gogeo.FormatWKT(shape)
+			if err != nil {
+				return err
+			}
+			b.WriteString(text)
+		}
+		b.WriteByte('\n')
+	}
+	_, err := b.WriteTo(c.stdout)
+	return err
+}
+
+// check_choice returns an error unless the `value` of a flag is one of the `choices`.
+func check_choice(flag, value string, choices ...string) error {
+	for _, choice := range choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v must be one of %v, not %q", flag, strings.Join(choices, ", "), value)
+}
+
+// read_shapes reads every geometry in `data`. The "auto" format is GeoJSON if the data
+// starts with '{', and Well-Known Text otherwise.
+func read_shapes(data []byte, format string) ([]gogeo.Bounded, error) {
+	if format == "auto" {
+		format = "wkt"
+		if trimmed := bytes.TrimSpace(data); (len(trimmed) > 0) && (trimmed[0] == '{') {
+			format = "geojson"
+		}
+	}
+	if format == "geojson" {
+		return read_geojson(data)
+	}
+	return read_wkt(data)
+}
+
+// read_wkt reads one geometry from each line that is not blank.
+func read_wkt(data []byte) ([]gogeo.Bounded, error) {
+	var shapes []gogeo.Bounded
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		shape, err := gogeo.ParseWKT(line)
+		if err != nil {
+			// Report the line in the whole input, rather than within the line.
+			var wkt_err *gogeo.WKTError
+			if errors.As(err, &wkt_err) {
+				wkt_err.Line += i
+			}
+			return nil, err
+		}
+		shapes = append(shapes, shape)
+	}
+	return shapes, nil
+}
+
+// read_geojson reads every geometry in a sequence of GeoJSON objects. The geometries of
+// Features and FeatureCollections are read, and Features without one are skipped.
+func read_geojson(data []byte) ([]gogeo.Bounded, error) {
+	var shapes []gogeo.Bounded
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var object json.RawMessage
+		if err := decoder.Decode(&object); err == io.EOF {
+			return shapes, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(object, &header); err != nil {
+			return nil, fmt.Errorf("%w: %v", gogeo.ErrInvalidGeoJSON, err)
+		}
+
+		switch header.Type {
+		case "Feature":
+			var feature gogeo.Feature
+			if err := json.Unmarshal(object, &feature); err != nil {
+				return nil, err
+			}
+			if feature.Geometry != nil {
+				shapes = append(shapes, feature.Geometry)
+			}
+		case "FeatureCollection":
+			var collection gogeo.FeatureCollection
+			if err := json.Unmarshal(object, &collection); err != nil {
+				return nil, err
+			}
+			for _, feature := range collection.Features {
+				if feature.Geometry != nil {
+					shapes = append(shapes, feature.Geometry)
+				}
+			}
+		default:
+			shape, err := gogeo.UnmarshalGeoJSON(object)
+			if err != nil {
+				return nil, err
+			}
+			shapes = append(shapes, shape)
+		}
+	}
+}
+
+// run_intersect writes the intersection of the areas of all the geometries, or, with
+// -test, whether the first geometry intersects every other one.
+func run_intersect(c *cli, flags *flag.FlagSet, args []string) error {
+	test := flags.Bool("test", false, "write true or false for whether the first geometry intersects all the others, and exit with status 1 if not")
+	to := output_flag(flags)
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(shapes) < 2 {
+		return fmt.Errorf("need at least two geometries, found %v", len(shapes))
+	}
+
+	if *test {
+		for _, shape := range shapes[1:] {
+			if !gogeo.ShapesIntersect(shapes[0], shape) {
+				fmt.Fprintln(c.stdout, false)
+				return err_false
+			}
+		}
+		fmt.Fprintln(c.stdout, true)
+		return nil
+	}
+
+	var result gogeo.MultiPolygon
+	for i, shape := range shapes {
+		area, err := as_multipolygon(shape)
+		if err != nil {
+			return fmt.Errorf("geometry %v: %w", i+1, err)
+		}
+		if i == 0 {
+			result = area
+		} else if result, err = gogeo.Overlay(result, area, gogeo.Intersection); err != nil {
+			return fmt.Errorf("geometry %v: %w", i+1, err)
+		}
+	}
+	return c.write([]gogeo.Bounded{result}, *to)
+}
+
+// run_area writes the area of each geometry, which is zero for Points and lines.
+func run_area(c *cli, flags *flag.FlagSet, args []string) error {
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	for _, shape := range shapes {
+		fmt.Fprintln(&b, shape_area(shape))
+	}
+	_, err = b.WriteTo(c.stdout)
+	return err
+}
+
+// run_hull writes the convex hull of every Point of every geometry. The hull is a
+// Polygon, or a Point or line if all the Points lie on one.
+func run_hull(c *cli, flags *flag.FlagSet, args []string) error {
+	algorithm := flags.String("algorithm", "monotone-chain", "`name` of the algorithm: monotone-chain or quickhull")
+	collinear := flags.Bool("collinear", false, "keep Points along the edges of the hull as vertices")
+	to := output_flag(flags)
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := check_choice("-algorithm", *algorithm, "monotone-chain", "quickhull"); err != nil {
+		return err
+	}
+
+	var points []gogeo.Point
+	for _, shape := range shapes {
+		points = append(points, shape_points(shape)...)
+	}
+	if len(points) == 0 {
+		return errors.New("no Points to take the hull of")
+	}
+	options := gogeo.HullOptions{IncludeCollinear: *collinear}
+	if *algorithm == "quickhull" {
+		options.Algorithm = gogeo.QuickHull
+	}
+	hull := gogeo.ConvexHull(points, options)
+
+	var result gogeo.Bounded
+	switch {
+	case len(hull.Points) == 1:
+		result = hull.Points[0]
+	case (len(hull.Points) == 2) || (hull.SignedArea() == 0):
+		result = gogeo.Polyline{Points: hull.Points}
+	default:
+		result = hull
+	}
+	return c.write([]gogeo.Bounded{result}, *to)
+}
+
+// run_triangulate writes the Triangles that each geometry is split into. Polygons are
+// triangulated within their rings, and the Points of any other geometry are joined up
+// by a Delaunay triangulation.
+func run_triangulate(c *cli, flags *flag.FlagSet, args []string) error {
+	algorithm := flags.String("algorithm", "ear-clipping", "`name` of the algorithm for polygons: ear-clipping or monotone")
+	to := output_flag(flags)
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := check_choice("-algorithm", *algorithm, "ear-clipping", "monotone"); err != nil {
+		return err
+	}
+	triangulation := gogeo.EarClipping
+	if *algorithm == "monotone" {
+		triangulation = gogeo.MonotonePartition
+	}
+
+	var triangles []gogeo.Bounded
+	for _, shape := range shapes {
+		var found []gogeo.Triangle
+		if area, err := as_multipolygon(shape); err == nil {
+			found = area.Triangulate(triangulation)
+		} else {
+			found = gogeo.NewDelaunay(shape_points(shape)).Triangles()
+		}
+		for _, triangle := range found {
+			triangles = append(triangles, triangle)
+		}
+	}
+	return c.write(triangles, *to)
+}
+
+// run_convert writes the geometries unchanged, for converting between formats.
+func run_convert(c *cli, flags *flag.FlagSet, args []string) error {
+	to := output_flag(flags)
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	return c.write(shapes, *to)
+}
+
+// run_simplify writes each geometry with its lines and rings simplified.
+func run_simplify(c *cli, flags *flag.FlagSet, args []string) error {
+	tolerance := flags.Float64("tolerance", 0, "how far the result may stray from the original, or the smallest area kept by visvalingam")
+	algorithm := flags.String("algorithm", "douglas-peucker", "`name` of the algorithm: douglas-peucker or visvalingam")
+	topology := flags.Bool("preserve-topology", false, "keep lines and rings from crossing, and Holes inside their Shells")
+	to := output_flag(flags)
+	shapes, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := check_choice("-algorithm", *algorithm, "douglas-peucker", "visvalingam"); err != nil {
+		return err
+	}
+	if !(*tolerance >= 0) {
+		return fmt.Errorf("-tolerance must not be negative, not %v", *tolerance)
+	}
+	options := gogeo.SimplifyOptions{PreserveTopology: *topology}
+	if *algorithm == "visvalingam" {
+		options.Algorithm = gogeo.VisvalingamWhyatt
+	}
+
+	for i, shape := range shapes {
+		shapes[i] = simplify_shape(shape, *tolerance, options)
+	}
+	return c.write(shapes, *to)
+}
+
+// as_multipolygon returns the area covered by a Triangle, a polygon, or a
+// GeometryCollection of them, as a MultiPolygon.
+func as_multipolygon(shape gogeo.Bounded) (gogeo.MultiPolygon, error) {
+	switch s := shape.(type) {
+	case gogeo.Triangle:
+		return as_multipolygon(gogeo.Polygon{Points: []gogeo.Point{s.P1, s.P2, s.P3}})
+	case gogeo.Polygon:
+		return as_multipolygon(gogeo.PolygonWithHoles{Shell: s})
+	case gogeo.PolygonWithHoles:
+		return gogeo.MultiPolygon{Polygons: []gogeo.PolygonWithHoles{s}}, nil
+	case gogeo.MultiPolygon:
+		return s, nil
+	case gogeo.GeometryCollection:
+		// The members may overlap, so they are merged together.
+		var union gogeo.MultiPolygon
+		for _, g := range s.Geometries {
+			area, err := as_multipolygon(g)
+			if err != nil {
+				return gogeo.MultiPolygon{}, err
+			}
+			if union, err = gogeo.Overlay(union, area, gogeo.Union); err != nil {
+				return gogeo.MultiPolygon{}, err
+			}
+		}
+		return union, nil
+	default:
+		return gogeo.MultiPolygon{}, fmt.Errorf("a %T has no area", shape)
+	}
+}
+
+// shape_area returns the area covered by a shape, or zero if it is not an area.
+func shape_area(shape gogeo.Bounded) float64 {
+	area, err := as_multipolygon(shape)
+	if err != nil {
+		return 0
+	}
+	return area.Area()
+}
+
+// shape_points returns every vertex of a shape. Empty Points are left out.
+func shape_points(shape gogeo.Bounded) []gogeo.Point {
+	switch s := shape.(type) {
+	case gogeo.Point:
+		if math.IsNaN(s.X) && math.IsNaN(s.Y) {
+			return nil
+		}
+		return []gogeo.Point{s}
+	case gogeo.LineSegment:
+		return []gogeo.Point{s.P1, s.P2}
+	case gogeo.Polyline:
+		return s.Points
+	case gogeo.Triangle:
+		return []gogeo.Point{s.P1, s.P2, s.P3}
+	case gogeo.Polygon:
+		return s.Points
+	case gogeo.PolygonWithHoles:
+		var points []gogeo.Point
+		for _, ring := range s.Rings() {
+			points = append(points, ring.Points...)
+		}
+		return points
+	case gogeo.MultiPoint:
+		var points []gogeo.Point
+		for _, p := range s.Points {
+			points = append(points, shape_points(p)...)
+		}
+		return points
+	case gogeo.MultiPolyline:
+		var points []gogeo.Point
+		for _, l := range s.Polylines {
+			points = append(points, l.Points...)
+		}
+		return points
+	case gogeo.MultiPolygon:
+		var points []gogeo.Point
+		for _, p := range s.Polygons {
+			points = append(points, shape_points(p)...)
+		}
+		return points
+	case gogeo.GeometryCollection:
+		var points []gogeo.Point
+		for _, g := range s.Geometries {
+			points = append(points, shape_points(g)...)
+		}
+		return points
+	default:
+		return nil
+	}
+}
+
+// simplify_shape simplifies the lines and rings of a shape. Points, LineSegments and
+// Triangles have no vertices that could be removed, and are returned unchanged.
+func simplify_shape(shape gogeo.Bounded, tolerance float64, options gogeo.SimplifyOptions) gogeo.Bounded {
+	switch s := shape.(type) {
+	case gogeo.Polyline:
+		if len(s.Points) == 0 {
+			return s
+		}
+		return s.Simplify(tolerance, options)
+	case gogeo.Polygon:
+		if len(s.Points) == 0 {
+			return s
+		}
+		return s.Simplify(tolerance, options)
+	case gogeo.PolygonWithHoles:
+		if len(s.Shell.Points) == 0 {
+			return s
+		}
+		return s.Simplify(tolerance, options)
+	case gogeo.MultiPolygon:
+		return s.Simplify(tolerance, options)
+	case gogeo.MultiPolyline:
+		lines := make([]gogeo.Polyline, len(s.Polylines))
+		for i, l := range s.Polylines {
+			lines[i] = simplify_shape(l, tolerance, options).(gogeo.Polyline)
+		}
+		return gogeo.MultiPolyline{Polylines: lines}
+	case gogeo.GeometryCollection:
+		geometries := make([]gogeo.Bounded, len(s.Geometries))
+		for i, g := range s.Geometries {
+			geometries[i] = simplify_shape(g, tolerance, options)
+		}
+		return gogeo.GeometryCollection{Geometries: geometries}
+	default:
+		return shape
+	}
 }
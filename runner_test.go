@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	squares := "POLYGON((0 0,4 0,4 4,0 4,0 0))\n\nPOLYGON((2 2,6 2,6 6,2 6,2 2))\n"
+	testCases := []struct {
+		desc   string
+		args   []string
+		stdin  string
+		stdout string
+		stderr string
+		status int
+	}{
+		{
+			desc:   "Intersect",
+			args:   []string{"intersect"},
+			stdin:  squares,
+			stdout: "MULTIPOLYGON(((2 2,4 2,4 4,2 4,2 2)))\n",
+		},
+		{
+			desc:   "Intersect, not an area",
+			args:   []string{"intersect"},
+			stdin:  squares + "POINT(1 1)\n",
+			stderr: "gogeo intersect: geometry 3: a gogeo.Point has no area\n",
+			status: 2,
+		},
+		{
+			desc:   "Intersect, testing",
+			args:   []string{"intersect", "-test"},
+			stdin:  squares + "LINESTRING(3 -1,3 1)\n",
+			stdout: "true\n",
+		},
+		{
+			desc:   "Intersect, testing, disjoint",
+			args:   []string{"intersect", "-test"},
+			stdin:  squares + "POINT(5 5)\n",
+			stdout: "false\n",
+			status: 1,
+		},
+		{
+			desc:   "Intersect a single geometry",
+			args:   []string{"intersect"},
+			stdin:  "POINT(1 1)\n",
+			stderr: "gogeo intersect: need at least two geometries, found 1\n",
+			status: 2,
+		},
+		{
+			desc:   "Area",
+			args:   []string{"area"},
+			stdin:  squares + "TRIANGLE((0 0,1 0,0 1,0 0))\nLINESTRING(0 0,1 1)\n",
+			stdout: "16\n16\n0.5\n0\n",
+		},
+		{
+			desc:   "Hull, to GeoJSON",
+			args:   []string{"hull", "-algorithm", "quickhull", "-to", "geojson"},
+			stdin:  squares,
+			stdout: `{"type":"Polygon","coordinates":[[[0,0],[4,0],[6,2],[6,6],[2,6],[0,4],[0,0]]]}` + "\n",
+		},
+		{
+			desc:   "Hull of a line",
+			args:   []string{"hull", "-collinear"},
+			stdin:  "MULTIPOINT(2 2,0 0,1 1)\n",
+			stdout: "LINESTRING(0 0,1 1,2 2)\n",
+		},
+		{
+			desc:   "Triangulate",
+			args:   []string{"triangulate", "-algorithm", "monotone"},
+			stdin:  "POLYGON((0 0,2 0,2 1,0 1,0 0))\nMULTIPOINT(0 0,1 0,0 1)\n",
+			stdout: "TRIANGLE((0 0,2 1,0 1,0 0))\nTRIANGLE((2 0,2 1,0 0,2 0))\nTRIANGLE((0 0,1 0,0 1,0 0))\n",
+		},
+		{
+			desc:   "Convert from GeoJSON",
+			args:   []string{"convert"},
+			stdin:  `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": null}]}` + "\n" + `{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`,
+			stdout: "POINT(1 2)\nLINESTRING(0 0,1 1)\n",
+		},
+		{
+			desc:   "Convert to SVG",
+			args:   []string{"convert", "-to", "svg"},
+			stdin:  "POINT(1 2)\n",
+			stdout: "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"800\" height=\"800\" viewBox=\"0 0 800 800\">\n<circle cx=\"400\" cy=\"400\" r=\"3\" fill=\"#1f4e79\"/>\n</svg>\n",
+		},
+		{
+			desc:   "Simplify",
+			args:   []string{"simplify", "-tolerance", "0.1"},
+			stdin:  "GEOMETRYCOLLECTION(LINESTRING(0 0,1 0.01,2 0),POINT(1 1))\n",
+			stdout: "GEOMETRYCOLLECTION(LINESTRING(0 0,2 0),POINT(1 1))\n",
+		},
+		{
+			desc:   "Simplify, negative tolerance",
+			args:   []string{"simplify", "-tolerance", "-1"},
+			stdin:  "LINESTRING(0 0,1 1)\n",
+			stderr: "gogeo simplify: -tolerance must not be negative, not -1\n",
+			status: 2,
+		},
+		{
+			desc:   "Malformed WKT, on its line",
+			args:   []string{"convert", "-"},
+			stdin:  "POINT(1 2)\n\nPOINT(1 x)\n",
+			stderr: "gogeo convert: standard input: wkt: line 3, column 9: expected a number, found \"x\"\n",
+			status: 2,
+		},
+		{
+			desc:   "WKT read as GeoJSON",
+			args:   []string{"convert", "-from", "geojson"},
+			stdin:  "POINT(1 2)\n",
+			stderr: "gogeo convert: standard input: invalid character 'P' looking for beginning of value\n",
+			status: 2,
+		},
+		{
+			desc:   "Unknown format",
+			args:   []string{"convert", "-to", "png"},
+			stdin:  "POINT(1 2)\n",
+			stderr: "gogeo convert: -to must be one of wkt, geojson, svg, not \"png\"\n",
+			status: 2,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(tC.args, strings.NewReader(tC.stdin), &stdout, &stderr)
+			if (status != tC.status) || (stdout.String() != tC.stdout) || (stderr.String() != tC.stderr) {
+				t.Errorf("run(%q) = %v, writing %q and %q, want %v, writing %q and %q",
+					tC.args, status, stdout.String(), stderr.String(), tC.status, tC.stdout, tC.stderr)
+			}
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		args   []string
+		want   string
+		status int
+	}{
+		{"No command", nil, "Commands:", 2},
+		{"Help", []string{"help"}, "Commands:", 0},
+		{"Unknown command", []string{"buffer"}, `unknown command "buffer"`, 2},
+		{"Command help", []string{"hull", "-h"}, "-collinear", 0},
+		{"Unknown flag", []string{"area", "-to", "wkt"}, "flag provided but not defined: -to", 2},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(tC.args, strings.NewReader(""), &stdout, &stderr)
+			if (status != tC.status) || (stdout.Len() != 0) || !strings.Contains(stderr.String(), tC.want) {
+				t.Errorf("run(%q) = %v, writing %q and %q, want %v, with %q in standard error",
+					tC.args, status, stdout.String(), stderr.String(), tC.status, tC.want)
+			}
+		})
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.wkt")
+	second := filepath.Join(dir, "second.json")
+	if err := ioutil.WriteFile(first, []byte("POINT(1 2)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(second, []byte(`{"type": "Point", "coordinates": [3, 4]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"convert", "-to", "geojson", first, "-", second}
+	status := run(args, strings.NewReader("POINT(5 6)"), &stdout, &stderr)
+	want := `{"type":"Point","coordinates":[1,2]}` + "\n" +
+		`{"type":"Point","coordinates":[5,6]}` + "\n" +
+		`{"type":"Point","coordinates":[3,4]}` + "\n"
+	if (status != 0) || (stdout.String() != want) || (stderr.Len() != 0) {
+		t.Errorf("run(%q) = %v, writing %q and %q, want 0, writing %q", args, status, stdout.String(), stderr.String(), want)
+	}
+
+	missing := filepath.Join(dir, "missing.wkt")
+	stdout.Reset()
+	stderr.Reset()
+	if status := run([]string{"area", missing}, strings.NewReader(""), &stdout, &stderr); (status != 2) || !strings.Contains(stderr.String(), missing) {
+		t.Errorf("run() of a missing file = %v, writing %q, want 2, naming the file", status, stderr.String())
+	}
+}